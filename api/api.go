@@ -58,6 +58,15 @@ const (
 	ZONE_REMOVEFORWARDPORT = ZONE + ".removeForwardPort"
 	ZONE_QUERYFORWARDPORT  = ZONE + ".queryForwardPort"
 
+	ZONE_REMOVEPROTOCOL           = ZONE + ".removeProtocol"
+	ZONE_REMOVESOURCE             = ZONE + ".removeSource"
+	ZONE_ADDICMPBLOCK             = ZONE + ".addIcmpBlock"
+	ZONE_REMOVEICMPBLOCK          = ZONE + ".removeIcmpBlock"
+	ZONE_ADDSOURCEPORT            = ZONE + ".addSourcePort"
+	ZONE_REMOVESOURCEPORT         = ZONE + ".removeSourcePort"
+	ZONE_ADDICMPBLOCKINVERSION    = ZONE + ".addIcmpBlockInversion"
+	ZONE_REMOVEICMPBLOCKINVERSION = ZONE + ".removeIcmpBlockInversion"
+
 	// get
 	ZONE_GETZONES           = ZONE + ".getZones"
 	ZONE_GETZONEOFINTERFACE = ZONE + ".getZoneOfInterface"
@@ -73,6 +82,7 @@ const (
 	// org.fedoraproject.FirewallD1.config.zone
 	CONFIG_ZONE                   = CONFIG_INTERFACE + ".zone"
 	CONFIG_UPDATE                 = CONFIG_ZONE + ".update"
+	CONFIG_GETSETTINGS            = CONFIG_ZONE + ".getSettings"
 	CONFIG_ZONE_ADDRICHRULE       = CONFIG_ZONE + ".addRichRule"
 	CONFIG_ZONE_REOMVERICHRULE    = CONFIG_ZONE + ".removeRichRule"
 	CONFIG_ZONE_QUERYRICHRULE     = CONFIG_ZONE + ".queryRichRule"
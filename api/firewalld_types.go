@@ -150,7 +150,7 @@ type QuerySettings struct {
 	Rule               []*Rule        `deepcopier:"skip" form:"rule" json:"rule,omitempty"`
 	Protocol           []*Protocol    `deepcopier:"field:Protocol" form:"protocol" json:"protocol,omitempty"`
 	SourcePort         []*SourcePort  `deepcopier:"field:SourcePort" form:"sourceport" json:"sourceport,omitempty"`
-	IcmpBlockInversion bool           `deepcopier:"field:IcmpBlockInversion" form:"icmp-block-inversion" json:"icmp-block-inversion",omitempty"`
+	IcmpBlockInversion bool           `deepcopier:"field:IcmpBlockInversion" form:"icmp-block-inversion" json:"icmp-block-inversion,omitempty"`
 }
 
 type Settings struct {
@@ -169,7 +169,7 @@ type Settings struct {
 	Rule               []string       `deepcopier:"skip" form:"rule" json:"rule,omitempty"`
 	Protocol           []*Protocol    `deepcopier:"field:Protocol" form:"protocol" json:"protocol,omitempty"`
 	SourcePort         []*SourcePort  `deepcopier:"field:SourcePort" form:"sourceport" json:"sourceport,omitempty"`
-	IcmpBlockInversion bool           `deepcopier:"field:IcmpBlockInversion" form:"icmp-block-inversion" json:"icmp-block-inversion",omitempty"`
+	IcmpBlockInversion bool           `deepcopier:"field:IcmpBlockInversion" form:"icmp-block-inversion" json:"icmp-block-inversion,omitempty"`
 }
 
 func (s *Source) IsEmpty() bool {
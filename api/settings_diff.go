@@ -0,0 +1,159 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SettingsDiff 描述两份 zone settings 之间的差异
+// Changed 记录标量字段的变化 [旧值, 新值]，Added/Removed 按字段名记录列表项的增删
+type SettingsDiff struct {
+	Changed map[string][2]string `json:"changed,omitempty"`
+	Added   map[string][]string  `json:"added,omitempty"`
+	Removed map[string][]string  `json:"removed,omitempty"`
+}
+
+func (d *SettingsDiff) IsEmpty() bool {
+	return d == nil || (len(d.Changed) == 0 && len(d.Added) == 0 && len(d.Removed) == 0)
+}
+
+// NormalizeRule 去掉引号并合并空白，使 ToString 生成的规则与 firewalld 返回的规则可以直接比较
+func NormalizeRule(rule string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(rule, `"`, "")), " ")
+}
+
+func (this *Port) Key() string {
+	return this.Port + "/" + this.Protocol
+}
+
+func (this *ForwardPort) Key() string {
+	return "port=" + this.Port + ":proto=" + this.Protocol + ":toport=" + this.ToPort + ":toaddr=" + this.ToAddr
+}
+
+func (this *Source) Key() string {
+	switch {
+	case this.Address != "":
+		return "address=" + this.Address
+	case this.Mac != "":
+		return "mac=" + this.Mac
+	case this.Ipset != "":
+		return "ipset=" + this.Ipset
+	}
+	return ""
+}
+
+func (this *SourcePort) Key() string {
+	return this.Port + "/" + this.Protocol
+}
+
+// Keys 将 settings 中的每个列表字段展开为可比较的字符串集合
+func (s *Settings) Keys() map[string][]string {
+	keys := make(map[string][]string)
+	keys["service"] = append(keys["service"], s.Service...)
+	for _, v := range s.Port {
+		keys["port"] = append(keys["port"], v.Key())
+	}
+	for _, v := range s.IcmpBlock {
+		keys["icmpblock"] = append(keys["icmpblock"], v.Name)
+	}
+	for _, v := range s.ForwardPort {
+		keys["forwardport"] = append(keys["forwardport"], v.Key())
+	}
+	for _, v := range s.Interface {
+		keys["interface"] = append(keys["interface"], v.Name)
+	}
+	for _, v := range s.Source {
+		keys["source"] = append(keys["source"], v.Key())
+	}
+	for _, v := range s.Rule {
+		keys["rule"] = append(keys["rule"], NormalizeRule(v))
+	}
+	for _, v := range s.Protocol {
+		keys["protocol"] = append(keys["protocol"], v.Value)
+	}
+	for _, v := range s.SourcePort {
+		keys["sourceport"] = append(keys["sourceport"], v.Key())
+	}
+	return keys
+}
+
+// DiffSettings 计算从 old 变为 new 需要的变化
+func DiffSettings(old, new *Settings) *SettingsDiff {
+	if old == nil {
+		old = &Settings{}
+	}
+	if new == nil {
+		new = &Settings{}
+	}
+	diff := &SettingsDiff{
+		Changed: make(map[string][2]string),
+		Added:   make(map[string][]string),
+		Removed: make(map[string][]string),
+	}
+
+	scalars := []struct {
+		name     string
+		old, new string
+	}{
+		{"short", old.Short, new.Short},
+		{"description", old.Description, new.Description},
+		{"target", old.Target, new.Target},
+		{"forward", strconv.FormatBool(old.Forward), strconv.FormatBool(new.Forward)},
+		{"masquerade", strconv.FormatBool(old.Masquerade), strconv.FormatBool(new.Masquerade)},
+		{"icmp-block-inversion", strconv.FormatBool(old.IcmpBlockInversion), strconv.FormatBool(new.IcmpBlockInversion)},
+	}
+	for _, v := range scalars {
+		if v.old != v.new {
+			diff.Changed[v.name] = [2]string{v.old, v.new}
+		}
+	}
+
+	oldKeys, newKeys := old.Keys(), new.Keys()
+	for field := range mergeKeys(oldKeys, newKeys) {
+		if added := subtract(newKeys[field], oldKeys[field]); len(added) > 0 {
+			diff.Added[field] = added
+		}
+		if removed := subtract(oldKeys[field], newKeys[field]); len(removed) > 0 {
+			diff.Removed[field] = removed
+		}
+	}
+	return diff
+}
+
+func mergeKeys(a, b map[string][]string) map[string]struct{} {
+	fields := make(map[string]struct{}, len(a))
+	for k := range a {
+		fields[k] = struct{}{}
+	}
+	for k := range b {
+		fields[k] = struct{}{}
+	}
+	return fields
+}
+
+// subtract 返回存在于 a 而不存在于 b 的元素，结果已排序并去重
+func subtract(a, b []string) []string {
+	set := make(map[string]struct{}, len(b))
+	for _, v := range b {
+		set[v] = struct{}{}
+	}
+	var result []string
+	for _, v := range a {
+		if _, ok := set[v]; !ok {
+			set[v] = struct{}{}
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// DiffRuntimeSettings 与 DiffSettings 相同，但忽略 runtime 中无法修改的 short、description、target
+func DiffRuntimeSettings(old, new *Settings) *SettingsDiff {
+	diff := DiffSettings(old, new)
+	for _, name := range []string{"short", "description", "target"} {
+		delete(diff.Changed, name)
+	}
+	return diff
+}
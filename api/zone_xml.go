@@ -0,0 +1,307 @@
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// emptyElement 匹配没有内容的元素，用于输出与 firewalld 一致的自闭合标签
+var emptyElement = regexp.MustCompile(`<([\w-]+)([^<>]*)></([\w-]+)>`)
+
+// ZoneXML 对应 /etc/firewalld/zones/*.xml 的结构
+type ZoneXML struct {
+	XMLName            xml.Name         `xml:"zone"`
+	Version            string           `xml:"version,attr,omitempty"`
+	Target             string           `xml:"target,attr,omitempty"`
+	Short              string           `xml:"short,omitempty"`
+	Description        string           `xml:"description,omitempty"`
+	Interfaces         []XMLName        `xml:"interface"`
+	Sources            []XMLAddress     `xml:"source"`
+	Services           []XMLName        `xml:"service"`
+	Ports              []XMLPort        `xml:"port"`
+	Protocols          []XMLValue       `xml:"protocol"`
+	IcmpBlocks         []XMLName        `xml:"icmp-block"`
+	IcmpBlockInversion *struct{}        `xml:"icmp-block-inversion"`
+	Masquerade         *struct{}        `xml:"masquerade"`
+	ForwardPorts       []XMLForwardPort `xml:"forward-port"`
+	SourcePorts        []XMLPort        `xml:"source-port"`
+	Rules              []XMLRule        `xml:"rule"`
+}
+
+// ServiceXML 对应 /etc/firewalld/services/*.xml 的结构
+type ServiceXML struct {
+	XMLName     xml.Name   `xml:"service"`
+	Version     string     `xml:"version,attr,omitempty"`
+	Short       string     `xml:"short,omitempty"`
+	Description string     `xml:"description,omitempty"`
+	Ports       []XMLPort  `xml:"port"`
+	Protocols   []XMLValue `xml:"protocol"`
+	SourcePorts []XMLPort  `xml:"source-port"`
+	Modules     []XMLName  `xml:"module"`
+}
+
+type XMLName struct {
+	Name string `xml:"name,attr"`
+}
+
+type XMLValue struct {
+	Value string `xml:"value,attr"`
+}
+
+type XMLPort struct {
+	Port     string `xml:"port,attr"`
+	Protocol string `xml:"protocol,attr"`
+}
+
+type XMLForwardPort struct {
+	Port     string `xml:"port,attr"`
+	Protocol string `xml:"protocol,attr"`
+	ToPort   string `xml:"to-port,attr,omitempty"`
+	ToAddr   string `xml:"to-addr,attr,omitempty"`
+}
+
+type XMLAddress struct {
+	Address string `xml:"address,attr,omitempty"`
+	Mac     string `xml:"mac,attr,omitempty"`
+	Ipset   string `xml:"ipset,attr,omitempty"`
+	Invert  string `xml:"invert,attr,omitempty"`
+}
+
+type XMLLimit struct {
+	Value string `xml:"value,attr"`
+}
+
+// XMLAction 对应 accept、drop、audit 等只带可选 limit 的元素
+type XMLAction struct {
+	Limit *XMLLimit `xml:"limit"`
+}
+
+type XMLReject struct {
+	Type  string    `xml:"type,attr,omitempty"`
+	Limit *XMLLimit `xml:"limit"`
+}
+
+type XMLLog struct {
+	Prefix string    `xml:"prefix,attr,omitempty"`
+	Level  string    `xml:"level,attr,omitempty"`
+	Limit  *XMLLimit `xml:"limit"`
+}
+
+type XMLMark struct {
+	Set   string    `xml:"set,attr"`
+	Limit *XMLLimit `xml:"limit"`
+}
+
+// XMLRule 对应 zone xml 中的 rich rule
+type XMLRule struct {
+	Family      string          `xml:"family,attr,omitempty"`
+	Source      *XMLAddress     `xml:"source"`
+	Destination *XMLAddress     `xml:"destination"`
+	Service     *XMLName        `xml:"service"`
+	Port        *XMLPort        `xml:"port"`
+	Protocol    *XMLValue       `xml:"protocol"`
+	IcmpBlock   *XMLName        `xml:"icmp-block"`
+	IcmpType    *XMLName        `xml:"icmp-type"`
+	ForwardPort *XMLForwardPort `xml:"forward-port"`
+	SourcePort  *XMLPort        `xml:"source-port"`
+	Log         *XMLLog         `xml:"log"`
+	Audit       *XMLAction      `xml:"audit"`
+	Accept      *XMLAction      `xml:"accept"`
+	Reject      *XMLReject      `xml:"reject"`
+	Drop        *XMLAction      `xml:"drop"`
+	Mark        *XMLMark        `xml:"mark"`
+}
+
+func ParseZoneXML(content []byte) (*ZoneXML, error) {
+	zone := &ZoneXML{}
+	if err := xml.Unmarshal(content, zone); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+func ParseServiceXML(content []byte) (*ServiceXML, error) {
+	service := &ServiceXML{}
+	if err := xml.Unmarshal(content, service); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+// Marshal 生成带 xml 声明的 zone 文件内容
+func (z *ZoneXML) Marshal() ([]byte, error) {
+	content, err := xml.MarshalIndent(z, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	content = emptyElement.ReplaceAllFunc(content, func(element []byte) []byte {
+		match := emptyElement.FindSubmatch(element)
+		if string(match[1]) != string(match[3]) {
+			return element
+		}
+		return []byte("<" + string(match[1]) + string(match[2]) + "/>")
+	})
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
+
+// ZoneXMLFromSettings 将 zone settings 转换为 zone xml，rich rule 字符串会被解析为结构化的规则
+func ZoneXMLFromSettings(s *Settings) (*ZoneXML, error) {
+	zone := &ZoneXML{
+		Version:     s.Version,
+		Target:      s.Target,
+		Short:       s.Short,
+		Description: s.Description,
+	}
+	for _, v := range s.Interface {
+		zone.Interfaces = append(zone.Interfaces, XMLName{Name: v.Name})
+	}
+	for _, v := range s.Source {
+		zone.Sources = append(zone.Sources, XMLAddress{Address: v.Address, Mac: v.Mac, Ipset: v.Ipset})
+	}
+	for _, v := range s.Service {
+		zone.Services = append(zone.Services, XMLName{Name: v})
+	}
+	for _, v := range s.Port {
+		zone.Ports = append(zone.Ports, XMLPort{Port: v.Port, Protocol: v.Protocol})
+	}
+	for _, v := range s.Protocol {
+		zone.Protocols = append(zone.Protocols, XMLValue{Value: v.Value})
+	}
+	for _, v := range s.IcmpBlock {
+		zone.IcmpBlocks = append(zone.IcmpBlocks, XMLName{Name: v.Name})
+	}
+	if s.IcmpBlockInversion {
+		zone.IcmpBlockInversion = &struct{}{}
+	}
+	if s.Masquerade {
+		zone.Masquerade = &struct{}{}
+	}
+	for _, v := range s.ForwardPort {
+		zone.ForwardPorts = append(zone.ForwardPorts, XMLForwardPort{Port: v.Port, Protocol: v.Protocol, ToPort: v.ToPort, ToAddr: v.ToAddr})
+	}
+	for _, v := range s.SourcePort {
+		zone.SourcePorts = append(zone.SourcePorts, XMLPort{Port: v.Port, Protocol: v.Protocol})
+	}
+	for _, v := range s.Rule {
+		rule, err := ParseRichRule(v)
+		if err != nil {
+			return nil, err
+		}
+		zone.Rules = append(zone.Rules, *rule)
+	}
+	return zone, nil
+}
+
+// tokenizeRule 按空白切分 rich rule，引号内的空白不切分，并去掉引号
+func tokenizeRule(rule string) []string {
+	var (
+		tokens  []string
+		current strings.Builder
+		quoted  bool
+	)
+	for _, r := range rule {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case (r == ' ' || r == '\t') && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
+
+// ParseRichRule 将 firewalld 返回的 rich rule 字符串解析为结构化的规则
+func ParseRichRule(rule string) (*XMLRule, error) {
+	tokens := tokenizeRule(rule)
+	if len(tokens) == 0 || tokens[0] != "rule" {
+		return nil, fmt.Errorf("invalid rich rule: %s", rule)
+	}
+	result := &XMLRule{}
+	// attrs 读取元素之后连续的 key=value
+	i := 1
+	attrs := func() map[string]string {
+		values := make(map[string]string)
+		for i < len(tokens) {
+			kv := strings.SplitN(tokens[i], "=", 2)
+			if len(kv) != 2 {
+				break
+			}
+			values[kv[0]] = kv[1]
+			i++
+		}
+		return values
+	}
+	limit := func() *XMLLimit {
+		if i < len(tokens) && tokens[i] == "limit" {
+			i++
+			return &XMLLimit{Value: attrs()["value"]}
+		}
+		return nil
+	}
+	for i < len(tokens) {
+		element := tokens[i]
+		i++
+		invert := ""
+		if i < len(tokens) && strings.EqualFold(tokens[i], "not") {
+			invert = "True"
+			i++
+		}
+		switch element {
+		case "source":
+			a := attrs()
+			result.Source = &XMLAddress{Address: a["address"], Mac: a["mac"], Ipset: a["ipset"], Invert: invert}
+		case "destination":
+			a := attrs()
+			result.Destination = &XMLAddress{Address: a["address"], Ipset: a["ipset"], Invert: invert}
+		case "service":
+			result.Service = &XMLName{Name: attrs()["name"]}
+		case "port":
+			a := attrs()
+			result.Port = &XMLPort{Port: a["port"], Protocol: a["protocol"]}
+		case "source-port":
+			a := attrs()
+			result.SourcePort = &XMLPort{Port: a["port"], Protocol: a["protocol"]}
+		case "protocol":
+			result.Protocol = &XMLValue{Value: attrs()["value"]}
+		case "icmp-block":
+			result.IcmpBlock = &XMLName{Name: attrs()["name"]}
+		case "icmp-type":
+			result.IcmpType = &XMLName{Name: attrs()["name"]}
+		case "forward-port":
+			a := attrs()
+			result.ForwardPort = &XMLForwardPort{Port: a["port"], Protocol: a["protocol"], ToPort: a["to-port"], ToAddr: a["to-addr"]}
+		case "log":
+			a := attrs()
+			result.Log = &XMLLog{Prefix: a["prefix"], Level: a["level"], Limit: limit()}
+		case "audit":
+			result.Audit = &XMLAction{Limit: limit()}
+		case "accept":
+			result.Accept = &XMLAction{Limit: limit()}
+		case "drop":
+			result.Drop = &XMLAction{Limit: limit()}
+		case "reject":
+			a := attrs()
+			result.Reject = &XMLReject{Type: a["type"], Limit: limit()}
+		case "mark":
+			a := attrs()
+			result.Mark = &XMLMark{Set: a["set"], Limit: limit()}
+		default:
+			if strings.HasPrefix(element, "family=") {
+				result.Family = strings.TrimPrefix(element, "family=")
+				continue
+			}
+			return nil, fmt.Errorf("unknown element %s in rich rule: %s", element, rule)
+		}
+	}
+	return result, nil
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"net"
+	"net/url"
 	"reflect"
 
 	"github.com/spf13/viper"
@@ -33,27 +35,330 @@ func (this *SQLiteConfig) IsEmpty() bool {
 	return reflect.DeepEqual(this, SQLiteConfig{})
 }
 
+type PostgreSQLConfig struct {
+	IP                string
+	Port              string
+	User              string
+	Password          string
+	Database          string
+	SSLMode           string `mapstructure:"sslmode"`
+	MaxIdleConnection int    `mapstructure:"max_idle_connection"`
+	MaxOpenConnection int    `mapstructure:"max_open_connection"`
+}
+
+func (this *PostgreSQLConfig) IsEmpty() bool {
+	return reflect.DeepEqual(*this, PostgreSQLConfig{})
+}
+
+// DSN 返回 postgres:// 格式的连接串，用户名与密码中的特殊字符会被转义，sslmode 为空时不使用 TLS
+func (this *PostgreSQLConfig) DSN() string {
+	sslMode := this.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(this.User, this.Password),
+		Host:     net.JoinHostPort(this.IP, this.Port),
+		Path:     "/" + this.Database,
+		RawQuery: url.Values{"sslmode": []string{sslMode}}.Encode(),
+	}
+	return dsn.String()
+}
+
 // Config对象和config.toml文件保持一致
 type Config struct {
 	AppName            string
 	Address            string
 	Port               string
-	DbusPort           string       `mapstructure:"dbus_port"`
-	AsyncProcess       bool         `mapstructure:"async_process"`
-	MissionRetryNumber int          `mapstructure:"mission_retry_number"`
-	DatabaseDriver     string       `mapstructure:"database_driver"`
-	MySQL              MySQLConfig  //需要定义子类型对应的变量，如果不定义映射不成功
-	SQLite             SQLiteConfig //需要定义子类型对应的变量，如果不定义映射不成功
+	DbusPort           string           `mapstructure:"dbus_port"`
+	AsyncProcess       bool             `mapstructure:"async_process"`
+	MissionRetryNumber int              `mapstructure:"mission_retry_number"`
+	DatabaseDriver     string           `mapstructure:"database_driver"`
+	MySQL              MySQLConfig      //需要定义子类型对应的变量，如果不定义映射不成功
+	SQLite             SQLiteConfig     //需要定义子类型对应的变量，如果不定义映射不成功
+	PostgreSQL         PostgreSQLConfig //需要定义子类型对应的变量，如果不定义映射不成功
 	HA                 ha
+	Drift              drift
+	Metrics            metrics
+	Tracing            tracing
+	Health             health
+	Password           password
+	JWT                jwt
+	OIDC               oidc
+	LDAP               ldap
+	Session            session
+	MFA                mfa
+	Lockout            lockout
+	Approval           approval
+	Audit              audit
 }
 
+// ha 多副本部署，enabled 为 true 时副本之间选主，只有 leader 处理异步任务、执行模板下发任务与运行后台任务，所有副本都把这些任务保存到数据库中；
+// backend 为 kubernetes 时使用 namespace 下名为 lease_name 的 Lease，为 database 时使用数据库中的记录，为空时在集群内使用 Lease；
+// identity 为空时使用主机名，lease_duration、renew_deadline、retry_period、poll_interval 的单位为秒
 type ha struct {
-	Namespace string
+	Enabled       bool
+	Backend       string
+	Namespace     string
+	LeaseName     string `mapstructure:"lease_name"`
+	Identity      string
+	LeaseDuration int `mapstructure:"lease_duration"`
+	RenewDeadline int `mapstructure:"renew_deadline"`
+	RetryPeriod   int `mapstructure:"retry_period"`
+	PollInterval  int `mapstructure:"poll_interval"`
+}
+
+// drift 模板漂移检查，interval 为检查周期(秒)，0 表示不启动检查
+type drift struct {
+	Interval int
+}
+
+// metrics 是否在 /metrics 提供 Prometheus 格式的指标
+type metrics struct {
+	Enabled bool
+}
+
+// tracing OpenTelemetry 链路追踪，span 通过 OTLP/HTTP 发送到 endpoint(host:port)，insecure 为 true 时不使用 TLS，
+// sample_ratio 为没有上游 span 的请求的采样比例
+type tracing struct {
+	Enabled     bool
+	Endpoint    string
+	URLPath     string `mapstructure:"url_path"`
+	Insecure    bool
+	Headers     map[string]string
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// health 就绪检查，canary_host 不为空时检查该主机的 D-Bus 端口是否可以连接，timeout 为每项检查的超时时间(秒)
+type health struct {
+	CanaryHost string `mapstructure:"canary_host"`
+	Timeout    int
+}
+
+// password 密码的哈希算法与密码策略，algorithm 为 bcrypt 或 argon2id，
+// complexity 为大写、小写、数字、符号中至少需要包含的种类数，history 为不能与最近多少个密码重复
+type password struct {
+	Algorithm     string
+	Cost          int
+	Argon2Time    uint32 `mapstructure:"argon2_time"`
+	Argon2Memory  uint32 `mapstructure:"argon2_memory"`
+	Argon2Threads uint8  `mapstructure:"argon2_threads"`
+	MinLength     int    `mapstructure:"min_length"`
+	Complexity    int
+	History       int
+}
+
+// jwtKey 一个 jwt 密钥，HS256 使用 secret，RS256/ES256/EdDSA 使用 key_file 指定的 PEM 文件，
+// key_id 为空时根据密钥计算
+type jwtKey struct {
+	Algorithm string
+	KeyID     string `mapstructure:"key_id"`
+	Secret    string
+	KeyFile   string `mapstructure:"key_file"`
+}
+
+// jwt 签发 token 使用的密钥，verify_keys 为轮换期间仍然可以用于校验的旧密钥
+type jwt struct {
+	Algorithm  string
+	KeyID      string `mapstructure:"key_id"`
+	Secret     string
+	KeyFile    string   `mapstructure:"key_file"`
+	VerifyKeys []jwtKey `mapstructure:"verify_keys"`
+}
+
+// oidc OpenID Connect 单点登录，groups_claim 中的组按 role_mappings 映射为网关的角色，
+// disable_local_login 为 true 时关闭本地用户的登录与注册
+type oidc struct {
+	Enabled           bool
+	Issuer            string
+	ClientID          string `mapstructure:"client_id"`
+	ClientSecret      string `mapstructure:"client_secret"`
+	RedirectURL       string `mapstructure:"redirect_url"`
+	SuccessURL        string `mapstructure:"success_url"`
+	Scopes            []string
+	UsernameClaim     string        `mapstructure:"username_claim"`
+	GroupsClaim       string        `mapstructure:"groups_claim"`
+	RoleMappings      []roleMapping `mapstructure:"role_mappings"`
+	AutoProvision     bool          `mapstructure:"auto_provision"`
+	DisableLocalLogin bool          `mapstructure:"disable_local_login"`
+}
+
+type roleMapping struct {
+	Group string
+	Roles []string
+}
+
+// ldap LDAP/AD 认证，bind_dn 为空时匿名搜索用户，user_filter 中的 %s 会被替换为转义后的用户名，
+// group_attribute 中的组 DN 按 role_mappings 映射为网关的角色，sync_interval 为周期同步角色的间隔(秒)
+type ldap struct {
+	Enabled            bool
+	URL                string
+	StartTLS           bool   `mapstructure:"start_tls"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+	CAFile             string `mapstructure:"ca_file"`
+	Timeout            int
+	BindDN             string        `mapstructure:"bind_dn"`
+	BindPassword       string        `mapstructure:"bind_password"`
+	BaseDN             string        `mapstructure:"base_dn"`
+	UserFilter         string        `mapstructure:"user_filter"`
+	UsernameAttribute  string        `mapstructure:"username_attribute"`
+	GroupAttribute     string        `mapstructure:"group_attribute"`
+	RoleMappings       []roleMapping `mapstructure:"role_mappings"`
+	AutoProvision      bool          `mapstructure:"auto_provision"`
+	SyncInterval       int           `mapstructure:"sync_interval"`
+}
+
+// session 登录会话，access_ttl 为 access token 的有效期(秒)，refresh_ttl 为 refresh token 的有效期(秒)，
+// 每次刷新都会轮换 refresh token 并重新计算有效期
+type session struct {
+	AccessTTL  int `mapstructure:"access_ttl"`
+	RefreshTTL int `mapstructure:"refresh_ttl"`
+}
+
+// mfa TOTP 二次认证，需要二次认证的角色在角色的 require_mfa 中设置；step_up_routes 中的操作("METHOD /path")
+// 要求登录会话在 step_up_ttl(秒) 内验证过 TOTP，没有启用二次认证的用户与 API token 不能执行这些操作
+type mfa struct {
+	Issuer        string
+	StepUpTTL     int      `mapstructure:"step_up_ttl"`
+	StepUpRoutes  []string `mapstructure:"step_up_routes"`
+	RecoveryCodes int      `mapstructure:"recovery_codes"`
+}
+
+// lockout 登录失败锁定，window(秒) 内同一用户失败 max_attempts 次或同一来源 ip 失败 ip_max_attempts 次后锁定，
+// 锁定时间从 duration(秒) 开始每多失败一次翻倍，最长 max_duration(秒)；max_attempts 为 0 时不锁定
+type lockout struct {
+	MaxAttempts   int `mapstructure:"max_attempts"`
+	IPMaxAttempts int `mapstructure:"ip_max_attempts"`
+	Window        int
+	Duration      int
+	MaxDuration   int `mapstructure:"max_duration"`
+}
+
+// approval 双人审批，匹配 policies 的修改请求不会立即执行，而是保存为待审批的变更，
+// 由 approver_roles 中角色的其他用户审批后再执行；expiry(秒) 内没有审批的变更自动过期
+type approval struct {
+	Expiry   int
+	Policies []approvalPolicy
+}
+
+// approvalPolicy routes 为 "METHOD /path" 形式的路由；tags 与 hosts 为需要审批的主机范围，都为空时不限制主机；
+// fields 要求请求参数中的字段为其中的值，例如只有删除 22 端口时需要审批；approvals 为需要的审批人数
+type approvalPolicy struct {
+	Name          string
+	Routes        []string
+	Tags          []string
+	Hosts         []string
+	Fields        map[string][]string
+	ApproverRoles []string `mapstructure:"approver_roles"`
+	Approvals     int
+}
+
+// audit 审计日志，body_limit 为保存的请求内容的最大长度，redact_fields 为需要隐藏的请求字段，
+// 字段名包含其中任意一项时隐藏；snapshot 为 true 时保存修改请求执行前后被修改资源的快照；
+// 审计日志以 hash 链接，每 checkpoint_interval(秒) 使用 checkpoint_key 签名一次链的末端，未配置时使用 jwt 的签发密钥
+type audit struct {
+	BodyLimit          int      `mapstructure:"body_limit"`
+	RedactFields       []string `mapstructure:"redact_fields"`
+	Snapshot           bool
+	CheckpointInterval int              `mapstructure:"checkpoint_interval"`
+	CheckpointKey      jwtKey           `mapstructure:"checkpoint_key"`
+	Forwarders         []auditForwarder `mapstructure:"forwarders"`
+	Retention          auditRetention
+}
+
+// auditRetention 审计日志的保留策略，超过 days 天或超出最新 rows 条的日志每 interval(秒) 清理一次，0 表示不限制；
+// archive_dir 不为空时清理前按 batch 条一个文件归档为 gzip 压缩的 JSON Lines
+type auditRetention struct {
+	Days       int
+	Rows       int
+	ArchiveDir string `mapstructure:"archive_dir"`
+	Interval   int
+	Batch      int
+}
+
+// auditForwarder 实时转发审计日志，type 为 syslog 或 http：syslog 按 RFC 5424 发送到 address，network 为 udp、tcp 或 tls；
+// http 将 format 为 json 或 cef 的日志以换行分隔批量 POST 到 url。发送失败时日志缓存在内存中，每 retry_interval(秒) 重试，
+// 缓存超过 buffer 条时丢弃新的日志；timeout(秒) 为连接与请求的超时时间
+type auditForwarder struct {
+	Name               string
+	Type               string
+	Network            string
+	Address            string
+	URL                string `mapstructure:"url"`
+	Format             string
+	Headers            map[string]string
+	Facility           int
+	CAFile             string `mapstructure:"ca_file"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+	Buffer             int
+	RetryInterval      int `mapstructure:"retry_interval"`
+	Timeout            int
 }
 
 func InitConfiguration(configFile string) error {
 	viper.SetDefault("Port", "2952")
 	viper.SetDefault("Address", "127.0.0.1")
+	viper.SetDefault("ha.lease_name", "firewalld-gateway")
+	viper.SetDefault("ha.lease_duration", 15)
+	viper.SetDefault("ha.renew_deadline", 10)
+	viper.SetDefault("ha.retry_period", 2)
+	viper.SetDefault("ha.poll_interval", 1)
+	viper.SetDefault("drift.interval", 300)
+	viper.SetDefault("metrics.enabled", true)
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.url_path", "/v1/traces")
+	viper.SetDefault("tracing.service_name", "firewalld-gateway")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("health.timeout", 2)
+	viper.SetDefault("password.algorithm", "bcrypt")
+	viper.SetDefault("password.cost", 12)
+	viper.SetDefault("password.argon2_time", 3)
+	viper.SetDefault("password.argon2_memory", 64*1024)
+	viper.SetDefault("password.argon2_threads", 2)
+	viper.SetDefault("password.min_length", 8)
+	viper.SetDefault("password.complexity", 3)
+	viper.SetDefault("password.history", 5)
+	viper.SetDefault("jwt.algorithm", "HS256")
+	viper.SetDefault("oidc.scopes", []string{"openid", "profile", "email"})
+	viper.SetDefault("oidc.username_claim", "preferred_username")
+	viper.SetDefault("oidc.groups_claim", "groups")
+	viper.SetDefault("oidc.auto_provision", true)
+	viper.SetDefault("ldap.timeout", 10)
+	viper.SetDefault("ldap.user_filter", "(&(objectClass=person)(uid=%s))")
+	viper.SetDefault("ldap.username_attribute", "uid")
+	viper.SetDefault("ldap.group_attribute", "memberOf")
+	viper.SetDefault("ldap.auto_provision", true)
+	viper.SetDefault("ldap.sync_interval", 900)
+	viper.SetDefault("session.access_ttl", 900)
+	viper.SetDefault("session.refresh_ttl", 7*24*3600)
+	viper.SetDefault("mfa.issuer", "firewalld-gateway")
+	viper.SetDefault("mfa.step_up_ttl", 300)
+	viper.SetDefault("mfa.step_up_routes", []string{
+		"POST /fw/v2/setting/reload",
+		"POST /fw/v2/setting/flush",
+		"POST /fw/v2/setting/sdz",
+		"POST /fw/v3/setting/reload/runtime",
+		"PUT /fw/v3/setting/sdzone",
+		"POST /fw/template/:id",
+		"POST /fw/template/:id/apply",
+		"POST /fw/template/:id/revisions/:revision/apply",
+		"POST /security/changes/:id/approve",
+	})
+	viper.SetDefault("mfa.recovery_codes", 10)
+	viper.SetDefault("lockout.max_attempts", 5)
+	viper.SetDefault("lockout.ip_max_attempts", 20)
+	viper.SetDefault("lockout.window", 900)
+	viper.SetDefault("lockout.duration", 60)
+	viper.SetDefault("lockout.max_duration", 3600)
+	viper.SetDefault("approval.expiry", 24*3600)
+	viper.SetDefault("audit.body_limit", 4096)
+	viper.SetDefault("audit.redact_fields", []string{"password", "secret", "token", "code", "private_key"})
+	viper.SetDefault("audit.snapshot", true)
+	viper.SetDefault("audit.checkpoint_interval", 3600)
+	viper.SetDefault("audit.retention.interval", 3600)
+	viper.SetDefault("audit.retention.batch", 5000)
 	viper.SetConfigType("toml")
 	viper.SetConfigFile(configFile)
 
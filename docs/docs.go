@@ -15,6 +15,27 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Return the JSON Web Key Set of the gateway, other services can use it to verify tokens issued by the gateway. HMAC keys are never published.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SSO"
+                ],
+                "summary": "Return public keys used to sign tokens.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
         "/fw/host": {
             "get": {
                 "security": [
@@ -454,14 +475,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/template/port": {
-            "get": {
+        "/fw/template/capture": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List port rules.",
+                "description": "Read the permanent settings of a zone (default zone if empty) on host and save them as a new template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -471,14 +492,14 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "List port rules.",
+                "summary": "Capture template from host.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ListQuery"
+                            "$ref": "#/definitions/query.TemplateCaptureQuery"
                         }
                     }
                 ],
@@ -490,14 +511,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/fw/template/drift": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new port rule to template.",
+                "description": "List the latest drift detection result of every host, filter by template and status.",
                 "consumes": [
                     "application/json"
                 ],
@@ -507,15 +530,53 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "Create a new port rule to template.",
+                "summary": "Drift report of templates.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.PortEditQuery"
-                        }
+                        "enum": [
+                            "in_sync",
+                            "drifted",
+                            "remediated",
+                            "error"
+                        ],
+                        "type": "string",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "template_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "simple",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "title",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -526,14 +587,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/fw/template/drift/mode": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update port rule information with port rule id.",
+                "description": "Set drift mode of template, off disables detection, audit only records drift, enforce records and remediates drift.",
                 "consumes": [
                     "application/json"
                 ],
@@ -543,14 +606,14 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "Update port rule information with port rule id.",
+                "summary": "Set drift mode of template.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.PortEditQuery"
+                            "$ref": "#/definitions/query.DriftModeEditQuery"
                         }
                     }
                 ],
@@ -562,14 +625,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/fw/template/forward": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete port rule with rule id.",
+                "description": "List forward ports of templates.",
                 "consumes": [
                     "application/json"
                 ],
@@ -579,14 +644,14 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "Delete port rule with rule id.",
+                "summary": "List forward ports of templates.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.IDQuery"
+                            "$ref": "#/definitions/query.ListQuery"
                         }
                     }
                 ],
@@ -598,16 +663,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/template/rich": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List rich rules.",
+                "description": "Create a new forward port to template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -617,14 +680,14 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "List rich rules.",
+                "summary": "Create a new forward port to template.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ListQuery"
+                            "$ref": "#/definitions/query.ForwardPortEditQuery"
                         }
                     }
                 ],
@@ -637,13 +700,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new rich rule to template.",
+                "description": "Update forward port information with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -653,14 +716,14 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "Create a new rich rule to template.",
+                "summary": "Update forward port information with id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.RichEditQuery"
+                            "$ref": "#/definitions/query.ForwardPortEditQuery"
                         }
                     }
                 ],
@@ -673,13 +736,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update rich rule information with rich rule id.",
+                "description": "Delete forward port with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -689,14 +752,14 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "Update rich rule information with rich rule id.",
+                "summary": "Delete forward port with id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.RichEditQuery"
+                            "$ref": "#/definitions/query.TemplateDeleteQuery"
                         }
                     }
                 ],
@@ -708,14 +771,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/fw/template/icmpblock": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete rich rule with rule id.",
+                "description": "List icmp blocks of templates.",
                 "consumes": [
                     "application/json"
                 ],
@@ -725,14 +790,14 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "Delete rich rule with rule id.",
+                "summary": "List icmp blocks of templates.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.IDQuery"
+                            "$ref": "#/definitions/query.ListQuery"
                         }
                     }
                 ],
@@ -744,16 +809,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/template/{id}": {
-            "post": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List template rules by template ID.",
+                "description": "Create a new icmp block to template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -763,14 +826,15 @@ const docTemplate = `{
                 "tags": [
                     "Template"
                 ],
-                "summary": "List template rules.",
+                "summary": "Create a new icmp block to template.",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Template ID",
-                        "name": "template_id",
-                        "in": "path",
-                        "required": true
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.IcmpBlockEditQuery"
+                        }
                     }
                 ],
                 "responses": {
@@ -781,16 +845,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v1/masquerade": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get nat rule list on firewalld runtime.",
+                "description": "Update icmp block information with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -798,37 +860,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld masquerade"
+                    "Template"
                 ],
-                "summary": "Get nat rule list on firewalld runtime.",
+                "summary": "Update icmp block information with id.",
                 "parameters": [
                     {
-                        "type": "string",
                         "description": "body",
-                        "name": "ip",
-                        "in": "query",
-                        "required": true
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.IcmpBlockEditQuery"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "type": "object"
                         }
                     }
                 }
             },
-            "put": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Enable masqerade on firewalld runtime.",
+                "description": "Delete icmp block with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -836,16 +896,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld masquerade"
+                    "Template"
                 ],
-                "summary": "Enable masqerade on firewalld runtime.",
+                "summary": "Delete icmp block with id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.TemplateDeleteQuery"
                         }
                     }
                 ],
@@ -857,32 +917,37 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/fw/template/import": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Disable masqerade on firewalld runtime.",
+                "description": "Import firewalld zone xml (e.g. /etc/firewalld/zones/public.xml) as a new template, the xml is the request body or the file field of multipart form.\nName defaults to the short of zone, content which template can not hold is returned as warnings.",
                 "consumes": [
-                    "application/json"
+                    "text/xml",
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld masquerade"
+                    "Template"
                 ],
-                "summary": "Disable masqerade on firewalld runtime.",
+                "summary": "Import zone xml as a new template.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.Query"
-                        }
+                        "type": "string",
+                        "name": "message",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -895,14 +960,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v1/nat": {
+        "/fw/template/interface": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get nat rules at firewalld runtime",
+                "description": "List interfaces of templates.",
                 "consumes": [
                     "application/json"
                 ],
@@ -910,16 +975,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld NAT"
+                    "Template"
                 ],
-                "summary": "Get nat rules at firewalld runtime.",
+                "summary": "List interfaces of templates.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.ListQuery"
                         }
                     }
                 ],
@@ -938,7 +1003,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a nat rule at firewall runtime.",
+                "description": "Create a new interface to template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -946,16 +1011,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld NAT"
+                    "Template"
                 ],
-                "summary": "Add a nat rule at firewall runtime.",
+                "summary": "Create a new interface to template.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ForwardQuery"
+                            "$ref": "#/definitions/query.InterfaceEditQuery"
                         }
                     }
                 ],
@@ -968,13 +1033,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a nat rule at firewalld runtime.",
+                "description": "Update interface information with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -982,16 +1047,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld NAT"
+                    "Template"
                 ],
-                "summary": "Remove a nat rule at firewalld runtime.",
+                "summary": "Update interface information with id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.InterfaceEditQuery"
                         }
                     }
                 ],
@@ -1003,16 +1068,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v1/ports": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get port rule at firewalld runtime.",
+                "description": "Delete interface with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1020,37 +1083,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld port"
+                    "Template"
                 ],
-                "summary": "Get port rule at firewalld runtime.",
+                "summary": "Delete interface with id.",
                 "parameters": [
                     {
-                        "type": "string",
                         "description": "body",
-                        "name": "ip",
-                        "in": "query",
-                        "required": true
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.TemplateDeleteQuery"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "type": "object"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/fw/template/jobs/{job}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add port rule at firewalld runtime.",
+                "description": "Get application job and the result (applied, skipped, failed) of every host.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1058,17 +1121,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld port"
+                    "Template"
                 ],
-                "summary": "Add port rule at firewalld runtime.",
+                "summary": "Get application job.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.Query"
-                        }
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "job",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -1079,14 +1141,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/fw/template/masquerade": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a firewalld port rule in runtime.",
+                "description": "Enable or disable masquerade of template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1094,16 +1158,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld port"
+                    "Template"
                 ],
-                "summary": "Delete a firewalld port rule in runtime.",
+                "summary": "Enable or disable masquerade of template.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.PortQuery"
+                            "$ref": "#/definitions/query.MasqueradeEditQuery"
                         }
                     }
                 ],
@@ -1111,21 +1175,20 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "object"
                         }
                     }
                 }
             }
         },
-        "/fw/v1/rich": {
+        "/fw/template/port": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get rich rule list at firewalld runtimes.",
+                "description": "List port rules.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1133,26 +1196,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld rich"
+                    "Template"
                 ],
-                "summary": "Get rich rule list at firewalld runtimes.",
+                "summary": "List port rules.",
                 "parameters": [
                     {
-                        "type": "string",
                         "description": "body",
-                        "name": "ip",
-                        "in": "query",
-                        "required": true
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ListQuery"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "type": "object"
                         }
                     }
                 }
@@ -1163,7 +1224,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add rich rule at firewalld runtimes.",
+                "description": "Create a new port rule to template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1171,16 +1232,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld rich"
+                    "Template"
                 ],
-                "summary": "Add rich rule at firewalld runtimes.",
+                "summary": "Create a new port rule to template.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.RichQuery"
+                            "$ref": "#/definitions/query.PortEditQuery"
                         }
                     }
                 ],
@@ -1193,13 +1254,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove rich rule at firewalld runtimes.",
+                "description": "Update port rule information with port rule id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1207,16 +1268,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld rich"
+                    "Template"
                 ],
-                "summary": "Remove rich rule at firewalld runtimes.",
+                "summary": "Update port rule information with port rule id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.RichQuery"
+                            "$ref": "#/definitions/query.PortEditQuery"
                         }
                     }
                 ],
@@ -1228,16 +1289,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v1/service": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List all service rule on firewalld runtime.",
+                "description": "Delete port rule with rule id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1245,16 +1304,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "Template"
                 ],
-                "summary": "List available service type on firewalld runtime.",
+                "summary": "Delete port rule with rule id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ServiceQuery"
+                            "$ref": "#/definitions/query.TemplateDeleteQuery"
                         }
                     }
                 ],
@@ -1266,14 +1325,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/fw/template/protocol": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a service rule on firewalld runtime.",
+                "description": "List protocols of templates.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1281,16 +1342,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "Template"
                 ],
-                "summary": "Add a service rule on firewalld runtime.",
+                "summary": "List protocols of templates.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ServiceQuery"
+                            "$ref": "#/definitions/query.ListQuery"
                         }
                     }
                 ],
@@ -1303,13 +1364,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a service rule on firewalld runtime.",
+                "description": "Create a new protocol to template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1317,16 +1378,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "Template"
                 ],
-                "summary": "Remove a service rule on firewalld runtime.",
+                "summary": "Create a new protocol to template.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ServiceQuery"
+                            "$ref": "#/definitions/query.ProtocolEditQuery"
                         }
                     }
                 ],
@@ -1338,16 +1399,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v2/masquerade": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get status of masqurade at firewalld permanent.",
+                "description": "Update protocol information with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1355,37 +1414,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld masquerade"
+                    "Template"
                 ],
-                "summary": "Get status of masqurade at firewalld permanent.",
+                "summary": "Update protocol information with id.",
                 "parameters": [
                     {
-                        "type": "string",
                         "description": "body",
-                        "name": "ip",
-                        "in": "query",
-                        "required": true
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ProtocolEditQuery"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "type": "object"
                         }
                     }
                 }
             },
-            "put": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Enable masqerade at firewall permanent.",
+                "description": "Delete protocol with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1393,16 +1450,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld masquerade"
+                    "Template"
                 ],
-                "summary": "Enable masqerade at firewall permanent.",
+                "summary": "Delete protocol with id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.TemplateDeleteQuery"
                         }
                     }
                 ],
@@ -1414,14 +1471,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/fw/template/rich": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Disable masqerade at firewall permanent.",
+                "description": "List rich rules.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1429,16 +1488,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld masquerade"
+                    "Template"
                 ],
-                "summary": "Disable masqerade at firewall permanent.",
+                "summary": "List rich rules.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.ListQuery"
                         }
                     }
                 ],
@@ -1450,16 +1509,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v2/nat": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get nat rules at firewalld permanent.",
+                "description": "Create a new rich rule to template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1467,22 +1524,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld NAT"
+                    "Template"
                 ],
-                "summary": "Get nat rules at firewalld permanent.",
+                "summary": "Create a new rich rule to template.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ip",
-                        "name": "ip",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "zone",
-                        "name": "zone",
-                        "in": "query"
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.RichEditQuery"
+                        }
                     }
                 ],
                 "responses": {
@@ -1494,13 +1546,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a nat rule at firewall permanent.",
+                "description": "Update rich rule information with rich rule id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1508,16 +1560,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld NAT"
+                    "Template"
                 ],
-                "summary": "Add a nat rule at firewall permanent.",
+                "summary": "Update rich rule information with rich rule id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ForwardQuery"
+                            "$ref": "#/definitions/query.RichEditQuery"
                         }
                     }
                 ],
@@ -1536,7 +1588,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a nat rule at firewalld permanent.",
+                "description": "Delete rich rule with rule id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1544,16 +1596,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld NAT"
+                    "Template"
                 ],
-                "summary": "Remove a nat rule at firewalld permanent.",
+                "summary": "Delete rich rule with rule id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.TemplateDeleteQuery"
                         }
                     }
                 ],
@@ -1567,14 +1619,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v2/ports": {
+        "/fw/template/service": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get port rule on firewalld permanent.",
+                "description": "List services of templates.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1582,26 +1634,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld port"
+                    "Template"
                 ],
-                "summary": "Get port rule on firewalld permanent.",
+                "summary": "List services of templates.",
                 "parameters": [
                     {
-                        "type": "string",
                         "description": "body",
-                        "name": "ip",
-                        "in": "query",
-                        "required": true
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ListQuery"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "type": "object"
                         }
                     }
                 }
@@ -1612,7 +1662,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add port rule on firewalld permanent.",
+                "description": "Create a new service to template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1620,16 +1670,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld port"
+                    "Template"
                 ],
-                "summary": "Add port rule on firewalld permanent.",
+                "summary": "Create a new service to template.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.ServiceEditQuery"
                         }
                     }
                 ],
@@ -1642,13 +1692,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a firewalld port rule on permanent.",
+                "description": "Update service information with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1656,16 +1706,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld port"
+                    "Template"
                 ],
-                "summary": "Delete a firewalld port rule on permanent.",
+                "summary": "Update service information with id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.PortQuery"
+                            "$ref": "#/definitions/query.ServiceEditQuery"
                         }
                     }
                 ],
@@ -1673,21 +1723,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "object"
                         }
                     }
                 }
-            }
-        },
-        "/fw/v2/rich": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get rich rule list on firewalld permanent.",
+                "description": "Delete service with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1695,37 +1742,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld rich"
+                    "Template"
                 ],
-                "summary": "Get rich rule list on firewalld permanent.",
+                "summary": "Delete service with id.",
                 "parameters": [
                     {
-                        "type": "string",
                         "description": "body",
-                        "name": "ip",
-                        "in": "query",
-                        "required": true
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.TemplateDeleteQuery"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object"
-                            }
+                            "type": "object"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/fw/template/source": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add rich on firewalld permanent.",
+                "description": "List sources of templates.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1733,16 +1780,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld rich"
+                    "Template"
                 ],
-                "summary": "Add rich rule on firewalld permanent.",
+                "summary": "List sources of templates.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.RichQuery"
+                            "$ref": "#/definitions/query.ListQuery"
                         }
                     }
                 ],
@@ -1755,13 +1802,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove rich rule on firewalld permanent.",
+                "description": "Create a new source to template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1769,16 +1816,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld rich"
+                    "Template"
                 ],
-                "summary": "Remove rich rule on firewalld permanent.",
+                "summary": "Create a new source to template.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.RichQuery"
+                            "$ref": "#/definitions/query.SourceEditQuery"
                         }
                     }
                 ],
@@ -1790,16 +1837,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v2/service": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List all service rule on firewalld permanent.",
+                "description": "Update source information with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1807,16 +1852,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "Template"
                 ],
-                "summary": "List available service type on firewalld permanent.",
+                "summary": "Update source information with id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.SourceEditQuery"
                         }
                     }
                 ],
@@ -1829,13 +1874,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Permanently add service to list of services used in zone.",
+                "description": "Delete source with id.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1843,16 +1888,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "Template"
                 ],
-                "summary": "Permanently add service to list of services used in zone.",
+                "summary": "Delete source with id.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ServiceQuery"
+                            "$ref": "#/definitions/query.TemplateDeleteQuery"
                         }
                     }
                 ],
@@ -1864,14 +1909,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/fw/template/{id}": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a service rule on firewalld premanent.",
+                "description": "Apply template to the hosts in the tag named as template asynchronously, the result of every host is recorded in the returned job.\nRevision 0 means the current content of template, mode is permanent (default) or runtime.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1879,16 +1926,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "Template"
                 ],
-                "summary": "Remove a service rule on firewalld premanent.",
+                "summary": "Apply template to hosts.",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ServiceQuery"
+                            "$ref": "#/definitions/query.TemplateApplyQuery"
                         }
                     }
                 ],
@@ -1902,14 +1956,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v2/service/config": {
+        "/fw/template/{id}/applications": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return list of service names (s) in runtime configuration.",
+                "description": "List which revision of template was pushed to which hosts.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1917,17 +1971,46 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "Template"
                 ],
-                "summary": "Return list of service names (s) in runtime configuration.",
+                "summary": "List applications of template.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.Query"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "simple",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "title",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1938,13 +2021,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/fw/template/{id}/apply": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
+                "description": "Apply template to the hosts in the tag named as template asynchronously, the result of every host is recorded in the returned job.\nRevision 0 means the current content of template, mode is permanent (default) or runtime.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1952,15 +2038,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "Template"
                 ],
+                "summary": "Apply template to hosts.",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.ServiceSettingQuery"
+                            "$ref": "#/definitions/query.TemplateApplyQuery"
                         }
                     }
                 ],
@@ -1974,14 +2068,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v2/setting": {
-            "get": {
+        "/fw/template/{id}/drift/check": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List zone.",
+                "description": "Run drift detection of template immediately, remediation follows the drift mode of template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1989,17 +2083,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "Template"
                 ],
-                "summary": "List zone.",
+                "summary": "Check drift of template now.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.Query"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -2010,107 +2103,134 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/fw/template/{id}/export": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add setting rule in firewalld.",
+                "description": "Export template, or a revision of template, as firewalld zone xml.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/xml"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "Template"
                 ],
-                "summary": "Add setting rule in firewalld.",
+                "summary": "Export template as zone xml.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.ZoneSettingQuery"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "revision",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "type": "string"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/fw/template/{id}/import": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a setting rules in firewalld.",
+                "description": "Replace the content of template with firewalld zone xml, the name of template is kept.",
                 "consumes": [
-                    "application/json"
+                    "text/xml",
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "Template"
                 ],
-                "summary": "Remove a setting rules in firewalld.",
+                "summary": "Replace template with zone xml.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.RemoveQuery"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "message",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "object"
                         }
                     }
                 }
             }
         },
-        "/fw/v2/setting/dp": {
-            "get": {
+        "/fw/template/{id}/import/service": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get default policy.",
+                "description": "Merge the ports and protocols of firewalld service xml (e.g. /etc/firewalld/services/http.xml) into template.",
                 "consumes": [
-                    "application/json"
+                    "text/xml",
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "Template"
                 ],
-                "summary": "Get default policy.",
+                "summary": "Import service xml into template.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.Query"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "message",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -2123,14 +2243,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v2/setting/dz": {
+        "/fw/template/{id}/jobs": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get default zone.",
+                "description": "List application jobs of template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2138,17 +2258,46 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "Template"
                 ],
-                "summary": "Get default zone.",
+                "summary": "List application jobs of template.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.Query"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "simple",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "title",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -2161,14 +2310,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v2/setting/flush": {
+        "/fw/template/{id}/preview": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Flush all firewalld rules to default.",
+                "description": "Show the zone settings computed from template and the diff between every host and the settings, nothing is changed on hosts.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2176,16 +2325,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "Template"
                 ],
-                "summary": "Flush all firewalld rules to default.",
+                "summary": "Preview template application.",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.Query"
+                            "$ref": "#/definitions/query.TemplateApplyQuery"
                         }
                     }
                 ],
@@ -2199,14 +2355,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v2/setting/reload": {
-            "post": {
+        "/fw/template/{id}/revisions": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Reload firewalld.",
+                "description": "List revisions of template, newest first by default.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2214,19 +2370,49 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "Template"
                 ],
-                "summary": "Reload firewalld.",
+                "summary": "List revisions of template.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ip",
-                        "name": "ip",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
+                    },
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "simple",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "title",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
@@ -2236,14 +2422,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v2/setting/sdz": {
-            "post": {
+        "/fw/template/{id}/revisions/diff": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Set default zone in firewalld.",
+                "description": "Diff two template revisions.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2251,14 +2437,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "Template"
                 ],
-                "summary": "Set default zone in firewalld.",
+                "summary": "Diff two template revisions.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ip",
-                        "name": "ip",
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "to",
                         "in": "query",
                         "required": true
                     }
@@ -2273,14 +2471,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v3/masquerade": {
-            "put": {
+        "/fw/template/{id}/revisions/{revision}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Enable masqerade on firewalld runtime with delay timer.",
+                "description": "Get the content of template revision.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2288,17 +2486,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld masquerade"
+                    "Template"
                 ],
-                "summary": "Enable masqerade on firewalld runtime with delay timer.",
+                "summary": "Get template revision.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.BatchZoneQuery"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -2309,14 +2513,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/fw/template/{id}/revisions/{revision}/apply": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Disable masqerade on firewalld runtime with delay timer.",
+                "description": "Apply a past template revision to the hosts of template asynchronously, the result of every host is recorded in the returned job.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2324,16 +2530,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld masquerade"
+                    "Template"
                 ],
-                "summary": "Disable masqerade on firewalld runtime with delay timer.",
+                "summary": "Apply template revision.",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.BatchZoneQuery"
+                            "$ref": "#/definitions/query.TemplateApplyQuery"
                         }
                     }
                 ],
@@ -2347,14 +2567,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v3/nat": {
-            "put": {
+        "/fw/template/{id}/revisions/{revision}/restore": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a NAT rule on firewalld runtime with delay timer.",
+                "description": "Restore template to revision, the restore creates a new revision.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2362,17 +2582,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld NAT"
+                    "Template"
                 ],
-                "summary": "Add a NAT rule on firewalld runtime with delay timer.",
+                "summary": "Restore template to revision.",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.BatchForwardQuery"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision",
+                        "name": "revision",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -2385,14 +2611,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v3/ports": {
-            "put": {
+        "/fw/v1/masquerade": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a port rule on firewalld runtime with delay timer.",
+                "description": "Get nat rule list on firewalld runtime.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2400,37 +2626,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld port"
+                    "firewalld masquerade"
                 ],
-                "summary": "Add a port rule on firewalld runtime with delay timer.",
+                "summary": "Get nat rule list on firewalld runtime.",
                 "parameters": [
                     {
+                        "type": "string",
                         "description": "body",
-                        "name": "query",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/query.BatchPortQuery"
-                        }
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
                         }
                     }
                 }
-            }
-        },
-        "/fw/v3/ports/permanent": {
+            },
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a port rule on firewalld permanent with delay timer.",
+                "description": "Enable masqerade on firewalld runtime.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2438,16 +2664,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld port"
+                    "firewalld masquerade"
                 ],
-                "summary": "Add a port rule on firewalld permanent with delay timer.",
+                "summary": "Enable masqerade on firewalld runtime.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.BatchPortQuery"
+                            "$ref": "#/definitions/query.Query"
                         }
                     }
                 ],
@@ -2459,16 +2685,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v3/rich": {
-            "put": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a rich rules on firewalld runtime with delay timer.",
+                "description": "Disable masqerade on firewalld runtime.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2476,16 +2700,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld rich"
+                    "firewalld masquerade"
                 ],
-                "summary": "Add a rich rules on firewalld runtime with delay timer.",
+                "summary": "Disable masqerade on firewalld runtime.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.BatchRichQuery"
+                            "$ref": "#/definitions/query.Query"
                         }
                     }
                 ],
@@ -2499,14 +2723,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/fw/v3/service": {
-            "put": {
+        "/fw/v1/nat": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Add a service rule on firewalld runtime with delay timer.",
+                "description": "Get nat rules at firewalld runtime",
                 "consumes": [
                     "application/json"
                 ],
@@ -2514,16 +2738,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld service"
+                    "firewalld NAT"
                 ],
-                "summary": "Add a service rule on firewalld runtime with delay timer.",
+                "summary": "Get nat rules at firewalld runtime.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.BatchServiceQuery"
+                            "$ref": "#/definitions/query.Query"
                         }
                     }
                 ],
@@ -2535,16 +2759,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v3/setting/reload/runtime": {
+            },
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Reload firewalld runtime with delay timer.",
+                "description": "Add a nat rule at firewall runtime.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2552,16 +2774,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "firewalld NAT"
                 ],
-                "summary": "Reload firewalld runtime with delay timer.",
+                "summary": "Add a nat rule at firewall runtime.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.BatchSettingQuery"
+                            "$ref": "#/definitions/query.ForwardQuery"
                         }
                     }
                 ],
@@ -2573,16 +2795,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/fw/v3/setting/sdzone": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Set default zone on firewalld with delay timer, this is a runtime and permanent change.",
+                "description": "Remove a nat rule at firewalld runtime.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2590,16 +2810,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "firewalld setting"
+                    "firewalld NAT"
                 ],
-                "summary": "Set default zone on firewalld with delay timer, this is a runtime and permanent change.",
+                "summary": "Remove a nat rule at firewalld runtime.",
                 "parameters": [
                     {
                         "description": "body",
                         "name": "query",
                         "in": "body",
                         "schema": {
-                            "$ref": "#/definitions/query.BatchPortQuery"
+                            "$ref": "#/definitions/query.Query"
                         }
                     }
                 ],
@@ -2613,9 +2833,3177 @@ const docTemplate = `{
                 }
             }
         },
-        "/ping": {
+        "/fw/v1/ports": {
             "get": {
-                "description": "Return process status.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get port rule at firewalld runtime.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld port"
+                ],
+                "summary": "Get port rule at firewalld runtime.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "body",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add port rule at firewalld runtime.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld port"
+                ],
+                "summary": "Add port rule at firewalld runtime.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a firewalld port rule in runtime.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld port"
+                ],
+                "summary": "Delete a firewalld port rule in runtime.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.PortQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v1/rich": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get rich rule list at firewalld runtimes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld rich"
+                ],
+                "summary": "Get rich rule list at firewalld runtimes.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "body",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add rich rule at firewalld runtimes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld rich"
+                ],
+                "summary": "Add rich rule at firewalld runtimes.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.RichQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove rich rule at firewalld runtimes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld rich"
+                ],
+                "summary": "Remove rich rule at firewalld runtimes.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.RichQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v1/service": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List all service rule on firewalld runtime.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "summary": "List available service type on firewalld runtime.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ServiceQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a service rule on firewalld runtime.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "summary": "Add a service rule on firewalld runtime.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ServiceQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a service rule on firewalld runtime.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "summary": "Remove a service rule on firewalld runtime.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ServiceQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/masquerade": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get status of masqurade at firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld masquerade"
+                ],
+                "summary": "Get status of masqurade at firewalld permanent.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "body",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enable masqerade at firewall permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld masquerade"
+                ],
+                "summary": "Enable masqerade at firewall permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Disable masqerade at firewall permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld masquerade"
+                ],
+                "summary": "Disable masqerade at firewall permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/nat": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get nat rules at firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld NAT"
+                ],
+                "summary": "Get nat rules at firewalld permanent.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ip",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "zone",
+                        "name": "zone",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a nat rule at firewall permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld NAT"
+                ],
+                "summary": "Add a nat rule at firewall permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ForwardQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a nat rule at firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld NAT"
+                ],
+                "summary": "Remove a nat rule at firewalld permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/ports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get port rule on firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld port"
+                ],
+                "summary": "Get port rule on firewalld permanent.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "body",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add port rule on firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld port"
+                ],
+                "summary": "Add port rule on firewalld permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a firewalld port rule on permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld port"
+                ],
+                "summary": "Delete a firewalld port rule on permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.PortQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/rich": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get rich rule list on firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld rich"
+                ],
+                "summary": "Get rich rule list on firewalld permanent.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "body",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "object"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add rich on firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld rich"
+                ],
+                "summary": "Add rich rule on firewalld permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.RichQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove rich rule on firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld rich"
+                ],
+                "summary": "Remove rich rule on firewalld permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.RichQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/service": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List all service rule on firewalld permanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "summary": "List available service type on firewalld permanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Permanently add service to list of services used in zone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "summary": "Permanently add service to list of services used in zone.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ServiceQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a service rule on firewalld premanent.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "summary": "Remove a service rule on firewalld premanent.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ServiceQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/service/config": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return list of service names (s) in runtime configuration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "summary": "Return list of service names (s) in runtime configuration.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ServiceSettingQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/setting": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List zone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "List zone.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add setting rule in firewalld.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Add setting rule in firewalld.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ZoneSettingQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a setting rules in firewalld.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Remove a setting rules in firewalld.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.RemoveQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/setting/dp": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get default policy.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Get default policy.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/setting/dz": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get default zone.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Get default zone.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/setting/flush": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Flush all firewalld rules to default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Flush all firewalld rules to default.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.Query"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/setting/reload": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reload firewalld.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Reload firewalld.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ip",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v2/setting/sdz": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set default zone in firewalld.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Set default zone in firewalld.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ip",
+                        "name": "ip",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v3/masquerade": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enable masqerade on firewalld runtime with delay timer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld masquerade"
+                ],
+                "summary": "Enable masqerade on firewalld runtime with delay timer.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchZoneQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Disable masqerade on firewalld runtime with delay timer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld masquerade"
+                ],
+                "summary": "Disable masqerade on firewalld runtime with delay timer.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchZoneQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v3/nat": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a NAT rule on firewalld runtime with delay timer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld NAT"
+                ],
+                "summary": "Add a NAT rule on firewalld runtime with delay timer.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchForwardQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v3/ports": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a port rule on firewalld runtime with delay timer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld port"
+                ],
+                "summary": "Add a port rule on firewalld runtime with delay timer.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchPortQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v3/ports/permanent": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a port rule on firewalld permanent with delay timer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld port"
+                ],
+                "summary": "Add a port rule on firewalld permanent with delay timer.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchPortQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v3/rich": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a rich rules on firewalld runtime with delay timer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld rich"
+                ],
+                "summary": "Add a rich rules on firewalld runtime with delay timer.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchRichQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v3/service": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a service rule on firewalld runtime with delay timer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld service"
+                ],
+                "summary": "Add a service rule on firewalld runtime with delay timer.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchServiceQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v3/setting/reload/runtime": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reload firewalld runtime with delay timer.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Reload firewalld runtime with delay timer.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchSettingQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/fw/v3/setting/sdzone": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set default zone on firewalld with delay timer, this is a runtime and permanent change.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "firewalld setting"
+                ],
+                "summary": "Set default zone on firewalld with delay timer, this is a runtime and permanent change.",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.BatchPortQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Return ok as long as the process is serving HTTP, used by the liveness probe. Dependencies are checked by /readyz.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Return liveness.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Return metrics of HTTP requests, D-Bus calls, the batch queue, host reachability, template drift and login failures in Prometheus exposition format.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Return metrics.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/ping": {
+            "get": {
+                "description": "Return process status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Return process status.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Check database connectivity, migration version, batch processor state, leader election and the optional canary host, return 503 with the failed checks if any check fails.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Return readiness.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return audit logs filtered by user, target host and zone, request and response fields. Snapshots of modified resources are only returned by the detail API.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Return audit logs.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "API token id",
+                        "name": "token_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "one of the target hosts",
+                        "name": "host",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "target zone",
+                        "name": "zone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "request method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "request path prefix",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "event, e.g. login_failed",
+                        "name": "event",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "response http status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "response error code",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only failed requests",
+                        "name": "failed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimum latency in milliseconds",
+                        "name": "min_latency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 start time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 end time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/audit/archives": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the signed records of pruned audit logs and their archive files.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Return audit archives.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/audit/checkpoints": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the signed checkpoints of the audit log chain.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Return audit checkpoints.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sign the end of the audit log chain now instead of waiting for the periodic checkpoint.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Sign the audit log chain.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/audit/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export the audit logs matching the filters as CSV or JSON Lines in the order they were written, pagination parameters are ignored.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Export audit logs.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv or jsonl, default jsonl",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 start time",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 end time",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "username",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "one of the target hosts",
+                        "name": "host",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "target zone",
+                        "name": "zone",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "request method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "request path prefix",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "event",
+                        "name": "event",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only failed requests",
+                        "name": "failed",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/audit/retention": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the retention policy of audit logs and the status of the pruning job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Return the audit retention policy.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/audit/retention/run": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Archive and delete expired audit logs now instead of waiting for the periodic pruning.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Prune audit logs.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/audit/verify": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Walk the hash chain of audit logs and verify the signed checkpoints, return the first broken link if the audit logs were modified or deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Verify the audit log chain.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/audit/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return an audit log with the snapshots of the modified resource before and after the request.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Return an audit log.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Audit log ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/cip": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get client login ip.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get client login ip.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/lockouts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the usernames and source ips locked by failed logins, all=true also returns records that have failures but are not locked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return login lockouts.",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "include records that are not locked",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/lockouts/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unlock a username or a source ip and reset its failed login count.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Clear a login lockout.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Lockout ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/roleRouters": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return all routers of role。",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return all routers of role.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Input parameter",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/roles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return all roles.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return all roles.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "token id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort",
+                        "name": "title",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create a role.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Create a role.",
+                "parameters": [
+                    {
+                        "description": "role body",
+                        "name": "userinput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.RoleEditQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update token with role_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Update token with role_id.",
+                "parameters": [
+                    {
+                        "description": "Input parameter",
+                        "name": "userinput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.RoleEditQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete a role with role_id.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Delete a role with role_id.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/roles/allocate": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "allocate roles to user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "allocate roles to user.",
+                "parameters": [
+                    {
+                        "description": "Input parameter",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.RoleEditQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/roles/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return roles associated with a specific user ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return roles by user ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/roles/{id}/scopes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the hosts and tags a role can operate, a role without scopes is not limited to any host.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return host scopes of a role.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Role ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/routers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return all routers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return all routers.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort",
+                        "name": "title",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create a router rule, path is a gin route pattern, a path ending with /* matches all routes under the prefix and method * matches all methods.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Create a router rule.",
+                "parameters": [
+                    {
+                        "description": "router body",
+                        "name": "userinput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.RouterQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/routers/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return routers associated with a specific role ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return routers by role ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Role ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/userRoles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return all routers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return all routers.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "token id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/auth/userinfo": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return userinfo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Return userinfo.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token",
+                        "name": "token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/changes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return change requests captured by approval policies, filtered by status and requester. Users other than the administrator only see their own requests and the requests they can approve.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Change"
+                ],
+                "summary": "Return change requests.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, approved, rejected, cancelled, expired, executed or failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "requester user id",
+                        "name": "requester_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/changes/policies": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the approval policies in configuration file, requests matching a policy are captured as change requests.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Change"
+                ],
+                "summary": "Return approval policies.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/changes/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return a change request with its full payload, comments, approvals and execution result.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Change"
+                ],
+                "summary": "Return a change request.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Change request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/changes/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Approve a pending change request. The requester cannot approve its own request, approvers must hold one of the approver roles of the policy. The change is executed with the requester's permissions once enough approvals are collected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Change"
+                ],
+                "summary": "Approve a change request.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Change request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "comment",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ChangeCommentQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/changes/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Withdraw a pending change request, only the requester can cancel it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Change"
+                ],
+                "summary": "Cancel a change request.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Change request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "comment",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ChangeCommentQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/changes/{id}/comments": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a comment to a change request.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Change"
+                ],
+                "summary": "Comment a change request.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Change request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "comment",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.ChangeCommentQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/changes/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reject a pending change request, the change is never executed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Change"
+                ],
+                "summary": "Reject a change request.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Change request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "comment",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.ChangeCommentQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/security/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return all tokens.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Token"
+                ],
+                "summary": "Return all tokens.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "token id",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sort",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create an API token with optional expiry, roles and explicit scopes. The token is only returned once, only its hash is stored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2623,27 +6011,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Health"
+                    "Token"
+                ],
+                "summary": "Create a token.",
+                "parameters": [
+                    {
+                        "description": "token body",
+                        "name": "userinput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.TokenEditQuery"
+                        }
+                    }
                 ],
-                "summary": "Return process status.",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/security/audit": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
-                        "BearerAuth": []
+                        "Bearer": []
                     }
                 ],
-                "description": "Return all tokens.",
+                "description": "Update token with token_id, is_update rotates the token and returns the new token once. role_ids and scopes replace the current ones when given.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2651,39 +6049,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Audit"
+                    "Token"
                 ],
-                "summary": "Return all tokens.",
+                "summary": "Update token with token_id.",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "token id",
-                        "name": "id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "sort",
-                        "name": "sort",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "sort",
-                        "name": "title",
-                        "in": "query"
+                        "description": "Input parameter",
+                        "name": "userinput",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.TokenEditQuery"
+                        }
                     }
                 ],
                 "responses": {
@@ -2695,44 +6072,51 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/security/auth/cip": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
-                        "BearerAuth": []
+                        "Bearer": []
                     }
                 ],
-                "description": "Get client login ip.",
+                "description": "Delete a token with token_id.",
                 "consumes": [
-                    "application/json"
+                    "application/x-www-form-urlencoded"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Token"
+                ],
+                "summary": "Delete a token with token_id.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "Get client login ip.",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/security/auth/roleRouters": {
+        "/security/tokens/{id}/scopes": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return all routers of role。",
+                "description": "Return the roles and explicit route, host and tag scopes of a token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2740,15 +6124,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Token"
                 ],
-                "summary": "Return all routers of role.",
+                "summary": "Return roles and scopes of a token.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Input parameter",
+                        "description": "Token ID",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -2763,14 +6147,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/auth/roles": {
+        "/security/users": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return all roles.",
+                "description": "Return all users。",
                 "consumes": [
                     "application/json"
                 ],
@@ -2778,16 +6162,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "Return all roles.",
+                "summary": "Return all users.",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "token id",
-                        "name": "id",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
                         "description": "limit",
@@ -2829,7 +6207,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Create a role.",
+                "description": "Create a user。",
                 "consumes": [
                     "application/json"
                 ],
@@ -2837,17 +6215,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "Create a role.",
+                "summary": "Create a user.",
                 "parameters": [
                     {
-                        "description": "role body",
+                        "description": "Input parameter",
                         "name": "userinput",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/query.RoleEditQuery"
+                            "$ref": "#/definitions/query.UserQuery"
                         }
                     }
                 ],
@@ -2867,7 +6245,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Update token with role_id.",
+                "description": "Create a user。",
                 "consumes": [
                     "application/json"
                 ],
@@ -2875,9 +6253,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "Update token with role_id.",
+                "summary": "update user information.",
                 "parameters": [
                     {
                         "description": "Input parameter",
@@ -2885,7 +6263,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/query.RoleEditQuery"
+                            "$ref": "#/definitions/query.UserEditQuery"
                         }
                     }
                 ],
@@ -2905,7 +6283,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Delete a role with role_id.",
+                "description": "Delete a user.",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -2913,9 +6291,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "Delete a role with role_id.",
+                "summary": "Delete a user.",
                 "parameters": [
                     {
                         "type": "integer",
@@ -2935,14 +6313,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/auth/roles/allocate": {
+        "/security/users/allocate": {
             "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "allocate roles to user.",
+                "description": "Assign roles to users。",
                 "consumes": [
                     "application/json"
                 ],
@@ -2950,17 +6328,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "allocate roles to user.",
+                "summary": "Assign roles to users.",
                 "parameters": [
                     {
                         "description": "Input parameter",
-                        "name": "user",
+                        "name": "id",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/query.RoleEditQuery"
+                            "$ref": "#/definitions/query.AllocateRoleQuery"
                         }
                     }
                 ],
@@ -2975,14 +6353,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/auth/roles/{id}": {
-            "get": {
+        "/security/users/{id}/mfa": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return roles associated with a specific user ID.",
+                "description": "Remove the TOTP secret and recovery codes of a user who lost the authenticator, the user can enroll again after next login.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2990,9 +6368,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "Return roles by user ID.",
+                "summary": "Reset the second factor of a user.",
                 "parameters": [
                     {
                         "type": "integer",
@@ -3013,14 +6391,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/auth/routers": {
+        "/security/users/{id}/sessions": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return all routers.",
+                "description": "Return the active login sessions of a user, all=true also returns revoked and expired sessions.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3028,32 +6406,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "Return all routers.",
+                "summary": "Return login sessions of a user.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "sort",
-                        "name": "sort",
-                        "in": "query"
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "sort",
-                        "name": "title",
+                        "type": "boolean",
+                        "description": "include revoked and expired sessions",
+                        "name": "all",
                         "in": "query"
                     }
                 ],
@@ -3066,16 +6433,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/security/auth/routers/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return routers associated with a specific role ID.",
+                "description": "Revoke one login session of a user, or all of the user's sessions when session is omitted. Access tokens of revoked sessions are rejected immediately.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3083,16 +6448,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "Return routers by role ID.",
+                "summary": "Revoke login sessions of a user.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Role ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "session",
+                        "in": "path"
                     }
                 ],
                 "responses": {
@@ -3106,14 +6477,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/auth/userRoles": {
-            "get": {
+        "/security/users/{id}/sessions/{session}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return all routers.",
+                "description": "Revoke one login session of a user, or all of the user's sessions when session is omitted. Access tokens of revoked sessions are rejected immediately.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3121,33 +6492,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Users"
                 ],
-                "summary": "Return all routers.",
+                "summary": "Revoke login sessions of a user.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "token id",
+                        "description": "User ID",
                         "name": "id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "sort",
-                        "name": "sort",
-                        "in": "query"
+                        "description": "Session ID",
+                        "name": "session",
+                        "in": "path"
                     }
                 ],
                 "responses": {
@@ -3161,14 +6521,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/auth/userinfo": {
-            "get": {
+        "/sso/logout": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return userinfo.",
+                "description": "Revoke the login session of the bearer access token, or of the refresh token in body when the access token is expired.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3176,17 +6536,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "SSO"
                 ],
-                "summary": "Return userinfo.",
+                "summary": "logout.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "token",
-                        "name": "token",
-                        "in": "query"
+                        "description": "refresh token",
+                        "name": "query",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/query.LogoutQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
+                }
+            }
+        },
+        "/sso/methods": {
+            "get": {
+                "description": "Return which login methods are enabled, the frontend uses it to show the local login form or the single sign-on button.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SSO"
                 ],
+                "summary": "Return enabled login methods.",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -3198,50 +6581,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/tokens": {
+        "/sso/mfa": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return all tokens.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Return whether the current user enabled TOTP, whether any role of the user requires it, and the count of unused recovery codes.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Token"
-                ],
-                "summary": "Return all tokens.",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "token id",
-                        "name": "id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "sort",
-                        "name": "sort",
-                        "in": "query"
-                    }
+                    "MFA"
                 ],
+                "summary": "Return second factor status of the current user.",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -3252,13 +6606,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "delete": {
                 "security": [
                     {
-                        "Bearer": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Create a token.",
+                "description": "Verify a TOTP code or a recovery code, then remove the TOTP secret and recovery codes of the current user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3266,17 +6620,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Token"
+                    "MFA"
                 ],
-                "summary": "Create a token.",
+                "summary": "Disable the second factor.",
                 "parameters": [
                     {
-                        "description": "token body",
-                        "name": "userinput",
+                        "description": "TOTP code or recovery code",
+                        "name": "query",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/query.TokenEditQuery"
+                            "$ref": "#/definitions/query.MFACodeQuery"
                         }
                     }
                 ],
@@ -3289,14 +6643,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/sso/mfa/confirm": {
             "post": {
                 "security": [
                     {
-                        "Bearer": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Update token with token_id.",
+                "description": "Enable the second factor with a code from the authenticator and return the recovery codes. Recovery codes are only returned once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3304,17 +6660,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Token"
+                    "MFA"
                 ],
-                "summary": "Update token with token_id.",
+                "summary": "Confirm TOTP enrollment.",
                 "parameters": [
                     {
-                        "description": "Input parameter",
-                        "name": "userinput",
+                        "description": "TOTP code",
+                        "name": "query",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/query.TokenEditQuery"
+                            "$ref": "#/definitions/query.MFACodeQuery"
                         }
                     }
                 ],
@@ -3327,32 +6683,23 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/sso/mfa/enroll": {
+            "post": {
                 "security": [
                     {
-                        "Bearer": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Delete a token with token_id.",
-                "consumes": [
-                    "application/x-www-form-urlencoded"
-                ],
+                "description": "Generate a new TOTP secret and otpauth uri for the current user. The second factor is enabled after it is confirmed with a code.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Token"
-                ],
-                "summary": "Delete a token with token_id.",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    }
+                    "MFA"
                 ],
+                "summary": "Start TOTP enrollment.",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -3364,14 +6711,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/users": {
-            "get": {
+        "/sso/mfa/recovery": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Return all users。",
+                "description": "Verify a TOTP code or a recovery code, then replace all recovery codes of the current user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3379,33 +6726,18 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "MFA"
                 ],
-                "summary": "Return all users.",
+                "summary": "Regenerate recovery codes.",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "offset",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "sort",
-                        "name": "sort",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "sort",
-                        "name": "title",
-                        "in": "query"
+                        "description": "TOTP code or recovery code",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.MFACodeQuery"
+                        }
                     }
                 ],
                 "responses": {
@@ -3417,14 +6749,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/sso/mfa/verify": {
+            "post": {
                 "security": [
                     {
-                        "Bearer": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Create a user。",
+                "description": "Verify a TOTP code or a recovery code, sensitive operations listed in mfa.step_up_routes are allowed within mfa.step_up_ttl seconds after it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3432,17 +6766,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "MFA"
                 ],
-                "summary": "Create a user.",
+                "summary": "Verify the second factor for the current session.",
                 "parameters": [
                     {
-                        "description": "Input parameter",
-                        "name": "userinput",
+                        "description": "TOTP code or recovery code",
+                        "name": "query",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/query.UserQuery"
+                            "$ref": "#/definitions/query.MFACodeQuery"
                         }
                     }
                 ],
@@ -3455,33 +6789,39 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
-                "description": "Create a user。",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/sso/oidc/callback": {
+            "get": {
+                "description": "Exchange the authorization code for id token, provision the user on first login and map groups to roles.\nThe gateway token is appended to success_url as url fragment, or returned as json if success_url is empty.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "SSO"
                 ],
-                "summary": "update user information.",
+                "summary": "OpenID Connect callback.",
                 "parameters": [
                     {
-                        "description": "Input parameter",
-                        "name": "userinput",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/query.UserEditQuery"
-                        }
+                        "type": "string",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "error",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "error_description",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -3491,32 +6831,49 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "302": {
+                        "description": "Found"
                     }
                 }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
+            }
+        },
+        "/sso/oidc/login": {
+            "get": {
+                "description": "Redirect to the authorization endpoint of the OpenID Connect provider, authorization code flow with PKCE is used.",
+                "tags": [
+                    "SSO"
                 ],
-                "description": "Delete a user.",
+                "summary": "Start OpenID Connect login.",
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    }
+                }
+            }
+        },
+        "/sso/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a new refresh token. Every refresh token can only be used once, using a rotated refresh token again revokes the whole session.",
                 "consumes": [
-                    "application/x-www-form-urlencoded"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "SSO"
                 ],
-                "summary": "Delete a user.",
+                "summary": "Refresh access token.",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
+                        "description": "refresh token",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/query.RefreshQuery"
+                        }
                     }
                 ],
                 "responses": {
@@ -3530,14 +6887,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/security/users/allocate": {
+        "/sso/signin": {
             "post": {
                 "security": [
                     {
-                        "Bearer": []
+                        "BearerAuth": []
                     }
                 ],
-                "description": "Assign roles to users。",
+                "description": "login. Unknown users and wrong passwords return the same error, too many failures lock the username or the source ip for an exponentially growing time.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3545,17 +6902,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "SSO"
                 ],
-                "summary": "Assign roles to users.",
+                "summary": "login.",
                 "parameters": [
                     {
-                        "description": "Input parameter",
-                        "name": "id",
+                        "description": "signup body",
+                        "name": "query",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/query.AllocateRoleQuery"
+                            "$ref": "#/definitions/query.UserQuery"
                         }
                     }
                 ],
@@ -3570,14 +6926,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/sso/signin": {
+        "/sso/signin/mfa": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "login.",
+                "description": "Exchange the mfa_token returned by signin and a TOTP code or a recovery code for an access token and a refresh token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3587,14 +6938,15 @@ const docTemplate = `{
                 "tags": [
                     "SSO"
                 ],
-                "summary": "login.",
+                "summary": "Complete login with a second factor.",
                 "parameters": [
                     {
-                        "description": "signup body",
+                        "description": "mfa token and code",
                         "name": "query",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/query.UserQuery"
+                            "$ref": "#/definitions/query.MFASigninQuery"
                         }
                     }
                 ],
@@ -4113,8 +7465,68 @@ const docTemplate = `{
                         "$ref": "#/definitions/query.ZoneDst"
                     }
                 },
-                "delay": {
+                "delay": {
+                    "type": "integer"
+                }
+            }
+        },
+        "query.ChangeCommentQuery": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "maxLength": 1024
+                }
+            }
+        },
+        "query.DriftModeEditQuery": {
+            "type": "object",
+            "required": [
+                "mode",
+                "template_id"
+            ],
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "off",
+                        "audit",
+                        "enforce"
+                    ]
+                },
+                "template_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "query.ForwardPortEditQuery": {
+            "type": "object",
+            "required": [
+                "port",
+                "protocol",
+                "template_id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "port": {
+                    "type": "string"
+                },
+                "protocol": {
+                    "type": "string"
+                },
+                "template_id": {
                     "type": "integer"
+                },
+                "toaddr": {
+                    "type": "string"
+                },
+                "toport": {
+                    "type": "string"
                 }
             }
         },
@@ -4177,6 +7589,48 @@ const docTemplate = `{
                 }
             }
         },
+        "query.IcmpBlockEditQuery": {
+            "type": "object",
+            "required": [
+                "name",
+                "template_id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "query.InterfaceEditQuery": {
+            "type": "object",
+            "required": [
+                "name",
+                "template_id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "query.ListHostQuery": {
             "type": "object",
             "properties": {
@@ -4214,6 +7668,59 @@ const docTemplate = `{
                 }
             }
         },
+        "query.LogoutQuery": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "query.MFACodeQuery": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code TOTP 验证码或恢复码",
+                    "type": "string"
+                }
+            }
+        },
+        "query.MFASigninQuery": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "query.MasqueradeEditQuery": {
+            "type": "object",
+            "required": [
+                "enable",
+                "template_id"
+            ],
+            "properties": {
+                "enable": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "query.PortEditQuery": {
             "type": "object",
             "required": [
@@ -4225,6 +7732,9 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "message": {
+                    "type": "string"
+                },
                 "port": {
                     "type": "integer"
                 },
@@ -4257,6 +7767,27 @@ const docTemplate = `{
                 }
             }
         },
+        "query.ProtocolEditQuery": {
+            "type": "object",
+            "required": [
+                "template_id",
+                "value"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
         "query.Query": {
             "type": "object",
             "required": [
@@ -4286,6 +7817,17 @@ const docTemplate = `{
                 }
             }
         },
+        "query.RefreshQuery": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
         "query.RemoveQuery": {
             "type": "object",
             "required": [
@@ -4326,6 +7868,9 @@ const docTemplate = `{
                 "limit_unit": {
                     "type": "string"
                 },
+                "message": {
+                    "type": "string"
+                },
                 "port": {
                     "type": "string"
                 },
@@ -4376,11 +7921,85 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "require_mfa": {
+                    "description": "RequireMFA 为 nil 时更新角色不修改",
+                    "type": "boolean"
+                },
                 "router_ids": {
                     "type": "array",
                     "items": {
                         "type": "integer"
                     }
+                },
+                "scopes": {
+                    "description": "Scopes 为 nil 时更新角色不修改 scope，为空数组时清除 scope",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/query.RoleScopeQuery"
+                    }
+                }
+            }
+        },
+        "query.RoleScopeQuery": {
+            "type": "object",
+            "required": [
+                "kind",
+                "value"
+            ],
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "host",
+                        "tag"
+                    ]
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "query.RouterQuery": {
+            "type": "object",
+            "required": [
+                "method",
+                "path"
+            ],
+            "properties": {
+                "method": {
+                    "type": "string",
+                    "enum": [
+                        "GET",
+                        "POST",
+                        "PUT",
+                        "DELETE",
+                        "PATCH",
+                        "*"
+                    ]
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "query.ServiceEditQuery": {
+            "type": "object",
+            "required": [
+                "name",
+                "template_id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
                 }
             }
         },
@@ -4424,6 +8043,32 @@ const docTemplate = `{
                 }
             }
         },
+        "query.SourceEditQuery": {
+            "type": "object",
+            "required": [
+                "template_id"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ipset": {
+                    "type": "string"
+                },
+                "mac": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "query.TagEditQuery": {
             "type": "object",
             "required": [
@@ -4441,6 +8086,65 @@ const docTemplate = `{
                 }
             }
         },
+        "query.TemplateApplyQuery": {
+            "type": "object",
+            "properties": {
+                "continue_on_error": {
+                    "type": "boolean"
+                },
+                "host_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "permanent",
+                        "runtime"
+                    ]
+                },
+                "revision": {
+                    "type": "integer"
+                }
+            }
+        },
+        "query.TemplateCaptureQuery": {
+            "type": "object",
+            "required": [
+                "host_id",
+                "name"
+            ],
+            "properties": {
+                "host_id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "query.TemplateDeleteQuery": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
         "query.TemplateEditQuery": {
             "type": "object",
             "required": [
@@ -4453,6 +8157,9 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "message": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -4467,17 +8174,54 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
+                "expires_at": {
+                    "description": "ExpiresAt 为空时 token 不过期",
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "is_update": {
                     "type": "boolean"
                 },
+                "role_ids": {
+                    "description": "RoleIDs 与 Scopes 为 nil 时更新 token 不修改权限",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/query.TokenScopeQuery"
+                    }
+                },
                 "signed_to": {
                     "type": "string"
                 }
             }
         },
+        "query.TokenScopeQuery": {
+            "type": "object",
+            "required": [
+                "kind",
+                "value"
+            ],
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "route",
+                        "host",
+                        "tag"
+                    ]
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
         "query.UserEditQuery": {
             "type": "object",
             "required": [
@@ -1,8 +1,6 @@
 package audit
 
 import (
-	"errors"
-
 	"github.com/gin-gonic/gin"
 
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
@@ -15,19 +13,39 @@ func (a *Audit) RegisterAuditAPI(g *gin.RouterGroup) {
 	// user
 	userGroup := g.Group("/")
 	userGroup.GET("/", a.getAuditLogs)
+	userGroup.GET("/export", a.exportAuditLogs)
+	userGroup.GET("/verify", a.verifyAuditLogs)
+	userGroup.GET("/checkpoints", a.listCheckpoints)
+	userGroup.POST("/checkpoints", a.createCheckpoint)
+	userGroup.GET("/retention", a.getRetention)
+	userGroup.POST("/retention/run", a.runRetention)
+	userGroup.GET("/archives", a.listArchives)
+	userGroup.GET("/:id", a.getAuditLog)
 }
 
 // getAuditLogs godoc
-// @Summary Return all tokens.
-// @Description Return all tokens.
+// @Summary Return audit logs.
+// @Description Return audit logs filtered by user, target host and zone, request and response fields. Snapshots of modified resources are only returned by the detail API.
 // @Tags Audit
 // @Accept  json
 // @Produce json
-// @Param   id  	query  int  	false "token id"
-// @Param   limit  	query  int   	false "limit"
-// @Param   offset  query  int   	false "offset"
-// @Param   sort  	query  string   false "sort"
-// @Param   title  	query  string   false "sort"
+// @Param   title  		 query  string  false "username"
+// @Param   user_id  	 query  int     false "user id"
+// @Param   token_id  	 query  int     false "API token id"
+// @Param   host  		 query  string  false "one of the target hosts"
+// @Param   zone  		 query  string  false "target zone"
+// @Param   method  	 query  string  false "request method"
+// @Param   path  		 query  string  false "request path prefix"
+// @Param   event  		 query  string  false "event, e.g. login_failed"
+// @Param   status  	 query  int     false "response http status"
+// @Param   code  		 query  int     false "response error code"
+// @Param   failed  	 query  bool    false "only failed requests"
+// @Param   min_latency  query  int     false "minimum latency in milliseconds"
+// @Param   since  		 query  string  false "RFC3339 start time"
+// @Param   until  		 query  string  false "RFC3339 end time"
+// @Param   limit  		 query  int     false "limit"
+// @Param   offset  	 query  int     false "offset"
+// @Param   sort  		 query  string  false "sort"
 // @Security BearerAuth
 // @Success 200 {object} map[string]interface{}
 // @Router /security/audit [get]
@@ -35,21 +53,109 @@ func (a *Audit) getAuditLogs(c *gin.Context) {
 
 	// 1. 获取参数和参数校验
 	var enconterError error
-	auditQuery := &query.ListQuery{}
-	enconterError = c.Bind(&auditQuery)
+	auditQuery := &query.AuditListQuery{}
+	enconterError = c.ShouldBindQuery(auditQuery)
 	// 手动对请求参数进行详细的业务规则校验
 	if enconterError != nil {
-		query.APIResponse(c, enconterError, nil)
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	logs, enconterError := model.GetAuditLogs(auditQuery)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, logs)
+}
+
+// getAuditLog godoc
+// @Summary Return an audit log.
+// @Description Return an audit log with the snapshots of the modified resource before and after the request.
+// @Tags Audit
+// @Produce json
+// @Param   id  path  int  true "Audit log ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/audit/{id} [get]
+func (a *Audit) getAuditLog(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	auditQuery := &query.AuditQuery{}
+	if enconterError = c.ShouldBindUri(auditQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	log, enconterError := model.GetAuditLog(auditQuery.ID)
+	if enconterError == query.ErrAuditNotFound {
+		query.API404Response(c, enconterError)
+		return
+	}
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, log)
+}
+
+// verifyAuditLogs godoc
+// @Summary Verify the audit log chain.
+// @Description Walk the hash chain of audit logs and verify the signed checkpoints, return the first broken link if the audit logs were modified or deleted.
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/audit/verify [get]
+func (a *Audit) verifyAuditLogs(c *gin.Context) {
+	verification, enconterError := model.VerifyAuditChain()
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
 		return
 	}
+	query.SuccessResponse(c, nil, verification)
+}
 
-	if roles, enconterError := model.GetAuditLogs(auditQuery.Title, int(auditQuery.Offset), int(auditQuery.Limit), auditQuery.Sort); enconterError == nil {
-		if len(roles) > 0 {
-			query.SuccessResponse(c, nil, roles)
-			return
-		}
-		enconterError = errors.New(query.ErrRoleIsEmpty.Error())
+// listCheckpoints godoc
+// @Summary Return audit checkpoints.
+// @Description Return the signed checkpoints of the audit log chain.
+// @Tags Audit
+// @Produce json
+// @Param   limit  	query  int   	false "limit"
+// @Param   offset  query  int   	false "offset"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/audit/checkpoints [get]
+func (a *Audit) listCheckpoints(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	listQuery := &query.ListQuery{}
+	if enconterError = c.ShouldBindQuery(listQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	checkpoints, enconterError := model.GetAuditCheckpoints(int(listQuery.Offset), int(listQuery.Limit))
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, checkpoints)
+}
 
+// createCheckpoint godoc
+// @Summary Sign the audit log chain.
+// @Description Sign the end of the audit log chain now instead of waiting for the periodic checkpoint.
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/audit/checkpoints [post]
+func (a *Audit) createCheckpoint(c *gin.Context) {
+	checkpoint, enconterError := model.CreateAuditCheckpoint()
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
 	}
-	query.SuccessResponse(c, enconterError, nil)
+	query.SuccessResponse(c, nil, checkpoint)
 }
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praserx/ipconv"
+	"k8s.io/klog/v2"
+
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+var exportHeader = []string{"id", "created_at", "user_id", "username", "token_id", "ip", "method", "path", "browser", "system",
+	"event", "detail", "host", "zone", "status", "code", "latency", "body", "before", "after", "prev_hash", "hash"}
+
+func exportRecord(log *model.AuditDetail) []string {
+	return []string{
+		strconv.FormatUint(uint64(log.ID), 10),
+		log.CreatedAt.Format(time.RFC3339Nano),
+		strconv.FormatUint(log.UserID, 10),
+		log.Username,
+		strconv.FormatUint(uint64(log.TokenID), 10),
+		ipconv.IntToIPv4(log.IP).String(),
+		log.Method,
+		log.Path,
+		log.Browser,
+		log.System,
+		log.Event,
+		log.Detail,
+		log.Host,
+		log.Zone,
+		strconv.Itoa(log.Status),
+		strconv.Itoa(log.Code),
+		strconv.FormatInt(log.Latency, 10),
+		log.Body,
+		log.SnapshotBefore,
+		log.SnapshotAfter,
+		log.PrevHash,
+		log.Hash,
+	}
+}
+
+// exportAuditLogs godoc
+// @Summary Export audit logs.
+// @Description Export the audit logs matching the filters as CSV or JSON Lines in the order they were written, pagination parameters are ignored.
+// @Tags Audit
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param   format  	 query  string  false "csv or jsonl, default jsonl"
+// @Param   since  		 query  string  false "RFC3339 start time"
+// @Param   until  		 query  string  false "RFC3339 end time"
+// @Param   title  		 query  string  false "username"
+// @Param   user_id  	 query  int     false "user id"
+// @Param   host  		 query  string  false "one of the target hosts"
+// @Param   zone  		 query  string  false "target zone"
+// @Param   method  	 query  string  false "request method"
+// @Param   path  		 query  string  false "request path prefix"
+// @Param   event  		 query  string  false "event"
+// @Param   failed  	 query  bool    false "only failed requests"
+// @Security BearerAuth
+// @Success 200 {string} string
+// @Router /security/audit/export [get]
+func (a *Audit) exportAuditLogs(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	exportQuery := &query.AuditExportQuery{}
+	if enconterError = c.ShouldBindQuery(exportQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().Format("20060102150405"), exportQuery.Format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	var write func(log *model.AuditDetail) error
+	switch exportQuery.Format {
+	case "csv":
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		writer := csv.NewWriter(c.Writer)
+		if enconterError = writer.Write(exportHeader); enconterError != nil {
+			return
+		}
+		write = func(log *model.AuditDetail) error {
+			if err := writer.Write(exportRecord(log)); err != nil {
+				return err
+			}
+			writer.Flush()
+			return writer.Error()
+		}
+	default:
+		c.Header("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(c.Writer)
+		write = func(log *model.AuditDetail) error {
+			return encoder.Encode(log)
+		}
+	}
+	c.Status(http.StatusOK)
+
+	// 开始写入后无法再返回错误响应，只记录日志
+	if enconterError = model.ExportAuditLogs(&exportQuery.AuditListQuery, write); enconterError != nil {
+		klog.Errorf("Export audit logs failed: %v", enconterError)
+	}
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/server/auditor"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// getRetention godoc
+// @Summary Return the audit retention policy.
+// @Description Return the retention policy of audit logs and the status of the pruning job.
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/audit/retention [get]
+func (a *Audit) getRetention(c *gin.Context) {
+	retention := config.CONFIG.Audit.Retention
+	query.SuccessResponse(c, nil, map[string]interface{}{
+		"days":        retention.Days,
+		"rows":        retention.Rows,
+		"archive_dir": retention.ArchiveDir,
+		"interval":    retention.Interval,
+		"batch":       retention.Batch,
+		"status":      auditor.GetPruneStatus(),
+	})
+}
+
+// runRetention godoc
+// @Summary Prune audit logs.
+// @Description Archive and delete expired audit logs now instead of waiting for the periodic pruning.
+// @Tags Audit
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Router /security/audit/retention/run [post]
+func (a *Audit) runRetention(c *gin.Context) {
+	enconterError := auditor.Prune()
+	if enconterError == query.ErrPruneRunning {
+		query.API409Response(c, enconterError)
+		return
+	}
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, auditor.GetPruneStatus())
+}
+
+// listArchives godoc
+// @Summary Return audit archives.
+// @Description Return the signed records of pruned audit logs and their archive files.
+// @Tags Audit
+// @Produce json
+// @Param   limit  	query  int   	false "limit"
+// @Param   offset  query  int   	false "offset"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/audit/archives [get]
+func (a *Audit) listArchives(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	listQuery := &query.ListQuery{}
+	if enconterError = c.ShouldBindQuery(listQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	archives, enconterError := model.GetAuditArchives(int(listQuery.Offset), int(listQuery.Limit))
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, archives)
+}
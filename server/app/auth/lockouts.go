@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// getLockouts godoc
+// @Summary Return login lockouts.
+// @Description Return the usernames and source ips locked by failed logins, all=true also returns records that have failures but are not locked.
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   all  query  bool  false "include records that are not locked"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/auth/lockouts [get]
+func (a *Auth) getLockouts(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	listQuery := &query.LockoutListQuery{}
+	if enconterError = c.ShouldBindQuery(listQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	lockouts, enconterError := model.GetLockouts(listQuery.All)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, map[string]interface{}{"list": lockouts})
+}
+
+// clearLockout godoc
+// @Summary Clear a login lockout.
+// @Description Unlock a username or a source ip and reset its failed login count.
+// @Tags Auth
+// @Accept  json
+// @Produce json
+// @Param   id  path  int  true "Lockout ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/auth/lockouts/{id} [delete]
+func (a *Auth) clearLockout(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	lockoutQuery := &query.LockoutQuery{}
+	if enconterError = c.ShouldBindUri(lockoutQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	found, enconterError := model.ClearLockout(lockoutQuery.ID)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	if !found {
+		query.API404Response(c, query.ErrLockoutNotFound)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
@@ -11,9 +11,11 @@ func (a *Auth) RegisterAuthAPI(g *gin.RouterGroup) {
 	// router handler
 	authGroup.GET("/routers", a.getRouters)
 	authGroup.GET("/routers/:id", a.getRoutersByRoleID)
+	authGroup.PUT("/routers", a.createRouter)
 	// role
 	authGroup.GET("/roles", a.getRoles)
 	authGroup.GET("/roles/:id", a.getRoleByUserId)
+	authGroup.GET("/roles/:id/scopes", a.getRoleScopes)
 	authGroup.PUT("/roles", a.createRole)
 	authGroup.POST("/roles", a.updateRole)
 	authGroup.DELETE("/roles", a.deleteRoleWithID)
@@ -21,5 +23,8 @@ func (a *Auth) RegisterAuthAPI(g *gin.RouterGroup) {
 	// user
 	authGroup.GET("/userRoles", a.getUserRoles)
 	authGroup.GET("/roleRouters", a.getRoleRouters)
+	// lockout
+	authGroup.GET("/lockouts", a.getLockouts)
+	authGroup.DELETE("/lockouts/:id", a.clearLockout)
 
 }
@@ -79,6 +79,32 @@ func (u *Auth) getRoleByUserId(c *gin.Context) {
 	query.SuccessResponse(c, nil, roles)
 }
 
+// getRoleScopes godoc
+// @Summary Return host scopes of a role.
+// @Description Return the hosts and tags a role can operate, a role without scopes is not limited to any host.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/auth/roles/{id}/scopes [get]
+func (u *Auth) getRoleScopes(c *gin.Context) {
+	var enconterError error
+	roleQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(roleQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	scopes, err := model.GetRoleScopes(roleQuery.ID)
+	if err != nil {
+		query.APIResponse(c, err, nil)
+		return
+	}
+	query.SuccessResponse(c, nil, map[string]interface{}{"list": scopes})
+}
+
 // createRole godoc
 // @Summary Create a role.
 // @Description Create a role.
@@ -76,3 +76,32 @@ func (u *Auth) getRoutersByRoleID(c *gin.Context) {
 
 	query.SuccessResponse(c, nil, routers)
 }
+
+// createRouter godoc
+// @Summary Create a router rule.
+// @Description Create a router rule, path is a gin route pattern, a path ending with /* matches all routes under the prefix and method * matches all methods.
+// @Tags Auth
+// @Produce json
+// @Accept  json
+// @Param userinput body query.RouterQuery true "router body"
+// @Security Bearer
+// @Success 200 {object} map[string]interface{}
+// @Router /security/auth/routers [PUT]
+func (a *Auth) createRouter(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	routerQuery := &query.RouterQuery{}
+	enconterError = c.ShouldBindJSON(&routerQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	if enconterError = model.CreateRouter(routerQuery.Path, routerQuery.Method); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
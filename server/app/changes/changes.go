@@ -0,0 +1,255 @@
+package changes
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/server/app/middlewares"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+type Change struct{}
+
+func (ch *Change) RegisterChangeAPI(g *gin.RouterGroup) {
+	changeGroup := g.Group("/")
+	changeGroup.GET("/", ch.listChanges)
+	changeGroup.GET("/policies", ch.listPolicies)
+	changeGroup.GET("/:id", ch.getChange)
+	changeGroup.POST("/:id/approve", ch.approveChange)
+	changeGroup.POST("/:id/reject", ch.rejectChange)
+	changeGroup.POST("/:id/cancel", ch.cancelChange)
+	changeGroup.POST("/:id/comments", ch.commentChange)
+}
+
+// requestUID 返回当前请求的用户 id
+func requestUID(c *gin.Context) uint {
+	return uint(c.GetInt64(auther.UserIDKey))
+}
+
+// redactChange 返回的变更只包含隐藏敏感字段后的请求内容，原始的请求内容只用于执行变更
+func redactChange(change *model.ChangeRequest) *model.ChangeRequest {
+	change.RedactedBody = middlewares.RedactBody([]byte(change.Body), change.ContentType)
+	return change
+}
+
+// bindChangeAction 获取变更 id 与可选的评论
+func bindChangeAction(c *gin.Context) (*query.ChangeQuery, *query.ChangeCommentQuery, bool) {
+	changeQuery := &query.ChangeQuery{}
+	commentQuery := &query.ChangeCommentQuery{}
+	if enconterError := c.ShouldBindUri(changeQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return nil, nil, false
+	}
+	if c.Request.ContentLength > 0 {
+		if enconterError := c.ShouldBindJSON(commentQuery); enconterError != nil {
+			query.API400Response(c, enconterError)
+			return nil, nil, false
+		}
+	}
+	return changeQuery, commentQuery, true
+}
+
+// changeResponse 将变更操作的错误转换为对应的响应
+func changeResponse(c *gin.Context, err error) {
+	switch err {
+	case query.ErrChangeNotFound:
+		query.API404Response(c, err)
+	case query.ErrChangeNotPending, query.ErrChangeExpired, query.ErrChangeApproved:
+		query.API409Response(c, err)
+	case query.ErrChangeSelfApproval, query.ErrChangeApprover, query.ErrNoPermission:
+		query.AuthNoPermission(c, err.(*query.Errno))
+	default:
+		query.API500Response(c, err)
+	}
+}
+
+// listChanges godoc
+// @Summary Return change requests.
+// @Description Return change requests captured by approval policies, filtered by status and requester. Users other than the administrator only see their own requests and the requests they can approve.
+// @Tags Change
+// @Accept  json
+// @Produce json
+// @Param   status        query  string  false "pending, approved, rejected, cancelled, expired, executed or failed"
+// @Param   requester_id  query  int     false "requester user id"
+// @Param   limit  	query  int   	false "limit"
+// @Param   offset  query  int   	false "offset"
+// @Param   sort  	query  string   false "sort"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/changes [get]
+func (ch *Change) listChanges(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	listQuery := &query.ChangeListQuery{}
+	if enconterError = c.ShouldBindQuery(listQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	changes, enconterError := model.GetChangeRequests(requestUID(c), listQuery.Status, listQuery.RequesterID, int(listQuery.Offset), int(listQuery.Limit), listQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, changes)
+}
+
+// listPolicies godoc
+// @Summary Return approval policies.
+// @Description Return the approval policies in configuration file, requests matching a policy are captured as change requests.
+// @Tags Change
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/changes/policies [get]
+func (ch *Change) listPolicies(c *gin.Context) {
+	query.SuccessResponse(c, nil, map[string]interface{}{"list": config.CONFIG.Approval.Policies, "expiry": config.CONFIG.Approval.Expiry})
+}
+
+// getChange godoc
+// @Summary Return a change request.
+// @Description Return a change request with its full payload, comments, approvals and execution result.
+// @Tags Change
+// @Produce json
+// @Param   id  path  int  true "Change request ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/changes/{id} [get]
+func (ch *Change) getChange(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	changeQuery := &query.ChangeQuery{}
+	if enconterError = c.ShouldBindUri(changeQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	change, enconterError := model.GetChangeRequest(changeQuery.ID, requestUID(c))
+	if enconterError != nil {
+		changeResponse(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, redactChange(change))
+}
+
+// approveChange godoc
+// @Summary Approve a change request.
+// @Description Approve a pending change request. The requester cannot approve its own request, approvers must hold one of the approver roles of the policy. The change is executed with the requester's permissions once enough approvals are collected.
+// @Tags Change
+// @Accept  json
+// @Produce json
+// @Param   id     path  int                       true  "Change request ID"
+// @Param   query  body  query.ChangeCommentQuery  false "comment"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/changes/{id}/approve [post]
+func (ch *Change) approveChange(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	changeQuery, commentQuery, ok := bindChangeAction(c)
+	if !ok {
+		return
+	}
+
+	uid := requestUID(c)
+	change, ready, enconterError := model.ApproveChangeRequest(changeQuery.ID, uid, commentQuery.Comment)
+	if enconterError != nil {
+		changeResponse(c, enconterError)
+		return
+	}
+	if !ready {
+		query.SuccessResponse(c, nil, map[string]interface{}{"status": model.ChangePending})
+		return
+	}
+	status, code, result := execute(change)
+	if enconterError = model.FinishChangeRequest(change.ID, uid, status, code, result); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	change, enconterError = model.GetChangeRequest(change.ID, uid)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, redactChange(change))
+}
+
+// rejectChange godoc
+// @Summary Reject a change request.
+// @Description Reject a pending change request, the change is never executed.
+// @Tags Change
+// @Accept  json
+// @Produce json
+// @Param   id     path  int                       true  "Change request ID"
+// @Param   query  body  query.ChangeCommentQuery  false "comment"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/changes/{id}/reject [post]
+func (ch *Change) rejectChange(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	changeQuery, commentQuery, ok := bindChangeAction(c)
+	if !ok {
+		return
+	}
+
+	if enconterError := model.RejectChangeRequest(changeQuery.ID, requestUID(c), commentQuery.Comment); enconterError != nil {
+		changeResponse(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// cancelChange godoc
+// @Summary Cancel a change request.
+// @Description Withdraw a pending change request, only the requester can cancel it.
+// @Tags Change
+// @Accept  json
+// @Produce json
+// @Param   id     path  int                       true  "Change request ID"
+// @Param   query  body  query.ChangeCommentQuery  false "comment"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/changes/{id}/cancel [post]
+func (ch *Change) cancelChange(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	changeQuery, commentQuery, ok := bindChangeAction(c)
+	if !ok {
+		return
+	}
+
+	if enconterError := model.CancelChangeRequest(changeQuery.ID, requestUID(c), commentQuery.Comment); enconterError != nil {
+		changeResponse(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// commentChange godoc
+// @Summary Comment a change request.
+// @Description Add a comment to a change request.
+// @Tags Change
+// @Accept  json
+// @Produce json
+// @Param   id     path  int                       true "Change request ID"
+// @Param   query  body  query.ChangeCommentQuery  true "comment"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/changes/{id}/comments [post]
+func (ch *Change) commentChange(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	changeQuery, commentQuery, ok := bindChangeAction(c)
+	if !ok {
+		return
+	}
+	if commentQuery.Comment == "" {
+		query.API400Response(c, query.ErrParam)
+		return
+	}
+
+	if enconterError := model.CommentChangeRequest(changeQuery.ID, requestUID(c), commentQuery.Comment); enconterError != nil {
+		changeResponse(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
@@ -0,0 +1,60 @@
+package changes
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/server/app/middlewares"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// handler 执行审批通过的变更使用的路由，变更按原请求经过正常的中间件与 handler 执行
+var handler http.Handler
+
+// SetHandler 设置执行变更使用的路由
+func SetHandler(h http.Handler) {
+	handler = h
+}
+
+// resultWriter 保存 handler 的响应
+type resultWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *resultWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *resultWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *resultWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// execute 以申请人的身份重放变更的请求，返回响应的状态码、错误码与内容
+func execute(change *model.ChangeRequest) (int, int, string) {
+	target := &url.URL{Path: change.Path, RawQuery: change.RawQuery}
+	r, err := http.NewRequest(change.Method, target.String(), strings.NewReader(change.Body))
+	if err != nil {
+		return http.StatusInternalServerError, 0, err.Error()
+	}
+	if change.ContentType != "" {
+		r.Header.Set("Content-Type", change.ContentType)
+	}
+	r.Header.Set("User-Agent", "firewalld-gateway-approval")
+	r.RemoteAddr = "127.0.0.1:0"
+	r = middlewares.WithApprovedChange(r, change)
+
+	w := &resultWriter{header: make(http.Header), status: http.StatusOK}
+	handler.ServeHTTP(w, r)
+	klog.V(2).Infof("change request %d %s %s executed with status %d", change.ID, change.Method, change.Path, w.status)
+	return w.status, middlewares.ResponseCode(w.body.Bytes()), w.body.String()
+}
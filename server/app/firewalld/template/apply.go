@@ -0,0 +1,410 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praserx/ipconv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/firewalld"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
+)
+
+// hostPreview 预览中单台主机的结果
+type hostPreview struct {
+	HostID   uint              `json:"host_id"`
+	IP       string            `json:"ip,omitempty"`
+	Hostname string            `json:"hostname,omitempty"`
+	Status   string            `json:"status"`
+	Error    string            `json:"error,omitempty"`
+	Diff     *api.SettingsDiff `json:"diff,omitempty"`
+}
+
+const (
+	previewChanged     = "changed"
+	previewUnchanged   = "unchanged"
+	previewSkipped     = "skipped"
+	previewUnreachable = "unreachable"
+)
+
+// bindApplyQuery 下发参数都是可选的，允许空请求体
+func bindApplyQuery(c *gin.Context) (*query.TemplateApplyQuery, error) {
+	applyQuery := &query.TemplateApplyQuery{}
+	if err := c.ShouldBind(applyQuery); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if applyQuery.Mode == "" {
+		applyQuery.Mode = model.TemplateModePermanent
+	}
+	return applyQuery, nil
+}
+
+// resolveRevision 未指定版本时使用与模板当前内容一致的版本
+func resolveRevision(c *gin.Context, templateID uint, revision int) (*model.TemplateRevision, error) {
+	if revision == 0 {
+		return model.EnsureTemplateRevision(templateID, operator(c))
+	}
+	return model.GetTemplateRevision(templateID, revision)
+}
+
+// selectHosts 返回模板 tag 下需要下发的主机，指定了主机但不在 tag 下的主机作为跳过的结果返回
+func selectHosts(tagName string, hostIDs []uint) (targets []model.Host, skipped map[uint]string, enconterError error) {
+	hosts, enconterError := model.GetHostsByTagName(tagName)
+	if enconterError != nil {
+		return nil, nil, enconterError
+	}
+	if len(hostIDs) == 0 {
+		return hosts, nil, nil
+	}
+	inTag := make(map[uint]model.Host, len(hosts))
+	for _, host := range hosts {
+		inTag[host.ID] = host
+	}
+	skipped = make(map[uint]string)
+	for _, id := range hostIDs {
+		if host, ok := inTag[id]; ok {
+			targets = append(targets, host)
+		} else {
+			skipped[id] = fmt.Sprintf("host is not in tag %s", tagName)
+		}
+	}
+	return targets, skipped, nil
+}
+
+// applyTemplate godoc
+// @Summary Apply template to hosts.
+// @Description Apply template to the hosts in the tag named as template asynchronously, the result of every host is recorded in the returned job.
+// @Description Revision 0 means the current content of template, mode is permanent (default) or runtime.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param query body query.TemplateApplyQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id} [POST]
+// @Router /fw/template/{id}/apply [POST]
+func (t *Template) applyTemplate(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	templateQuery := &query.QueryWithID{}
+	var enconterError error
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	applyQuery, enconterError := bindApplyQuery(c)
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	t.startJob(c, templateQuery.ID, applyQuery)
+}
+
+// startJob 创建下发任务，任务保存在数据库中，由 leader 上的 JobRunner 取出执行
+func (t *Template) startJob(c *gin.Context, templateID uint, applyQuery *query.TemplateApplyQuery) {
+	revision, enconterError := resolveRevision(c, templateID, applyQuery.Revision)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	settings, enconterError := revision.Settings()
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	hosts, _, enconterError := selectHosts(settings.Short, applyQuery.HostIDs)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	if len(hosts) == 0 {
+		query.API404Response(c, fmt.Errorf("No host in tag %s", settings.Short))
+		return
+	}
+
+	carrier := propagation.MapCarrier{}
+	traceContext.Inject(c.Request.Context(), carrier)
+	job := &model.TemplateJob{
+		TemplateID:      templateID,
+		RevisionID:      revision.ID,
+		Revision:        revision.Revision,
+		Mode:            applyQuery.Mode,
+		ContinueOnError: applyQuery.ContinueOnError,
+		CreatedBy:       operator(c),
+		TraceParent:     carrier.Get("traceparent"),
+	}
+	if enconterError = model.CreateTemplateJob(job, applyQuery.HostIDs); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	notifyJobRunner()
+
+	query.SuccessResponse(c, query.OK, job)
+}
+
+// runJob 依次下发到每台主机，未开启 continue_on_error 时第一台失败后其余主机记为跳过；
+// 主机在执行时按任务保存的版本与主机重新选择
+func runJob(job *model.TemplateJob) {
+	ctx := traceContext.Extract(context.Background(), propagation.MapCarrier{"traceparent": job.TraceParent})
+	ctx, span := tracing.Tracer().Start(ctx, "template.job", trace.WithAttributes(
+		attribute.Int("template.id", int(job.TemplateID)),
+		attribute.Int("template.revision", job.Revision),
+		attribute.Int("template.job", int(job.ID)),
+	))
+	defer span.End()
+
+	status := model.JobSucceeded
+	defer func() {
+		if err := model.UpdateTemplateJobStatus(job, status); err != nil {
+			klog.Errorf("Update template job %d failed: %v", job.ID, err)
+		}
+		klog.V(4).Infof("Template job %d finished: %s", job.ID, status)
+	}()
+
+	revision, err := model.GetTemplateRevisionByID(job.RevisionID)
+	if err != nil {
+		klog.Errorf("Get revision %d of template job %d failed: %v", job.Revision, job.ID, err)
+		status = model.JobFailed
+		return
+	}
+	settings, err := revision.Settings()
+	if err != nil {
+		klog.Errorf("Decode revision %d of template job %d failed: %v", job.Revision, job.ID, err)
+		status = model.JobFailed
+		return
+	}
+	hosts, skipped, err := selectHosts(settings.Short, job.HostIDs())
+	if err != nil {
+		klog.Errorf("Select hosts of template job %d failed: %v", job.ID, err)
+		status = model.JobFailed
+		return
+	}
+	klog.V(4).Infof("Template job %d started, %d hosts", job.ID, len(hosts))
+
+	record := func(host model.Host, result, reason string) {
+		application := &model.TemplateApplication{
+			JobID:      job.ID,
+			TemplateID: job.TemplateID,
+			RevisionID: job.RevisionID,
+			Revision:   job.Revision,
+			HostID:     host.ID,
+			IP:         host.IP,
+			AppliedBy:  job.CreatedBy,
+			Mode:       job.Mode,
+			Status:     result,
+			Error:      reason,
+		}
+		if err := model.RecordTemplateApplication(application); err != nil {
+			klog.Errorf("Record result of template job %d on host %d failed: %v", job.ID, host.ID, err)
+		}
+	}
+
+	for id, reason := range skipped {
+		record(model.Host{Model: gorm.Model{ID: id}}, model.TemplateSkipped, reason)
+	}
+	var stopped error
+	for _, host := range hosts {
+		if stopped != nil {
+			record(host, model.TemplateSkipped, "skipped after previous failure: "+stopped.Error())
+			continue
+		}
+		if err := applyToHost(ctx, host, settings, job.Mode); err != nil {
+			record(host, model.TemplateFailed, err.Error())
+			status = model.JobFailed
+			if !job.ContinueOnError {
+				stopped = err
+			}
+			continue
+		}
+		record(host, model.TemplateApplied, "")
+	}
+}
+
+func applyToHost(ctx context.Context, host model.Host, settings *api.Settings, mode string) error {
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(ctx, ipconv.IntToIPv4(host.IP).String())
+	if err != nil {
+		return err
+	}
+	defer dbusClient.Destroy()
+	if mode == model.TemplateModeRuntime {
+		return dbusClient.RuntimeApply(*settings)
+	}
+	return dbusClient.RuntimeSet(*settings)
+}
+
+// previewTemplate godoc
+// @Summary Preview template application.
+// @Description Show the zone settings computed from template and the diff between every host and the settings, nothing is changed on hosts.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param query body query.TemplateApplyQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/preview [POST]
+func (t *Template) previewTemplate(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	templateQuery := &query.QueryWithID{}
+	var enconterError error
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	applyQuery, enconterError := bindApplyQuery(c)
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	var revision *model.TemplateRevision
+	if applyQuery.Revision == 0 {
+		// 预览不生成新版本，直接使用模板当前内容
+		revision, enconterError = currentRevision(templateQuery.ID)
+	} else {
+		revision, enconterError = model.GetTemplateRevision(templateQuery.ID, applyQuery.Revision)
+	}
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	settings, enconterError := revision.Settings()
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	hosts, skipped, enconterError := selectHosts(settings.Short, applyQuery.HostIDs)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+
+	previews := []*hostPreview{}
+	for _, host := range hosts {
+		previews = append(previews, previewHost(c.Request.Context(), host, settings, applyQuery.Mode))
+	}
+	for id, reason := range skipped {
+		previews = append(previews, &hostPreview{HostID: id, Status: previewSkipped, Error: reason})
+	}
+	query.SuccessResponse(c, query.OK, map[string]interface{}{
+		"revision": revision.Revision,
+		"mode":     applyQuery.Mode,
+		"settings": settings,
+		"hosts":    previews,
+	})
+}
+
+// currentRevision 以模板当前内容构造一个未保存的版本
+func currentRevision(templateID uint) (*model.TemplateRevision, error) {
+	details, err := model.GetTemplateDetails(model.DB, templateID)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewTemplateRevision(templateID, details)
+}
+
+func previewHost(ctx context.Context, host model.Host, settings *api.Settings, mode string) *hostPreview {
+	ip := ipconv.IntToIPv4(host.IP).String()
+	preview := &hostPreview{HostID: host.ID, IP: ip, Hostname: host.Hostname}
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(ctx, ip)
+	if err != nil {
+		preview.Status, preview.Error = previewUnreachable, err.Error()
+		return preview
+	}
+	defer dbusClient.Destroy()
+
+	var (
+		live *api.Settings
+		diff *api.SettingsDiff
+	)
+	if mode == model.TemplateModeRuntime {
+		if live, err = dbusClient.GetZoneSettings(""); err == nil {
+			diff = api.DiffRuntimeSettings(live, settings)
+		}
+	} else {
+		if live, err = dbusClient.GetPermanentZoneSettings(""); err == nil {
+			diff = api.DiffSettings(live, settings)
+		}
+	}
+	if err != nil {
+		preview.Status, preview.Error = previewUnreachable, err.Error()
+		return preview
+	}
+	preview.Status = previewUnchanged
+	if !diff.IsEmpty() {
+		preview.Status, preview.Diff = previewChanged, diff
+	}
+	return preview
+}
+
+// listJobs godoc
+// @Summary List application jobs of template.
+// @Description List application jobs of template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param query query query.ListQuery false "query"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/jobs [GET]
+func (t *Template) listJobs(c *gin.Context) {
+	var enconterError error
+	templateQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	listQuery := &query.ListQuery{}
+	if enconterError = c.Bind(&listQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetTemplateJobs(templateQuery.ID, int(listQuery.Offset), int(listQuery.Limit), listQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// getJob godoc
+// @Summary Get application job.
+// @Description Get application job and the result (applied, skipped, failed) of every host.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param job path int true "Job ID"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/jobs/{job} [GET]
+func (t *Template) getJob(c *gin.Context) {
+	jobQuery := &query.TemplateJobQuery{}
+	if enconterError := c.ShouldBindUri(jobQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	job, enconterError := model.GetTemplateJob(jobQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	results, enconterError := model.GetTemplateJobResults(job.ID)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, map[string]interface{}{
+		"job":   job,
+		"hosts": results,
+	})
+}
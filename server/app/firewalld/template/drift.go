@@ -0,0 +1,102 @@
+package template
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/server/reconciler"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// listDrifts godoc
+// @Summary Drift report of templates.
+// @Description List the latest drift detection result of every host, filter by template and status.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query query query.TemplateDriftQuery false "query"
+// @Param list query query.ListQuery false "list"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/drift [GET]
+func (t *Template) listDrifts(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	driftQuery := &query.TemplateDriftQuery{}
+	if enconterError = c.ShouldBindQuery(driftQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	listQuery := &query.ListQuery{}
+	if enconterError = c.Bind(&listQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetTemplateDrifts(driftQuery.TemplateID, driftQuery.Status, int(listQuery.Offset), int(listQuery.Limit), listQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// setDriftMode godoc
+// @Summary Set drift mode of template.
+// @Description Set drift mode of template, off disables detection, audit only records drift, enforce records and remediates drift.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.DriftModeEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/drift/mode [POST]
+func (t *Template) setDriftMode(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	modeQuery := &query.DriftModeEditQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&modeQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if enconterError = model.UpdateTemplateDriftMode(modeQuery.TemplateId, modeQuery.Mode); enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// checkDrift godoc
+// @Summary Check drift of template now.
+// @Description Run drift detection of template immediately, remediation follows the drift mode of template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/drift/check [POST]
+func (t *Template) checkDrift(c *gin.Context) {
+	var enconterError error
+	templateQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	template, enconterError := model.GetTemplateWithID(templateQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	if enconterError = reconciler.ReconcileTemplate(template); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	list, enconterError := model.GetTemplateDrifts(template.ID, "", 1, 9999, "asc")
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
@@ -0,0 +1,159 @@
+package template
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// createForwardPort godoc
+// @Summary Create a new forward port to template.
+// @Description Create a new forward port to template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ForwardPortEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/forward [PUT]
+func (t *Template) createForwardPort(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateForwardPortQuery := &query.ForwardPortEditQuery{}
+	enconterError = c.ShouldBindJSON(&templateForwardPortQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.CreateForwardPort(tx, templateForwardPortQuery); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateForwardPortQuery.TemplateId, templateForwardPortQuery.Message, "add forward port")}, nil
+	})
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// listForwardPort godoc
+// @Summary List forward ports of templates.
+// @Description List forward ports of templates.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ListQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/forward [GET]
+func (t *Template) listForwardPort(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateForwardPortQuery := &query.ListQuery{}
+	enconterError = c.Bind(&templateForwardPortQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetForwardPorts(templateForwardPortQuery.Title, int(templateForwardPortQuery.Offset), int(templateForwardPortQuery.Limit), templateForwardPortQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// deleteForwardPortWithID godoc
+// @Summary Delete forward port with id.
+// @Description Delete forward port with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.TemplateDeleteQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/forward [DELETE]
+func (t *Template) deleteForwardPortWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateForwardPortQuery := &query.TemplateDeleteQuery{}
+	enconterError = c.Bind(&templateForwardPortQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	templateID, enconterError := model.QueryTemplateIDWithForwardPortID(templateForwardPortQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.DeleteForwardPortWithID(tx, templateForwardPortQuery.ID); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateID, templateForwardPortQuery.Message, "delete forward port")}, nil
+	})
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// updateForwardPortWithID godoc
+// @Summary Update forward port information with id.
+// @Description Update forward port information with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ForwardPortEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/forward [POST]
+func (t *Template) updateForwardPortWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateForwardPortQuery := &query.ForwardPortEditQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&templateForwardPortQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if templateForwardPortQuery.ID > 0 {
+		previousTemplateID, enconterError := model.QueryTemplateIDWithForwardPortID(templateForwardPortQuery.ID)
+		if enconterError != nil {
+			query.API404Response(c, enconterError)
+			return
+		}
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdateForwardPortWithID(tx, templateForwardPortQuery); err != nil {
+				return nil, err
+			}
+			var commits []model.TemplateRevisionCommit
+			if previousTemplateID != templateForwardPortQuery.TemplateId {
+				commits = append(commits, revisionCommit(previousTemplateID, templateForwardPortQuery.Message, "move forward port to another template"))
+			}
+			return append(commits, revisionCommit(templateForwardPortQuery.TemplateId, templateForwardPortQuery.Message, "update forward port")), nil
+		})
+		if enconterError != nil {
+			query.API409Response(c, enconterError)
+			return
+		}
+		query.SuccessResponse(c, query.OK, nil)
+		return
+	}
+	query.APIResponse(c, errors.New("invaild id"), nil)
+}
@@ -0,0 +1,159 @@
+package template
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// createIcmpBlock godoc
+// @Summary Create a new icmp block to template.
+// @Description Create a new icmp block to template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.IcmpBlockEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/icmpblock [PUT]
+func (t *Template) createIcmpBlock(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateIcmpBlockQuery := &query.IcmpBlockEditQuery{}
+	enconterError = c.ShouldBindJSON(&templateIcmpBlockQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.CreateIcmpBlock(tx, templateIcmpBlockQuery); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateIcmpBlockQuery.TemplateId, templateIcmpBlockQuery.Message, "add icmp block")}, nil
+	})
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// listIcmpBlock godoc
+// @Summary List icmp blocks of templates.
+// @Description List icmp blocks of templates.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ListQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/icmpblock [GET]
+func (t *Template) listIcmpBlock(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateIcmpBlockQuery := &query.ListQuery{}
+	enconterError = c.Bind(&templateIcmpBlockQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetIcmpBlocks(templateIcmpBlockQuery.Title, int(templateIcmpBlockQuery.Offset), int(templateIcmpBlockQuery.Limit), templateIcmpBlockQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// deleteIcmpBlockWithID godoc
+// @Summary Delete icmp block with id.
+// @Description Delete icmp block with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.TemplateDeleteQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/icmpblock [DELETE]
+func (t *Template) deleteIcmpBlockWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateIcmpBlockQuery := &query.TemplateDeleteQuery{}
+	enconterError = c.Bind(&templateIcmpBlockQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	templateID, enconterError := model.QueryTemplateIDWithIcmpBlockID(templateIcmpBlockQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.DeleteIcmpBlockWithID(tx, templateIcmpBlockQuery.ID); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateID, templateIcmpBlockQuery.Message, "delete icmp block")}, nil
+	})
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// updateIcmpBlockWithID godoc
+// @Summary Update icmp block information with id.
+// @Description Update icmp block information with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.IcmpBlockEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/icmpblock [POST]
+func (t *Template) updateIcmpBlockWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateIcmpBlockQuery := &query.IcmpBlockEditQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&templateIcmpBlockQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if templateIcmpBlockQuery.ID > 0 {
+		previousTemplateID, enconterError := model.QueryTemplateIDWithIcmpBlockID(templateIcmpBlockQuery.ID)
+		if enconterError != nil {
+			query.API404Response(c, enconterError)
+			return
+		}
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdateIcmpBlockWithID(tx, templateIcmpBlockQuery); err != nil {
+				return nil, err
+			}
+			var commits []model.TemplateRevisionCommit
+			if previousTemplateID != templateIcmpBlockQuery.TemplateId {
+				commits = append(commits, revisionCommit(previousTemplateID, templateIcmpBlockQuery.Message, "move icmp block to another template"))
+			}
+			return append(commits, revisionCommit(templateIcmpBlockQuery.TemplateId, templateIcmpBlockQuery.Message, "update icmp block")), nil
+		})
+		if enconterError != nil {
+			query.API409Response(c, enconterError)
+			return
+		}
+		query.SuccessResponse(c, query.OK, nil)
+		return
+	}
+	query.APIResponse(c, errors.New("invaild id"), nil)
+}
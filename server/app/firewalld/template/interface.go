@@ -0,0 +1,159 @@
+package template
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// createInterface godoc
+// @Summary Create a new interface to template.
+// @Description Create a new interface to template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.InterfaceEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/interface [PUT]
+func (t *Template) createInterface(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateInterfaceQuery := &query.InterfaceEditQuery{}
+	enconterError = c.ShouldBindJSON(&templateInterfaceQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.CreateInterface(tx, templateInterfaceQuery); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateInterfaceQuery.TemplateId, templateInterfaceQuery.Message, "add interface")}, nil
+	})
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// listInterface godoc
+// @Summary List interfaces of templates.
+// @Description List interfaces of templates.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ListQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/interface [GET]
+func (t *Template) listInterface(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateInterfaceQuery := &query.ListQuery{}
+	enconterError = c.Bind(&templateInterfaceQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetInterfaces(templateInterfaceQuery.Title, int(templateInterfaceQuery.Offset), int(templateInterfaceQuery.Limit), templateInterfaceQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// deleteInterfaceWithID godoc
+// @Summary Delete interface with id.
+// @Description Delete interface with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.TemplateDeleteQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/interface [DELETE]
+func (t *Template) deleteInterfaceWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateInterfaceQuery := &query.TemplateDeleteQuery{}
+	enconterError = c.Bind(&templateInterfaceQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	templateID, enconterError := model.QueryTemplateIDWithInterfaceID(templateInterfaceQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.DeleteInterfaceWithID(tx, templateInterfaceQuery.ID); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateID, templateInterfaceQuery.Message, "delete interface")}, nil
+	})
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// updateInterfaceWithID godoc
+// @Summary Update interface information with id.
+// @Description Update interface information with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.InterfaceEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/interface [POST]
+func (t *Template) updateInterfaceWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateInterfaceQuery := &query.InterfaceEditQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&templateInterfaceQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if templateInterfaceQuery.ID > 0 {
+		previousTemplateID, enconterError := model.QueryTemplateIDWithInterfaceID(templateInterfaceQuery.ID)
+		if enconterError != nil {
+			query.API404Response(c, enconterError)
+			return
+		}
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdateInterfaceWithID(tx, templateInterfaceQuery); err != nil {
+				return nil, err
+			}
+			var commits []model.TemplateRevisionCommit
+			if previousTemplateID != templateInterfaceQuery.TemplateId {
+				commits = append(commits, revisionCommit(previousTemplateID, templateInterfaceQuery.Message, "move interface to another template"))
+			}
+			return append(commits, revisionCommit(templateInterfaceQuery.TemplateId, templateInterfaceQuery.Message, "update interface")), nil
+		})
+		if enconterError != nil {
+			query.API409Response(c, enconterError)
+			return
+		}
+		query.SuccessResponse(c, query.OK, nil)
+		return
+	}
+	query.APIResponse(c, errors.New("invaild id"), nil)
+}
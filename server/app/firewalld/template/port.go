@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
 	"github.com/cylonchau/firewalld-gateway/utils/model"
@@ -31,7 +32,13 @@ func (t *Template) createPort(c *gin.Context) {
 		return
 	}
 
-	if enconterError = model.CreatePort(templatePortquery.Port, templatePortquery.Protocol, templatePortquery.TemplateId); enconterError != nil {
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.CreatePort(tx, templatePortquery.Port, templatePortquery.Protocol, templatePortquery.TemplateId); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templatePortquery.TemplateId, templatePortquery.Message, "add port")}, nil
+	})
+	if enconterError != nil {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
@@ -73,7 +80,7 @@ func (t *Template) listPort(c *gin.Context) {
 // @Tags Template
 // @Accept json
 // @Produce json
-// @Param query body query.IDQuery false "body"
+// @Param query body query.TemplateDeleteQuery false "body"
 // @Security BearerAuth
 // @Success 200 {object} interface{}
 // @Router /fw/template/port [DELETE]
@@ -81,14 +88,24 @@ func (t *Template) deletePortWithID(c *gin.Context) {
 
 	// 1. 获取参数和参数校验
 	var enconterError error
-	templatePortquery := &query.IDQuery{}
+	templatePortquery := &query.TemplateDeleteQuery{}
 	enconterError = c.Bind(&templatePortquery)
 	// 手动对请求参数进行详细的业务规则校验
 	if enconterError != nil {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
-	enconterError = model.DeletePortWithID(templatePortquery.ID)
+	templateID, enconterError := model.QueryTemplateIDWithPortID(templatePortquery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.DeletePortWithID(tx, templatePortquery.ID); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateID, templatePortquery.Message, "delete port")}, nil
+	})
 	if enconterError != nil {
 		query.API500Response(c, enconterError)
 		return
@@ -119,7 +136,22 @@ func (t *Template) updatePortWithID(c *gin.Context) {
 	}
 
 	if templatePortquery.ID > 0 {
-		if enconterError = model.UpdatePortWithID(templatePortquery.ID, templatePortquery.Port, templatePortquery.Protocol, templatePortquery.TemplateId); enconterError != nil {
+		previousTemplateID, enconterError := model.QueryTemplateIDWithPortID(templatePortquery.ID)
+		if enconterError != nil {
+			query.API404Response(c, enconterError)
+			return
+		}
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdatePortWithID(tx, templatePortquery.ID, templatePortquery.Port, templatePortquery.Protocol, templatePortquery.TemplateId); err != nil {
+				return nil, err
+			}
+			var commits []model.TemplateRevisionCommit
+			if previousTemplateID != templatePortquery.TemplateId {
+				commits = append(commits, revisionCommit(previousTemplateID, templatePortquery.Message, "move port to another template"))
+			}
+			return append(commits, revisionCommit(templatePortquery.TemplateId, templatePortquery.Message, "update port")), nil
+		})
+		if enconterError != nil {
 			query.API409Response(c, enconterError)
 			return
 		}
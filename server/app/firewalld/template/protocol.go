@@ -0,0 +1,159 @@
+package template
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// createProtocol godoc
+// @Summary Create a new protocol to template.
+// @Description Create a new protocol to template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ProtocolEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/protocol [PUT]
+func (t *Template) createProtocol(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateProtocolQuery := &query.ProtocolEditQuery{}
+	enconterError = c.ShouldBindJSON(&templateProtocolQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.CreateProtocol(tx, templateProtocolQuery); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateProtocolQuery.TemplateId, templateProtocolQuery.Message, "add protocol")}, nil
+	})
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// listProtocol godoc
+// @Summary List protocols of templates.
+// @Description List protocols of templates.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ListQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/protocol [GET]
+func (t *Template) listProtocol(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateProtocolQuery := &query.ListQuery{}
+	enconterError = c.Bind(&templateProtocolQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetProtocols(templateProtocolQuery.Title, int(templateProtocolQuery.Offset), int(templateProtocolQuery.Limit), templateProtocolQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// deleteProtocolWithID godoc
+// @Summary Delete protocol with id.
+// @Description Delete protocol with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.TemplateDeleteQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/protocol [DELETE]
+func (t *Template) deleteProtocolWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateProtocolQuery := &query.TemplateDeleteQuery{}
+	enconterError = c.Bind(&templateProtocolQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	templateID, enconterError := model.QueryTemplateIDWithProtocolID(templateProtocolQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.DeleteProtocolWithID(tx, templateProtocolQuery.ID); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateID, templateProtocolQuery.Message, "delete protocol")}, nil
+	})
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// updateProtocolWithID godoc
+// @Summary Update protocol information with id.
+// @Description Update protocol information with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ProtocolEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/protocol [POST]
+func (t *Template) updateProtocolWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateProtocolQuery := &query.ProtocolEditQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&templateProtocolQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if templateProtocolQuery.ID > 0 {
+		previousTemplateID, enconterError := model.QueryTemplateIDWithProtocolID(templateProtocolQuery.ID)
+		if enconterError != nil {
+			query.API404Response(c, enconterError)
+			return
+		}
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdateProtocolWithID(tx, templateProtocolQuery); err != nil {
+				return nil, err
+			}
+			var commits []model.TemplateRevisionCommit
+			if previousTemplateID != templateProtocolQuery.TemplateId {
+				commits = append(commits, revisionCommit(previousTemplateID, templateProtocolQuery.Message, "move protocol to another template"))
+			}
+			return append(commits, revisionCommit(templateProtocolQuery.TemplateId, templateProtocolQuery.Message, "update protocol")), nil
+		})
+		if enconterError != nil {
+			query.API409Response(c, enconterError)
+			return
+		}
+		query.SuccessResponse(c, query.OK, nil)
+		return
+	}
+	query.APIResponse(c, errors.New("invaild id"), nil)
+}
@@ -0,0 +1,190 @@
+package template
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// listRevisions godoc
+// @Summary List revisions of template.
+// @Description List revisions of template, newest first by default.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param query query query.ListQuery false "query"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/revisions [GET]
+func (t *Template) listRevisions(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	listQuery := &query.ListQuery{}
+	if enconterError = c.Bind(&listQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetTemplateRevisions(templateQuery.ID, int(listQuery.Offset), int(listQuery.Limit), listQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// getRevision godoc
+// @Summary Get template revision.
+// @Description Get the content of template revision.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param revision path int true "Revision"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/revisions/{revision} [GET]
+func (t *Template) getRevision(c *gin.Context) {
+	revisionQuery := &query.TemplateRevisionQuery{}
+	if enconterError := c.ShouldBindUri(revisionQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	revision, enconterError := model.GetTemplateRevision(revisionQuery.ID, revisionQuery.Revision)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	details, enconterError := revision.Details()
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, map[string]interface{}{
+		"revision": revision.Revision,
+		"author":   revision.Author,
+		"message":  revision.Message,
+		"created":  revision.CreatedAt,
+		"template": details,
+	})
+}
+
+// diffRevisions godoc
+// @Summary Diff two template revisions.
+// @Description Diff two template revisions.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param query query query.TemplateRevisionDiffQuery true "query"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/revisions/diff [GET]
+func (t *Template) diffRevisions(c *gin.Context) {
+	var enconterError error
+	templateQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	diffQuery := &query.TemplateRevisionDiffQuery{}
+	if enconterError = c.ShouldBindQuery(diffQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	diff, enconterError := model.DiffTemplateRevisions(templateQuery.ID, diffQuery.From, diffQuery.To)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, diff)
+}
+
+// restoreRevision godoc
+// @Summary Restore template to revision.
+// @Description Restore template to revision, the restore creates a new revision.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param revision path int true "Revision"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/revisions/{revision}/restore [POST]
+func (t *Template) restoreRevision(c *gin.Context) {
+	revisionQuery := &query.TemplateRevisionQuery{}
+	if enconterError := c.ShouldBindUri(revisionQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	revision, enconterError := model.RestoreTemplateRevision(revisionQuery.ID, revisionQuery.Revision, operator(c))
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, revision.Revision)
+}
+
+// applyRevision godoc
+// @Summary Apply template revision.
+// @Description Apply a past template revision to the hosts of template asynchronously, the result of every host is recorded in the returned job.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param revision path int true "Revision"
+// @Param query body query.TemplateApplyQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/revisions/{revision}/apply [POST]
+func (t *Template) applyRevision(c *gin.Context) {
+	revisionQuery := &query.TemplateRevisionQuery{}
+	if enconterError := c.ShouldBindUri(revisionQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	applyQuery, enconterError := bindApplyQuery(c)
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	applyQuery.Revision = revisionQuery.Revision
+	t.startJob(c, revisionQuery.ID, applyQuery)
+}
+
+// listApplications godoc
+// @Summary List applications of template.
+// @Description List which revision of template was pushed to which hosts.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param query query query.ListQuery false "query"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/applications [GET]
+func (t *Template) listApplications(c *gin.Context) {
+	var enconterError error
+	templateQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	listQuery := &query.ListQuery{}
+	if enconterError = c.Bind(&listQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetTemplateApplications(templateQuery.ID, int(listQuery.Offset), int(listQuery.Limit), listQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
 	"github.com/cylonchau/firewalld-gateway/utils/model"
@@ -31,7 +32,13 @@ func (t *Template) createRich(c *gin.Context) {
 		return
 	}
 
-	if enconterError = model.CreateRich(templateRichQuery); enconterError != nil {
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.CreateRich(tx, templateRichQuery); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateRichQuery.TemplateId, templateRichQuery.Message, "add rich rule")}, nil
+	})
+	if enconterError != nil {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
@@ -73,7 +80,7 @@ func (t *Template) listRich(c *gin.Context) {
 // @Tags Template
 // @Accept json
 // @Produce json
-// @Param query body query.IDQuery false "body"
+// @Param query body query.TemplateDeleteQuery false "body"
 // @Security BearerAuth
 // @Success 200 {object} interface{}
 // @Router /fw/template/rich [DELETE]
@@ -81,14 +88,24 @@ func (t *Template) deleteRichWithID(c *gin.Context) {
 
 	// 1. 获取参数和参数校验
 	var enconterError error
-	templateRichQuery := &query.IDQuery{}
+	templateRichQuery := &query.TemplateDeleteQuery{}
 	enconterError = c.Bind(&templateRichQuery)
 	// 手动对请求参数进行详细的业务规则校验
 	if enconterError != nil {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
-	enconterError = model.DeleteRichWithID(templateRichQuery.ID)
+	templateID, enconterError := model.QueryTemplateIDWithRichID(templateRichQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.DeleteRichWithID(tx, templateRichQuery.ID); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateID, templateRichQuery.Message, "delete rich rule")}, nil
+	})
 	if enconterError != nil {
 		query.API500Response(c, enconterError)
 		return
@@ -117,7 +134,22 @@ func (t *Template) updateRichWithID(c *gin.Context) {
 		return
 	}
 	if templateRichQuery.ID > 0 {
-		if enconterError = model.UpdateRichWithID(templateRichQuery); enconterError != nil {
+		previousTemplateID, enconterError := model.QueryTemplateIDWithRichID(templateRichQuery.ID)
+		if enconterError != nil {
+			query.API404Response(c, enconterError)
+			return
+		}
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdateRichWithID(tx, templateRichQuery); err != nil {
+				return nil, err
+			}
+			var commits []model.TemplateRevisionCommit
+			if previousTemplateID != templateRichQuery.TemplateId {
+				commits = append(commits, revisionCommit(previousTemplateID, templateRichQuery.Message, "move rich rule to another template"))
+			}
+			return append(commits, revisionCommit(templateRichQuery.TemplateId, templateRichQuery.Message, "update rich rule")), nil
+		})
+		if enconterError != nil {
 			query.APIResponse(c, enconterError, nil)
 			return
 		}
@@ -0,0 +1,82 @@
+package template
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// jobBatch 每次从数据库中取出的任务数
+const jobBatch = 10
+
+var traceContext = propagation.TraceContext{}
+
+// wakeup 本副本创建任务后通知 JobRunner 立即取出任务，不需要等待下一次轮询
+var wakeup = make(chan struct{}, 1)
+
+func notifyJobRunner() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// JobRunner 执行保存在数据库中的模板下发任务，所有副本都可以创建任务，只有 leader 运行 JobRunner；
+// 模板下发不使用 batch_processor 的任务队列：一个任务按顺序下发到多台主机，失败后跳过其余主机并记录每台主机的结果，
+// 而队列中的任务只对应一台主机，失败后单独重试，并且只在开启 async_process 时处理。
+// 任务与队列一样保存在数据库中，由 leader 取出执行，上一个 leader 没有执行完的任务重新执行
+type JobRunner struct {
+	interval time.Duration
+	// jobs 正在执行的任务，失去 leader 后等待它们结束
+	jobs sync.WaitGroup
+}
+
+func NewJobRunner(interval time.Duration) *JobRunner {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &JobRunner{interval: interval}
+}
+
+// Run 将上一个 leader 没有执行完的任务放回等待执行，然后定期取出任务执行直到 stopCh 关闭；
+// 正在执行的任务结束后才返回，避免下一个 leader 在它们执行时重新执行同一个任务
+func (r *JobRunner) Run(stopCh <-chan struct{}) {
+	if reset, err := model.ResetTemplateJobs(); err != nil {
+		klog.Errorf("Reset running template jobs failed: %v", err)
+	} else if reset > 0 {
+		klog.V(2).Infof("%d template jobs left by the previous leader are requeued", reset)
+	}
+	klog.V(2).Infof("Template job runner started, interval %v", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.claim()
+		select {
+		case <-stopCh:
+			r.jobs.Wait()
+			klog.V(2).Infof("Template job runner exit.")
+			return
+		case <-ticker.C:
+		case <-wakeup:
+		}
+	}
+}
+
+func (r *JobRunner) claim() {
+	jobs, err := model.ClaimTemplateJobs(jobBatch)
+	if err != nil {
+		klog.Errorf("Claim template jobs failed: %v", err)
+	}
+	for i := range jobs {
+		r.jobs.Add(1)
+		go func(job *model.TemplateJob) {
+			defer r.jobs.Done()
+			runJob(job)
+		}(&jobs[i])
+	}
+}
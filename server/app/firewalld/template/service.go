@@ -0,0 +1,159 @@
+package template
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// createService godoc
+// @Summary Create a new service to template.
+// @Description Create a new service to template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ServiceEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/service [PUT]
+func (t *Template) createService(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateServiceQuery := &query.ServiceEditQuery{}
+	enconterError = c.ShouldBindJSON(&templateServiceQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.CreateService(tx, templateServiceQuery); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateServiceQuery.TemplateId, templateServiceQuery.Message, "add service")}, nil
+	})
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// listService godoc
+// @Summary List services of templates.
+// @Description List services of templates.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ListQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/service [GET]
+func (t *Template) listService(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateServiceQuery := &query.ListQuery{}
+	enconterError = c.Bind(&templateServiceQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetServices(templateServiceQuery.Title, int(templateServiceQuery.Offset), int(templateServiceQuery.Limit), templateServiceQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// deleteServiceWithID godoc
+// @Summary Delete service with id.
+// @Description Delete service with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.TemplateDeleteQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/service [DELETE]
+func (t *Template) deleteServiceWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateServiceQuery := &query.TemplateDeleteQuery{}
+	enconterError = c.Bind(&templateServiceQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	templateID, enconterError := model.QueryTemplateIDWithServiceID(templateServiceQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.DeleteServiceWithID(tx, templateServiceQuery.ID); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateID, templateServiceQuery.Message, "delete service")}, nil
+	})
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// updateServiceWithID godoc
+// @Summary Update service information with id.
+// @Description Update service information with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ServiceEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/service [POST]
+func (t *Template) updateServiceWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateServiceQuery := &query.ServiceEditQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&templateServiceQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if templateServiceQuery.ID > 0 {
+		previousTemplateID, enconterError := model.QueryTemplateIDWithServiceID(templateServiceQuery.ID)
+		if enconterError != nil {
+			query.API404Response(c, enconterError)
+			return
+		}
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdateServiceWithID(tx, templateServiceQuery); err != nil {
+				return nil, err
+			}
+			var commits []model.TemplateRevisionCommit
+			if previousTemplateID != templateServiceQuery.TemplateId {
+				commits = append(commits, revisionCommit(previousTemplateID, templateServiceQuery.Message, "move service to another template"))
+			}
+			return append(commits, revisionCommit(templateServiceQuery.TemplateId, templateServiceQuery.Message, "update service")), nil
+		})
+		if enconterError != nil {
+			query.API409Response(c, enconterError)
+			return
+		}
+		query.SuccessResponse(c, query.OK, nil)
+		return
+	}
+	query.APIResponse(c, errors.New("invaild id"), nil)
+}
@@ -0,0 +1,159 @@
+package template
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// createSource godoc
+// @Summary Create a new source to template.
+// @Description Create a new source to template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.SourceEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/source [PUT]
+func (t *Template) createSource(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateSourceQuery := &query.SourceEditQuery{}
+	enconterError = c.ShouldBindJSON(&templateSourceQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.CreateSource(tx, templateSourceQuery); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateSourceQuery.TemplateId, templateSourceQuery.Message, "add source")}, nil
+	})
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// listSource godoc
+// @Summary List sources of templates.
+// @Description List sources of templates.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.ListQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/source [GET]
+func (t *Template) listSource(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateSourceQuery := &query.ListQuery{}
+	enconterError = c.Bind(&templateSourceQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	list, enconterError := model.GetSources(templateSourceQuery.Title, int(templateSourceQuery.Offset), int(templateSourceQuery.Limit), templateSourceQuery.Sort)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, list)
+}
+
+// deleteSourceWithID godoc
+// @Summary Delete source with id.
+// @Description Delete source with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.TemplateDeleteQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/source [DELETE]
+func (t *Template) deleteSourceWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateSourceQuery := &query.TemplateDeleteQuery{}
+	enconterError = c.Bind(&templateSourceQuery)
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	templateID, enconterError := model.QueryTemplateIDWithSourceID(templateSourceQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.DeleteSourceWithID(tx, templateSourceQuery.ID); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(templateID, templateSourceQuery.Message, "delete source")}, nil
+	})
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// updateSourceWithID godoc
+// @Summary Update source information with id.
+// @Description Update source information with id.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.SourceEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/source [POST]
+func (t *Template) updateSourceWithID(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateSourceQuery := &query.SourceEditQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&templateSourceQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if templateSourceQuery.ID > 0 {
+		previousTemplateID, enconterError := model.QueryTemplateIDWithSourceID(templateSourceQuery.ID)
+		if enconterError != nil {
+			query.API404Response(c, enconterError)
+			return
+		}
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdateSourceWithID(tx, templateSourceQuery); err != nil {
+				return nil, err
+			}
+			var commits []model.TemplateRevisionCommit
+			if previousTemplateID != templateSourceQuery.TemplateId {
+				commits = append(commits, revisionCommit(previousTemplateID, templateSourceQuery.Message, "move source to another template"))
+			}
+			return append(commits, revisionCommit(templateSourceQuery.TemplateId, templateSourceQuery.Message, "update source")), nil
+		})
+		if enconterError != nil {
+			query.API409Response(c, enconterError)
+			return
+		}
+		query.SuccessResponse(c, query.OK, nil)
+		return
+	}
+	query.APIResponse(c, errors.New("invaild id"), nil)
+}
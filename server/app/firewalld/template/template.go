@@ -2,13 +2,12 @@ package template
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/gin-gonic/gin"
-	"github.com/praserx/ipconv"
+	"gorm.io/gorm"
 
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
-	"github.com/cylonchau/firewalld-gateway/utils/firewalld"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
 	"github.com/cylonchau/firewalld-gateway/utils/model"
 )
 
@@ -16,7 +15,7 @@ type Template struct{}
 
 func (t *Template) RegisterTemplateAPI(g *gin.RouterGroup) {
 	g.GET("/", t.listTemplate)
-	g.POST("/:id", t.getTemplateRulesWithID)
+	g.POST("/:id", t.applyTemplate)
 	g.PUT("/", t.createTemplate)
 	g.DELETE("/", t.deleteTemplateWithID)
 	g.POST("/", t.updateTemplateWithID)
@@ -28,6 +27,67 @@ func (t *Template) RegisterTemplateAPI(g *gin.RouterGroup) {
 	g.PUT("/rich", t.createRich)
 	g.DELETE("/rich", t.deleteRichWithID)
 	g.POST("/rich", t.updateRichWithID)
+	g.GET("/service", t.listService)
+	g.PUT("/service", t.createService)
+	g.DELETE("/service", t.deleteServiceWithID)
+	g.POST("/service", t.updateServiceWithID)
+	g.GET("/forward", t.listForwardPort)
+	g.PUT("/forward", t.createForwardPort)
+	g.DELETE("/forward", t.deleteForwardPortWithID)
+	g.POST("/forward", t.updateForwardPortWithID)
+	g.GET("/icmpblock", t.listIcmpBlock)
+	g.PUT("/icmpblock", t.createIcmpBlock)
+	g.DELETE("/icmpblock", t.deleteIcmpBlockWithID)
+	g.POST("/icmpblock", t.updateIcmpBlockWithID)
+	g.GET("/source", t.listSource)
+	g.PUT("/source", t.createSource)
+	g.DELETE("/source", t.deleteSourceWithID)
+	g.POST("/source", t.updateSourceWithID)
+	g.GET("/protocol", t.listProtocol)
+	g.PUT("/protocol", t.createProtocol)
+	g.DELETE("/protocol", t.deleteProtocolWithID)
+	g.POST("/protocol", t.updateProtocolWithID)
+	g.GET("/interface", t.listInterface)
+	g.PUT("/interface", t.createInterface)
+	g.DELETE("/interface", t.deleteInterfaceWithID)
+	g.POST("/interface", t.updateInterfaceWithID)
+	g.POST("/masquerade", t.setMasquerade)
+	g.GET("/:id/revisions", t.listRevisions)
+	g.GET("/:id/revisions/diff", t.diffRevisions)
+	g.GET("/:id/revisions/:revision", t.getRevision)
+	g.POST("/:id/revisions/:revision/restore", t.restoreRevision)
+	g.POST("/:id/revisions/:revision/apply", t.applyRevision)
+	g.GET("/:id/applications", t.listApplications)
+	g.POST("/:id/apply", t.applyTemplate)
+	g.POST("/:id/preview", t.previewTemplate)
+	g.GET("/:id/jobs", t.listJobs)
+	g.GET("/jobs/:job", t.getJob)
+	g.PUT("/import", t.importTemplate)
+	g.PUT("/capture", t.captureTemplate)
+	g.POST("/:id/import", t.importZone)
+	g.POST("/:id/import/service", t.importService)
+	g.GET("/:id/export", t.exportTemplate)
+	g.GET("/drift", t.listDrifts)
+	g.POST("/drift/mode", t.setDriftMode)
+	g.POST("/:id/drift/check", t.checkDrift)
+}
+
+// operator 返回当前请求的用户名，作为模板版本和下发记录的操作人
+func operator(c *gin.Context) string {
+	if uid, ok := c.Get(auther.UserIDKey); ok {
+		if user, err := model.QueryUserWithUID(uid.(int64)); err == nil {
+			return user.Username
+		}
+	}
+	return ""
+}
+
+// revisionCommit 返回修改模板后需要生成的版本，message 为空时使用 defaultMessage
+func revisionCommit(templateID int, message, defaultMessage string) model.TemplateRevisionCommit {
+	if message == "" {
+		message = defaultMessage
+	}
+	return model.TemplateRevisionCommit{TemplateID: templateID, Message: message}
 }
 
 // createTemplate godoc
@@ -52,7 +112,14 @@ func (t *Template) createTemplate(c *gin.Context) {
 		return
 	}
 
-	if enconterError = model.CreateTemplate(templateQuery.Name, templateQuery.Description, templateQuery.Target); enconterError != nil {
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		templateID, err := model.CreateTemplate(tx, templateQuery.Name, templateQuery.Description, templateQuery.Target)
+		if err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(int(templateID), templateQuery.Message, "create template")}, nil
+	})
+	if enconterError != nil {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
@@ -93,56 +160,6 @@ func (t *Template) listTemplate(c *gin.Context) {
 	query.SuccessResponse(c, query.OK, list)
 }
 
-// getTemplateRulesWithID godoc
-// @Summary List template rules.
-// @Description List template rules by template ID.
-// @Tags Template
-// @Accept json
-// @Produce json
-// @Param template_id path int true "Template ID"
-// @Security BearerAuth
-// @Success 200 {object} interface{}
-// @Router /fw/template/{id} [POST]
-func (t *Template) getTemplateRulesWithID(c *gin.Context) {
-	// 1. 获取参数
-	templateQuery := &query.QueryWithID{}
-	var enconterError error
-	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
-		query.API400Response(c, enconterError)
-		return
-	}
-
-	templateDetails, enconterError := model.GetRichWithDetailsByTemplateID(templateQuery.ID)
-	if enconterError != nil {
-		query.API500Response(c, enconterError)
-		return
-	}
-	if hosts, enconterError := model.GetHostsByTagName(templateDetails.Short); enconterError == nil {
-		if len(hosts) == 0 {
-			query.API404Response(c, fmt.Errorf("No host in tag %s", templateDetails.Short))
-			return
-		}
-		for _, host := range hosts {
-			ip := ipconv.IntToIPv4(host.IP).String()
-
-			dbusClient, enconterError := firewalld.NewDbusClientService(ip)
-			if enconterError != nil {
-				query.ConnectDbusService(c, enconterError)
-				return
-			}
-			defer dbusClient.Destroy()
-			if err := dbusClient.RuntimeSet(*templateDetails); err != nil {
-				query.API500Response(c, err)
-				return
-			}
-		}
-		query.SuccessResponse(c, query.OK, templateDetails)
-		return
-	}
-	// 3. 返回成功响应
-	query.SuccessResponse(c, query.OK, templateDetails)
-}
-
 // deleteTemplateWithID godoc
 // @Summary Delete template with template id.
 // @Description Delete template with template id.
@@ -194,7 +211,13 @@ func (t *Template) updateTemplateWithID(c *gin.Context) {
 	}
 
 	if templateQuery.ID > 0 {
-		if enconterError = model.UpdateTemplateWithID(templateQuery.ID, templateQuery.Name, templateQuery.Description, templateQuery.Target); enconterError != nil {
+		enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+			if err := model.UpdateTemplateWithID(tx, templateQuery.ID, templateQuery.Name, templateQuery.Description, templateQuery.Target); err != nil {
+				return nil, err
+			}
+			return []model.TemplateRevisionCommit{revisionCommit(int(templateQuery.ID), templateQuery.Message, "update template")}, nil
+		})
+		if enconterError != nil {
 			query.API409Response(c, enconterError)
 			return
 		}
@@ -204,3 +227,40 @@ func (t *Template) updateTemplateWithID(c *gin.Context) {
 	}
 	query.APIResponse(c, errors.New("invaild id"), nil)
 }
+
+// setMasquerade godoc
+// @Summary Enable or disable masquerade of template.
+// @Description Enable or disable masquerade of template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.MasqueradeEditQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/masquerade [POST]
+func (t *Template) setMasquerade(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	masqueradeQuery := &query.MasqueradeEditQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&masqueradeQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	defaultMessage := "disable masquerade"
+	if *masqueradeQuery.Enable {
+		defaultMessage = "enable masquerade"
+	}
+	enconterError = model.EditTemplate(operator(c), func(tx *gorm.DB) ([]model.TemplateRevisionCommit, error) {
+		if err := model.UpdateTemplateMasquerade(tx, masqueradeQuery.TemplateId, *masqueradeQuery.Enable); err != nil {
+			return nil, err
+		}
+		return []model.TemplateRevisionCommit{revisionCommit(masqueradeQuery.TemplateId, masqueradeQuery.Message, defaultMessage)}, nil
+	})
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
@@ -0,0 +1,297 @@
+package template
+
+import (
+	"errors"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praserx/ipconv"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/firewalld"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// readXML 读取上传的 xml，支持 multipart 的 file 字段或直接作为请求体
+func readXML(c *gin.Context) ([]byte, error) {
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(f)
+	}
+	content, err := io.ReadAll(c.Request.Body)
+	if err == nil && len(content) == 0 {
+		err = errors.New("empty xml")
+	}
+	return content, err
+}
+
+func importResponse(c *gin.Context, revision *model.TemplateRevision, warnings []string) {
+	query.SuccessResponse(c, query.OK, map[string]interface{}{
+		"template_id": revision.TemplateID,
+		"revision":    revision.Revision,
+		"warnings":    warnings,
+	})
+}
+
+// importTemplate godoc
+// @Summary Import zone xml as a new template.
+// @Description Import firewalld zone xml (e.g. /etc/firewalld/zones/public.xml) as a new template, the xml is the request body or the file field of multipart form.
+// @Description Name defaults to the short of zone, content which template can not hold is returned as warnings.
+// @Tags Template
+// @Accept xml
+// @Accept mpfd
+// @Produce json
+// @Param query query query.TemplateImportQuery false "query"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/import [PUT]
+func (t *Template) importTemplate(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	importQuery := &query.TemplateImportQuery{}
+	if enconterError = c.ShouldBindQuery(importQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	content, enconterError := readXML(c)
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	zone, enconterError := api.ParseZoneXML(content)
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	details, warnings := model.TemplateDetailsFromZone(zone)
+	name := importQuery.Name
+	if name == "" {
+		name = zone.Short
+	}
+	if name == "" {
+		query.API400Response(c, errors.New("template name is required"))
+		return
+	}
+	if importQuery.Message == "" {
+		importQuery.Message = "import from zone xml"
+	}
+	revision, enconterError := model.ImportTemplate(name, details, operator(c), importQuery.Message)
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	importResponse(c, revision, warnings)
+}
+
+// importZone godoc
+// @Summary Replace template with zone xml.
+// @Description Replace the content of template with firewalld zone xml, the name of template is kept.
+// @Tags Template
+// @Accept xml
+// @Accept mpfd
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param query query query.TemplateImportQuery false "query"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/import [POST]
+func (t *Template) importZone(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	importQuery := &query.TemplateImportQuery{}
+	if enconterError = c.ShouldBindQuery(importQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	current, enconterError := model.GetTemplateDetails(model.DB, templateQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	content, enconterError := readXML(c)
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	zone, enconterError := api.ParseZoneXML(content)
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	details, warnings := model.TemplateDetailsFromZone(zone)
+	details.Short = current.Short
+	if importQuery.Message == "" {
+		importQuery.Message = "import from zone xml"
+	}
+	revision, enconterError := model.ReplaceTemplateContent(templateQuery.ID, details, operator(c), importQuery.Message)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	importResponse(c, revision, warnings)
+}
+
+// importService godoc
+// @Summary Import service xml into template.
+// @Description Merge the ports and protocols of firewalld service xml (e.g. /etc/firewalld/services/http.xml) into template.
+// @Tags Template
+// @Accept xml
+// @Accept mpfd
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param query query query.TemplateImportQuery false "query"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/{id}/import/service [POST]
+func (t *Template) importService(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	importQuery := &query.TemplateImportQuery{}
+	if enconterError = c.ShouldBindQuery(importQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	details, enconterError := model.GetTemplateDetails(model.DB, templateQuery.ID)
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	content, enconterError := readXML(c)
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	service, enconterError := api.ParseServiceXML(content)
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	warnings := model.MergeServiceXML(details, service)
+	if importQuery.Message == "" {
+		importQuery.Message = "import service " + service.Short
+	}
+	revision, enconterError := model.ReplaceTemplateContent(templateQuery.ID, details, operator(c), importQuery.Message)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	importResponse(c, revision, warnings)
+}
+
+// exportTemplate godoc
+// @Summary Export template as zone xml.
+// @Description Export template, or a revision of template, as firewalld zone xml.
+// @Tags Template
+// @Accept json
+// @Produce xml
+// @Param id path int true "Template ID"
+// @Param query query query.TemplateExportQuery false "query"
+// @Security BearerAuth
+// @Success 200 {string} string
+// @Router /fw/template/{id}/export [GET]
+func (t *Template) exportTemplate(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	templateQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(templateQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	exportQuery := &query.TemplateExportQuery{}
+	if enconterError = c.ShouldBindQuery(exportQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	var details *model.TemplateWithDetails
+	if exportQuery.Revision == 0 {
+		details, enconterError = model.GetTemplateDetails(model.DB, templateQuery.ID)
+	} else {
+		var revision *model.TemplateRevision
+		if revision, enconterError = model.GetTemplateRevision(templateQuery.ID, exportQuery.Revision); enconterError == nil {
+			details, enconterError = revision.Details()
+		}
+	}
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+	content, enconterError := details.ToZoneXML().Marshal()
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+details.Short+`.xml"`)
+	c.Data(200, "application/xml; charset=utf-8", content)
+}
+
+// captureTemplate godoc
+// @Summary Capture template from host.
+// @Description Read the permanent settings of a zone (default zone if empty) on host and save them as a new template.
+// @Tags Template
+// @Accept json
+// @Produce json
+// @Param query body query.TemplateCaptureQuery false "body"
+// @Security BearerAuth
+// @Success 200 {object} interface{}
+// @Router /fw/template/capture [PUT]
+func (t *Template) captureTemplate(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	captureQuery := &query.TemplateCaptureQuery{}
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError = c.ShouldBindJSON(&captureQuery); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	host, enconterError := model.QueryHostWithID(int(captureQuery.HostID))
+	if enconterError == nil && host.ID == 0 {
+		enconterError = errors.New("host not found")
+	}
+	if enconterError != nil {
+		query.API404Response(c, enconterError)
+		return
+	}
+
+	dbusClient, enconterError := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), ipconv.IntToIPv4(host.IP).String())
+	if enconterError != nil {
+		query.ConnectDbusService(c, enconterError)
+		return
+	}
+	defer dbusClient.Destroy()
+	settings, enconterError := dbusClient.GetPermanentZoneSettings(captureQuery.Zone)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	zone, enconterError := api.ZoneXMLFromSettings(settings)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	details, warnings := model.TemplateDetailsFromZone(zone)
+	if captureQuery.Message == "" {
+		captureQuery.Message = "capture from host " + ipconv.IntToIPv4(host.IP).String()
+	}
+	revision, enconterError := model.ImportTemplate(captureQuery.Name, details, operator(c), captureQuery.Message)
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	importResponse(c, revision, warnings)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
 	"github.com/cylonchau/firewalld-gateway/utils/model"
 )
 
@@ -16,11 +17,20 @@ func (t *Token) RegisterTokenAPI(g *gin.RouterGroup) {
 	g.PUT("/", t.createToken)
 	g.DELETE("/", t.deleteTokenWithID)
 	g.POST("/", t.updateTokenWithID)
+	g.GET("/:id/scopes", t.getTokenScopes)
+}
+
+// requestUID 返回当前请求的用户 id，使用 API token 请求时为 0
+func requestUID(c *gin.Context) uint {
+	if uid, ok := c.Get(auther.UserIDKey); ok {
+		return uint(uid.(int64))
+	}
+	return 0
 }
 
 // createToken godoc
 // @Summary Create a token.
-// @Description Create a token.
+// @Description Create an API token with optional expiry, roles and explicit scopes. The token is only returned once, only its hash is stored.
 // @Tags Token
 // @Produce json
 // @Accept json
@@ -39,15 +49,16 @@ func (t *Token) createToken(c *gin.Context) {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
-	if tokenQuery != nil {
-		if enconterError = model.CreateToken(tokenQuery.SignedTo, tokenQuery.Description); enconterError != nil {
-			query.APIResponse(c, enconterError, nil)
-			return
-		}
-	} else {
+	if tokenQuery == nil {
 		query.SuccessResponse(c, query.QUERY_NULL, nil)
+		return
 	}
-	query.SuccessResponse(c, query.OK, nil)
+	secret, enconterError := model.CreateToken(tokenQuery, requestUID(c))
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	query.SuccessResponse(c, query.OK, secret)
 }
 
 // listToken godoc
@@ -102,9 +113,9 @@ func (t *Token) deleteTokenWithID(c *gin.Context) {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
-	enconterError = model.DeleteTokenWithID(tokenQuery.ID)
+	enconterError = model.DeleteTokenWithID(tokenQuery.ID, requestUID(c))
 	if enconterError != nil {
-		query.API500Response(c, enconterError)
+		query.API409Response(c, enconterError)
 		return
 	}
 	query.SuccessResponse(c, query.OK, nil)
@@ -112,7 +123,7 @@ func (t *Token) deleteTokenWithID(c *gin.Context) {
 
 // updateTokenWithID godoc
 // @Summary Update token with token_id.
-// @Description Update token with token_id.
+// @Description Update token with token_id, is_update rotates the token and returns the new token once. role_ids and scopes replace the current ones when given.
 // @Tags Token
 // @Produce json
 // @Accept  json
@@ -133,13 +144,40 @@ func (t *Token) updateTokenWithID(c *gin.Context) {
 	}
 
 	if tokenQuery.ID > 0 {
-		if enconterError = model.UpdateTokenWithID(tokenQuery.ID, tokenQuery.SignedTo, tokenQuery.Description, tokenQuery.IsUpdate); enconterError != nil {
+		secret, enconterError := model.UpdateTokenWithID(tokenQuery, requestUID(c))
+		if enconterError != nil {
 			query.API409Response(c, enconterError)
 			return
 		}
 
-		query.SuccessResponse(c, query.OK, nil)
+		query.SuccessResponse(c, query.OK, secret)
 		return
 	}
 	query.APIResponse(c, errors.New("invaild id"), nil)
 }
+
+// getTokenScopes godoc
+// @Summary Return roles and scopes of a token.
+// @Description Return the roles and explicit route, host and tag scopes of a token.
+// @Tags Token
+// @Accept json
+// @Produce json
+// @Param id path int true "Token ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/tokens/{id}/scopes [get]
+func (t *Token) getTokenScopes(c *gin.Context) {
+	var enconterError error
+	tokenQuery := &query.QueryWithID{}
+	if enconterError = c.ShouldBindUri(tokenQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	scopes, enconterError := model.GetTokenScopes(tokenQuery.ID)
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	query.SuccessResponse(c, nil, scopes)
+}
@@ -28,7 +28,7 @@ func (this *DashboardRouter) getRuntimeStatus(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
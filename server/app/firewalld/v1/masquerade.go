@@ -39,7 +39,7 @@ func (this *MasqueradeRouter) enableInRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
@@ -74,7 +74,7 @@ func (this *MasqueradeRouter) disableInRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
@@ -109,7 +109,7 @@ func (this *MasqueradeRouter) queryInRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -38,7 +38,7 @@ func (this *NATRouter) addForwardInRuntime(c *gin.Context) {
 		query.Zone = "public"
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -72,7 +72,7 @@ func (this *NATRouter) getForwardInRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -109,7 +109,7 @@ func (this *NATRouter) delForwardInRuntime(c *gin.Context) {
 		query.Zone = "public"
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
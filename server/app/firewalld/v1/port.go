@@ -36,7 +36,7 @@ func (this *PortV1Router) getInRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -82,7 +82,7 @@ func (this *PortV1Router) addInRuntime(c *gin.Context) {
 		query.Port.Protocol = "tcp"
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -120,7 +120,7 @@ func (this *PortV1Router) removeInRuntime(c *gin.Context) {
 		query.Port.Protocol = "tcp"
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
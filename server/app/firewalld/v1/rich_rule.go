@@ -35,7 +35,7 @@ func (this *RichRuleV1Router) getRichRulesAtRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(rich.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), rich.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -71,7 +71,7 @@ func (this *RichRuleV1Router) addRichRuleAtRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -105,7 +105,7 @@ func (this *RichRuleV1Router) delRichRuleAtRuntime(c *gin.Context) {
 		api_query.APIResponse(c, err, nil)
 		return
 	}
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -36,7 +36,7 @@ func (this *ServiceRouter) addServicesAtRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(serviceQuery.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), serviceQuery.Ip)
 	if err != nil {
 		query.ConnectDbusService(c, err)
 		return
@@ -70,7 +70,7 @@ func (this *ServiceRouter) deleteServicesAtRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(serviceQuery.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), serviceQuery.Ip)
 	if err != nil {
 		query.ConnectDbusService(c, err)
 		return
@@ -103,7 +103,7 @@ func (this *ServiceRouter) getServicesAtRuntime(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(serviceQuery.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), serviceQuery.Ip)
 	if err != nil {
 		query.ConnectDbusService(c, err)
 		return
@@ -37,7 +37,7 @@ func (this *MasqueradeRouter) enableInPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
@@ -73,7 +73,7 @@ func (this *MasqueradeRouter) disableInPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
@@ -109,7 +109,7 @@ func (this *MasqueradeRouter) queryInPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
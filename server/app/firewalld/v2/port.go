@@ -38,7 +38,7 @@ func (this *PortV2Router) getOnPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -84,7 +84,7 @@ func (this *PortV2Router) addOnPermanent(c *gin.Context) {
 		query.Port.Protocol = "tcp"
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -122,7 +122,7 @@ func (this *PortV2Router) removeOnPermanent(c *gin.Context) {
 		query.Port.Protocol = "tcp"
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
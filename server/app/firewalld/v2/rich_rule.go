@@ -34,7 +34,7 @@ func (this *RichRuleV2Router) getRichRulesOnPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(rich.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), rich.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -70,7 +70,7 @@ func (this *RichRuleV2Router) addRichRuleOnPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
@@ -104,7 +104,7 @@ func (this *RichRuleV2Router) delRichRuleOnPermanent(c *gin.Context) {
 		api_query.APIResponse(c, err, nil)
 		return
 	}
-	dbusClient, err := firewalld.NewDbusClientService(query.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), query.Ip)
 	if err != nil {
 		api_query.ConnectDbusService(c, err)
 		return
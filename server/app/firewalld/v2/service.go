@@ -40,7 +40,7 @@ func (this *ServiceRouter) getServicesAtPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(serviceQuery.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), serviceQuery.Ip)
 	if err != nil {
 		query.ConnectDbusService(c, err)
 		return
@@ -82,7 +82,7 @@ func (this *ServiceRouter) deleteServicesAtPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(serviceQuery.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), serviceQuery.Ip)
 	if err != nil {
 		query.ConnectDbusService(c, err)
 		return
@@ -116,7 +116,7 @@ func (this *ServiceRouter) addServicesPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(serviceQuery.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), serviceQuery.Ip)
 	if err != nil {
 		query.ConnectDbusService(c, err)
 		return
@@ -150,7 +150,7 @@ func (this *ServiceRouter) listServices(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(serviceQuery.Ip)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), serviceQuery.Ip)
 	if err != nil {
 		query.ConnectDbusService(c, err)
 		return
@@ -190,7 +190,7 @@ func (this *ServiceRouter) newServiceAtPermanent(c *gin.Context) {
 		return
 	}
 
-	dbusClient, err := firewalld.NewDbusClientService(serviceSettingQuery.Host)
+	dbusClient, err := firewalld.NewDbusClientServiceWithContext(c.Request.Context(), serviceSettingQuery.Host)
 	if err != nil {
 		query.ConnectDbusService(c, err)
 		return
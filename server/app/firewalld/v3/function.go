@@ -4,8 +4,12 @@ import (
 	"context"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 func batchFunction(c context.Context) {
@@ -14,6 +18,15 @@ func batchFunction(c context.Context) {
 	delayTime := c.Value("delay_time").(uint32)
 	eventName := c.Value("event_name").(string)
 	tName := batch_processor.RandName()
+	ctx, span := tracing.Tracer().Start(c, "batch.enqueue "+eventName,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("batch.task", tName),
+			attribute.String("batch.event", eventName),
+			attribute.Int64("batch.delay", int64(delayTime)),
+		),
+	)
+	defer span.End()
 	switch b.(type) {
 
 	case query.ZoneDst:
@@ -27,6 +40,7 @@ func batchFunction(c context.Context) {
 				EventName: eventName,
 				Host:      obj.Host,
 				TaskName:  tName,
+				Context:   ctx,
 				Task:      obj.Zone,
 			}
 		}
@@ -42,6 +56,7 @@ func batchFunction(c context.Context) {
 			EventName: eventName,
 			Host:      portRule.Ip,
 			TaskName:  tName,
+			Context:   ctx,
 			Task:      portRule,
 		}
 		if delayTime > 0 {
@@ -55,6 +70,7 @@ func batchFunction(c context.Context) {
 			EventName: eventName,
 			Host:      forwardRule.Ip,
 			TaskName:  tName,
+			Context:   ctx,
 			Task:      forwardRule,
 		}
 		if delayTime > 0 {
@@ -68,6 +84,7 @@ func batchFunction(c context.Context) {
 			EventName: eventName,
 			Host:      richRule.Ip,
 			TaskName:  tName,
+			Context:   ctx,
 			Task:      richRule,
 		}
 		if delayTime > 0 {
@@ -81,6 +98,7 @@ func batchFunction(c context.Context) {
 			EventName: eventName,
 			Host:      service.Ip,
 			TaskName:  tName,
+			Context:   ctx,
 			Task:      service,
 		}
 		if delayTime > 0 {
@@ -97,6 +115,7 @@ func batchFunction(c context.Context) {
 				EventName: eventName,
 				Host:      obj,
 				TaskName:  tName,
+				Context:   ctx,
 			}
 		}
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
 	api_query "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 type MasqueradeRouterV3 struct{}
@@ -34,9 +35,10 @@ func (this *MasqueradeRouterV3) batchEnableMasquerade(c *gin.Context) {
 		return
 	}
 
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range query.ActionObject {
 		go func(host api_query.ZoneDst) {
-			contexts := context.WithValue(c, "action_obj", host)
+			contexts := context.WithValue(requestContext, "action_obj", host)
 			contexts = context.WithValue(contexts, "delay_time", query.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.ENABLE_MASQUERADE)
 			go batchFunction(contexts)
@@ -63,9 +65,10 @@ func (this *MasqueradeRouterV3) batchDisableMasquerade(c *gin.Context) {
 		return
 	}
 
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range query.ActionObject {
 		go func(host api_query.ZoneDst) {
-			contexts := context.WithValue(c, "action_obj", host)
+			contexts := context.WithValue(requestContext, "action_obj", host)
 			contexts = context.WithValue(contexts, "delay_time", query.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.DISABLE_MASQUERADE)
 			go batchFunction(contexts)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
 	api_query "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 type NATRuleRouterV3 struct{}
@@ -34,9 +35,10 @@ func (this *NATRuleRouterV3) batchAddNATRuntime(c *gin.Context) {
 		return
 	}
 	fmt.Println(query.Forwards[0].Forward)
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range query.Forwards {
 		go func(rule api_query.ForwardQuery) {
-			contexts := context.WithValue(c, "action_obj", rule)
+			contexts := context.WithValue(requestContext, "action_obj", rule)
 			contexts = context.WithValue(contexts, "delay_time", query.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.CREATE_FORWARD)
 			go batchFunction(contexts)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
 	api_query "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 type PortRouter struct{}
@@ -33,10 +34,10 @@ func (this *PortRouter) batchAddPortRuntime(c *gin.Context) {
 		api_query.APIResponse(c, err, nil)
 		return
 	}
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range query.Ports {
 		go func(p api_query.PortQuery) {
-			contexts := context.TODO()
-			contexts = context.WithValue(contexts, "action_obj", p)
+			contexts := context.WithValue(requestContext, "action_obj", p)
 			contexts = context.WithValue(contexts, "delay_time", query.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.CREATE_PORT)
 			go batchFunction(contexts)
@@ -61,10 +62,10 @@ func (this *PortRouter) batchAddPortPerment(c *gin.Context) {
 		api_query.APIResponse(c, err, nil)
 		return
 	}
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range query.Ports {
 		go func(p api_query.PortQuery) {
-			contexts := context.TODO()
-			contexts = context.WithValue(contexts, "action_obj", p)
+			contexts := context.WithValue(requestContext, "action_obj", p)
 			contexts = context.WithValue(contexts, "delay_time", query.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.CREATE_PORT_PERMANENT)
 			go batchFunction(contexts)
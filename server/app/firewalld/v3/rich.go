@@ -7,6 +7,7 @@ import (
 
 	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 type RichRuleRouterV3 struct{}
@@ -32,10 +33,10 @@ func (this *RichRuleRouterV3) batchAddRichRuntime(c *gin.Context) {
 		query.APIResponse(c, err, nil)
 		return
 	}
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range batchRichQuery.Richs {
 		go func(p query.RichQuery) {
-			contexts := context.TODO()
-			contexts = context.WithValue(contexts, "action_obj", p)
+			contexts := context.WithValue(requestContext, "action_obj", p)
 			contexts = context.WithValue(contexts, "delay_time", batchRichQuery.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.CREATE_RICH)
 			go batchFunction(contexts)
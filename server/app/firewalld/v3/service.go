@@ -7,6 +7,7 @@ import (
 
 	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
 	api_query "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 type ServiceRouter struct{}
@@ -32,10 +33,10 @@ func (this *ServiceRouter) batchAddServiceRuntime(c *gin.Context) {
 		api_query.APIResponse(c, err, nil)
 		return
 	}
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range query.Services {
 		go func(p api_query.ServiceQuery) {
-			contexts := context.TODO()
-			contexts = context.WithValue(contexts, "action_obj", p)
+			contexts := context.WithValue(requestContext, "action_obj", p)
 			contexts = context.WithValue(contexts, "delay_time", query.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.CREATE_SERVICE)
 			go batchFunction(contexts)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
 	api_query "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 type SettingRouter struct{}
@@ -34,9 +35,10 @@ func (this *SettingRouter) reloadRuntime(c *gin.Context) {
 		api_query.APIResponse(c, err, nil)
 		return
 	}
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range query.Hosts {
 		go func(host string) {
-			contexts := context.WithValue(c, "action_obj", item)
+			contexts := context.WithValue(requestContext, "action_obj", host)
 			contexts = context.WithValue(contexts, "delay_time", query.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.RELOAD_FIREWALD)
 
@@ -64,9 +66,10 @@ func (this *SettingRouter) setDefautZone(c *gin.Context) {
 		return
 	}
 
+	requestContext := tracing.Detach(c.Request.Context())
 	for _, item := range query.ActionObject {
 		go func(zoneAction api_query.ZoneDst) {
-			contexts := context.WithValue(c, "action_obj", zoneAction)
+			contexts := context.WithValue(requestContext, "action_obj", zoneAction)
 			contexts = context.WithValue(contexts, "delay_time", query.Delay)
 			contexts = context.WithValue(contexts, "event_name", batch_processor.SET_DEFAULT_ZONE)
 			go batchFunction(contexts)
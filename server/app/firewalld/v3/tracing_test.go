@@ -0,0 +1,281 @@
+package v3
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/godbus/dbus/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/server/app/middlewares"
+	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
+)
+
+// fakeFirewalld 在 tcp 上模拟 firewalld 的 D-Bus 服务，第一次 addPort 返回错误，之后成功
+type fakeFirewalld struct {
+	net.Listener
+	sync.Mutex
+	addPort int
+}
+
+func startFirewalld(t *testing.T) *fakeFirewalld {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeFirewalld{Listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return f
+}
+
+// serve 完成 ANONYMOUS 认证后应答网关用到的方法
+func (f *fakeFirewalld) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	if _, err := reader.ReadByte(); err != nil {
+		return
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "AUTH":
+			fmt.Fprint(conn, "REJECTED ANONYMOUS\r\n")
+		case strings.HasPrefix(line, "AUTH ANONYMOUS"):
+			fmt.Fprint(conn, "OK 0123456789abcdef0123456789abcdef\r\n")
+		case line == "BEGIN":
+			f.handle(conn, reader)
+			return
+		default:
+			fmt.Fprint(conn, "ERROR\r\n")
+		}
+	}
+}
+
+func (f *fakeFirewalld) handle(conn net.Conn, reader *bufio.Reader) {
+	for {
+		call, err := dbus.DecodeMessage(reader)
+		if err != nil {
+			return
+		}
+		if call.Type != dbus.TypeMethodCall {
+			continue
+		}
+		var member string
+		call.Headers[dbus.FieldMember].Store(&member)
+		reply := &dbus.Message{
+			Type:    dbus.TypeMethodReply,
+			Headers: map[dbus.HeaderField]dbus.Variant{dbus.FieldReplySerial: dbus.MakeVariant(call.Serial())},
+		}
+		switch member {
+		case "Hello":
+			reply.Body = []interface{}{":1.1"}
+		case "RequestName":
+			reply.Body = []interface{}{uint32(dbus.RequestNameReplyPrimaryOwner)}
+		case "getDefaultZone":
+			reply.Body = []interface{}{"public"}
+		case "addPort":
+			f.Lock()
+			f.addPort++
+			first := f.addPort == 1
+			f.Unlock()
+			if first {
+				reply.Type = dbus.TypeError
+				reply.Headers[dbus.FieldErrorName] = dbus.MakeVariant(api.INTERFACE + ".Exception")
+				reply.Body = []interface{}{"ALREADY_ENABLED"}
+			} else {
+				reply.Body = []interface{}{"public"}
+			}
+		default:
+			reply.Type = dbus.TypeError
+			reply.Headers[dbus.FieldErrorName] = dbus.MakeVariant("org.freedesktop.DBus.Error.UnknownMethod")
+		}
+		if len(reply.Body) > 0 {
+			reply.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(reply.Body...))
+		}
+		if err = reply.EncodeTo(conn, binary.LittleEndian); err != nil {
+			return
+		}
+	}
+}
+
+func setupTracing(t *testing.T, dbusPort int) *tracetest.InMemoryExporter {
+	t.Helper()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "firewalld-gateway.toml")
+	content := fmt.Sprintf(`appname = "test"
+port = "2952"
+dbus_port = "%d"
+mission_retry_number = 3
+
+[tracing]
+sample_ratio = 1.0
+service_name = "firewalld-gateway-test"
+`, dbusPort)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitConfiguration(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracing.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewProvider(sdktrace.NewSimpleSpanProcessor(exporter))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+	return exporter
+}
+
+// spanTree 按名称与父 span 查找导出的 span
+type spanTree []tracetest.SpanStub
+
+func (s spanTree) find(name string, parent trace.SpanContext) []tracetest.SpanStub {
+	var spans []tracetest.SpanStub
+	for _, span := range s {
+		if span.Name == name && span.Parent.SpanID() == parent.SpanID() {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func attributeValue(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestBatchTraceFromRequestToDbus(t *testing.T) {
+	firewalld := startFirewalld(t)
+	exporter := setupTracing(t, firewalld.Addr().(*net.TCPAddr).Port)
+
+	retryInterval := batch_processor.T
+	batch_processor.T = 10 * time.Millisecond
+	t.Cleanup(func() { batch_processor.T = retryInterval })
+	batch_processor.P = batch_processor.NewProcessor()
+	go batch_processor.P.Run()
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(middlewares.TracingMiddleware())
+	(&PortRouter{}).RegisterBatchAPI(e.Group("/fw/v3"))
+
+	// 上游传入的 trace context
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	upstream := "00f067aa0ba902b7"
+	req := httptest.NewRequest(http.MethodPut, "/fw/v3/ports/",
+		strings.NewReader(`{"delay":1,"ports":[{"ip":"127.0.0.1","port":{"port":"8080","protocol":"tcp"}}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", "00-"+traceID+"-"+upstream+"-01")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("batch request: %d %s", w.Code, w.Body.String())
+	}
+
+	// 延迟 1 秒执行，第一次 addPort 失败后重试
+	var spans spanTree
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		spans = spanTree(exporter.GetSpans())
+		processed := 0
+		for _, span := range spans {
+			if span.Name == "batch.process "+batch_processor.CREATE_PORT {
+				processed++
+			}
+		}
+		if processed >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for the retried task, got %d spans", len(spans))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	for _, span := range spans {
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Fatalf("span %s is in trace %s, expected %s", span.Name, span.SpanContext.TraceID(), traceID)
+		}
+	}
+	var server []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == "PUT /fw/v3/ports/" && span.Parent.SpanID().String() == upstream && span.Parent.IsRemote() {
+			server = append(server, span)
+		}
+	}
+	if len(server) != 1 {
+		t.Fatalf("expected the request span as a child of the upstream span, got %+v", server)
+	}
+	enqueue := spans.find("batch.enqueue "+batch_processor.CREATE_PORT, server[0].SpanContext)
+	if len(enqueue) != 1 || attributeValue(enqueue[0], "batch.delay").AsInt64() != 1 {
+		t.Fatalf("expected one delayed batch.enqueue under the request span, got %+v", enqueue)
+	}
+	attempts := spans.find("batch.process "+batch_processor.CREATE_PORT, enqueue[0].SpanContext)
+	if len(attempts) != 2 {
+		t.Fatalf("expected both attempts under batch.enqueue, got %d", len(attempts))
+	}
+
+	addPort := api.INTERFACE + ".zone.addPort"
+	for i, attempt := range attempts {
+		if n := attributeValue(attempt, "batch.attempt").AsInt64(); n != int64(i+1) {
+			t.Fatalf("attempt %d: batch.attempt is %d", i+1, n)
+		}
+		if connect := spans.find("dbus.connect", attempt.SpanContext); len(connect) != 1 ||
+			len(spans.find(api.INTERFACE+".getDefaultZone", connect[0].SpanContext)) != 1 {
+			t.Fatalf("attempt %d: expected dbus.connect with getDefaultZone", i+1)
+		}
+		calls := spans.find(addPort, attempt.SpanContext)
+		if len(calls) != 1 || attributeValue(calls[0], "rpc.system").AsString() != "dbus" ||
+			attributeValue(calls[0], "net.peer.name").AsString() != "127.0.0.1" {
+			t.Fatalf("attempt %d: expected one addPort D-Bus span, got %+v", i+1, calls)
+		}
+		status := codes.Unset
+		if i == 0 {
+			status = codes.Error
+		}
+		if calls[0].Status.Code != status || attempt.Status.Code != status {
+			t.Fatalf("attempt %d: expected status %v, got addPort %v and batch.process %v",
+				i+1, status, calls[0].Status.Code, attempt.Status.Code)
+		}
+	}
+}
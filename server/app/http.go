@@ -1,14 +1,22 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
+	"time"
 
 	"k8s.io/klog/v2"
 
 	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/server/app/firewalld/template"
 	"github.com/cylonchau/firewalld-gateway/server/app/router"
+	"github.com/cylonchau/firewalld-gateway/server/auditor"
 	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
+	"github.com/cylonchau/firewalld-gateway/server/directory"
+	"github.com/cylonchau/firewalld-gateway/server/election"
+	"github.com/cylonchau/firewalld-gateway/server/reconciler"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,13 +30,36 @@ func init() {
 }
 
 func NewHTTPSever() (err error) {
+	shutdownTracing, err := tracing.Init(context.Background())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("Export remaining spans failed: %v", err)
+		}
+	}()
+
 	http = gin.New()
 	router.RegisteredRouter(http)
 	klog.V(2).Infof("Listening and serving HTTP on %s:%s", config.CONFIG.Address, config.CONFIG.Port)
 
 	if config.CONFIG.AsyncProcess {
 		batch_processor.P = batch_processor.NewProcessor()
-		go batch_processor.P.Run()
+	}
+	if config.CONFIG.HA.Enabled {
+		go func() {
+			if err := election.Run(stopCh, runWorkers); err != nil {
+				klog.Errorf("Leader election failed: %v", err)
+			}
+		}()
+	} else {
+		go runWorkers(stopCh)
+	}
+	if len(config.CONFIG.Audit.Forwarders) > 0 {
+		if err = auditor.StartForwarders(stopCh); err != nil {
+			return err
+		}
 	}
 	if err = http.Run(fmt.Sprintf("%s:%s", config.CONFIG.Address, config.CONFIG.Port)); err != nil {
 		return err
@@ -36,3 +67,33 @@ func NewHTTPSever() (err error) {
 	<-stopCh
 	return
 }
+
+// runWorkers 运行异步任务处理与后台任务直到 stopCh 关闭，开启选主时只在 leader 上运行
+func runWorkers(stopCh <-chan struct{}) {
+	if config.CONFIG.AsyncProcess && !config.CONFIG.HA.Enabled {
+		go batch_processor.P.Run()
+	}
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		template.NewJobRunner(time.Duration(config.CONFIG.HA.PollInterval) * time.Second).Run(stopCh)
+	}()
+	if config.CONFIG.Drift.Interval > 0 {
+		go reconciler.NewReconciler(time.Duration(config.CONFIG.Drift.Interval) * time.Second).Run(stopCh)
+	}
+	if config.CONFIG.LDAP.Enabled && config.CONFIG.LDAP.SyncInterval > 0 {
+		go directory.NewSyncer(time.Duration(config.CONFIG.LDAP.SyncInterval) * time.Second).Run(stopCh)
+	}
+	if config.CONFIG.Audit.CheckpointInterval > 0 {
+		go auditor.NewCheckpointer(time.Duration(config.CONFIG.Audit.CheckpointInterval) * time.Second).Run(stopCh)
+	}
+	if retention := config.CONFIG.Audit.Retention; (retention.Days > 0 || retention.Rows > 0) && retention.Interval > 0 {
+		go auditor.NewPruner(time.Duration(retention.Interval) * time.Second).Run(stopCh)
+	}
+	if config.CONFIG.AsyncProcess && config.CONFIG.HA.Enabled {
+		batch_processor.P.Lead(stopCh, time.Duration(config.CONFIG.HA.PollInterval)*time.Second)
+	}
+	<-stopCh
+	// 正在执行的模板下发任务结束后才返回
+	<-jobs
+}
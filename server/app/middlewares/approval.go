@@ -0,0 +1,301 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praserx/ipconv"
+	"github.com/spf13/cast"
+	"k8s.io/klog/v2"
+
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+type approvedChangeKey struct{}
+
+// WithApprovedChange 标记请求为审批通过后执行的变更，只能在服务内部构造，外部请求无法伪造
+func WithApprovedChange(r *http.Request, change *model.ChangeRequest) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), approvedChangeKey{}, change))
+}
+
+func approvedChange(r *http.Request) (*model.ChangeRequest, bool) {
+	change, ok := r.Context().Value(approvedChangeKey{}).(*model.ChangeRequest)
+	return change, ok
+}
+
+// approvedAuth 以申请人的身份执行审批通过的变更，执行前重新检查申请人当前的权限
+func approvedAuth(c *gin.Context, change *model.ChangeRequest) {
+	if change.TokenID > 0 {
+		token, err := model.GetTokenWithID(change.TokenID)
+		if err != nil {
+			query.Auth403Failed(c, query.ErrTokenInvalid, err.Error())
+			c.Abort()
+			return
+		}
+		permissions, restrict, err := model.TokenPermissions(token)
+		if err != nil || !authorize(c, permissions, restrict) {
+			query.AuthNoPermission(c, query.ErrNoPermission)
+			c.Abort()
+			return
+		}
+		c.Set(auther.TokenIDKey, token.ID)
+		auditNext(c, 0, token.ID)
+		return
+	}
+
+	if change.RequesterID != 1 {
+		permissions, err := model.GetPermissionsWithUID(change.RequesterID)
+		if err != nil || !authorize(c, permissions, nil) {
+			query.AuthNoPermission(c, query.ErrNoPermission)
+			c.Abort()
+			return
+		}
+	}
+	c.Set(auther.UserIDKey, int64(change.RequesterID))
+	auditNext(c, int64(change.RequesterID), 0)
+}
+
+// ApprovalMiddleware 匹配审批策略的修改请求保存为待审批的变更，不执行 handler，需要放在认证中间件之后
+func ApprovalMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if _, ok := approvedChange(c.Request); ok || c.Request.Method == http.MethodGet || len(config.CONFIG.Approval.Policies) == 0 {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				query.API400Response(c, err)
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		index, hosts, err := matchApprovalPolicy(c, body)
+		if err != nil {
+			klog.Warningf("Match approval policies of request %s %s failed: %v", c.Request.Method, c.FullPath(), err)
+			query.API500Response(c, err)
+			c.Abort()
+			return
+		}
+		if index < 0 {
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Next()
+			return
+		}
+		policy := config.CONFIG.Approval.Policies[index]
+
+		change := &model.ChangeRequest{
+			Policy:      policy.Name,
+			Method:      c.Request.Method,
+			Route:       c.FullPath(),
+			Path:        c.Request.URL.Path,
+			RawQuery:    c.Request.URL.RawQuery,
+			Body:        string(body),
+			ContentType: c.ContentType(),
+			Hosts:       strings.Join(hosts, ","),
+			RequesterID: uint(c.GetInt64(auther.UserIDKey)),
+			TokenID:     c.GetUint(auther.TokenIDKey),
+			Approvals:   policy.Approvals,
+		}
+		if err = model.CreateChangeRequest(change, policy.ApproverRoles); err != nil {
+			query.API500Response(c, err)
+			c.Abort()
+			return
+		}
+		query.API202Response(c, query.ErrChangePending, map[string]interface{}{
+			"change_id":  change.ID,
+			"policy":     change.Policy,
+			"expires_at": change.ExpiresAt,
+		})
+		c.Abort()
+	}
+}
+
+// matchApprovalPolicy 返回请求匹配的第一个审批策略的下标与请求操作的主机，没有匹配的策略时返回 -1
+func matchApprovalPolicy(c *gin.Context, body []byte) (int, []string, error) {
+	var (
+		document interface{}
+		targets  []uint32
+		resolved bool
+		known    bool
+	)
+	if len(body) > 0 && strings.Contains(c.ContentType(), "json") {
+		json.Unmarshal(body, &document)
+	}
+	for i, policy := range config.CONFIG.Approval.Policies {
+		if !policyRouteMatch(c, policy.Routes) || !policyFieldsMatch(c, document, policy.Fields) {
+			continue
+		}
+		if !resolved {
+			var err error
+			if targets, known, err = approvalTargets(c, document); err != nil {
+				return -1, nil, err
+			}
+			resolved = true
+		}
+		hosts := make([]string, 0, len(targets))
+		for _, ip := range targets {
+			hosts = append(hosts, ipconv.IntToIPv4(ip).String())
+		}
+		if len(policy.Tags) == 0 && len(policy.Hosts) == 0 {
+			return i, hosts, nil
+		}
+		ok, err := policyScopeMatch(policy.Tags, policy.Hosts, targets, known)
+		if err != nil {
+			return -1, nil, err
+		}
+		if ok {
+			return i, hosts, nil
+		}
+	}
+	return -1, nil, nil
+}
+
+// approvalTargets 返回请求操作的主机，下发模板且未指定主机时为模板 tag 下的全部主机，此时 known 为 true；
+// 模板下发到与模板短名称同名的 tag，指定版本时使用该版本的短名称
+func approvalTargets(c *gin.Context, document interface{}) (targets []uint32, known bool, err error) {
+	targets, err = requestTargets(c)
+	if err != nil || len(targets) > 0 || !strings.HasPrefix(c.FullPath(), "/fw/template/:id") {
+		return targets, len(targets) > 0, err
+	}
+	template, err := model.GetTemplateWithID(cast.ToUint(c.Param("id")))
+	if err != nil {
+		// 模板不存在时由 handler 返回错误
+		return nil, false, nil
+	}
+	tagName := template.Name
+	if revision := templateRevision(c, document); revision > 0 {
+		stored, err := model.GetTemplateRevision(template.ID, revision)
+		if err != nil {
+			return nil, false, nil
+		}
+		settings, err := stored.Settings()
+		if err != nil {
+			return nil, false, err
+		}
+		tagName = settings.Short
+	}
+	hosts, err := model.GetHostsByTagName(tagName)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, host := range hosts {
+		targets = append(targets, host.IP)
+	}
+	return targets, true, nil
+}
+
+// templateRevision 返回下发的模板版本，与 handler 绑定参数的规则一致：路径中的版本优先，其次为 json body 或 form 中的 revision
+func templateRevision(c *gin.Context, document interface{}) int {
+	if revision := c.Param("revision"); revision != "" {
+		return cast.ToInt(revision)
+	}
+	if fields, ok := document.(map[string]interface{}); ok {
+		for field, value := range fields {
+			if strings.EqualFold(field, "revision") {
+				return cast.ToInt(value)
+			}
+		}
+		return 0
+	}
+	return cast.ToInt(c.Request.FormValue("revision"))
+}
+
+func policyRouteMatch(c *gin.Context, routes []string) bool {
+	for _, route := range routes {
+		router, err := model.ParseRouteScope(route)
+		if err == nil && matchRouter(&router, c.FullPath(), c.Request.Method) {
+			return true
+		}
+	}
+	return false
+}
+
+// policyFieldsMatch 每个字段在 query 或 json body 的任意层级中至少有一个值在策略的取值中
+func policyFieldsMatch(c *gin.Context, document interface{}, fields map[string][]string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	values := make(map[string][]string)
+	names := make([]string, 0, len(fields))
+	for key := range fields {
+		values[key] = append(values[key], c.QueryArray(key)...)
+		names = append(names, key)
+	}
+	collectFields(document, names, values)
+	for key, allowed := range fields {
+		matched := false
+		for _, v := range values[key] {
+			for _, a := range allowed {
+				if strings.EqualFold(v, a) {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// collectFields 收集 json body 中与 names 忽略大小写相同的字段的值，与 handler 绑定 body 的规则一致
+func collectFields(document interface{}, names []string, values map[string][]string) {
+	switch v := document.(type) {
+	case map[string]interface{}:
+		for field, value := range v {
+			if key, ok := foldKey(field, names); ok {
+				switch typed := value.(type) {
+				case string, float64, bool:
+					values[key] = append(values[key], cast.ToString(value))
+					continue
+				case []interface{}:
+					// 字段的值为数组时收集数组中的值
+					for _, item := range typed {
+						switch item.(type) {
+						case string, float64, bool:
+							values[key] = append(values[key], cast.ToString(item))
+						}
+					}
+				}
+			}
+			collectFields(value, names, values)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectFields(value, names, values)
+		}
+	}
+}
+
+// policyScopeMatch 请求操作的主机中有主机在策略的主机范围内，无法确定操作的主机时按需要审批处理
+func policyScopeMatch(tags, hosts []string, targets []uint32, known bool) (bool, error) {
+	if !known {
+		return true, nil
+	}
+	scopes := make([]model.RoleScope, 0, len(tags)+len(hosts))
+	for _, tag := range tags {
+		scopes = append(scopes, model.RoleScope{Kind: model.ScopeTag, Value: tag})
+	}
+	for _, host := range hosts {
+		scopes = append(scopes, model.RoleScope{Kind: model.ScopeHost, Value: host})
+	}
+	for _, ip := range targets {
+		if ok, err := model.HostInScopes(ip, scopes); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,155 @@
+package middlewares
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+	"github.com/cylonchau/firewalld-gateway/utils/migration"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// setupDatabase 写入配置，初始化 sqlite 数据库并迁移到最新版本
+func setupDatabase(t *testing.T, extra string) {
+	t.Helper()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "firewalld-gateway.toml")
+	content := fmt.Sprintf("appname = \"test\"\ndatabase_driver = \"sqlite\"\ndbus_port = \"55556\"\n%s\n[sqlite]\nfile = %q\nmax_open_connection = 1\n",
+		extra, filepath.Join(dir, "uranus"))
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitConfiguration(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.InitDB("sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if conn, err := model.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	migration.RegisterRouter = func(*gin.Engine) {}
+	t.Cleanup(func() { migration.RegisterRouter = nil })
+	if err := migration.Up(model.DB, 0, false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTaggedHost 创建 tag 与 tag 下的一台主机
+func createTaggedHost(t *testing.T, tagName, ip string) {
+	t.Helper()
+	tag := &model.Tag{Name: tagName}
+	if err := model.DB.Create(tag).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := model.CreateHost(ip, tagName+"-host", int(tag.ID)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApprovalTemplateApplyOnTaggedHosts(t *testing.T) {
+	setupDatabase(t, `
+[approval]
+expiry = 3600
+
+[[approval.policies]]
+name = "production-template"
+routes = ["POST /fw/template/:id/apply", "POST /fw/template/:id/revisions/:revision/apply"]
+tags = ["production"]
+approver_roles = ["template_editer"]
+approvals = 1
+`)
+	createTaggedHost(t, "production", "10.0.0.1")
+	createTaggedHost(t, "staging", "10.0.0.2")
+	production, err := model.CreateTemplate(model.DB, "production", "", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	staging, err := model.CreateTemplate(model.DB, "staging", "", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 版本 1 以 production 的短名称保存，之后模板改名为 canary
+	canary, err := model.CreateTemplate(model.DB, "canary-old", "", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = model.DB.Model(&model.Template{}).Where("id = ?", canary).Update("name", "production").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err = model.CreateTemplateRevision(canary, "admin", "initial"); err != nil {
+		t.Fatal(err)
+	}
+	if err = model.DB.Model(&model.Template{}).Where("id = ?", canary).Update("name", "canary").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(func(c *gin.Context) {
+		c.Set(auther.UserIDKey, int64(2))
+		c.Next()
+	})
+	e.Use(ApprovalMiddleware())
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	e.POST("/fw/template/:id/apply", handler)
+	e.POST("/fw/template/:id/revisions/:revision/apply", handler)
+
+	cases := []struct {
+		name     string
+		target   string
+		body     string
+		expected int
+	}{
+		{"production template", fmt.Sprintf("/fw/template/%d/apply", production), "", http.StatusAccepted},
+		{"staging template", fmt.Sprintf("/fw/template/%d/apply", staging), "", http.StatusOK},
+		// 模板的 Target 是 zone，与 tag 无关
+		{"renamed template", fmt.Sprintf("/fw/template/%d/apply", canary), "", http.StatusOK},
+		{"revision in path", fmt.Sprintf("/fw/template/%d/revisions/1/apply", canary), "", http.StatusAccepted},
+		{"revision in body", fmt.Sprintf("/fw/template/%d/apply", canary), `{"Revision": 1}`, http.StatusAccepted},
+		{"staging host of production template", fmt.Sprintf("/fw/template/%d/apply", production), `{"host_ids": [2]}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, req)
+			if w.Code != tc.expected {
+				t.Fatalf("expected %d, got %d %s", tc.expected, w.Code, w.Body.String())
+			}
+			var count int64
+			model.DB.Model(&model.ChangeRequest{}).Where("path = ? AND body = ?", tc.target, tc.body).Count(&count)
+			if pending := count > 0; pending != (tc.expected == http.StatusAccepted) {
+				t.Fatalf("change request saved: %v", pending)
+			}
+		})
+	}
+}
+
+func TestRedactChangeBody(t *testing.T) {
+	setupDatabase(t, "")
+	var got, expected interface{}
+	json.Unmarshal([]byte(RedactBody([]byte(`{"username":"alice","Password":"s3cret","items":[{"secret":"x"}]}`), "application/json")), &got)
+	json.Unmarshal([]byte(`{"username":"alice","Password":"******","items":[{"secret":"******"}]}`), &expected)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("redact json: expected %v, got %v", expected, got)
+	}
+	if form := RedactBody([]byte("username=alice&password=s3cret"), "application/x-www-form-urlencoded"); form != "password=%2A%2A%2A%2A%2A%2A&username=alice" {
+		t.Fatalf("redact form: got %s", form)
+	}
+}
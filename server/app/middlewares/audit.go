@@ -0,0 +1,304 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praserx/ipconv"
+	"github.com/spf13/cast"
+	"k8s.io/klog/v2"
+
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/firewalld"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+const (
+	// auditResponseHead 保存的响应内容的长度，响应的错误码在响应的开头
+	auditResponseHead = 256
+	redacted          = "******"
+)
+
+var responseCode = regexp.MustCompile(`"code"\s*:\s*(-?\d+)`)
+
+// auditWriter 保存响应开头的内容，用于读取响应中的错误码
+type auditWriter struct {
+	gin.ResponseWriter
+	head []byte
+}
+
+func (w *auditWriter) capture(b []byte) {
+	if n := auditResponseHead - len(w.head); n > 0 {
+		if len(b) > n {
+			b = b[:n]
+		}
+		w.head = append(w.head, b...)
+	}
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// code 返回响应内容中的错误码，响应不是 json 时为 0
+func (w *auditWriter) code() int {
+	return ResponseCode(w.head)
+}
+
+// ResponseCode 返回响应内容中的错误码，错误码在响应的开头，响应不是 json 时为 0
+func ResponseCode(body []byte) int {
+	if len(body) > auditResponseHead {
+		body = body[:auditResponseHead]
+	}
+	if match := responseCode.FindSubmatch(body); match != nil {
+		return cast.ToInt(string(match[1]))
+	}
+	return 0
+}
+
+// auditNext 执行后续的 handler，在 handler 返回后记录审计日志，包括请求操作的主机与 zone、去除敏感字段的请求内容、
+// 响应的状态码与错误码、处理时间，修改请求同时记录被修改资源在执行前后的快照
+func auditNext(c *gin.Context, id int64, tokenID uint) {
+	auditLog := auditLogData(c.Request, id, tokenID)
+
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(c.Request.Body); err != nil {
+			klog.Warningf("Read body of request %s %s for audit failed: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	var document interface{}
+	if len(body) > 0 && strings.Contains(c.ContentType(), "json") {
+		json.Unmarshal(body, &document)
+	}
+	hosts, zones := auditTargets(c, document)
+	auditLog["host"] = strings.Join(hosts, ",")
+	auditLog["zone"] = strings.Join(zones, ",")
+	auditLog["body"] = sanitizeBody(body, document, c.ContentType())
+
+	snapshot := auditSnapshot(c, document, hosts, zones)
+	if snapshot != nil {
+		auditLog["before"] = snapshot()
+	}
+
+	writer := &auditWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	start := time.Now()
+	c.Next()
+	auditLog["latency"] = time.Since(start).Milliseconds()
+	auditLog["status"] = writer.Status()
+	auditLog["code"] = writer.code()
+
+	if snapshot != nil {
+		auditLog["after"] = snapshot()
+	}
+	model.AppendAuditLog(auditLog)
+}
+
+// auditTargets 返回 query 与 json body 中请求操作的主机与 zone，firewalld 的 v1 与 v2 接口未指定 zone 时为 public
+func auditTargets(c *gin.Context, document interface{}) (hosts, zones []string) {
+	values := make(map[string][]string)
+	keys := append([]string{"zone"}, targetKeys...)
+	for _, key := range keys {
+		values[key] = append(values[key], c.QueryArray(key)...)
+	}
+	collectFields(document, keys, values)
+
+	for _, key := range []string{"host_id", "host_ids"} {
+		for _, v := range values[key] {
+			if host, err := model.QueryHostWithID(cast.ToInt(v)); err == nil && host.IP > 0 {
+				values["ip"] = append(values["ip"], ipconv.IntToIPv4(host.IP).String())
+			}
+		}
+	}
+	for _, key := range []string{"ip", "host", "hosts"} {
+		hosts = appendUnique(hosts, values[key]...)
+	}
+	zones = appendUnique(zones, values["zone"]...)
+	if len(zones) == 0 && (strings.HasPrefix(c.FullPath(), "/fw/v1/") || strings.HasPrefix(c.FullPath(), "/fw/v2/")) {
+		zones = []string{"public"}
+	}
+	return hosts, zones
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		exist := v == ""
+		for _, item := range list {
+			exist = exist || item == v
+		}
+		if !exist {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// redactField 字段名包含 redact_fields 中任意一项时需要隐藏
+func redactField(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range config.CONFIG.Audit.RedactFields {
+		if field != "" && strings.Contains(key, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactDocument(document interface{}) {
+	switch v := document.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if redactField(key) {
+				v[key] = redacted
+				continue
+			}
+			redactDocument(value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			redactDocument(value)
+		}
+	}
+}
+
+// sanitizeBody 返回隐藏敏感字段后的请求内容，json 与表单以外的内容只记录长度，超过 body_limit 时截断
+func sanitizeBody(body []byte, document interface{}, contentType string) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var sanitized string
+	switch {
+	case document != nil:
+		redactDocument(document)
+		content, _ := json.Marshal(document)
+		sanitized = string(content)
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			sanitized = fmt.Sprintf("<invalid form, %d bytes>", len(body))
+			break
+		}
+		for key := range form {
+			if redactField(key) {
+				form[key] = []string{redacted}
+			}
+		}
+		sanitized = form.Encode()
+	default:
+		sanitized = fmt.Sprintf("<%s, %d bytes>", contentType, len(body))
+	}
+	if limit := config.CONFIG.Audit.BodyLimit; limit > 0 && len(sanitized) > limit {
+		sanitized = sanitized[:limit]
+	}
+	return sanitized
+}
+
+// RedactBody 返回隐藏敏感字段后的请求内容，规则与审计日志相同
+func RedactBody(body []byte, contentType string) string {
+	var document interface{}
+	if len(body) > 0 && strings.Contains(contentType, "json") {
+		json.Unmarshal(body, &document)
+	}
+	return sanitizeBody(body, document, contentType)
+}
+
+// auditSnapshot 返回读取被修改资源快照的函数：firewalld v1 接口为运行时的 zone 配置，v2 接口为永久的 zone 配置，
+// 模板接口为模板的全部规则；GET 请求、异步的 v3 接口以及无法确定被修改的资源时返回 nil
+func auditSnapshot(c *gin.Context, document interface{}, hosts, zones []string) func() string {
+	if c.Request.Method == http.MethodGet || !config.CONFIG.Audit.Snapshot {
+		return nil
+	}
+	route := c.FullPath()
+	switch {
+	case strings.HasPrefix(route, "/fw/v1/"), strings.HasPrefix(route, "/fw/v2/"):
+		if len(hosts) != 1 || len(zones) != 1 {
+			return nil
+		}
+		permanent := strings.HasPrefix(route, "/fw/v2/")
+		return func() string {
+			return zoneSnapshot(c.Request.Context(), hosts[0], zones[0], permanent)
+		}
+	case strings.HasPrefix(route, "/fw/template"):
+		if id := snapshotTemplateID(c, document); id > 0 {
+			return func() string {
+				return templateSnapshot(id)
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotTemplateID 返回修改模板的请求修改的模板 id，下发模板不修改模板，返回 0
+func snapshotTemplateID(c *gin.Context, document interface{}) uint {
+	route := c.FullPath()
+	if c.Param("id") != "" {
+		if strings.HasSuffix(route, "/restore") || strings.HasSuffix(route, "/import") || strings.HasSuffix(route, "/import/service") {
+			return cast.ToUint(c.Param("id"))
+		}
+		return 0
+	}
+	fields, _ := document.(map[string]interface{})
+	if id := cast.ToUint(fields["template_id"]); id > 0 {
+		return id
+	}
+	if id := cast.ToUint(c.Query("template_id")); id > 0 {
+		return id
+	}
+	// 修改与删除模板时 id 为模板的 id
+	if route == "/fw/template/" && c.Request.Method != http.MethodPut {
+		if id := cast.ToUint(fields["id"]); id > 0 {
+			return id
+		}
+		return cast.ToUint(c.Query("id"))
+	}
+	return 0
+}
+
+func zoneSnapshot(ctx context.Context, host, zone string, permanent bool) string {
+	client, err := firewalld.NewDbusClientServiceWithContext(ctx, host)
+	if err != nil {
+		klog.V(4).Infof("Connect %s for audit snapshot failed: %v", host, err)
+		return ""
+	}
+	defer client.Destroy()
+	var settings *api.Settings
+	if permanent {
+		settings, err = client.GetPermanentZoneSettings(zone)
+	} else {
+		settings, err = client.GetZoneSettings(zone)
+	}
+	if err != nil {
+		return ""
+	}
+	content, _ := json.Marshal(settings)
+	return string(content)
+}
+
+func templateSnapshot(id uint) string {
+	details, err := model.GetTemplateDetails(model.DB, id)
+	if err != nil {
+		return ""
+	}
+	content, _ := json.Marshal(details)
+	return string(content)
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/utils/metrics"
+)
+
+// MetricsMiddleware 按路由统计请求次数与耗时，没有匹配路由的请求(静态文件与 404)统一记为 unmatched，
+// 不是标准 HTTP 方法的请求统一记为 other
+func MetricsMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := metrics.Method(c.Request.Method)
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequests.WithLabelValues(method, route, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
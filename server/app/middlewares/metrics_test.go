@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/cylonchau/firewalld-gateway/utils/metrics"
+)
+
+func TestMetricsMethodLabel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(MetricsMiddleware())
+	e.GET("/fw/v1/port", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, method := range []string{http.MethodGet, "FOO", "BAR", "get"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/fw/v1/port", nil))
+	}
+
+	if n := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/fw/v1/port", "200")); n != 1 {
+		t.Fatalf("GET requests: expected 1, got %v", n)
+	}
+	// 不是标准方法的请求没有匹配的路由，合并为一个序列
+	if n := testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues("other", "unmatched", "404")); n != 3 {
+		t.Fatalf("other requests: expected 3, got %v", n)
+	}
+	for _, method := range []string{"FOO", "BAR", "get"} {
+		if metrics.HTTPRequests.DeleteLabelValues(method, "unmatched", "404") {
+			t.Fatalf("unexpected series for method %s", method)
+		}
+	}
+}
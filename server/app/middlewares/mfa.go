@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// checkMFA 角色要求二次认证而用户尚未启用时拒绝访问；执行 step_up_routes 中的操作前，
+// 会话需要在 step_up_ttl 内通过二次认证，没有启用二次认证的用户不能执行这些操作，失败时已经写入响应
+func checkMFA(c *gin.Context, uid int64, session *model.Session) bool {
+	enabled, required, err := model.MFAState(uint(uid))
+	if err != nil {
+		query.API500Response(c, err)
+		c.Abort()
+		return false
+	}
+	if required && !enabled {
+		query.Auth403Failed(c, query.ErrMFAEnrollRequired, nil)
+		c.Abort()
+		return false
+	}
+	if !stepUpRequired(c) {
+		return true
+	}
+	if !enabled {
+		query.Auth403Failed(c, query.ErrMFAStepUpEnroll, nil)
+		c.Abort()
+		return false
+	}
+	ttl := time.Duration(config.CONFIG.MFA.StepUpTTL) * time.Second
+	if session.MFAVerifiedAt == nil || time.Since(*session.MFAVerifiedAt) > ttl {
+		query.Auth403Failed(c, query.ErrMFAStepUpRequired, nil)
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+// MFAMiddleware 检查二次认证，需要放在 AuthenticationMiddleware 之后
+func MFAMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		session, ok := model.GetActiveSession(c.GetString(auther.SessionIDKey))
+		if !ok {
+			query.Auth403Failed(c, query.ErrSessionRevoked, nil)
+			c.Abort()
+			return
+		}
+		if checkMFA(c, c.GetInt64(auther.UserIDKey), session) {
+			c.Next()
+		}
+	}
+}
+
+// stepUpRequired 判断当前请求是否为需要再次验证的敏感操作
+func stepUpRequired(c *gin.Context) bool {
+	for _, route := range config.CONFIG.MFA.StepUpRoutes {
+		router, err := model.ParseRouteScope(route)
+		if err == nil && matchRouter(&router, c.FullPath(), c.Request.Method) {
+			return true
+		}
+	}
+	return false
+}
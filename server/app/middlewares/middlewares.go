@@ -2,7 +2,6 @@ package middlewares
 
 import (
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -13,89 +12,174 @@ import (
 	"github.com/cylonchau/firewalld-gateway/utils/model"
 )
 
-func writeLog(r *http.Request, id int64) {
+func auditLogData(r *http.Request, id int64, tokenID uint) map[string]interface{} {
 	ip, _ := model.GetRequestIP(r)
 	ua := user_agent.New(r.UserAgent())
 	browserName, _ := ua.Browser()
 	osName := ua.OS()
 
-	auditLogData := map[string]interface{}{
-		"user_id": id,
-		"ip":      ip,
-		"method":  r.Method,
-		"path":    r.URL.Path,
-		"browser": browserName,
-		"system":  osName,
+	return map[string]interface{}{
+		"user_id":  id,
+		"token_id": tokenID,
+		"ip":       ip,
+		"method":   r.Method,
+		"path":     r.URL.Path,
+		"browser":  browserName,
+		"system":   osName,
 	}
-	model.AppendAuditLog(auditLogData)
+}
+
+// WriteEventLog 记录不经过认证中间件的审计事件，例如登录失败
+func WriteEventLog(r *http.Request, id int64, event, detail string) {
+	auditLog := auditLogData(r, id, 0)
+	auditLog["event"] = event
+	auditLog["detail"] = detail
+	model.AppendAuditLog(auditLog)
+}
+
+// bearerToken 从 Authorization 头中取出 token，失败时已经写入响应
+func bearerToken(c *gin.Context) (string, bool) {
+	// 客户端携带Token有三种方式 1.放在请求头 2.放在请求体 3.放在URI
+	// 这里假设Token放在Header的Authorization中，并使用Bearer开头
+	// Authorization： Bearer xxxxxx.xxxx.xxx
+	// 这里的具体实现方式要依据你的实际业务情况决定
+	authHeader := c.Request.Header.Get("Authorization")
+	if authHeader == "" {
+		query.AuthFailed(c, query.ErrNeedAuth, nil)
+		c.Abort() // 中止
+		return "", false
+	}
+	// 按空格分割
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		query.Auth403Failed(c, query.ErrTokenInvalid, nil)
+		c.Abort()
+		return "", false
+	}
+	return parts[1], true
+}
+
+// sessionAuth 解析 access token 并检查登录会话，失败时已经写入响应
+func sessionAuth(c *gin.Context, tokenStr string) (*auther.Token, *model.Session, bool) {
+	// parts[1]是获取到的tokenString，我们使用之前定义好的解析JWT的函数来解析它
+	mc, err := auther.ParseToken(tokenStr)
+	if err != nil {
+		query.Auth403Failed(c, query.ErrTokenInvalid, err.Error())
+		c.Abort()
+		return nil, nil, false
+	}
+	// 登录会话被注销后 access token 立即失效
+	session, ok := model.GetActiveSession(mc.SessionID)
+	if !ok {
+		query.Auth403Failed(c, query.ErrSessionRevoked, nil)
+		c.Abort()
+		return nil, nil, false
+	}
+	c.Set(auther.SessionIDKey, mc.SessionID)
+	return mc, session, true
 }
 
 // JWTAuthMiddleware 基于JWT的认证中间件
 func JWTAuthMiddleware() func(c *gin.Context) {
 	return func(c *gin.Context) {
-
-		// 客户端携带Token有三种方式 1.放在请求头 2.放在请求体 3.放在URI
-		// 这里假设Token放在Header的Authorization中，并使用Bearer开头
-		// Authorization： Bearer xxxxxx.xxxx.xxx
-		// 这里的具体实现方式要依据你的实际业务情况决定
-		authHeader := c.Request.Header.Get("Authorization")
-		if authHeader == "" {
-			query.AuthFailed(c, query.ErrNeedAuth, nil)
-			c.Abort() // 中止
+		if change, ok := approvedChange(c.Request); ok {
+			approvedAuth(c, change)
 			return
 		}
-		// 按空格分割
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			query.Auth403Failed(c, query.ErrTokenInvalid, nil)
-			c.Abort()
+		tokenStr, ok := bearerToken(c)
+		if !ok {
 			return
 		}
-		tokenStr := parts[1]
-		if model.TokenIsDestoryed(tokenStr) {
-			query.Auth403Failed(c, query.ErrTokenDestoryed, nil)
-			c.Abort()
+		if strings.HasPrefix(tokenStr, model.APITokenPrefix) {
+			apiTokenAuth(c, tokenStr)
 			return
 		}
-		// parts[1]是获取到的tokenString，我们使用之前定义好的解析JWT的函数来解析它
-		mc, err := auther.ParseToken(tokenStr)
-		if err != nil {
-			query.Auth403Failed(c, query.ErrTokenInvalid, err.Error())
-			c.Abort()
+		mc, session, ok := sessionAuth(c, tokenStr)
+		if !ok {
+			return
+		}
+		if !checkMFA(c, mc.UserID, session) {
 			return
 		}
 
 		if mc.UserID == 1 {
 			c.Set(auther.UserIDKey, mc.UserID)
-			writeLog(c.Request, mc.UserID)
-			c.Next() // 后续的处理函数可以用过c.Get("username")来获取当前请求的用户信息
+			auditNext(c, mc.UserID, 0) // 后续的处理函数可以用过c.Get("username")来获取当前请求的用户信息
 			return
 		}
 
-		roles, err := model.GetRolesWithUID(int(mc.UserID))
+		permissions, err := model.GetPermissionsWithUID(uint(mc.UserID))
 		if err != nil {
 			query.Auth403Failed(c, query.ErrTokenInvalid, err.Error())
 			c.Abort()
 			return
 		}
 
-		var ids []string
-		for _, v := range roles.Roles {
-			ids = append(ids, strconv.Itoa(v.ID))
+		if authorize(c, permissions, nil) {
+			// 将当前请求的userid信息保存到请求的上下文c上
+			c.Set(auther.UserIDKey, mc.UserID)
+			auditNext(c, mc.UserID, 0) // 后续的处理函数可以用过c.Get("username")来获取当前请求的用户信息
+			return
 		}
-		routers, err := model.GetRoutersWithRID(ids)
+		query.AuthNoPermission(c, query.ErrNoPermission)
+		c.Abort()
+		return
+	}
+}
 
-		for _, v := range routers {
-			if v.Path == c.Request.URL.Path && v.Method == c.Request.Method {
-				// 将当前请求的userid信息保存到请求的上下文c上
-				c.Set(auther.UserIDKey, mc.UserID)
-				writeLog(c.Request, mc.UserID)
-				c.Next() // 后续的处理函数可以用过c.Get("username")来获取当前请求的用户信息
-				return
-			}
+// AuthenticationMiddleware 只校验登录会话、不检查角色权限的中间件，用于用户管理自己的账号，例如绑定二次认证
+func AuthenticationMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		tokenStr, ok := bearerToken(c)
+		if !ok {
+			return
 		}
+		// API token 不能管理账号
+		if strings.HasPrefix(tokenStr, model.APITokenPrefix) {
+			query.Auth403Failed(c, query.ErrTokenInvalid, nil)
+			c.Abort()
+			return
+		}
+		mc, _, ok := sessionAuth(c, tokenStr)
+		if !ok {
+			return
+		}
+		c.Set(auther.UserIDKey, mc.UserID)
+		auditNext(c, mc.UserID, 0)
+	}
+}
+
+// apiTokenAuth 使用 API token 的认证，token 的权限为角色的权限与显式 route scope 的并集，并受 host 与 tag scope 的限制
+func apiTokenAuth(c *gin.Context, tokenStr string) {
+	token, err := model.GetAPIToken(tokenStr)
+	if err != nil {
+		if err == query.ErrTokenExpired {
+			query.Auth403Failed(c, query.ErrTokenExpired, nil)
+		} else {
+			query.Auth403Failed(c, query.ErrTokenInvalid, nil)
+		}
+		c.Abort()
+		return
+	}
+	permissions, restrict, err := model.TokenPermissions(token)
+	if err != nil {
+		query.Auth403Failed(c, query.ErrTokenInvalid, err.Error())
+		c.Abort()
+		return
+	}
+	if !authorize(c, permissions, restrict) {
 		query.AuthNoPermission(c, query.ErrNoPermission)
 		c.Abort()
 		return
 	}
+	// API token 无法二次认证，step_up_routes 中的操作只能由登录会话执行
+	if stepUpRequired(c) {
+		query.Auth403Failed(c, query.ErrMFAStepUpToken, nil)
+		c.Abort()
+		return
+	}
+	ip, _ := model.GetRequestIP(c.Request)
+	model.TouchToken(token, ip)
+	c.Set(auther.TokenIDKey, token.ID)
+	auditNext(c, 0, token.ID)
 }
@@ -0,0 +1,176 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praserx/ipconv"
+	"github.com/spf13/cast"
+	"k8s.io/klog/v2"
+
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// matchRouter 判断路由规则是否匹配当前请求，path 为 gin 的路由模式，以 /* 结尾时匹配该前缀下的所有路由，method 为 * 时匹配所有方法
+func matchRouter(router *model.Router, fullPath, method string) bool {
+	if router.Method != "*" && !strings.EqualFold(router.Method, method) {
+		return false
+	}
+	if strings.HasSuffix(router.Path, "/*") {
+		return strings.HasPrefix(fullPath+"/", strings.TrimSuffix(router.Path, "*"))
+	}
+	return strings.TrimSuffix(router.Path, "/") == strings.TrimSuffix(fullPath, "/")
+}
+
+// targetKeys 表示请求操作的主机的字段
+var targetKeys = []string{"ip", "host", "hosts", "host_id", "host_ids"}
+
+// foldKey 返回 names 中与 key 忽略大小写相同的字段名；handler 用 encoding/json 绑定 body，
+// 字段名不区分大小写，{"ip": ..., "IP": ...} 两个字段都会绑定到 Ip，检查时必须同样不区分大小写
+func foldKey(key string, names []string) (string, bool) {
+	for _, name := range names {
+		if strings.EqualFold(key, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// requestTargets 从 query 与 json body 中找出请求操作的主机，包括任意层级的 ip、host、hosts、host_id、host_ids 字段
+func requestTargets(c *gin.Context) (ips []uint32, err error) {
+	values := make(map[string][]interface{})
+	for _, key := range targetKeys {
+		for _, v := range c.QueryArray(key) {
+			values[key] = append(values[key], v)
+		}
+	}
+	if c.Request.Body != nil && strings.Contains(c.ContentType(), "json") {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, err
+		}
+		// 后续的 handler 需要再次读取 body
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		var document interface{}
+		if len(body) > 0 && json.Unmarshal(body, &document) == nil {
+			collectTargets(document, values)
+		}
+	}
+
+	for key, list := range values {
+		for _, v := range list {
+			var ip uint32
+			switch key {
+			case "host_id", "host_ids":
+				host, err := model.QueryHostWithID(cast.ToInt(v))
+				if err != nil {
+					return nil, err
+				}
+				ip = host.IP
+			default:
+				ip, err = resolveHost(cast.ToString(v))
+				if err != nil {
+					return nil, err
+				}
+			}
+			ips = append(ips, ip)
+		}
+	}
+	return ips, nil
+}
+
+func collectTargets(document interface{}, values map[string][]interface{}) {
+	switch v := document.(type) {
+	case map[string]interface{}:
+		for field, value := range v {
+			key, _ := foldKey(field, targetKeys)
+			switch key {
+			case "ip", "host", "host_id":
+				if _, ok := value.(string); ok || key == "host_id" {
+					values[key] = append(values[key], value)
+					continue
+				}
+			case "hosts", "host_ids":
+				if list, ok := value.([]interface{}); ok {
+					values[key] = append(values[key], list...)
+					continue
+				}
+			}
+			collectTargets(value, values)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectTargets(value, values)
+		}
+	}
+}
+
+// resolveHost 将 ip 或主机名转换为 ip
+func resolveHost(host string) (uint32, error) {
+	if ip, version, err := ipconv.ParseIP(host); err == nil && version == 4 {
+		return ipconv.IPv4ToInt(ip)
+	}
+	h, err := model.QueryHostWithName(host)
+	if err != nil {
+		return 0, err
+	}
+	// 不存在的主机返回 0，不会匹配任何 scope
+	return h.IP, nil
+}
+
+// authorize 判断请求是否被允许：至少一个角色的路由匹配请求，并且请求操作的主机都在匹配角色的 scope 内；
+// 匹配的角色中有不限制主机的角色时不检查角色的 scope，有 scope 限制但无法确定操作的主机时只允许 GET 请求。
+// restrict 不为空时请求操作的主机还必须在 restrict 内，用于 API token 的主机限制
+func authorize(c *gin.Context, permissions []model.Permission, restrict []model.RoleScope) bool {
+	fullPath := c.FullPath()
+	var (
+		matched, unrestricted bool
+		scopes                []model.RoleScope
+	)
+	for _, permission := range permissions {
+		for i := range permission.Routers {
+			if !matchRouter(&permission.Routers[i], fullPath, c.Request.Method) {
+				continue
+			}
+			matched = true
+			if len(permission.Scopes) == 0 {
+				unrestricted = true
+			}
+			scopes = append(scopes, permission.Scopes...)
+			break
+		}
+	}
+	if !matched {
+		return false
+	}
+	if unrestricted && len(restrict) == 0 {
+		return true
+	}
+
+	targets, err := requestTargets(c)
+	if err != nil {
+		klog.Warningf("Resolve hosts of request %s %s failed: %v", c.Request.Method, fullPath, err)
+		return false
+	}
+	if len(targets) == 0 {
+		return c.Request.Method == http.MethodGet
+	}
+	for _, ip := range targets {
+		if !unrestricted {
+			if ok, err := model.HostInScopes(ip, scopes); err != nil || !ok {
+				return false
+			}
+		}
+		if len(restrict) > 0 {
+			if ok, err := model.HostInScopes(ip, restrict); err != nil || !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package middlewares
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/praserx/ipconv"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+func TestMatchRouter(t *testing.T) {
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		fullPath string
+		request  string
+		expected bool
+	}{
+		{"exact", "GET", "/fw/v1/port", "/fw/v1/port", "GET", true},
+		{"other method", "GET", "/fw/v1/port", "/fw/v1/port", "POST", false},
+		{"method case", "post", "/fw/v1/port", "/fw/v1/port", "POST", true},
+		{"any method", "*", "/fw/v1/port", "/fw/v1/port", "DELETE", true},
+		{"trailing slash in router", "PUT", "/fw/v3/ports/", "/fw/v3/ports", "PUT", true},
+		{"trailing slash in request", "PUT", "/fw/v3/ports", "/fw/v3/ports/", "PUT", true},
+		// 路径与 gin 一样区分大小写
+		{"path case", "GET", "/FW/v1/port", "/fw/v1/port", "GET", false},
+		{"param", "POST", "/fw/template/:id/apply", "/fw/template/:id/apply", "POST", true},
+		{"param of other route", "POST", "/fw/template/:id/apply", "/fw/template/:id", "POST", false},
+		{"param name", "DELETE", "/security/users/:uid", "/security/users/:id", "DELETE", false},
+		{"prefix", "*", "/fw/v3/*", "/fw/v3/ports", "GET", true},
+		{"prefix itself", "*", "/fw/v3/*", "/fw/v3", "GET", true},
+		{"prefix of other segment", "*", "/fw/v3/*", "/fw/v30/ports", "GET", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := &model.Router{Method: tc.method, Path: tc.path}
+			if got := matchRouter(router, tc.fullPath, tc.request); got != tc.expected {
+				t.Fatalf("%s %s on %s %s: expected %v, got %v", tc.method, tc.path, tc.request, tc.fullPath, tc.expected, got)
+			}
+		})
+	}
+}
+
+// serveTargets 在路由中执行 fn，返回响应
+func serveTargets(t *testing.T, method, target, body string, fn func(c *gin.Context)) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Handle(method, "/fw/v1/port", fn)
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func ip(t *testing.T, address string) uint32 {
+	t.Helper()
+	value, err := ipconv.IPv4ToInt(net.ParseIP(address))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestRequestTargets(t *testing.T) {
+	setupDatabase(t, "")
+	createTaggedHost(t, "production", "10.0.0.1")
+	createTaggedHost(t, "staging", "10.0.0.2")
+
+	production, staging := ip(t, "10.0.0.1"), ip(t, "10.0.0.2")
+	cases := []struct {
+		name     string
+		query    string
+		body     string
+		expected []uint32
+	}{
+		{"query", "ip=10.0.0.1&host=staging-host", "", []uint32{production, staging}},
+		{"json", "", `{"ip": "10.0.0.1"}`, []uint32{production}},
+		// handler 绑定 body 时字段名不区分大小写
+		{"json key case", "", `{"IP": "10.0.0.1", "Host_IDs": [2]}`, []uint32{production, staging}},
+		{"nested", "", `{"items": [{"host": "production-host"}, {"rule": {"hosts": ["10.0.0.2"]}}]}`, []uint32{production, staging}},
+		{"host id", "", `{"host_id": 2}`, []uint32{staging}},
+		// 不存在的主机返回 0，不会匹配任何 scope
+		{"unknown host", "", `{"host": "missing", "host_ids": [99]}`, []uint32{0, 0}},
+		{"no host", "", `{"port": "80"}`, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				targets []uint32
+				err     error
+				body    []byte
+			)
+			serveTargets(t, http.MethodPost, "/fw/v1/port?"+tc.query, tc.body, func(c *gin.Context) {
+				targets, err = requestTargets(c)
+				body, _ = io.ReadAll(c.Request.Body)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+			if !reflect.DeepEqual(targets, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, targets)
+			}
+			// handler 需要再次读取 body
+			if string(body) != tc.body {
+				t.Fatalf("body is not restored: %q", body)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	setupDatabase(t, "")
+	createTaggedHost(t, "production", "10.0.0.1")
+	createTaggedHost(t, "staging", "10.0.0.2")
+
+	route := []model.Router{{Method: "*", Path: "/fw/v1/port"}}
+	unrestricted := model.Permission{RoleID: 1, Routers: route}
+	productionRole := model.Permission{RoleID: 2, Routers: route, Scopes: []model.RoleScope{{Kind: model.ScopeTag, Value: "production"}}}
+	otherRoute := model.Permission{RoleID: 3, Routers: []model.Router{{Method: "*", Path: "/fw/v1/service"}}}
+	productionHost := []model.RoleScope{{Kind: model.ScopeHost, Value: "10.0.0.1"}}
+	productionTag := []model.RoleScope{{Kind: model.ScopeTag, Value: "production"}}
+
+	cases := []struct {
+		name        string
+		permissions []model.Permission
+		restrict    []model.RoleScope
+		method      string
+		query       url.Values
+		body        string
+		expected    bool
+	}{
+		{"no route", []model.Permission{otherRoute}, nil, http.MethodPost, nil, `{"ip": "10.0.0.1"}`, false},
+		{"unrestricted role", []model.Permission{unrestricted}, nil, http.MethodPost, nil, `{"ip": "10.0.0.2"}`, true},
+		{"unrestricted role without host", []model.Permission{unrestricted}, nil, http.MethodPost, nil, "", true},
+		{"tag scope", []model.Permission{productionRole}, nil, http.MethodPost, nil, `{"ip": "10.0.0.1"}`, true},
+		{"out of tag scope", []model.Permission{productionRole}, nil, http.MethodPost, nil, `{"ip": "10.0.0.2"}`, false},
+		{"one host out of tag scope", []model.Permission{productionRole}, nil, http.MethodPost, nil, `{"hosts": ["10.0.0.1", "10.0.0.2"]}`, false},
+		{"hostname in tag scope", []model.Permission{productionRole}, nil, http.MethodDelete, url.Values{"host": {"production-host"}}, "", true},
+		// 无法确定操作的主机时有 scope 限制的角色只能读
+		{"scoped write without host", []model.Permission{productionRole}, nil, http.MethodPost, nil, `{"port": "80"}`, false},
+		{"scoped read without host", []model.Permission{productionRole}, nil, http.MethodGet, nil, "", true},
+		{"unknown host", []model.Permission{productionRole}, nil, http.MethodPost, nil, `{"host": "missing"}`, false},
+		{"unknown host id", []model.Permission{productionRole}, nil, http.MethodPost, nil, `{"host_ids": [99]}`, false},
+		// 匹配的角色中有不限制主机的角色时不检查其他角色的 scope
+		{"unrestricted and scoped roles", []model.Permission{productionRole, unrestricted}, nil, http.MethodPost, nil, `{"ip": "10.0.0.2"}`, true},
+		{"token host", []model.Permission{unrestricted}, productionHost, http.MethodPost, nil, `{"ip": "10.0.0.1"}`, true},
+		{"out of token host", []model.Permission{unrestricted}, productionHost, http.MethodPost, nil, `{"ip": "10.0.0.2"}`, false},
+		{"token tag", []model.Permission{unrestricted}, productionTag, http.MethodPost, nil, `{"host_ids": [1]}`, true},
+		{"out of token tag", []model.Permission{unrestricted}, productionTag, http.MethodPost, nil, `{"host_ids": [2]}`, false},
+		{"token without host", []model.Permission{unrestricted}, productionTag, http.MethodPost, nil, "", false},
+		{"token read without host", []model.Permission{unrestricted}, productionTag, http.MethodGet, nil, "", true},
+		{"token and role scope", []model.Permission{productionRole}, productionHost, http.MethodPost, nil, `{"Ip": "10.0.0.1"}`, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var allowed bool
+			serveTargets(t, tc.method, "/fw/v1/port?"+tc.query.Encode(), tc.body, func(c *gin.Context) {
+				allowed = authorize(c, tc.permissions, tc.restrict)
+			})
+			if allowed != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, allowed)
+			}
+		})
+	}
+}
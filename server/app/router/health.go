@@ -1,13 +1,41 @@
 package router
 
 import (
+	"context"
 	"embed"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/server/batch_processor"
+	"github.com/cylonchau/firewalld-gateway/server/election"
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+	"github.com/cylonchau/firewalld-gateway/utils/migration"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
 )
 
+const (
+	checkOK       = "ok"
+	checkFailed   = "failed"
+	checkDisabled = "disabled"
+	checkSkipped  = "skipped"
+)
+
+var startedAt = time.Now()
+
+// healthCheck 一项就绪检查的结果
+type healthCheck struct {
+	Status  string      `json:"status"`
+	Latency string      `json:"latency,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Detail  interface{} `json:"detail,omitempty"`
+}
+
 // ping godoc
 // @Summary Return process status.
 // @Description Return process status.
@@ -20,6 +48,155 @@ func ping(c *gin.Context) {
 	query.SuccessResponse(c, query.OK, "pong")
 }
 
+// healthz godoc
+// @Summary Return liveness.
+// @Description Return ok as long as the process is serving HTTP, used by the liveness probe. Dependencies are checked by /readyz.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /healthz [get]
+func healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": checkOK,
+		"uptime": time.Since(startedAt).Round(time.Second).String(),
+	})
+}
+
+// readyz godoc
+// @Summary Return readiness.
+// @Description Check database connectivity, migration version, batch processor state, leader election and the optional canary host, return 503 with the failed checks if any check fails.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /readyz [get]
+func readyz(c *gin.Context) {
+	ctx := c.Request.Context()
+	timeout := time.Duration(config.CONFIG.Health.Timeout) * time.Second
+	checks := make(map[string]*healthCheck)
+
+	if config.CONFIG.MySQL.IsEmpty() && config.CONFIG.SQLite.IsEmpty() && config.CONFIG.PostgreSQL.IsEmpty() {
+		checks["database"] = &healthCheck{Status: checkDisabled}
+		checks["migration"] = &healthCheck{Status: checkDisabled}
+	} else {
+		checks["database"] = runCheck(ctx, timeout, func(ctx context.Context) (interface{}, error) {
+			return nil, model.PingDB(ctx)
+		})
+		// 数据库不可用时无法判断表是否存在
+		if checks["database"].Status == checkOK {
+			checks["migration"] = runCheck(ctx, timeout, func(ctx context.Context) (interface{}, error) {
+				return nil, migration.CheckVersion(model.DB.WithContext(ctx))
+			})
+		} else {
+			checks["migration"] = &healthCheck{Status: checkSkipped}
+		}
+	}
+	checks["batch_processor"] = processorCheck()
+	checks["leader_election"] = electionCheck()
+	if host := config.CONFIG.Health.CanaryHost; host != "" {
+		checks["canary"] = runCheck(ctx, timeout, func(ctx context.Context) (interface{}, error) {
+			address := net.JoinHostPort(host, config.CONFIG.DbusPort)
+			conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+			if err != nil {
+				return address, err
+			}
+			conn.Close()
+			return address, nil
+		})
+	} else {
+		checks["canary"] = &healthCheck{Status: checkDisabled}
+	}
+
+	status, code := checkOK, http.StatusOK
+	for _, check := range checks {
+		if check.Status == checkFailed {
+			status, code = checkFailed, http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+// runCheck 执行一项检查，超过 timeout 时不再等待检查返回，结果记为失败
+func runCheck(parent context.Context, timeout time.Duration, check func(ctx context.Context) (interface{}, error)) *healthCheck {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	type checkResult struct {
+		detail interface{}
+		err    error
+	}
+	done := make(chan checkResult, 1)
+	start := time.Now()
+	go func() {
+		detail, err := check(ctx)
+		done <- checkResult{detail, err}
+	}()
+	var result checkResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result.err = ctx.Err()
+	}
+
+	healthCheck := &healthCheck{Status: checkOK, Latency: time.Since(start).String(), Detail: result.detail}
+	if result.err != nil {
+		healthCheck.Status, healthCheck.Error = checkFailed, result.err.Error()
+	}
+	return healthCheck
+}
+
+// processorCheck 开启异步任务时检查任务处理器是否在运行，并返回等待处理的任务数
+func processorCheck() *healthCheck {
+	if !config.CONFIG.AsyncProcess {
+		return &healthCheck{Status: checkDisabled}
+	}
+	// 开启选主时只有 leader 处理任务，其他副本只把任务保存到数据库中
+	if config.CONFIG.HA.Enabled && !election.IsLeader() {
+		return &healthCheck{Status: checkOK, Detail: gin.H{"standby": true}}
+	}
+	processor := batch_processor.P
+	if processor == nil || !processor.Running() {
+		return &healthCheck{Status: checkFailed, Error: "batch processor is not running"}
+	}
+	return &healthCheck{Status: checkOK, Detail: gin.H{"queue_depth": processor.Len()}}
+}
+
+// electionCheck 开启选主时返回本副本的标识与观察到的 leader，没有 leader 不影响本副本接收请求
+func electionCheck() *healthCheck {
+	if !config.CONFIG.HA.Enabled {
+		return &healthCheck{Status: checkDisabled}
+	}
+	return &healthCheck{Status: checkOK, Detail: gin.H{
+		"identity":  election.Identity(),
+		"leader":    election.Leader(),
+		"is_leader": election.IsLeader(),
+	}}
+}
+
+var metricsHandler = promhttp.Handler()
+
+// prometheusMetrics godoc
+// @Summary Return metrics.
+// @Description Return metrics of HTTP requests, D-Bus calls, the batch queue, host reachability, template drift and login failures in Prometheus exposition format.
+// @Tags Health
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func prometheusMetrics(c *gin.Context) {
+	metricsHandler.ServeHTTP(c.Writer, c.Request)
+}
+
+// jwks godoc
+// @Summary Return public keys used to sign tokens.
+// @Description Return the JSON Web Key Set of the gateway, other services can use it to verify tokens issued by the gateway. HMAC keys are never published.
+// @Tags SSO
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /.well-known/jwks.json [get]
+func jwks(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"keys": auther.JWKS()})
+}
+
 //go:embed dist/*
 var distHandle embed.FS
 
@@ -11,6 +11,7 @@ import (
 	"github.com/cylonchau/firewalld-gateway/config"
 	"github.com/cylonchau/firewalld-gateway/server/app/audit"
 	"github.com/cylonchau/firewalld-gateway/server/app/auth"
+	"github.com/cylonchau/firewalld-gateway/server/app/changes"
 	"github.com/cylonchau/firewalld-gateway/server/app/firewalld/host"
 	"github.com/cylonchau/firewalld-gateway/server/app/firewalld/tag"
 	"github.com/cylonchau/firewalld-gateway/server/app/firewalld/template"
@@ -29,13 +30,20 @@ import (
 var distFileSystem embed.FS
 
 func RegisteredRouter(e *gin.Engine) {
+	e.Use(middlewares.TracingMiddleware(), middlewares.MetricsMiddleware())
 	e.Use(static.Serve("/", static.EmbedFolder(distFileSystem, "dist")))
 	e.Handle("GET", "/ping", ping)
+	e.Handle("GET", "/healthz", healthz)
+	e.Handle("GET", "/readyz", readyz)
+	if config.CONFIG.Metrics.Enabled {
+		e.Handle("GET", "/metrics", prometheusMetrics)
+	}
+	e.Handle("GET", "/.well-known/jwks.json", jwks)
 	ssoGroup := e.Group("/sso")
 	securityAPIGroup := e.Group("/security")
 	firewallAPIGroup := e.Group("/fw")
-	firewallAPIGroup.Use(middlewares.JWTAuthMiddleware())
-	securityAPIGroup.Use(middlewares.JWTAuthMiddleware())
+	firewallAPIGroup.Use(middlewares.JWTAuthMiddleware(), middlewares.ApprovalMiddleware())
+	securityAPIGroup.Use(middlewares.JWTAuthMiddleware(), middlewares.ApprovalMiddleware())
 
 	tagGroup := firewallAPIGroup.Group("/tag")
 	hostGroup := firewallAPIGroup.Group("/host")
@@ -50,6 +58,9 @@ func RegisteredRouter(e *gin.Engine) {
 	auditAPI := securityAPIGroup.Group("/audit")
 	// auth
 	authAPI := securityAPIGroup.Group("/auth")
+	// change requests，审批人由变更的审批角色决定，不使用路由权限
+	changeAPI := e.Group("/security/changes")
+	changeAPI.Use(middlewares.AuthenticationMiddleware(), middlewares.MFAMiddleware())
 
 	/* firewall  */
 	fv1Group := firewallAPIGroup.Group("/v1")
@@ -108,7 +119,7 @@ func RegisteredRouter(e *gin.Engine) {
 		richRuleRouterV3.RegisterBatchAPI(fv3Group)
 	}
 
-	if !config.CONFIG.MySQL.IsEmpty() || !config.CONFIG.SQLite.IsEmpty() {
+	if !config.CONFIG.MySQL.IsEmpty() || !config.CONFIG.SQLite.IsEmpty() || !config.CONFIG.PostgreSQL.IsEmpty() {
 		ssoRouter := &sso.SSO{}
 		ssoRouter.RegisterUserAPI(ssoGroup)
 		// auth route
@@ -118,6 +129,10 @@ func RegisteredRouter(e *gin.Engine) {
 		usersRouter := &user.User{}
 		usersRouter.RegisterUserAPI(userAPI)
 
+		changeRouter := &changes.Change{}
+		changeRouter.RegisterChangeAPI(changeAPI)
+		changes.SetHandler(e)
+
 		tokenRouter := &Token.Token{}
 		tokenRouter.RegisterTokenAPI(tokenAPI)
 
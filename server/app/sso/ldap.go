@@ -0,0 +1,16 @@
+package sso
+
+import (
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/ldap"
+	userModel "github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// ldapLogin 在目录服务中认证用户，并同步用户与角色
+func ldapLogin(username, password string) (*userModel.User, error) {
+	entry, err := ldap.Authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+	return userModel.SyncExternalUser(userModel.UserSourceLDAP, entry.Username, entry.Username, ldap.MapRoles(entry.Groups), config.CONFIG.LDAP.AutoProvision)
+}
@@ -0,0 +1,43 @@
+package sso
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+// unreachableLDAP 没有目录服务监听的地址，模拟目录服务不可用
+const unreachableLDAP = `
+[ldap]
+enabled = true
+url = "ldap://127.0.0.1:1"
+timeout = 1
+base_dn = "ou=people,dc=example,dc=com"
+user_filter = "(uid=%s)"
+`
+
+func TestLDAPFallsBackToLocalUsers(t *testing.T) {
+	e := setupTest(t, unreachableLDAP)
+
+	w, resp := serve(t, e, http.MethodPost, "/sso/signin", `{"username":"admin","password":"admin"}`)
+	if resp.Code != query.OK.Code || resp.Data == nil || resp.Data.Token == "" {
+		t.Fatalf("local fallback: %s", w.Body.String())
+	}
+	w, resp = serve(t, e, http.MethodPost, "/sso/signin", `{"username":"admin","password":"wrong"}`)
+	if resp.Code != query.ErrPasswordIncorrect.Code {
+		t.Fatalf("wrong password: expected %d, got %s", query.ErrPasswordIncorrect.Code, w.Body.String())
+	}
+}
+
+func TestLDAPWithoutLocalFallback(t *testing.T) {
+	e := setupTest(t, unreachableLDAP+`
+[oidc]
+disable_local_login = true
+`)
+
+	w, resp := serve(t, e, http.MethodPost, "/sso/signin", `{"username":"admin","password":"admin"}`)
+	if resp.Code != query.ErrPasswordIncorrect.Code {
+		t.Fatalf("expected %d when local login is disabled, got %s", query.ErrPasswordIncorrect.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,187 @@
+package sso
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	token2 "github.com/cylonchau/firewalld-gateway/utils/auther"
+	userModel "github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// currentUser 返回当前登录用户的 id 与会话 key
+func currentUser(c *gin.Context) (uint, string) {
+	uid := c.GetInt64(token2.UserIDKey)
+	return uint(uid), c.GetString(token2.SessionIDKey)
+}
+
+// bindMFACode 获取并校验请求体中的验证码
+func bindMFACode(c *gin.Context) (*query.MFACodeQuery, bool) {
+	codeQuery := &query.MFACodeQuery{}
+	if enconterError := c.ShouldBindJSON(&codeQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return nil, false
+	}
+	return codeQuery, true
+}
+
+// mfaStatusHandler godoc
+// @Summary Return second factor status of the current user.
+// @Description Return whether the current user enabled TOTP, whether any role of the user requires it, and the count of unused recovery codes.
+// @Tags MFA
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/mfa [get]
+func (s *SSO) mfaStatusHandler(c *gin.Context) {
+	uid, _ := currentUser(c)
+	status, enconterError := userModel.GetMFAStatus(uid)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, status)
+}
+
+// mfaEnrollHandler godoc
+// @Summary Start TOTP enrollment.
+// @Description Generate a new TOTP secret and otpauth uri for the current user. The second factor is enabled after it is confirmed with a code.
+// @Tags MFA
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/mfa/enroll [post]
+func (s *SSO) mfaEnrollHandler(c *gin.Context) {
+	uid, _ := currentUser(c)
+	user, enconterError := userModel.QueryUserWithUID(int64(uid))
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	enrollment, enconterError := userModel.EnrollMFA(uid, user.Username)
+	if enconterError == query.ErrMFAEnabled {
+		query.API409Response(c, enconterError)
+		return
+	} else if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, enrollment)
+}
+
+// mfaConfirmHandler godoc
+// @Summary Confirm TOTP enrollment.
+// @Description Enable the second factor with a code from the authenticator and return the recovery codes. Recovery codes are only returned once.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param   query  body  query.MFACodeQuery   true "TOTP code"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/mfa/confirm [post]
+func (s *SSO) mfaConfirmHandler(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	codeQuery, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+
+	uid, sessionKey := currentUser(c)
+	codes, enconterError := userModel.ConfirmMFA(uid, codeQuery.Code)
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if enconterError = userModel.MarkSessionMFA(sessionKey); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, map[string]interface{}{"recovery_codes": codes})
+}
+
+// mfaVerifyHandler godoc
+// @Summary Verify the second factor for the current session.
+// @Description Verify a TOTP code or a recovery code, sensitive operations listed in mfa.step_up_routes are allowed within mfa.step_up_ttl seconds after it.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param   query  body  query.MFACodeQuery   true "TOTP code or recovery code"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/mfa/verify [post]
+func (s *SSO) mfaVerifyHandler(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	codeQuery, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+
+	uid, sessionKey := currentUser(c)
+	if enconterError := userModel.VerifyMFA(uid, codeQuery.Code); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if enconterError := userModel.MarkSessionMFA(sessionKey); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// mfaRecoveryHandler godoc
+// @Summary Regenerate recovery codes.
+// @Description Verify a TOTP code or a recovery code, then replace all recovery codes of the current user.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param   query  body  query.MFACodeQuery   true "TOTP code or recovery code"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/mfa/recovery [post]
+func (s *SSO) mfaRecoveryHandler(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	codeQuery, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+
+	uid, _ := currentUser(c)
+	if enconterError := userModel.VerifyMFA(uid, codeQuery.Code); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	codes, enconterError := userModel.RegenerateRecoveryCodes(uid)
+	if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	query.SuccessResponse(c, nil, map[string]interface{}{"recovery_codes": codes})
+}
+
+// mfaDisableHandler godoc
+// @Summary Disable the second factor.
+// @Description Verify a TOTP code or a recovery code, then remove the TOTP secret and recovery codes of the current user.
+// @Tags MFA
+// @Accept json
+// @Produce json
+// @Param   query  body  query.MFACodeQuery   true "TOTP code or recovery code"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/mfa [delete]
+func (s *SSO) mfaDisableHandler(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	codeQuery, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+
+	uid, _ := currentUser(c)
+	if enconterError := userModel.VerifyMFA(uid, codeQuery.Code); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	if enconterError := userModel.DisableMFA(uid); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
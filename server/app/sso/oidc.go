@@ -0,0 +1,172 @@
+package sso
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	userModel "github.com/cylonchau/firewalld-gateway/utils/model"
+	"github.com/cylonchau/firewalld-gateway/utils/oidc"
+)
+
+// oidcStateTTL 从跳转到身份提供方到回调的最长时间
+const oidcStateTTL = 10 * time.Minute
+
+// mapRoles 将身份提供方的组按配置映射为网关的角色，没有配置映射时返回 nil，不修改用户的角色
+func mapRoles(groups []string) []string {
+	mappings := config.CONFIG.OIDC.RoleMappings
+	if len(mappings) == 0 {
+		return nil
+	}
+	member := make(map[string]bool)
+	for _, group := range groups {
+		member[group] = true
+	}
+	roles := []string{}
+	for _, mapping := range mappings {
+		if member[mapping.Group] {
+			roles = append(roles, mapping.Roles...)
+		}
+	}
+	return roles
+}
+
+// methodsHandler godoc
+// @Summary Return enabled login methods.
+// @Description Return which login methods are enabled, the frontend uses it to show the local login form or the single sign-on button.
+// @Tags SSO
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/methods [get]
+func (s *SSO) methodsHandler(c *gin.Context) {
+	query.SuccessResponse(c, nil, map[string]bool{
+		"local": !config.CONFIG.OIDC.DisableLocalLogin,
+		"oidc":  config.CONFIG.OIDC.Enabled,
+	})
+}
+
+// oidcLoginHandler godoc
+// @Summary Start OpenID Connect login.
+// @Description Redirect to the authorization endpoint of the OpenID Connect provider, authorization code flow with PKCE is used.
+// @Tags SSO
+// @Success 302
+// @Router /sso/oidc/login [get]
+func (s *SSO) oidcLoginHandler(c *gin.Context) {
+	if !config.CONFIG.OIDC.Enabled {
+		query.API404Response(c, query.ErrOIDCDisabled)
+		return
+	}
+	var values [3]string
+	for i := range values {
+		value, enconterError := oidc.RandomString()
+		if enconterError != nil {
+			query.API500Response(c, enconterError)
+			return
+		}
+		values[i] = value
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+	if enconterError := userModel.CreateOIDCState(state, verifier, nonce, oidcStateTTL); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	location, enconterError := oidc.GetProvider().AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+	if enconterError != nil {
+		klog.Errorf("oidc discovery failed: %v", enconterError)
+		query.API500Response(c, query.ErrOIDCLogin)
+		return
+	}
+	c.Redirect(http.StatusFound, location)
+}
+
+// oidcCallbackHandler godoc
+// @Summary OpenID Connect callback.
+// @Description Exchange the authorization code for id token, provision the user on first login and map groups to roles.
+// @Description The gateway token is appended to success_url as url fragment, or returned as json if success_url is empty.
+// @Tags SSO
+// @Produce json
+// @Param query query query.OIDCCallbackQuery true "query"
+// @Success 200 {object} map[string]interface{}
+// @Success 302
+// @Router /sso/oidc/callback [get]
+func (s *SSO) oidcCallbackHandler(c *gin.Context) {
+	if !config.CONFIG.OIDC.Enabled {
+		query.API404Response(c, query.ErrOIDCDisabled)
+		return
+	}
+	// 1. 获取参数和参数校验
+	callbackQuery := &query.OIDCCallbackQuery{}
+	if enconterError := c.ShouldBindQuery(callbackQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	state, enconterError := userModel.ConsumeOIDCState(callbackQuery.State)
+	if enconterError != nil || state.ExpiresAt.Before(time.Now()) {
+		query.API400Response(c, errors.New("invalid or expired state"))
+		return
+	}
+	if callbackQuery.Error != "" {
+		klog.Warningf("oidc provider returned error: %s %s", callbackQuery.Error, callbackQuery.ErrorDescription)
+		query.SuccessResponse(c, query.ErrOIDCLogin, nil)
+		return
+	}
+
+	claims, enconterError := oidc.GetProvider().Exchange(c.Request.Context(), callbackQuery.Code, state.Verifier, state.Nonce)
+	if enconterError != nil {
+		klog.Warningf("oidc code exchange failed: %v", enconterError)
+		query.SuccessResponse(c, query.ErrOIDCLogin, nil)
+		return
+	}
+	cfg := config.CONFIG.OIDC
+	subject := oidc.ClaimString(claims, "sub")
+	username := oidc.ClaimString(claims, cfg.UsernameClaim)
+	if subject == "" || username == "" {
+		klog.Warningf("oidc id token has no sub or %s claim", cfg.UsernameClaim)
+		query.SuccessResponse(c, query.ErrOIDCLogin, nil)
+		return
+	}
+	roles := mapRoles(oidc.ClaimStrings(claims, cfg.GroupsClaim))
+	user, enconterError := userModel.SyncExternalUser(userModel.UserSourceOIDC, subject, username, roles, cfg.AutoProvision)
+	if enconterError != nil {
+		query.SuccessResponse(c, enconterError, nil)
+		return
+	}
+	resp, enconterError := login(c, user)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	if cfg.SuccessURL == "" {
+		query.SuccessResponse(c, nil, resp)
+		return
+	}
+	// token 放在 fragment 中，不会出现在服务端日志与 Referer 里；success_url 已有 fragment 时(前端 hash 路由)追加到 fragment 中
+	separator := "#"
+	if i := strings.Index(cfg.SuccessURL, "#"); i >= 0 {
+		separator = "?"
+		if strings.Contains(cfg.SuccessURL[i:], "?") {
+			separator = "&"
+		}
+	}
+	values := url.Values{
+		"user_id":  {strconv.FormatUint(resp.UserID, 10)},
+		"username": {resp.Username},
+	}
+	// 启用了二次认证时只返回 mfa_token，由前端调用 /sso/signin/mfa 完成登录
+	if resp.MFARequired {
+		values.Set("mfa_token", resp.MFAToken)
+	} else {
+		values.Set("token", resp.Token)
+		values.Set("refresh_token", resp.RefreshToken)
+		values.Set("expires_in", strconv.Itoa(resp.ExpiresIn))
+	}
+	c.Redirect(http.StatusFound, cfg.SuccessURL+separator+values.Encode())
+}
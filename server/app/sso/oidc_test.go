@@ -0,0 +1,300 @@
+package sso
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	userModel "github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+const (
+	testClientID    = "firewalld-gateway"
+	testRedirectURL = "https://gateway.test/sso/oidc/callback"
+)
+
+// mockIdP 模拟 OpenID Connect 身份提供方，授权时记录 PKCE 的 code_challenge 与 nonce，
+// 换取 token 时校验 code_verifier 并签发 RS256 的 id_token
+type mockIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	sync.Mutex
+	codes map[string]authRequest
+	// subject、username、groups 为下一次签发的 id_token 中的用户
+	subject, username string
+	groups            []string
+	// nonce 不为空时代替授权请求中的 nonce，模拟被替换的 id_token
+	nonce string
+}
+
+type authRequest struct {
+	challenge, nonce string
+}
+
+// idp 所有测试共用的身份提供方，provider 只在第一次使用时获取 discovery
+var idp *mockIdP
+
+func TestMain(m *testing.M) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	idp = &mockIdP{key: key, codes: make(map[string]authRequest)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+	idp.Server = httptest.NewServer(mux)
+	code := m.Run()
+	idp.Close()
+	os.Exit(code)
+}
+
+func (p *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"jwks_uri":               p.URL + "/jwks",
+	})
+}
+
+func (p *mockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	if values.Get("response_type") != "code" || values.Get("client_id") != testClientID ||
+		values.Get("code_challenge_method") != "S256" || values.Get("code_challenge") == "" ||
+		values.Get("state") == "" || values.Get("nonce") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	p.Lock()
+	code := fmt.Sprintf("code-%d", len(p.codes))
+	p.codes[code] = authRequest{challenge: values.Get("code_challenge"), nonce: values.Get("nonce")}
+	p.Unlock()
+	http.Redirect(w, r, values.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {values.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (p *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	tokenError := func(description string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": description})
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError("invalid request")
+		return
+	}
+	p.Lock()
+	defer p.Unlock()
+	request, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	if !ok {
+		tokenError("unknown code")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != request.challenge {
+		tokenError("code_verifier does not match code_challenge")
+		return
+	}
+	nonce := request.nonce
+	if p.nonce != "" {
+		nonce = p.nonce
+	}
+	groups := make([]interface{}, 0, len(p.groups))
+	for _, group := range p.groups {
+		groups = append(groups, group)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                p.URL,
+		"aud":                testClientID,
+		"sub":                p.subject,
+		"preferred_username": p.username,
+		"groups":             groups,
+		"nonce":              nonce,
+		"iat":                time.Now().Unix(),
+		"exp":                time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(p.key)
+	if err != nil {
+		tokenError(err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+func (p *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "test",
+		"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}})
+}
+
+// setUser 设置下一次登录的用户
+func (p *mockIdP) setUser(subject, username string, groups []string, nonce string) {
+	p.Lock()
+	defer p.Unlock()
+	p.subject, p.username, p.groups, p.nonce = subject, username, groups, nonce
+}
+
+func setupOIDC(t *testing.T) *testEngine {
+	return &testEngine{t: t, Engine: setupTest(t, fmt.Sprintf(`
+[oidc]
+enabled = true
+issuer = %q
+client_id = %q
+redirect_url = %q
+scopes = ["openid", "groups"]
+username_claim = "preferred_username"
+groups_claim = "groups"
+auto_provision = true
+
+[[oidc.role_mappings]]
+group = "firewall-admins"
+roles = ["host_editer", "port_editer"]
+
+[[oidc.role_mappings]]
+group = "auditors"
+roles = ["host_viewer"]
+`, idp.URL, testClientID, testRedirectURL))}
+}
+
+// authorize 请求 /sso/oidc/login 并跟随跳转到身份提供方，返回身份提供方回调网关的参数
+func (e *testEngine) authorize() url.Values {
+	e.t.Helper()
+	w, _ := serve(e.t, e.Engine, http.MethodGet, "/sso/oidc/login", "")
+	if w.Code != http.StatusFound {
+		e.t.Fatalf("login: expected redirect, got %d %s", w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(location)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		e.t.Fatalf("authorize %s: %s", location, resp.Status)
+	}
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if callback.Scheme+"://"+callback.Host+callback.Path != testRedirectURL {
+		e.t.Fatalf("unexpected redirect_uri %s", callback)
+	}
+	return callback.Query()
+}
+
+func (e *testEngine) callback(values url.Values) (*httptest.ResponseRecorder, *testResponse) {
+	e.t.Helper()
+	return serve(e.t, e.Engine, http.MethodGet, "/sso/oidc/callback?"+values.Encode(), "")
+}
+
+func TestOIDCLoginMapsGroupsToRoles(t *testing.T) {
+	e := setupOIDC(t)
+	idp.setUser("sub-alice", "alice", []string{"firewall-admins", "unmapped"}, "")
+
+	w, resp := e.callback(e.authorize())
+	if w.Code != http.StatusOK || resp.Code != query.OK.Code {
+		t.Fatalf("callback: %d %s", w.Code, w.Body.String())
+	}
+	if resp.Data == nil || resp.Data.Username != "alice" || resp.Data.Token == "" || resp.Data.RefreshToken == "" {
+		t.Fatalf("callback returned no session: %s", w.Body.String())
+	}
+	roles := userRoles(t, userModel.UserSourceOIDC, "sub-alice")
+	sort.Strings(roles)
+	if expected := []string{"host_editer", "port_editer"}; !reflect.DeepEqual(roles, expected) {
+		t.Fatalf("roles: expected %v, got %v", expected, roles)
+	}
+
+	// 再次登录时按新的组替换角色
+	idp.setUser("sub-alice", "alice", []string{"auditors"}, "")
+	if w, resp = e.callback(e.authorize()); resp.Code != query.OK.Code {
+		t.Fatalf("second callback: %s", w.Body.String())
+	}
+	if roles = userRoles(t, userModel.UserSourceOIDC, "sub-alice"); !reflect.DeepEqual(roles, []string{"host_viewer"}) {
+		t.Fatalf("roles after second login: expected [host_viewer], got %v", roles)
+	}
+}
+
+func TestOIDCStateIsSingleUse(t *testing.T) {
+	e := setupOIDC(t)
+	idp.setUser("sub-bob", "bob", nil, "")
+
+	values := e.authorize()
+	if _, resp := e.callback(values); resp.Code != query.OK.Code {
+		t.Fatalf("callback failed: %+v", resp)
+	}
+	w, _ := e.callback(values)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("replayed state: expected 400, got %d %s", w.Code, w.Body.String())
+	}
+
+	values.Set("state", "unknown")
+	if w, _ = e.callback(values); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown state: expected 400, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestOIDCRejectsNonceMismatch(t *testing.T) {
+	e := setupOIDC(t)
+	idp.setUser("sub-carol", "carol", nil, "another-nonce")
+
+	w, resp := e.callback(e.authorize())
+	if resp.Code != query.ErrOIDCLogin.Code {
+		t.Fatalf("expected %d, got %s", query.ErrOIDCLogin.Code, w.Body.String())
+	}
+	var count int64
+	userModel.DB.Model(&userModel.User{}).Where("subject = ?", "sub-carol").Count(&count)
+	if count != 0 {
+		t.Fatal("user was provisioned from an id_token with a wrong nonce")
+	}
+}
+
+func TestOIDCRejectsWrongCodeVerifier(t *testing.T) {
+	e := setupOIDC(t)
+	idp.setUser("sub-dave", "dave", nil, "")
+
+	values := e.authorize()
+	// 网关保存的 code_verifier 与授权请求中的 code_challenge 不一致时身份提供方拒绝换取 token
+	if err := userModel.DB.Model(&userModel.OIDCState{}).Where("state = ?", values.Get("state")).Update("verifier", "tampered").Error; err != nil {
+		t.Fatal(err)
+	}
+	w, resp := e.callback(values)
+	if resp.Code != query.ErrOIDCLogin.Code {
+		t.Fatalf("expected %d, got %s", query.ErrOIDCLogin.Code, w.Body.String())
+	}
+}
+
+func TestMapRoles(t *testing.T) {
+	setupOIDC(t)
+	if roles := mapRoles([]string{"auditors", "firewall-admins"}); !reflect.DeepEqual(roles, []string{"host_editer", "port_editer", "host_viewer"}) {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+	if roles := mapRoles([]string{"unmapped"}); roles == nil || len(roles) != 0 {
+		t.Fatalf("unmapped groups should clear the roles, got %#v", roles)
+	}
+}
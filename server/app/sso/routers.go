@@ -1,10 +1,30 @@
 package sso
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/server/app/middlewares"
+)
 
 func (s *SSO) RegisterUserAPI(g *gin.RouterGroup) {
 	// user
 	authGroup := g.Group("/")
 	authGroup.POST("/signin", s.signinHandler)
+	authGroup.POST("/signin/mfa", s.mfaSigninHandler)
 	authGroup.POST("/signup", s.signupHandler)
+	authGroup.POST("/refresh", s.refreshHandler)
+	authGroup.POST("/logout", s.logoutHandler)
+	authGroup.GET("/methods", s.methodsHandler)
+	authGroup.GET("/oidc/login", s.oidcLoginHandler)
+	authGroup.GET("/oidc/callback", s.oidcCallbackHandler)
+
+	// 当前用户管理自己的二次认证，只需要登录，不检查角色权限
+	mfaGroup := g.Group("/mfa")
+	mfaGroup.Use(middlewares.AuthenticationMiddleware())
+	mfaGroup.GET("/", s.mfaStatusHandler)
+	mfaGroup.POST("/enroll", s.mfaEnrollHandler)
+	mfaGroup.POST("/confirm", s.mfaConfirmHandler)
+	mfaGroup.POST("/verify", s.mfaVerifyHandler)
+	mfaGroup.POST("/recovery", s.mfaRecoveryHandler)
+	mfaGroup.DELETE("/", s.mfaDisableHandler)
 }
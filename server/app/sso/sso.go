@@ -1,21 +1,294 @@
 package sso
 
 import (
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/praserx/ipconv"
+	"k8s.io/klog/v2"
 
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/server/app/middlewares"
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
 	token2 "github.com/cylonchau/firewalld-gateway/utils/auther"
+	"github.com/cylonchau/firewalld-gateway/utils/metrics"
 	userModel "github.com/cylonchau/firewalld-gateway/utils/model"
 )
 
 type SSO struct{}
 
+const (
+	auditLoginFailed = "login_failed"
+	auditLoginLocked = "login_locked"
+)
+
+// login 记录登录 ip，创建登录会话并签发 access token 与 refresh token
+func login(c *gin.Context, user *userModel.User) (*query.UserResp, error) {
+	resp := &query.UserResp{UserID: uint64(user.ID), Username: user.Username}
+	// 1 号用户为内置的管理员
+	if user.ID == 1 {
+		resp.IsPrivileged = true
+		resp.Roles = []string{}
+	}
+	ip, err := userModel.GetRequestIP(c.Request)
+	if err == nil {
+		userModel.LastLogin(int64(user.ID), ip)
+		resp.LoginIP = ipconv.IntToIPv4(ip).String()
+	}
+	enabled, required, err := userModel.MFAState(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	// 启用了二次认证的用户先返回 mfa challenge，验证通过后才创建会话
+	if enabled {
+		resp.MFARequired = true
+		challenge, err := userModel.CreateMFAChallenge(user.ID, token2.MFAChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		resp.MFAToken, err = token2.GenMFAChallenge(int64(user.ID), challenge)
+		return resp, err
+	}
+	resp.MFAEnrollmentRequired = required
+	return resp, startSession(c, resp, ip, false)
+}
+
+// startSession 创建登录会话并签发 access token 与 refresh token
+func startSession(c *gin.Context, resp *query.UserResp, ip uint32, mfa bool) error {
+	sessionKey, refresh, err := userModel.CreateSession(uint(resp.UserID), ip, c.Request.UserAgent(), mfa)
+	if err != nil {
+		return err
+	}
+	token, err := token2.GenToken(int64(resp.UserID), sessionKey)
+	if err != nil {
+		return err
+	}
+	resp.Token = token
+	resp.RefreshToken = refresh
+	resp.ExpiresIn = int(token2.AccessTTL().Seconds())
+	return nil
+}
+
+// mfaSigninHandler godoc
+// @Summary Complete login with a second factor.
+// @Description Exchange the mfa_token returned by signin and a TOTP code or a recovery code for an access token and a refresh token.
+// @Tags SSO
+// @Accept  json
+// @Produce json
+// @Param   query  body  query.MFASigninQuery   true "mfa token and code"
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/signin/mfa [post]
+func (s *SSO) mfaSigninHandler(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	mfaQuery := &query.MFASigninQuery{}
+	enconterError = c.ShouldBindJSON(&mfaQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	uid, challenge, enconterError := token2.ParseMFAChallenge(mfaQuery.MFAToken)
+	if enconterError != nil {
+		query.Auth403Failed(c, query.ErrMFAChallenge, enconterError.Error())
+		return
+	}
+	user, enconterError := userModel.QueryUserWithUID(uid)
+	if enconterError != nil || user.ID == 0 {
+		query.Auth403Failed(c, query.ErrMFAChallenge, nil)
+		return
+	}
+	ip, err := userModel.GetRequestIP(c.Request)
+	if wait, err := userModel.CheckLockout(user.Username, ip); err != nil {
+		query.API500Response(c, err)
+		return
+	} else if wait > 0 {
+		loginLocked(c, user.Username, wait)
+		return
+	}
+	if enconterError = userModel.VerifyMFA(user.ID, mfaQuery.Code); enconterError == query.ErrMFACode {
+		loginFailed(c, user.Username, ip, "mfa", enconterError)
+		return
+	} else if enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	// 验证通过后作废 mfa_token，同一个 mfa_token 不能再兑换会话
+	if enconterError = userModel.ConsumeMFAChallenge(challenge, user.ID); enconterError == query.ErrMFAChallenge {
+		query.Auth403Failed(c, query.ErrMFAChallenge, nil)
+		return
+	} else if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+
+	resp := &query.UserResp{UserID: uint64(user.ID), Username: user.Username}
+	if user.ID == 1 {
+		resp.IsPrivileged = true
+		resp.Roles = []string{}
+	}
+	if err == nil {
+		resp.LoginIP = ipconv.IntToIPv4(ip).String()
+	}
+	if enconterError = startSession(c, resp, ip, true); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	userModel.ClearLoginFailures(user.Username)
+	query.SuccessResponse(c, nil, resp)
+}
+
+// refreshHandler godoc
+// @Summary Refresh access token.
+// @Description Exchange a refresh token for a new access token and a new refresh token. Every refresh token can only be used once, using a rotated refresh token again revokes the whole session.
+// @Tags SSO
+// @Accept  json
+// @Produce json
+// @Param   query  body  query.RefreshQuery   true "refresh token"
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/refresh [post]
+func (s *SSO) refreshHandler(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	refreshQuery := &query.RefreshQuery{}
+	enconterError = c.ShouldBindJSON(&refreshQuery)
+
+	// 手动对请求参数进行详细的业务规则校验
+	if enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	ip, _ := userModel.GetRequestIP(c.Request)
+	uid, sessionKey, refresh, enconterError := userModel.RefreshSession(refreshQuery.RefreshToken, ip, c.Request.UserAgent())
+	if errno, ok := enconterError.(*query.Errno); ok {
+		query.Auth403Failed(c, errno, nil)
+		return
+	} else if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	token, enconterError := token2.GenToken(int64(uid), sessionKey)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, &query.UserResp{
+		UserID:       uint64(uid),
+		Token:        token,
+		RefreshToken: refresh,
+		ExpiresIn:    int(token2.AccessTTL().Seconds()),
+	})
+}
+
+// logoutHandler godoc
+// @Summary logout.
+// @Description Revoke the login session of the bearer access token, or of the refresh token in body when the access token is expired.
+// @Tags SSO
+// @Accept  json
+// @Produce json
+// @Param   query  body  query.LogoutQuery   false "refresh token"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /sso/logout [post]
+func (s *SSO) logoutHandler(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	logoutQuery := &query.LogoutQuery{}
+	if c.Request.ContentLength > 0 {
+		if enconterError = c.ShouldBindJSON(&logoutQuery); enconterError != nil {
+			query.API400Response(c, enconterError)
+			return
+		}
+	}
+
+	if parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2); len(parts) == 2 && parts[0] == "Bearer" {
+		if mc, err := token2.ParseToken(parts[1]); err == nil && mc.SessionID != "" {
+			if enconterError = userModel.RevokeSession(mc.SessionID, userModel.SessionRevokedLogout); enconterError != nil {
+				query.API500Response(c, enconterError)
+				return
+			}
+			query.SuccessResponse(c, query.OK, nil)
+			return
+		}
+	}
+	if logoutQuery.RefreshToken == "" {
+		query.AuthFailed(c, query.ErrNeedAuth, nil)
+		return
+	}
+	if enconterError = userModel.RevokeSessionWithRefresh(logoutQuery.RefreshToken); enconterError != nil {
+		query.APIResponse(c, enconterError, nil)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
+
+// authenticate 依次使用目录服务与本地用户认证，认证失败时统一返回 ErrPasswordIncorrect，不区分用户是否存在
+func authenticate(username, password string) (*userModel.User, error) {
+	if config.CONFIG.LDAP.Enabled {
+		user, err := ldapLogin(username, password)
+		if err == nil {
+			return user, nil
+		}
+		// 目录服务认证失败或不可用时继续尝试本地用户，本地用户作为应急账号
+		klog.V(2).Infof("ldap authentication of %s failed: %v", username, err)
+		if config.CONFIG.OIDC.DisableLocalLogin {
+			return nil, query.ErrPasswordIncorrect
+		}
+	}
+
+	user, err := userModel.QueryUserWithUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(user, userModel.User{}) {
+		userModel.VerifyDummyPassword(password)
+		return nil, query.ErrPasswordIncorrect
+	}
+	ok, rehash := userModel.VerifyPassword(user.Password, password)
+	if !user.IsLocal() || user.Username != username || !ok {
+		return nil, query.ErrPasswordIncorrect
+	}
+	if rehash {
+		if err := userModel.RehashPassword(user.ID, password); err != nil {
+			klog.Warningf("rehash password of user %s failed: %v", user.Username, err)
+		}
+	}
+	return &user, nil
+}
+
+// loginLocked 用户名或来源 ip 被锁定时返回剩余的锁定时间
+func loginLocked(c *gin.Context, username string, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	middlewares.WriteEventLog(c.Request, 0, auditLoginLocked, "username="+username)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	query.SuccessResponse(c, query.ErrLoginLocked, map[string]interface{}{"retry_after": seconds})
+}
+
+// loginFailed 记录登录失败，失败次数达到上限后锁定用户名或来源 ip
+func loginFailed(c *gin.Context, username string, ip uint32, reason string, err error) {
+	middlewares.WriteEventLog(c.Request, 0, auditLoginFailed, "username="+username+" reason="+reason)
+	metrics.LoginFailures.WithLabelValues(reason).Inc()
+	wait, recordError := userModel.RecordLoginFailure(username, ip)
+	if recordError != nil {
+		klog.Errorf("record login failure of %s failed: %v", username, recordError)
+	}
+	if wait > 0 {
+		loginLocked(c, username, wait)
+		return
+	}
+	query.SuccessResponse(c, err, nil)
+}
+
 // signinHandler godoc
 // @Summary login.
-// @Description login.
+// @Description login. Unknown users and wrong passwords return the same error, too many failures lock the username or the source ip for an exponentially growing time.
 // @Tags SSO
 // @Accept  json
 // @Produce json
@@ -34,54 +307,38 @@ func (s *SSO) signinHandler(c *gin.Context) {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
+	if config.CONFIG.OIDC.DisableLocalLogin && !config.CONFIG.LDAP.Enabled {
+		query.SuccessResponse(c, query.ErrLocalLoginDisabled, nil)
+		return
+	}
 
-	var (
-		user         userModel.User
-		token        string
-		isPrivileged bool
-		roles        []string
-	)
-	if user, enconterError = userModel.QueryUserWithUsername(userQuery.Username); enconterError == nil {
-		if reflect.DeepEqual(user, userModel.User{}) {
-			query.SuccessResponse(c, query.ErrUserNotExist, nil)
-			return
-		}
-
-		if user.Username == userQuery.Username && user.Password == userModel.EncryptPassword(userQuery.Password) {
-			var ip uint32
-			switch user.ID {
-			case 1:
-				isPrivileged = true
-				roles = []string{}
-			default:
-			}
-
-			if ip, enconterError = userModel.GetRequestIP(c.Request); enconterError == nil {
-				userModel.LastLogin(int64(user.ID), ip)
-				if token, enconterError = token2.GenToken(int64(user.ID)); enconterError == nil {
-					query.SuccessResponse(c, nil, query.UserResp{
-						UserID:       uint64(user.ID),
-						Token:        token,
-						LoginIP:      ipconv.IntToIPv4(ip).String(),
-						Roles:        roles,
-						IsPrivileged: isPrivileged,
-					})
-					return
-				}
-			}
+	ip, _ := userModel.GetRequestIP(c.Request)
+	if wait, err := userModel.CheckLockout(userQuery.Username, ip); err != nil {
+		query.API500Response(c, err)
+		return
+	} else if wait > 0 {
+		loginLocked(c, userQuery.Username, wait)
+		return
+	}
 
-			if token, enconterError = token2.GenToken(int64(user.ID)); enconterError == nil {
-				query.SuccessResponse(c, nil, query.UserResp{
-					UserID:       uint64(user.ID),
-					Token:        token,
-					Roles:        roles,
-					IsPrivileged: isPrivileged,
-				})
-				return
-			}
-		}
+	user, enconterError := authenticate(userQuery.Username, userQuery.Password)
+	if enconterError == query.ErrPasswordIncorrect {
+		loginFailed(c, userQuery.Username, ip, "password", enconterError)
+		return
+	} else if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	resp, enconterError := login(c, user)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	// 需要二次认证时在验证码通过后才清除失败计数
+	if !resp.MFARequired {
+		userModel.ClearLoginFailures(userQuery.Username)
 	}
-	query.SuccessResponse(c, query.ErrPasswordIncorrect, nil)
+	query.SuccessResponse(c, nil, resp)
 }
 
 // signupHandler godoc
@@ -104,6 +361,10 @@ func (s *SSO) signupHandler(c *gin.Context) {
 		query.APIResponse(c, enconterError, nil)
 		return
 	}
+	if config.CONFIG.OIDC.DisableLocalLogin {
+		query.SuccessResponse(c, query.ErrLocalLoginDisabled, nil)
+		return
+	}
 
 	if enconterError = userModel.CreateUser(userQuery); enconterError != nil {
 		query.API409Response(c, enconterError)
@@ -0,0 +1,116 @@
+package sso
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+	"github.com/cylonchau/firewalld-gateway/utils/migration"
+	userModel "github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// testResponse 接口返回的 json
+type testResponse struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data *query.UserResp `json:"data"`
+}
+
+// testEngine 测试使用的 engine，辅助函数失败时结束 t
+type testEngine struct {
+	*gin.Engine
+	t *testing.T
+}
+
+// setupTest 使用临时目录中的 sqlite 数据库初始化配置、数据库与签名密钥，extra 为追加的 toml 配置，
+// 返回注册了 /sso 接口的 engine
+func setupTest(t *testing.T, extra string) *gin.Engine {
+	t.Helper()
+	dir := t.TempDir()
+	content := fmt.Sprintf(`appname = "test"
+database_driver = "sqlite"
+
+[sqlite]
+file = %q
+max_open_connection = 1
+
+[jwt]
+algorithm = "HS256"
+secret = "jwt-test-secret"
+
+[audit.checkpoint_key]
+algorithm = "HS256"
+secret = "checkpoint-test-secret"
+`, filepath.Join(dir, "uranus")) + extra
+	file := filepath.Join(dir, "firewalld-gateway.toml")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitConfiguration(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := userModel.InitDB("sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if conn, err := userModel.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	// 测试不需要路由权限
+	migration.RegisterRouter = func(*gin.Engine) {}
+	if err := migration.Up(userModel.DB, 0, false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if err := auther.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	(&SSO{}).RegisterUserAPI(e.Group("/sso"))
+	return e
+}
+
+// serve 向 engine 发送请求并解析返回的 json
+func serve(t *testing.T, e *gin.Engine, method, target, body string) (*httptest.ResponseRecorder, *testResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	resp := &testResponse{}
+	if w.Code != http.StatusFound {
+		if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+			t.Fatalf("%s %s: decode %q: %v", method, target, w.Body.String(), err)
+		}
+	}
+	return w, resp
+}
+
+// userRoles 返回外部身份源用户的角色名
+func userRoles(t *testing.T, source, subject string) []string {
+	t.Helper()
+	user := &userModel.User{}
+	if err := userModel.DB.Preload("Roles").Where("source = ? AND subject = ?", source, subject).First(user).Error; err != nil {
+		t.Fatalf("query %s user %s: %v", source, subject, err)
+	}
+	roles := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		roles = append(roles, role.Name)
+	}
+	return roles
+}
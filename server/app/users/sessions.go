@@ -0,0 +1,100 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// getUserSessions godoc
+// @Summary Return login sessions of a user.
+// @Description Return the active login sessions of a user, all=true also returns revoked and expired sessions.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param all query bool false "include revoked and expired sessions"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/users/{id}/sessions [get]
+func (u *User) getUserSessions(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	sessionQuery := &query.SessionQuery{}
+	listQuery := &query.SessionListQuery{}
+	if enconterError = c.ShouldBindUri(sessionQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+	if enconterError = c.ShouldBindQuery(listQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	sessions, enconterError := model.GetUserSessions(sessionQuery.ID, listQuery.All)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, nil, map[string]interface{}{"list": sessions})
+}
+
+// revokeUserSessions godoc
+// @Summary Revoke login sessions of a user.
+// @Description Revoke one login session of a user, or all of the user's sessions when session is omitted. Access tokens of revoked sessions are rejected immediately.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param session path int false "Session ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/users/{id}/sessions [delete]
+// @Router /security/users/{id}/sessions/{session} [delete]
+func (u *User) revokeUserSessions(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	sessionQuery := &query.SessionQuery{}
+	if enconterError = c.ShouldBindUri(sessionQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	count, enconterError := model.RevokeUserSessions(sessionQuery.ID, sessionQuery.Session, model.SessionRevokedAdmin)
+	if enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	if sessionQuery.Session > 0 && count == 0 {
+		query.API404Response(c, query.ErrSessionNotFound)
+		return
+	}
+	query.SuccessResponse(c, nil, map[string]interface{}{"revoked": count})
+}
+
+// resetUserMFA godoc
+// @Summary Reset the second factor of a user.
+// @Description Remove the TOTP secret and recovery codes of a user who lost the authenticator, the user can enroll again after next login.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Router /security/users/{id}/mfa [delete]
+func (u *User) resetUserMFA(c *gin.Context) {
+	// 1. 获取参数和参数校验
+	var enconterError error
+	resetQuery := &query.MFAResetQuery{}
+	if enconterError = c.ShouldBindUri(resetQuery); enconterError != nil {
+		query.API400Response(c, enconterError)
+		return
+	}
+
+	if enconterError = model.DisableMFA(resetQuery.ID); enconterError != nil {
+		query.API500Response(c, enconterError)
+		return
+	}
+	query.SuccessResponse(c, query.OK, nil)
+}
@@ -20,6 +20,10 @@ func (u *User) RegisterUserAPI(g *gin.RouterGroup) {
 	userGroup.GET("/", u.getUsers)
 	userGroup.PUT("/", u.createUser)
 	userGroup.POST("/allocate", u.allocateRolesToUser)
+	userGroup.GET("/:id/sessions", u.getUserSessions)
+	userGroup.DELETE("/:id/sessions", u.revokeUserSessions)
+	userGroup.DELETE("/:id/sessions/:session", u.revokeUserSessions)
+	userGroup.DELETE("/:id/mfa", u.resetUserMFA)
 
 }
 
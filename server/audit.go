@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/server/auditor"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+	model2 "github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// newAuditCommand 校验与签名审计日志链的命令，退出码非 0 表示链已断开
+func newAuditCommand() *cobra.Command {
+	var configFile string
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Verify, sign and prune the tamper-evident audit log chain.",
+	}
+	cmd.PersistentFlags().StringVar(&configFile, "config", "./firewalld-gateway.toml", "The path to the configuration file.")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Walk the audit log chain and report the first broken link.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initAuditCommand(configFile); err != nil {
+				return err
+			}
+			verification, err := model2.VerifyAuditChain()
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "checked:     %d\n", verification.Checked)
+			fmt.Fprintf(out, "legacy:      %d\n", verification.Legacy)
+			fmt.Fprintf(out, "archived:    %d\n", verification.Archived)
+			fmt.Fprintf(out, "checkpoints: %d\n", verification.Checkpoints)
+			fmt.Fprintf(out, "last:        %d %s\n", verification.LastID, verification.LastHash)
+			if !verification.Valid {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("audit chain is broken at audit log %d: %s", verification.BrokenID, verification.Reason)
+			}
+			fmt.Fprintln(out, "audit chain is intact")
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "checkpoint",
+		Short: "Sign the end of the audit log chain.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initAuditCommand(configFile); err != nil {
+				return err
+			}
+			checkpoint, err := model2.CreateAuditCheckpoint()
+			if err != nil {
+				return err
+			}
+			if checkpoint == nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "no chained audit log to sign")
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "checkpoint %d signed audit log %d with key %s\n", checkpoint.ID, checkpoint.AuditID, checkpoint.KeyID)
+			return nil
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "prune",
+		Short: "Archive and delete audit logs expired by the retention policy.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := initAuditCommand(configFile); err != nil {
+				return err
+			}
+			if err := auditor.Prune(); err != nil {
+				return err
+			}
+			status := auditor.GetPruneStatus()
+			fmt.Fprintf(cmd.OutOrStdout(), "%d audit logs pruned in %s\n", status.LastPruned, status.LastDuration)
+			return nil
+		},
+	})
+	return cmd
+}
+
+func initAuditCommand(configFile string) error {
+	if err := config.InitConfiguration(configFile); err != nil {
+		return err
+	}
+	if config.CONFIG.MySQL.IsEmpty() && config.CONFIG.SQLite.IsEmpty() && config.CONFIG.PostgreSQL.IsEmpty() {
+		return errors.New("database is not configured")
+	}
+	if err := model2.InitDB(config.CONFIG.DatabaseDriver); err != nil {
+		return err
+	}
+	return auther.LoadKeys()
+}
@@ -0,0 +1,36 @@
+package auditor
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// Checkpointer 周期性签名审计日志链的末端
+type Checkpointer struct {
+	interval time.Duration
+}
+
+func NewCheckpointer(interval time.Duration) *Checkpointer {
+	return &Checkpointer{interval: interval}
+}
+
+func (c *Checkpointer) Run(stopCh <-chan struct{}) {
+	klog.V(2).Infof("Audit checkpointer started, interval %v", c.interval)
+	wait.Until(c.checkpoint, c.interval, stopCh)
+	klog.V(2).Infof("Audit checkpointer exit.")
+}
+
+func (c *Checkpointer) checkpoint() {
+	checkpoint, err := model.CreateAuditCheckpoint()
+	if err != nil {
+		klog.Errorf("Create audit checkpoint failed: %v", err)
+		return
+	}
+	if checkpoint != nil {
+		klog.V(4).Infof("Audit chain is signed at audit log %d", checkpoint.AuditID)
+	}
+}
@@ -0,0 +1,75 @@
+package auditor
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// httpSink 将审计日志以换行分隔批量 POST 到 http 收集器，非 2xx 响应按失败重试整批日志
+type httpSink struct {
+	url     string
+	format  string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newHTTPSink(url, format string, headers map[string]string, tlsConfig *tls.Config, timeout time.Duration) *httpSink {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &httpSink{
+		url:     url,
+		format:  format,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout, Transport: transport},
+	}
+}
+
+func (s *httpSink) send(events []*model.Audit) (int, error) {
+	var body bytes.Buffer
+	for _, event := range events {
+		if s.format == "cef" {
+			body.Write(formatCEF(event))
+		} else {
+			line, err := formatJSON(event)
+			if err != nil {
+				return 0, err
+			}
+			body.Write(line)
+		}
+		body.WriteByte('\n')
+	}
+	r, err := http.NewRequest(http.MethodPost, s.url, &body)
+	if err != nil {
+		return 0, err
+	}
+	if s.format == "cef" {
+		r.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		r.Header.Set("Content-Type", "application/x-ndjson")
+	}
+	for key, value := range s.headers {
+		r.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(r)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return 0, fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return len(events), nil
+}
+
+func (s *httpSink) close() {
+	s.client.CloseIdleConnections()
+}
@@ -0,0 +1,152 @@
+package auditor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/praserx/ipconv"
+
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+const (
+	appName        = "firewalld-gateway"
+	productVendor  = "cylonchau"
+	productVersion = "0.0.9"
+)
+
+// auditEvent 转发的审计日志
+type auditEvent struct {
+	ID       uint      `json:"id"`
+	Time     time.Time `json:"time"`
+	UserID   uint64    `json:"user_id"`
+	TokenID  uint      `json:"token_id"`
+	IP       string    `json:"ip"`
+	Method   string    `json:"method"`
+	Path     string    `json:"path"`
+	Browser  string    `json:"browser"`
+	System   string    `json:"system"`
+	Event    string    `json:"event,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	Host     string    `json:"host,omitempty"`
+	Zone     string    `json:"zone,omitempty"`
+	Body     string    `json:"body,omitempty"`
+	Status   int       `json:"status"`
+	Code     int       `json:"code"`
+	Latency  int64     `json:"latency"`
+	Before   string    `json:"before,omitempty"`
+	After    string    `json:"after,omitempty"`
+	PrevHash string    `json:"prev_hash"`
+	Hash     string    `json:"hash"`
+	Outcome  string    `json:"outcome"`
+}
+
+// failed 请求失败或者是登录失败等事件
+func failed(audit *model.Audit) bool {
+	return audit.Status >= 400 ||
+		(audit.Code != 0 && audit.Code != query.OK.Code && audit.Code != query.BatchSuccessCreated.Code) ||
+		strings.HasSuffix(audit.Event, "_failed") || strings.HasSuffix(audit.Event, "_locked")
+}
+
+func outcome(audit *model.Audit) string {
+	if failed(audit) {
+		return "failure"
+	}
+	return "success"
+}
+
+// summary 审计日志的简短说明
+func summary(audit *model.Audit) string {
+	if audit.Event != "" {
+		if audit.Detail != "" {
+			return audit.Event + ": " + audit.Detail
+		}
+		return audit.Event
+	}
+	return fmt.Sprintf("%s %s %d", audit.Method, audit.Path, audit.Status)
+}
+
+func formatJSON(audit *model.Audit) ([]byte, error) {
+	return json.Marshal(auditEvent{
+		ID:       audit.ID,
+		Time:     audit.CreatedAt,
+		UserID:   audit.UserID,
+		TokenID:  audit.TokenID,
+		IP:       ipconv.IntToIPv4(audit.IP).String(),
+		Method:   audit.Method,
+		Path:     audit.Path,
+		Browser:  audit.Browser,
+		System:   audit.System,
+		Event:    audit.Event,
+		Detail:   audit.Detail,
+		Host:     audit.Host,
+		Zone:     audit.Zone,
+		Body:     audit.Body,
+		Status:   audit.Status,
+		Code:     audit.Code,
+		Latency:  audit.Latency,
+		Before:   audit.SnapshotBefore,
+		After:    audit.SnapshotAfter,
+		PrevHash: audit.PrevHash,
+		Hash:     audit.Hash,
+		Outcome:  outcome(audit),
+	})
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+// formatCEF 返回 ArcSight Common Event Format 格式的日志
+func formatCEF(audit *model.Audit) []byte {
+	signature, name, severity := audit.Method, audit.Method+" "+audit.Path, 3
+	if audit.Event != "" {
+		signature, name = audit.Event, summary(audit)
+	}
+	if failed(audit) {
+		severity = 7
+	}
+	// 自定义字段的 label 只在字段有值时输出
+	extensions := [][3]string{
+		{"rt", "", strconv.FormatInt(audit.CreatedAt.UnixMilli(), 10)},
+		{"externalId", "", strconv.FormatUint(uint64(audit.ID), 10)},
+		{"src", "", ipconv.IntToIPv4(audit.IP).String()},
+		{"suid", "", strconv.FormatUint(audit.UserID, 10)},
+		{"requestMethod", "", audit.Method},
+		{"request", "", audit.Path},
+		{"requestClientApplication", "", audit.Browser},
+		{"dhost", "", audit.Host},
+		{"outcome", "", outcome(audit)},
+		{"msg", "", audit.Detail},
+		{"cs1", "zone", audit.Zone},
+		{"cs2", "hash", audit.Hash},
+		{"cs3", "body", audit.Body},
+		{"cn1", "status", strconv.Itoa(audit.Status)},
+		{"cn2", "code", strconv.Itoa(audit.Code)},
+		{"cn3", "latency", strconv.FormatInt(audit.Latency, 10)},
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		productVendor, appName, productVersion, cefHeaderEscaper.Replace(signature), cefHeaderEscaper.Replace(name), severity)
+	first := true
+	for _, extension := range extensions {
+		if extension[2] == "" {
+			continue
+		}
+		if !first {
+			builder.WriteByte(' ')
+		}
+		first = false
+		if extension[1] != "" {
+			builder.WriteString(extension[0] + "Label=" + extension[1] + " ")
+		}
+		builder.WriteString(extension[0] + "=" + cefExtensionEscaper.Replace(extension[2]))
+	}
+	return []byte(builder.String())
+}
@@ -0,0 +1,178 @@
+package auditor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+const (
+	defaultForwardBuffer  = 10000
+	defaultForwardRetry   = 5
+	defaultForwardTimeout = 10
+	// forwardBatch 每次发送的最大日志数
+	forwardBatch = 100
+)
+
+// sink 审计日志的发送目标，返回已经发送成功的日志数，发送失败的日志会重试
+type sink interface {
+	send(events []*model.Audit) (int, error)
+	close()
+}
+
+// Forwarder 缓存并发送审计日志，发送失败时保留日志并定期重试，缓存满时丢弃新的日志
+type Forwarder struct {
+	name    string
+	sink    sink
+	queue   chan *model.Audit
+	retry   time.Duration
+	dropped int64
+}
+
+// StartForwarders 按配置启动全部审计日志转发，并在每条审计日志保存后放入转发的缓存
+func StartForwarders(stopCh <-chan struct{}) error {
+	forwarders := make([]*Forwarder, 0, len(config.CONFIG.Audit.Forwarders))
+	for i := range config.CONFIG.Audit.Forwarders {
+		forwarder, err := newForwarder(i)
+		if err != nil {
+			return err
+		}
+		forwarders = append(forwarders, forwarder)
+	}
+	for _, forwarder := range forwarders {
+		go forwarder.Run(stopCh)
+	}
+	model.OnAuditLog(func(audit *model.Audit) {
+		for _, forwarder := range forwarders {
+			forwarder.enqueue(audit)
+		}
+	})
+	return nil
+}
+
+func newForwarder(index int) (*Forwarder, error) {
+	cfg := config.CONFIG.Audit.Forwarders[index]
+	if cfg.Name == "" {
+		cfg.Name = fmt.Sprintf("%s-%d", cfg.Type, index)
+	}
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = defaultForwardBuffer
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaultForwardRetry
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultForwardTimeout
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+
+	var tlsConfig *tls.Config
+	if cfg.Network == "tls" || cfg.CAFile != "" || cfg.InsecureSkipVerify {
+		tlsConfig = &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+		if cfg.CAFile != "" {
+			content, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("audit forwarder %s: %w", cfg.Name, err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(content) {
+				return nil, fmt.Errorf("audit forwarder %s: no certificate in %s", cfg.Name, cfg.CAFile)
+			}
+		}
+	}
+
+	forwarder := &Forwarder{
+		name:  cfg.Name,
+		queue: make(chan *model.Audit, cfg.Buffer),
+		retry: time.Duration(cfg.RetryInterval) * time.Second,
+	}
+	switch cfg.Type {
+	case "syslog":
+		switch cfg.Network {
+		case "udp", "tcp", "tls":
+		default:
+			return nil, fmt.Errorf("audit forwarder %s: unsupported syslog network %q", cfg.Name, cfg.Network)
+		}
+		if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+			return nil, fmt.Errorf("audit forwarder %s: %w", cfg.Name, err)
+		}
+		if cfg.Facility <= 0 || cfg.Facility > 23 {
+			cfg.Facility = syslogFacilityAudit
+		}
+		forwarder.sink = newSyslogSink(cfg.Network, cfg.Address, cfg.Facility, tlsConfig, timeout)
+	case "http":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("audit forwarder %s: url is required", cfg.Name)
+		}
+		switch cfg.Format {
+		case "":
+			cfg.Format = "json"
+		case "json", "cef":
+		default:
+			return nil, fmt.Errorf("audit forwarder %s: unsupported format %q", cfg.Name, cfg.Format)
+		}
+		forwarder.sink = newHTTPSink(cfg.URL, cfg.Format, cfg.Headers, tlsConfig, timeout)
+	default:
+		return nil, errors.New("audit forwarder " + cfg.Name + ": type must be syslog or http")
+	}
+	return forwarder, nil
+}
+
+// enqueue 放入缓存，缓存满时丢弃并计数，不阻塞写入审计日志的请求
+func (f *Forwarder) enqueue(audit *model.Audit) {
+	select {
+	case f.queue <- audit:
+	default:
+		if dropped := atomic.AddInt64(&f.dropped, 1); dropped == 1 || dropped%1000 == 0 {
+			klog.Warningf("Audit forwarder %s buffer is full, %d audit logs dropped", f.name, dropped)
+		}
+	}
+}
+
+func (f *Forwarder) Run(stopCh <-chan struct{}) {
+	klog.V(2).Infof("Audit forwarder %s started", f.name)
+	defer f.sink.close()
+	pending := make([]*model.Audit, 0, forwardBatch)
+	for {
+		if len(pending) == 0 {
+			select {
+			case <-stopCh:
+				klog.V(2).Infof("Audit forwarder %s exit.", f.name)
+				return
+			case audit := <-f.queue:
+				pending = append(pending, audit)
+			}
+		}
+		// 合并已经在缓存中的日志
+		for drained := false; !drained && len(pending) < forwardBatch; {
+			select {
+			case audit := <-f.queue:
+				pending = append(pending, audit)
+			default:
+				drained = true
+			}
+		}
+
+		sent, err := f.sink.send(pending)
+		pending = append(pending[:0], pending[sent:]...)
+		if err != nil {
+			klog.Warningf("Audit forwarder %s send failed, retry in %v: %v", f.name, f.retry, err)
+			select {
+			case <-stopCh:
+				klog.V(2).Infof("Audit forwarder %s exit with %d audit logs unsent.", f.name, len(pending)+len(f.queue))
+				return
+			case <-time.After(f.retry):
+			}
+		}
+	}
+}
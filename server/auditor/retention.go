@@ -0,0 +1,199 @@
+package auditor
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// PruneStatus 清理任务的运行状态
+type PruneStatus struct {
+	Running       bool      `json:"running"`
+	LastRunAt     time.Time `json:"last_run_at"`
+	LastSuccessAt time.Time `json:"last_success_at"`
+	LastError     string    `json:"last_error"`
+	LastDuration  string    `json:"last_duration"`
+	LastPruned    int       `json:"last_pruned"`
+	TotalPruned   int       `json:"total_pruned"`
+	LastArchive   string    `json:"last_archive"`
+	NextRunAt     time.Time `json:"next_run_at"`
+}
+
+var (
+	// pruneLock 同一时间只运行一次清理，定期清理与手动清理共用
+	pruneLock   sync.Mutex
+	statusLock  sync.RWMutex
+	pruneStatus PruneStatus
+)
+
+// Pruner 周期性按保留策略归档并清理审计日志
+type Pruner struct {
+	interval time.Duration
+}
+
+func NewPruner(interval time.Duration) *Pruner {
+	return &Pruner{interval: interval}
+}
+
+func (p *Pruner) Run(stopCh <-chan struct{}) {
+	klog.V(2).Infof("Audit pruner started, interval %v", p.interval)
+	wait.Until(p.prune, p.interval, stopCh)
+	klog.V(2).Infof("Audit pruner exit.")
+}
+
+func (p *Pruner) prune() {
+	if err := Prune(); err != nil && err != query.ErrPruneRunning {
+		klog.Errorf("Prune audit logs failed: %v", err)
+	}
+	statusLock.Lock()
+	pruneStatus.NextRunAt = time.Now().Add(p.interval)
+	statusLock.Unlock()
+}
+
+// GetPruneStatus 返回清理任务的运行状态
+func GetPruneStatus() PruneStatus {
+	statusLock.RLock()
+	defer statusLock.RUnlock()
+	return pruneStatus
+}
+
+// Prune 按保留策略清理审计日志，配置了 archive_dir 时每批日志先归档到压缩文件，归档成功后才删除；
+// 已经在清理时返回 ErrPruneRunning
+func Prune() error {
+	if !pruneLock.TryLock() {
+		return query.ErrPruneRunning
+	}
+	defer pruneLock.Unlock()
+
+	start := time.Now()
+	statusLock.Lock()
+	pruneStatus.Running = true
+	pruneStatus.LastRunAt = start
+	statusLock.Unlock()
+
+	pruned, err := prune()
+
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	pruneStatus.Running = false
+	pruneStatus.LastDuration = time.Since(start).String()
+	pruneStatus.LastPruned = pruned
+	pruneStatus.TotalPruned += pruned
+	pruneStatus.LastError = ""
+	if err != nil {
+		pruneStatus.LastError = err.Error()
+		return err
+	}
+	pruneStatus.LastSuccessAt = time.Now()
+	if pruned > 0 {
+		klog.V(2).Infof("%d audit logs pruned in %v", pruned, time.Since(start))
+	}
+	return nil
+}
+
+func prune() (int, error) {
+	retention := config.CONFIG.Audit.Retention
+	if retention.Days <= 0 && retention.Rows <= 0 {
+		return 0, nil
+	}
+	var before time.Time
+	if retention.Days > 0 {
+		before = time.Now().AddDate(0, 0, -retention.Days)
+	}
+	cutoff, err := model.ExpiredAuditCutoff(before, retention.Rows)
+	if err != nil || cutoff == 0 {
+		return 0, err
+	}
+	batch := retention.Batch
+	if batch <= 0 {
+		batch = 5000
+	}
+
+	pruned := 0
+	for {
+		audits, err := model.GetExpiredAudits(cutoff, batch)
+		if err != nil {
+			return pruned, err
+		}
+		if len(audits) == 0 {
+			return pruned, nil
+		}
+		first, last := audits[0], audits[len(audits)-1]
+		archive := &model.AuditArchive{
+			FirstID:  first.ID,
+			LastID:   last.ID,
+			FirstAt:  first.CreatedAt,
+			LastAt:   last.CreatedAt,
+			Records:  len(audits),
+			LastHash: last.Hash,
+		}
+		if retention.ArchiveDir != "" {
+			if archive.File, archive.Checksum, err = writeArchive(retention.ArchiveDir, audits); err != nil {
+				return pruned, err
+			}
+			statusLock.Lock()
+			pruneStatus.LastArchive = archive.File
+			statusLock.Unlock()
+		}
+		if err = model.PruneAudits(archive); err != nil {
+			return pruned, err
+		}
+		pruned += len(audits)
+	}
+}
+
+// writeArchive 将审计日志写入 gzip 压缩的 JSON Lines 文件，返回文件路径与压缩文件的 sha256；
+// 先写入临时文件，同步到磁盘后再重命名，避免留下不完整的归档
+func writeArchive(dir string, audits []*model.Audit) (string, string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("audit-%010d-%010d.jsonl.gz", audits[0].ID, audits[len(audits)-1].ID))
+	file, err := os.CreateTemp(dir, ".audit-*.tmp")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	hash := sha256.New()
+	buffer := bufio.NewWriter(io.MultiWriter(file, hash))
+	compressor := gzip.NewWriter(buffer)
+	encoder := json.NewEncoder(compressor)
+	for _, audit := range audits {
+		if err = encoder.Encode(audit); err != nil {
+			return "", "", err
+		}
+	}
+	if err = compressor.Close(); err != nil {
+		return "", "", err
+	}
+	if err = buffer.Flush(); err != nil {
+		return "", "", err
+	}
+	if err = file.Sync(); err != nil {
+		return "", "", err
+	}
+	if err = file.Close(); err != nil {
+		return "", "", err
+	}
+	if err = os.Rename(file.Name(), name); err != nil {
+		return "", "", err
+	}
+	return name, hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,134 @@
+package auditor
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/praserx/ipconv"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+const (
+	// syslogFacilityAudit RFC 5424 中的 log audit facility
+	syslogFacilityAudit = 13
+	syslogWarning       = 4
+	syslogNotice        = 5
+	// syslogSDID 结构化数据的 id，32473 为 RFC 5612 保留用于示例的企业编号
+	syslogSDID = "audit@32473"
+)
+
+var sdParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogSink 按 RFC 5424 发送审计日志，tcp 与 tls 使用 RFC 6587 的 octet counting 分帧
+type syslogSink struct {
+	network   string
+	address   string
+	facility  int
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	hostname  string
+	procID    string
+	conn      net.Conn
+}
+
+func newSyslogSink(network, address string, facility int, tlsConfig *tls.Config, timeout time.Duration) *syslogSink {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	if network == "tls" && tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	return &syslogSink{
+		network:   network,
+		address:   address,
+		facility:  facility,
+		tlsConfig: tlsConfig,
+		timeout:   timeout,
+		hostname:  hostname,
+		procID:    strconv.Itoa(os.Getpid()),
+	}
+}
+
+func (s *syslogSink) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.timeout}
+	if s.network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+	}
+	return dialer.Dial(s.network, s.address)
+}
+
+func (s *syslogSink) send(events []*model.Audit) (int, error) {
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+	}
+	for i, event := range events {
+		message := s.format(event)
+		if s.network != "udp" {
+			message = strconv.Itoa(len(message)) + " " + message
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		if _, err := s.conn.Write([]byte(message)); err != nil {
+			s.close()
+			return i, err
+		}
+	}
+	return len(events), nil
+}
+
+func (s *syslogSink) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// format 返回 RFC 5424 格式的消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+func (s *syslogSink) format(audit *model.Audit) string {
+	severity := syslogNotice
+	if failed(audit) {
+		severity = syslogWarning
+	}
+	msgID := "request"
+	if audit.Event != "" {
+		msgID = audit.Event
+	}
+	params := [][2]string{
+		{"id", strconv.FormatUint(uint64(audit.ID), 10)},
+		{"user_id", strconv.FormatUint(audit.UserID, 10)},
+		{"token_id", strconv.FormatUint(uint64(audit.TokenID), 10)},
+		{"src", ipconv.IntToIPv4(audit.IP).String()},
+		{"method", audit.Method},
+		{"path", audit.Path},
+		{"host", audit.Host},
+		{"zone", audit.Zone},
+		{"status", strconv.Itoa(audit.Status)},
+		{"code", strconv.Itoa(audit.Code)},
+		{"latency", strconv.FormatInt(audit.Latency, 10)},
+		{"outcome", outcome(audit)},
+		{"hash", audit.Hash},
+	}
+	var sd strings.Builder
+	sd.WriteString("[" + syslogSDID)
+	for _, param := range params {
+		if param[1] != "" {
+			fmt.Fprintf(&sd, ` %s="%s"`, param[0], sdParamEscaper.Replace(param[1]))
+		}
+	}
+	sd.WriteString("]")
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s",
+		s.facility*8+severity,
+		audit.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname, appName, s.procID, msgID, sd.String(),
+		strings.ReplaceAll(summary(audit), "\n", " "))
+}
@@ -0,0 +1,292 @@
+package auditor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// syslogPattern RFC 5424 的消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+var syslogPattern = regexp.MustCompile(`^<(\d{1,3})>1 (\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}Z) (\S+) (\S+) (\d+) (\S+) (\[audit@32473(?: [a-z_]+="(?:[^"\\\]]|\\["\\\]])*")*\]) (.*)$`)
+
+func testAudit(id uint) *model.Audit {
+	return &model.Audit{
+		ID:        id,
+		CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.FixedZone("CST", 8*3600)),
+		UserID:    1,
+		IP:        0x7f000001,
+		Method:    "POST",
+		Path:      "/fw/v1/port",
+		Host:      "10.0.0.1",
+		Zone:      `pub"lic]\`,
+		Status:    200,
+		Latency:   3,
+		Hash:      "abc",
+	}
+}
+
+// parseSyslog 校验消息的格式，返回 PRI、MSGID、结构化数据与 MSG
+func parseSyslog(t *testing.T, message string) (int, string, string, string) {
+	t.Helper()
+	match := syslogPattern.FindStringSubmatch(message)
+	if match == nil {
+		t.Fatalf("not an RFC 5424 message: %q", message)
+	}
+	if match[4] != appName {
+		t.Fatalf("app name: expected %s, got %s", appName, match[4])
+	}
+	pri, _ := strconv.Atoi(match[1])
+	return pri, match[6], match[7], match[8]
+}
+
+func TestSyslogUDPFormat(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sink := newSyslogSink("udp", conn.LocalAddr().String(), syslogFacilityAudit, nil, time.Second)
+	defer sink.close()
+
+	failure := testAudit(2)
+	failure.Event = "login_failed"
+	failure.Detail = "bad\npassword"
+	if sent, err := sink.send([]*model.Audit{testAudit(1), failure}); sent != 2 || err != nil {
+		t.Fatalf("send: %d %v", sent, err)
+	}
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// udp 每个数据报是一条完整的消息，不加长度前缀
+	pri, msgID, sd, msg := parseSyslog(t, string(buf[:n]))
+	if pri != syslogFacilityAudit*8+syslogNotice || msgID != "request" || msg != "POST /fw/v1/port 200" {
+		t.Fatalf("unexpected message %q", buf[:n])
+	}
+	if !strings.HasPrefix(string(buf[:n]), "<109>1 2024-05-05T23:08:09.123456Z ") {
+		t.Fatalf("timestamp should be UTC with microseconds: %q", buf[:n])
+	}
+	for _, param := range []string{`id="1"`, `src="127.0.0.1"`, `zone="pub\"lic\]\\"`, `outcome="success"`, `hash="abc"`} {
+		if !strings.Contains(sd, " "+param) {
+			t.Fatalf("structured data %s has no %s", sd, param)
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if n, _, err = conn.ReadFrom(buf); err != nil {
+		t.Fatal(err)
+	}
+	pri, msgID, sd, msg = parseSyslog(t, string(buf[:n]))
+	if pri != syslogFacilityAudit*8+syslogWarning || msgID != "login_failed" || msg != "login_failed: bad password" {
+		t.Fatalf("unexpected failure message %q", buf[:n])
+	}
+	if !strings.Contains(sd, ` outcome="failure"`) {
+		t.Fatalf("structured data %s has no failure outcome", sd)
+	}
+}
+
+// syslogListener 按 RFC 6587 octet counting 读取 tcp 上的消息
+type syslogListener struct {
+	net.Listener
+	messages chan string
+}
+
+func listenSyslog(t *testing.T, address string) *syslogListener {
+	t.Helper()
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &syslogListener{Listener: listener, messages: make(chan string, 100)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go l.read(t, conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return l
+}
+
+func (l *syslogListener) read(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		length, err := reader.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+		if err != nil || n <= 0 {
+			t.Errorf("invalid octet count %q", length)
+			return
+		}
+		message := make([]byte, n)
+		if _, err = io.ReadFull(reader, message); err != nil {
+			t.Errorf("read %d octets: %v", n, err)
+			return
+		}
+		l.messages <- string(message)
+	}
+}
+
+func (l *syslogListener) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case message := <-l.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for syslog message")
+	}
+	return ""
+}
+
+func TestSyslogTCPOctetCounting(t *testing.T) {
+	l := listenSyslog(t, "127.0.0.1:0")
+	sink := newSyslogSink("tcp", l.Addr().String(), 10, nil, time.Second)
+	defer sink.close()
+
+	events := []*model.Audit{testAudit(1), testAudit(2), testAudit(3)}
+	// 多字节字符按字节计数
+	events[1].Event = "rule_updated"
+	events[1].Detail = "端口 80 已更新"
+	if sent, err := sink.send(events); sent != len(events) || err != nil {
+		t.Fatalf("send: %d %v", sent, err)
+	}
+	for i := range events {
+		message := l.next(t)
+		pri, _, sd, _ := parseSyslog(t, message)
+		if pri != 10*8+syslogNotice {
+			t.Fatalf("facility: expected PRI %d, got %d", 10*8+syslogNotice, pri)
+		}
+		if !strings.Contains(sd, fmt.Sprintf(` id="%d"`, i+1)) {
+			t.Fatalf("message %d out of order: %q", i+1, message)
+		}
+	}
+}
+
+func TestForwarderRetriesUntilDelivered(t *testing.T) {
+	// 先占用一个端口再关闭，转发开始时没有服务监听
+	reserved, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := reserved.Addr().String()
+	reserved.Close()
+
+	forwarder := &Forwarder{
+		name:  "test",
+		sink:  newSyslogSink("tcp", address, syslogFacilityAudit, nil, time.Second),
+		queue: make(chan *model.Audit, 10),
+		retry: 50 * time.Millisecond,
+	}
+	for i := uint(1); i <= 3; i++ {
+		forwarder.enqueue(testAudit(i))
+	}
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		forwarder.Run(stopCh)
+		close(done)
+	}()
+	defer func() {
+		close(stopCh)
+		<-done
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	l := listenSyslog(t, address)
+	for i := 1; i <= 3; i++ {
+		_, _, sd, _ := parseSyslog(t, l.next(t))
+		if !strings.Contains(sd, fmt.Sprintf(` id="%d"`, i)) {
+			t.Fatalf("expected audit %d, got %s", i, sd)
+		}
+	}
+	select {
+	case message := <-l.messages:
+		t.Fatalf("audit log sent twice: %q", message)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// flakySink 第一次发送时只发送成功一条日志
+type flakySink struct {
+	sync.Mutex
+	calls int
+	sent  []uint
+}
+
+func (s *flakySink) send(events []*model.Audit) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.calls++
+	if s.calls == 1 {
+		s.sent = append(s.sent, events[0].ID)
+		return 1, errors.New("connection reset")
+	}
+	for _, event := range events {
+		s.sent = append(s.sent, event.ID)
+	}
+	return len(events), nil
+}
+
+func (s *flakySink) close() {}
+
+func (s *flakySink) delivered() []uint {
+	s.Lock()
+	defer s.Unlock()
+	return append([]uint(nil), s.sent...)
+}
+
+func TestForwarderResendsOnlyUnsent(t *testing.T) {
+	sink := &flakySink{}
+	forwarder := &Forwarder{name: "test", sink: sink, queue: make(chan *model.Audit, 10), retry: 10 * time.Millisecond}
+	for i := uint(1); i <= 3; i++ {
+		forwarder.enqueue(testAudit(i))
+	}
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		forwarder.Run(stopCh)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sink.delivered()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stopCh)
+	<-done
+	if sent := sink.delivered(); fmt.Sprint(sent) != "[1 2 3]" {
+		t.Fatalf("expected [1 2 3] delivered once in order, got %v", sent)
+	}
+}
+
+func TestForwarderDropsWhenBufferIsFull(t *testing.T) {
+	forwarder := &Forwarder{name: "test", sink: &flakySink{}, queue: make(chan *model.Audit, 2), retry: time.Second}
+	for i := uint(1); i <= 5; i++ {
+		forwarder.enqueue(testAudit(i))
+	}
+	if forwarder.dropped != 3 {
+		t.Fatalf("expected 3 dropped, got %d", forwarder.dropped)
+	}
+	// 丢弃的是新的日志，缓存中保留最早的日志
+	if first, second := <-forwarder.queue, <-forwarder.queue; first.ID != 1 || second.ID != 2 {
+		t.Fatalf("buffer should keep audits 1 and 2, got %d and %d", first.ID, second.ID)
+	}
+}
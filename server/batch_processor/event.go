@@ -1,14 +1,19 @@
 package batch_processor
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"strconv"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
 	"github.com/cylonchau/firewalld-gateway/utils/firewalld"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 var Store map[string]interface{}
@@ -19,7 +24,9 @@ type Event struct {
 	Host      string
 	TaskName  string
 	Task      interface{}
-	errNum    int
+	// Context 保存创建任务时的 span，延迟执行与每次重试的 span 都是它的子 span
+	Context context.Context
+	errNum  int
 }
 
 func init() {
@@ -38,6 +45,22 @@ func StoreDel(key string) {
 	delete(Store, key)
 }
 
+func StoreGet(key string) (interface{}, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	v, ok := Store[key]
+	return v, ok
+}
+
+// StoreClear 清空所有任务
+func StoreClear() {
+	mu.Lock()
+	defer mu.Unlock()
+	for key := range Store {
+		delete(Store, key)
+	}
+}
+
 func RandName() string {
 	var letters = []rune("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 	b := make([]rune, 8)
@@ -48,13 +71,27 @@ func RandName() string {
 	return "task-" + string(b) + strconv.Itoa(int(time.Now().Unix()))
 }
 
-func (e *Event) processEvent() error {
-	var (
-		incurredError error
-		dbusClient    *firewalld.DbusClientSerivce
+func (e *Event) processEvent() (incurredError error) {
+	var dbusClient *firewalld.DbusClientSerivce
+
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "batch.process "+e.EventName,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("batch.task", e.TaskName),
+			attribute.String("batch.event", e.EventName),
+			attribute.String("net.peer.name", e.Host),
+			attribute.Int("batch.attempt", e.errNum+1),
+		),
 	)
+	defer func() {
+		tracing.End(span, incurredError)
+	}()
 
-	if dbusClient, incurredError = firewalld.NewDbusClientService(e.Host); incurredError != nil {
+	if dbusClient, incurredError = firewalld.NewDbusClientServiceWithContext(ctx, e.Host); incurredError != nil {
 		return incurredError
 	}
 	defer dbusClient.Destroy()
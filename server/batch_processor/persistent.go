@@ -0,0 +1,150 @@
+package batch_processor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/metrics"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// feedBatch 每次从数据库中取出的任务数
+const feedBatch = 100
+
+var traceContext = propagation.TraceContext{}
+
+// persist 将任务保存到数据库中，由 leader 在 delay 之后取出处理
+func (p *Processor) persist(key string, delay time.Duration, event Event) error {
+	task, err := json.Marshal(event.Task)
+	if err != nil {
+		return err
+	}
+	carrier := propagation.MapCarrier{}
+	if event.Context != nil {
+		traceContext.Inject(event.Context, carrier)
+	}
+	return model.CreateBatchTask(&model.BatchTask{
+		Name:        key,
+		Event:       event.EventName,
+		Host:        event.Host,
+		Task:        string(task),
+		TraceParent: carrier.Get("traceparent"),
+		RunAt:       time.Now().Add(delay),
+		Attempts:    event.errNum,
+	})
+}
+
+// Lead 在本副本成为 leader 后运行，直到 stopCh 关闭：将上一个 leader 没有处理完的任务放回数据库，
+// 然后定期取出到期的任务加入队列处理；非 leader 的副本只保存任务，不处理任务
+func (p *Processor) Lead(stopCh <-chan struct{}, interval time.Duration) {
+	if reset, err := model.ResetBatchTasks(); err != nil {
+		klog.Errorf("Reset queued batch tasks failed: %v", err)
+	} else if reset > 0 {
+		klog.V(2).Infof("%d batch tasks left by the previous leader are requeued", reset)
+	}
+
+	queue := newQueue()
+	p.queueLock.Lock()
+	p.queue = queue
+	p.queueLock.Unlock()
+	go func() {
+		<-stopCh
+		queue.ShutDown()
+	}()
+	go wait.Until(p.feed, interval, stopCh)
+	p.Run()
+	// 正在执行的任务结束后才返回，避免下一个 leader 在它们执行时重新处理同一个任务
+	p.tasks.Wait()
+
+	// 已经取出但没有处理的任务由下一个 leader 重新处理
+	StoreClear()
+	klog.V(2).Infof("Batch processor stopped leading.")
+}
+
+func (p *Processor) feed() {
+	queue := p.currentQueue()
+	tasks, err := model.ClaimBatchTasks(feedBatch)
+	if err != nil {
+		klog.Errorf("Claim batch tasks failed: %v", err)
+	}
+	for _, task := range tasks {
+		event, err := decodeEvent(task)
+		if err != nil {
+			klog.Errorf("Decode batch task %s failed: %v", task.Name, err)
+			p.finish(task.Name, err)
+			continue
+		}
+		StoreAdd(task.Name, event)
+		queue.Add(task.Name)
+	}
+	if len(tasks) > 0 {
+		metrics.BatchQueueDepth.Set(float64(queue.Len()))
+	}
+}
+
+func decodeEvent(task model.BatchTask) (event Event, err error) {
+	event = Event{
+		EventName: task.Event,
+		Host:      task.Host,
+		TaskName:  task.Name,
+		Context:   traceContext.Extract(context.Background(), propagation.MapCarrier{"traceparent": task.TraceParent}),
+		errNum:    task.Attempts,
+	}
+	switch task.Event {
+	case CREATE_PORT, REMOVE_PORT:
+		var q query.PortQuery
+		err = json.Unmarshal([]byte(task.Task), &q)
+		event.Task = q
+	case CREATE_RICH:
+		var q query.RichQuery
+		err = json.Unmarshal([]byte(task.Task), &q)
+		event.Task = q
+	case CREATE_FORWARD:
+		var q query.ForwardQuery
+		err = json.Unmarshal([]byte(task.Task), &q)
+		event.Task = q
+	case CREATE_SERVICE:
+		var q query.ServiceQuery
+		err = json.Unmarshal([]byte(task.Task), &q)
+		event.Task = q
+	case ENABLE_MASQUERADE, DISABLE_MASQUERADE, FLUSH_SETTING, SET_DEFAULT_ZONE:
+		var q string
+		err = json.Unmarshal([]byte(task.Task), &q)
+		event.Task = q
+	case RELOAD_FIREWALD:
+	default:
+		err = fmt.Errorf("unkown event %s", task.Event)
+	}
+	return event, err
+}
+
+// retry 在 delay 之后重新处理失败的任务
+func (p *Processor) retry(key string, delay time.Duration, event Event, reason error) {
+	if !p.persistent {
+		StoreAdd(key, event)
+		p.AddAfter(key, delay, nil)
+		return
+	}
+	StoreDel(key)
+	if err := model.RetryBatchTask(key, event.errNum, time.Now().Add(delay), reason.Error()); err != nil {
+		klog.Errorf("Requeue batch task %s failed: %v", key, err)
+	}
+}
+
+// finish 结束任务，err 不为空时任务超过了重试次数
+func (p *Processor) finish(key string, err error) {
+	StoreDel(key)
+	if !p.persistent {
+		return
+	}
+	if err := model.FinishBatchTask(key, err); err != nil {
+		klog.Errorf("Finish batch task %s failed: %v", key, err)
+	}
+}
@@ -3,6 +3,7 @@ package batch_processor
 import (
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -10,6 +11,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/metrics"
 )
 
 var P *Processor
@@ -20,44 +22,87 @@ type Processor struct {
 	addCh         chan interface{}
 	listenersLock sync.RWMutex
 	wg            wait.Group
-	queue         workqueue.RateLimitingInterface
+	// tasks 正在处理的任务，失去 leader 后等待它们结束
+	tasks     sync.WaitGroup
+	queueLock sync.RWMutex
+	queue     workqueue.RateLimitingInterface
+	running   int32
+	// persistent 为 true 时任务保存在数据库中，由 leader 取出处理
+	persistent bool
+}
+
+func newQueue() workqueue.RateLimitingInterface {
+	return workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 }
 
 func NewProcessor() *Processor {
 	if !reflect.DeepEqual(P, nil) {
 		return &Processor{
-			stopCh: make(chan interface{}),
-			addCh:  make(chan interface{}),
-			queue:  workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+			stopCh:     make(chan interface{}),
+			addCh:      make(chan interface{}),
+			queue:      newQueue(),
+			persistent: config.CONFIG.HA.Enabled,
 		}
 	}
 	return P
 }
 
 func (p *Processor) Run() {
+	atomic.StoreInt32(&p.running, 1)
 	defer func() {
-		p.queue.ShutDown()
+		atomic.StoreInt32(&p.running, 0)
+		p.currentQueue().ShutDown()
 	}()
 	p.wg.Start(p.pop)
 	p.wg.Wait()
 }
 
+// currentQueue 返回当前的队列，每次成为 leader 时都会使用新的队列
+func (p *Processor) currentQueue() workqueue.RateLimitingInterface {
+	p.queueLock.RLock()
+	defer p.queueLock.RUnlock()
+	return p.queue
+}
+
+// Running 返回处理任务的协程是否在运行
+func (p *Processor) Running() bool {
+	return atomic.LoadInt32(&p.running) == 1 && !p.currentQueue().ShuttingDown()
+}
+
+// Len 返回等待处理的任务数，不包括延迟执行与等待重试的任务
+func (p *Processor) Len() int {
+	return p.currentQueue().Len()
+}
+
 func (p *Processor) Add(notification string, event interface{}) {
+	if p.persistent {
+		p.AddAfter(notification, 0, event)
+		return
+	}
 	StoreAdd(notification, event)
 
-	p.queue.Add(notification)
+	queue := p.currentQueue()
+	queue.Add(notification)
+	metrics.BatchQueueDepth.Set(float64(queue.Len()))
 }
 
 func (p *Processor) AddAfter(notification string, t time.Duration, event interface{}) {
+	if e, ok := event.(Event); ok && p.persistent {
+		if err := p.persist(notification, t, e); err != nil {
+			klog.Errorf("Save batch task %s failed: %v", notification, err)
+		}
+		return
+	}
 	if v, ok := event.(interface{}); ok && v != nil {
 		StoreAdd(notification, event)
 	}
-	p.queue.AddAfter(notification, t)
+	p.currentQueue().AddAfter(notification, t)
 }
 
 func (p *Processor) pop() {
 
 	klog.V(5).Infof("Async event processor started, waitting task...")
+	queue := p.currentQueue()
 
 	for {
 		var event Event
@@ -66,40 +111,46 @@ func (p *Processor) pop() {
 			klog.V(5).Infof("Async evnet process exit.")
 			return
 		default:
-			notificationKey, quit := p.queue.Get()
+			notificationKey, quit := queue.Get()
 			if quit {
 				return
 			}
+			metrics.BatchQueueDepth.Set(float64(queue.Len()))
 
+			p.tasks.Add(1)
 			go func(notificationKey interface{}) {
+				defer p.tasks.Done()
 				var encouterError error
 				key := notificationKey.(string)
-				eventInterface, enconterBool := Store[key]
+				eventInterface, enconterBool := StoreGet(key)
 				if enconterBool {
 					event, enconterBool = eventInterface.(Event)
 					if enconterBool {
 						klog.V(5).Infof("Recived mission %s", event.TaskName)
 						encouterError = event.processEvent()
 						if encouterError != nil {
+							metrics.BatchEvents.WithLabelValues(event.EventName, "error").Inc()
 							if event.errNum <= config.CONFIG.MissionRetryNumber {
+								metrics.BatchRetries.WithLabelValues(event.EventName).Inc()
 								event.errNum++
-								Store[key] = event
 								retryTime := time.Duration(event.errNum+1) * T
-								p.queue.Forget(key)
-								p.AddAfter(key, retryTime, nil)
+								queue.Forget(key)
+								p.retry(key, retryTime, event, encouterError)
 								klog.Warningf("Event processing failed, will retry on %v second after.", retryTime)
 							} else {
-								p.queue.Forget(key)
-								StoreDel(key)
+								metrics.BatchFailures.WithLabelValues(event.EventName).Inc()
+								queue.Forget(key)
+								p.finish(key, encouterError)
 								klog.Warningf("Task %s exceed MRN value: %v.", event.TaskName, encouterError)
 							}
 						} else {
-							p.queue.Forget(key)
-							StoreDel(key)
+							metrics.BatchEvents.WithLabelValues(event.EventName, "success").Inc()
+							queue.Forget(key)
+							p.finish(key, nil)
 						}
 					}
 				}
-				p.queue.Done(key)
+				queue.Done(key)
 				if encouterError != nil || !enconterBool {
 					klog.Errorf("Event failed: %v", encouterError)
 				}
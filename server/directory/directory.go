@@ -0,0 +1,54 @@
+package directory
+
+import (
+	"errors"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/utils/ldap"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// Syncer 周期性按目录服务中的组重新计算 LDAP 用户的角色，已从目录中删除的用户会失去全部角色
+type Syncer struct {
+	interval time.Duration
+}
+
+func NewSyncer(interval time.Duration) *Syncer {
+	return &Syncer{interval: interval}
+}
+
+func (s *Syncer) Run(stopCh <-chan struct{}) {
+	klog.V(2).Infof("LDAP role syncer started, interval %v", s.interval)
+	wait.Until(s.sync, s.interval, stopCh)
+	klog.V(2).Infof("LDAP role syncer exit.")
+}
+
+func (s *Syncer) sync() {
+	users, err := model.GetExternalUsers(model.UserSourceLDAP)
+	if err != nil {
+		klog.Errorf("List ldap users failed: %v", err)
+		return
+	}
+	for _, user := range users {
+		var groups []string
+		entry, err := ldap.Lookup(user.Subject)
+		switch {
+		case err == nil:
+			groups = entry.Groups
+		case errors.Is(err, ldap.ErrUserNotFound):
+			klog.V(2).Infof("User %s was removed from ldap, revoke roles and sessions", user.Username)
+			if _, err = model.RevokeUserSessions(user.ID, 0, model.SessionRevokedUser); err != nil {
+				klog.Errorf("Revoke sessions of ldap user %s failed: %v", user.Username, err)
+			}
+		default:
+			klog.Errorf("Lookup ldap user %s failed: %v", user.Username, err)
+			continue
+		}
+		if _, err = model.SyncExternalUser(model.UserSourceLDAP, user.Subject, user.Username, ldap.MapRoles(groups), false); err != nil {
+			klog.Errorf("Sync roles of ldap user %s failed: %v", user.Username, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package election
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+var (
+	lock    sync.RWMutex
+	elector *leaderelection.LeaderElector
+	leading sync.Mutex
+)
+
+// Identity 返回本副本参与选主的标识，未配置时使用主机名
+func Identity() string {
+	if identity := config.CONFIG.HA.Identity; identity != "" {
+		return identity
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "firewalld-gateway"
+	}
+	return hostname
+}
+
+// IsLeader 返回本副本是否为 leader，未开启选主时总是 leader
+func IsLeader() bool {
+	if !config.CONFIG.HA.Enabled {
+		return true
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	return elector != nil && elector.IsLeader()
+}
+
+// Leader 返回最近一次观察到的 leader 标识
+func Leader() string {
+	if !config.CONFIG.HA.Enabled {
+		return Identity()
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	if elector == nil {
+		return ""
+	}
+	return elector.GetLeader()
+}
+
+// Run 参与选主直到 stopCh 关闭，成为 leader 后运行 lead，失去 leader 时关闭传给 lead 的 stopCh 并重新参与选主；
+// 退出时主动释放 Lease，其他副本不需要等待 Lease 过期，并等待 lead 返回
+func Run(stopCh <-chan struct{}, lead func(stopCh <-chan struct{})) error {
+	cfg := config.CONFIG.HA
+	identity := Identity()
+	resourceLock, err := newLock(cfg.Backend, cfg.LeaseName, cfg.Namespace, identity)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	klog.V(2).Infof("%s joined leader election of %s", identity, resourceLock.Describe())
+	var runErr error
+	wait.Until(func() {
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            resourceLock,
+			LeaseDuration:   time.Duration(cfg.LeaseDuration) * time.Second,
+			RenewDeadline:   time.Duration(cfg.RenewDeadline) * time.Second,
+			RetryPeriod:     time.Duration(cfg.RetryPeriod) * time.Second,
+			ReleaseOnCancel: true,
+			Name:            cfg.LeaseName,
+			Callbacks: leaderelection.LeaderCallbacks{
+				// OnStartedLeading 是异步调用的，leading 保证上一任期的 lead 返回后才开始新的任期
+				OnStartedLeading: func(leaderCtx context.Context) {
+					leading.Lock()
+					defer leading.Unlock()
+					if leaderCtx.Err() != nil {
+						return
+					}
+					klog.V(2).Infof("%s became the leader of %s", identity, resourceLock.Describe())
+					lead(leaderCtx.Done())
+					klog.V(2).Infof("%s stopped leading %s", identity, resourceLock.Describe())
+				},
+				OnStoppedLeading: func() {},
+				OnNewLeader: func(leader string) {
+					if leader != identity {
+						klog.V(2).Infof("The leader of %s is %s", resourceLock.Describe(), leader)
+					}
+				},
+			},
+		})
+		if err != nil {
+			runErr = err
+			cancel()
+			return
+		}
+		lock.Lock()
+		elector = le
+		lock.Unlock()
+		le.Run(ctx)
+	}, time.Duration(cfg.RetryPeriod)*time.Second, ctx.Done())
+	leading.Lock()
+	defer leading.Unlock()
+	return runErr
+}
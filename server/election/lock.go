@@ -0,0 +1,161 @@
+package election
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+const (
+	BackendKubernetes = "kubernetes"
+	BackendDatabase   = "database"
+
+	namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+var errLeaseConflict = errors.New("leader lease was modified by another replica")
+
+// dbLock 使用数据库中的一条记录实现 resourcelock.Interface，
+// 修改时比较上一次读取到的 version，记录已经被其他副本修改时放弃本次修改
+type dbLock struct {
+	name     string
+	identity string
+	version  int64
+}
+
+func (l *dbLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	lease, err := model.GetLeaderLease(l.name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, apierrors.NewNotFound(schema.GroupResource{Resource: "leases"}, l.name)
+		}
+		return nil, nil, err
+	}
+	l.version = lease.Version
+	record := &resourcelock.LeaderElectionRecord{
+		HolderIdentity:       lease.HolderIdentity,
+		LeaseDurationSeconds: lease.LeaseDurationSeconds,
+		AcquireTime:          metav1.NewTime(lease.AcquireTime),
+		RenewTime:            metav1.NewTime(lease.RenewTime),
+		LeaderTransitions:    lease.LeaderTransitions,
+	}
+	raw, err := json.Marshal(lease)
+	if err != nil {
+		return nil, nil, err
+	}
+	return record, raw, nil
+}
+
+func (l *dbLock) Create(ctx context.Context, record resourcelock.LeaderElectionRecord) error {
+	lease := l.lease(record)
+	if err := model.CreateLeaderLease(lease); err != nil {
+		return err
+	}
+	l.version = lease.Version
+	return nil
+}
+
+func (l *dbLock) Update(ctx context.Context, record resourcelock.LeaderElectionRecord) error {
+	updated, err := model.UpdateLeaderLease(l.lease(record), l.version)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errLeaseConflict
+	}
+	l.version++
+	return nil
+}
+
+func (l *dbLock) RecordEvent(event string) {
+	klog.V(2).Infof("%s %s", l.identity, event)
+}
+
+func (l *dbLock) Identity() string {
+	return l.identity
+}
+
+func (l *dbLock) Describe() string {
+	return "database/" + l.name
+}
+
+func (l *dbLock) lease(record resourcelock.LeaderElectionRecord) *model.LeaderLease {
+	return &model.LeaderLease{
+		Name:                 l.name,
+		HolderIdentity:       record.HolderIdentity,
+		LeaseDurationSeconds: record.LeaseDurationSeconds,
+		AcquireTime:          record.AcquireTime.Time,
+		RenewTime:            record.RenewTime.Time,
+		LeaderTransitions:    record.LeaderTransitions,
+		Version:              l.version,
+	}
+}
+
+// newLeaseLock 使用 Pod 的 ServiceAccount 访问 coordination.k8s.io/v1 的 Lease，
+// 未配置 namespace 时使用 Pod 所在的 namespace
+func newLeaseLock(name, namespace, identity string) (resourcelock.Interface, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := coordinationv1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		namespace = podNamespace()
+	}
+	return &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Client:     client,
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}, nil
+}
+
+func podNamespace() string {
+	if namespace, err := os.ReadFile(namespaceFile); err == nil {
+		if namespace := strings.TrimSpace(string(namespace)); namespace != "" {
+			return namespace
+		}
+	}
+	return metav1.NamespaceDefault
+}
+
+// inCluster 与 rest.InClusterConfig 的判断方式一致
+func inCluster() bool {
+	return os.Getenv("KUBERNETES_SERVICE_HOST") != "" && os.Getenv("KUBERNETES_SERVICE_PORT") != ""
+}
+
+func newLock(backend, name, namespace, identity string) (resourcelock.Interface, error) {
+	if backend == "" {
+		backend = BackendDatabase
+		if inCluster() {
+			backend = BackendKubernetes
+		}
+	}
+	switch backend {
+	case BackendKubernetes:
+		return newLeaseLock(name, namespace, identity)
+	case BackendDatabase:
+		if model.DB == nil {
+			return nil, errors.New("database backend of leader election requires a database")
+		}
+		return &dbLock{name: name, identity: identity}, nil
+	default:
+		return nil, fmt.Errorf("unknown leader election backend %q", backend)
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/migration"
+)
+
+// newMigrateCommand 执行、回滚与查看数据库版本迁移的命令
+func newMigrateCommand() *cobra.Command {
+	var configFile, sqlDriver string
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade, roll back and show the versioned database schema.",
+	}
+	cmd.PersistentFlags().StringVar(&configFile, "config", "./firewalld-gateway.toml", "The path to the configuration file.")
+	cmd.PersistentFlags().StringVar(&sqlDriver, "sql-driver", "", "mysql, postgres or sqlite, empty means database_driver in the configuration file.")
+
+	var upTo uint
+	var upDryRun bool
+	up := &cobra.Command{
+		Use:   "up",
+		Short: "Apply pending migrations up to the latest or the given version.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openMigrateDB(configFile, sqlDriver)
+			if err != nil {
+				return err
+			}
+			return migration.Up(db, upTo, upDryRun, cmd.OutOrStdout())
+		},
+	}
+	up.Flags().UintVar(&upTo, "to", 0, "The version to migrate to, 0 means the latest version.")
+	up.Flags().BoolVar(&upDryRun, "dry-run", false, "Print the SQL instead of executing it.")
+	cmd.AddCommand(up)
+
+	var downTo int
+	var downDryRun bool
+	down := &cobra.Command{
+		Use:   "down",
+		Short: "Roll back the last migration or all migrations after the given version.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openMigrateDB(configFile, sqlDriver)
+			if err != nil {
+				return err
+			}
+			target := uint(downTo)
+			if downTo < 0 {
+				current, err := migration.Current(db)
+				if err != nil {
+					return err
+				}
+				if current == 0 {
+					fmt.Fprintln(cmd.OutOrStdout(), "no migration to roll back")
+					return nil
+				}
+				target = current - 1
+			}
+			return migration.Down(db, target, downDryRun, cmd.OutOrStdout())
+		},
+	}
+	down.Flags().IntVar(&downTo, "to", -1, "The version to roll back to, 0 rolls back all migrations, -1 rolls back the last migration.")
+	down.Flags().BoolVar(&downDryRun, "dry-run", false, "Print the SQL instead of executing it.")
+	cmd.AddCommand(down)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show the state of every migration.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openMigrateDB(configFile, sqlDriver)
+			if err != nil {
+				return err
+			}
+			statuses, err := migration.Status(db)
+			if err != nil {
+				return err
+			}
+			current, err := migration.Current(db)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "current: %d\nlatest:  %d\n\n", current, migration.Latest())
+			writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(writer, "VERSION\tNAME\tSTATE\tAPPLIED AT\tCHECKSUM")
+			for _, status := range statuses {
+				appliedAt := "-"
+				if status.AppliedAt != nil {
+					appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				checksum := status.Checksum
+				if len(checksum) > 12 {
+					checksum = checksum[:12]
+				}
+				fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt, checksum)
+			}
+			return writer.Flush()
+		},
+	})
+	return cmd
+}
+
+func openMigrateDB(configFile, sqlDriver string) (*gorm.DB, error) {
+	if err := config.InitConfiguration(configFile); err != nil {
+		return nil, err
+	}
+	if sqlDriver == "" {
+		sqlDriver = config.CONFIG.DatabaseDriver
+	}
+	return migration.Open(sqlDriver)
+}
@@ -0,0 +1,181 @@
+package reconciler
+
+import (
+	"context"
+	"time"
+
+	"github.com/praserx/ipconv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+	"github.com/cylonchau/firewalld-gateway/utils/firewalld"
+	"github.com/cylonchau/firewalld-gateway/utils/metrics"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
+)
+
+const appliedBy = "reconciler"
+
+// zoneClient 检查与修复漂移使用的主机 D-Bus 接口
+type zoneClient interface {
+	GetZoneSettings(zone string) (*api.Settings, error)
+	GetPermanentZoneSettings(zone string) (*api.Settings, error)
+	RuntimeApply(setting api.Settings) error
+	RuntimeSet(setting api.Settings) error
+	Destroy()
+}
+
+// newZoneClient 连接主机的 D-Bus
+var newZoneClient = func(ctx context.Context, ip string) (zoneClient, error) {
+	return firewalld.NewDbusClientServiceWithContext(ctx, ip)
+}
+
+// Reconciler 周期性比较主机的 zone settings 与其最近一次下发的模板版本，记录漂移并在 enforce 模式下修复
+type Reconciler struct {
+	interval time.Duration
+}
+
+func NewReconciler(interval time.Duration) *Reconciler {
+	return &Reconciler{interval: interval}
+}
+
+func (r *Reconciler) Run(stopCh <-chan struct{}) {
+	klog.V(2).Infof("Template drift reconciler started, interval %v", r.interval)
+	wait.Until(r.reconcile, r.interval, stopCh)
+	klog.V(2).Infof("Template drift reconciler exit.")
+}
+
+func (r *Reconciler) reconcile() {
+	templates, err := model.GetDriftTemplates()
+	if err != nil {
+		klog.Errorf("List templates for drift detection failed: %v", err)
+		return
+	}
+	for _, template := range templates {
+		if err = ReconcileTemplate(&template); err != nil {
+			klog.Errorf("Reconcile template %s failed: %v", template.Name, err)
+		}
+	}
+}
+
+// ReconcileTemplate 检查模板 tag 下所有已下发过该模板的主机
+func ReconcileTemplate(template *model.Template) error {
+	applications, err := model.GetLatestTemplateApplications(template.ID)
+	if err != nil {
+		return err
+	}
+	hosts, err := model.GetHostsByTagName(template.Name)
+	if err != nil {
+		return err
+	}
+	revisions := make(map[int]*model.TemplateRevision)
+	for _, host := range hosts {
+		application, ok := applications[host.ID]
+		if !ok {
+			klog.V(4).Infof("Template %s was never applied to host %d, skip drift detection", template.Name, host.ID)
+			continue
+		}
+		revision, ok := revisions[application.Revision]
+		if !ok {
+			if revision, err = model.GetTemplateRevision(template.ID, application.Revision); err != nil {
+				return err
+			}
+			revisions[application.Revision] = revision
+		}
+		reconcileHost(template, revision, application.Mode, host)
+	}
+	return nil
+}
+
+func reconcileHost(template *model.Template, revision *model.TemplateRevision, mode string, host model.Host) {
+	drift := &model.TemplateDrift{
+		TemplateID: template.ID,
+		Revision:   revision.Revision,
+		HostID:     host.ID,
+		IP:         host.IP,
+		Status:     model.DriftInSync,
+	}
+	ctx, span := tracing.Tracer().Start(context.Background(), "drift.reconcile", trace.WithAttributes(
+		attribute.String("template.name", template.Name),
+		attribute.Int("template.revision", revision.Revision),
+		attribute.Int("host.id", int(host.ID)),
+	))
+	defer span.End()
+	diff, err := checkHost(ctx, template, revision, mode, host, drift)
+	if err != nil {
+		drift.Status, drift.Error = model.DriftError, err.Error()
+		span.RecordError(err)
+	}
+	span.SetAttributes(attribute.String("drift.status", drift.Status))
+	metrics.TemplateDrift.WithLabelValues(template.Name, drift.Status).Inc()
+	if err = model.RecordTemplateDrift(drift, diff); err != nil {
+		klog.Errorf("Record drift of template %s on host %d failed: %v", template.Name, host.ID, err)
+	}
+}
+
+// checkHost 按上次下发的方式比较主机的配置与模板版本，enforce 模式下重新下发；
+// permanent 方式比较 permanent 配置，只存在于 runtime 的修改在 reload 后消失，不视为漂移
+func checkHost(ctx context.Context, template *model.Template, revision *model.TemplateRevision, mode string, host model.Host, drift *model.TemplateDrift) (*api.SettingsDiff, error) {
+	expected, err := revision.Settings()
+	if err != nil {
+		return nil, err
+	}
+	ip := ipconv.IntToIPv4(host.IP).String()
+	dbusClient, err := newZoneClient(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	defer dbusClient.Destroy()
+
+	var (
+		live *api.Settings
+		diff *api.SettingsDiff
+	)
+	if mode == model.TemplateModeRuntime {
+		if live, err = dbusClient.GetZoneSettings(""); err == nil {
+			diff = api.DiffRuntimeSettings(expected, live)
+		}
+	} else {
+		if live, err = dbusClient.GetPermanentZoneSettings(""); err == nil {
+			diff = api.DiffSettings(expected, live)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if diff.IsEmpty() {
+		return nil, nil
+	}
+	klog.Warningf("Host %s drifted from template %s revision %d: %+v", ip, template.Name, revision.Revision, diff)
+	drift.Status = model.DriftDetected
+	if template.DriftMode != model.DriftModeEnforce {
+		return diff, nil
+	}
+
+	application := &model.TemplateApplication{
+		TemplateID: revision.TemplateID,
+		RevisionID: revision.ID,
+		Revision:   revision.Revision,
+		HostID:     host.ID,
+		IP:         host.IP,
+		AppliedBy:  appliedBy,
+		Mode:       mode,
+		Status:     model.TemplateApplied,
+	}
+	if mode == model.TemplateModeRuntime {
+		err = dbusClient.RuntimeApply(*expected)
+	} else {
+		err = dbusClient.RuntimeSet(*expected)
+	}
+	if err != nil {
+		application.Status, application.Error = model.TemplateFailed, err.Error()
+		model.RecordTemplateApplication(application)
+		return diff, err
+	}
+	model.RecordTemplateApplication(application)
+	drift.Status = model.DriftRemediated
+	return diff, nil
+}
@@ -0,0 +1,220 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/migration"
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+// fakeZoneClient 返回固定的 zone settings，记录重新下发的配置
+type fakeZoneClient struct {
+	runtime   *api.Settings
+	permanent *api.Settings
+	applyErr  error
+	applied   *api.Settings
+	set       *api.Settings
+}
+
+func (c *fakeZoneClient) GetZoneSettings(string) (*api.Settings, error) {
+	return c.runtime, nil
+}
+
+func (c *fakeZoneClient) GetPermanentZoneSettings(string) (*api.Settings, error) {
+	return c.permanent, nil
+}
+
+func (c *fakeZoneClient) RuntimeApply(setting api.Settings) error {
+	c.applied = &setting
+	return c.applyErr
+}
+
+func (c *fakeZoneClient) RuntimeSet(setting api.Settings) error {
+	c.set = &setting
+	return c.applyErr
+}
+
+func (c *fakeZoneClient) Destroy() {}
+
+// setupTemplate 初始化数据库，以 mode 将模板 web 下发到 tag web 下的主机，返回模板与下发的配置
+func setupTemplate(t *testing.T, mode, driftMode string) (*model.Template, *api.Settings) {
+	t.Helper()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "firewalld-gateway.toml")
+	content := fmt.Sprintf("appname = \"test\"\ndatabase_driver = \"sqlite\"\ndbus_port = \"55556\"\n[sqlite]\nfile = %q\nmax_open_connection = 1\n",
+		filepath.Join(dir, "uranus"))
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitConfiguration(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.InitDB("sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if conn, err := model.DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	migration.RegisterRouter = func(*gin.Engine) {}
+	t.Cleanup(func() { migration.RegisterRouter = nil })
+	if err := migration.Up(model.DB, 0, false, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	tag := &model.Tag{Name: "web"}
+	if err := model.DB.Create(tag).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := model.CreateHost("10.0.0.1", "web-host", int(tag.ID)); err != nil {
+		t.Fatal(err)
+	}
+	revision, err := model.ImportTemplate("web", &model.TemplateWithDetails{
+		Target:   "default",
+		Ports:    []model.PortListWithoutID{{Port: "80", Protocol: "tcp"}},
+		Services: []model.ServiceListWithoutID{{Name: "ssh"}},
+	}, "admin", "import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = model.UpdateTemplateDriftMode(int(revision.TemplateID), driftMode); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := model.GetHostsByTagName("web")
+	if err != nil || len(hosts) != 1 {
+		t.Fatalf("expected 1 host of tag web, got %v %v", hosts, err)
+	}
+	if err = model.RecordTemplateApplication(&model.TemplateApplication{
+		TemplateID: revision.TemplateID, RevisionID: revision.ID, Revision: revision.Revision,
+		HostID: hosts[0].ID, IP: hosts[0].IP, AppliedBy: "admin", Mode: mode, Status: model.TemplateApplied,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	template := &model.Template{}
+	if err = model.DB.First(template, revision.TemplateID).Error; err != nil {
+		t.Fatal(err)
+	}
+	expected, err := revision.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return template, expected
+}
+
+// useZoneClient 在测试中使用 connect 代替主机的 D-Bus 连接
+func useZoneClient(t *testing.T, connect func(ctx context.Context, ip string) (zoneClient, error)) {
+	original := newZoneClient
+	newZoneClient = connect
+	t.Cleanup(func() { newZoneClient = original })
+}
+
+// withPort 返回增加了端口的配置副本
+func withPort(settings *api.Settings, port string) *api.Settings {
+	drifted := *settings
+	drifted.Port = append(append([]*api.Port{}, settings.Port...), &api.Port{Port: port, Protocol: "tcp"})
+	return &drifted
+}
+
+func TestReconcileTemplate(t *testing.T) {
+	cases := []struct {
+		name      string
+		mode      string
+		driftMode string
+		// runtime 与 permanent 中额外开放的端口
+		runtime   string
+		permanent string
+		applyErr  error
+		status    string
+	}{
+		{"runtime in sync", model.TemplateModeRuntime, model.DriftModeEnforce, "", "8080", nil, model.DriftInSync},
+		// permanent 方式下只存在于 runtime 的修改在 reload 后消失
+		{"permanent in sync", model.TemplateModePermanent, model.DriftModeEnforce, "8080", "", nil, model.DriftInSync},
+		{"runtime drift", model.TemplateModeRuntime, model.DriftModeAudit, "8080", "", nil, model.DriftDetected},
+		{"permanent drift", model.TemplateModePermanent, model.DriftModeAudit, "", "8080", nil, model.DriftDetected},
+		{"runtime enforce", model.TemplateModeRuntime, model.DriftModeEnforce, "8080", "", nil, model.DriftRemediated},
+		{"permanent enforce", model.TemplateModePermanent, model.DriftModeEnforce, "", "8080", nil, model.DriftRemediated},
+		{"enforce failed", model.TemplateModePermanent, model.DriftModeEnforce, "", "8080", errors.New("dbus: access denied"), model.DriftError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			template, expected := setupTemplate(t, tc.mode, tc.driftMode)
+			client := &fakeZoneClient{runtime: expected, permanent: expected, applyErr: tc.applyErr}
+			if tc.runtime != "" {
+				client.runtime = withPort(expected, tc.runtime)
+			}
+			if tc.permanent != "" {
+				client.permanent = withPort(expected, tc.permanent)
+			}
+			useZoneClient(t, func(context.Context, string) (zoneClient, error) { return client, nil })
+
+			if err := ReconcileTemplate(template); err != nil {
+				t.Fatal(err)
+			}
+			drift := &model.TemplateDrift{}
+			if err := model.DB.Where("template_id = ?", template.ID).First(drift).Error; err != nil {
+				t.Fatal(err)
+			}
+			if drift.Status != tc.status {
+				t.Fatalf("expected status %s, got %+v", tc.status, drift)
+			}
+			if drifted := tc.status != model.DriftInSync; drifted != strings.Contains(drift.Diff, "8080") {
+				t.Fatalf("unexpected diff %q", drift.Diff)
+			}
+
+			// enforce 时按上次下发的方式重新下发模板版本
+			remediated := tc.status == model.DriftRemediated || tc.status == model.DriftError
+			applied, set := client.applied, client.set
+			if tc.mode == model.TemplateModePermanent {
+				applied, set = set, applied
+			}
+			if set != nil || (applied != nil) != remediated || (remediated && !api.DiffSettings(expected, applied).IsEmpty()) {
+				t.Fatalf("unexpected remediation, runtime apply %+v, runtime set %+v", client.applied, client.set)
+			}
+			var applications []model.TemplateApplication
+			model.DB.Where("template_id = ? AND applied_by = ?", template.ID, appliedBy).Find(&applications)
+			if !remediated {
+				if len(applications) != 0 {
+					t.Fatalf("expected no application, got %+v", applications)
+				}
+				return
+			}
+			status := model.TemplateApplied
+			if tc.applyErr != nil {
+				status = model.TemplateFailed
+			}
+			if len(applications) != 1 || applications[0].Status != status || applications[0].Mode != tc.mode {
+				t.Fatalf("expected a %s application, got %+v", status, applications)
+			}
+		})
+	}
+}
+
+func TestReconcileTemplateUnreachable(t *testing.T) {
+	template, _ := setupTemplate(t, model.TemplateModeRuntime, model.DriftModeEnforce)
+	useZoneClient(t, func(context.Context, string) (zoneClient, error) {
+		return nil, errors.New("dial tcp 10.0.0.1:55556: connection refused")
+	})
+
+	if err := ReconcileTemplate(template); err != nil {
+		t.Fatal(err)
+	}
+	drift := &model.TemplateDrift{}
+	if err := model.DB.Where("template_id = ?", template.ID).First(drift).Error; err != nil {
+		t.Fatal(err)
+	}
+	if drift.Status != model.DriftError || !strings.Contains(drift.Error, "connection refused") {
+		t.Fatalf("expected error status, got %+v", drift)
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/cylonchau/firewalld-gateway/config"
 	"github.com/cylonchau/firewalld-gateway/server/app"
+	"github.com/cylonchau/firewalld-gateway/server/app/router"
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
 	"github.com/cylonchau/firewalld-gateway/utils/migration"
 	model2 "github.com/cylonchau/firewalld-gateway/utils/model"
 )
@@ -23,6 +25,11 @@ type Options struct {
 	errCh      chan error
 }
 
+func init() {
+	// 初始化路由表时需要服务注册的全部路由
+	migration.RegisterRouter = router.RegisteredRouter
+}
+
 func NewOptions() *Options {
 	return &Options{}
 }
@@ -63,14 +70,16 @@ run only host, docker, kubernetes.`,
 	fs.AddGoFlagSet(flag.CommandLine) // for --boot-id-file and --machine-id-file
 
 	_ = cmd.MarkFlagFilename("config", "yaml", "yml", "json")
+	cmd.AddCommand(newAuditCommand())
+	cmd.AddCommand(newMigrateCommand())
 
 	return cmd
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.ConfigFile, "config", "./firewalld-gateway.toml", "The path to the configuration file.")
-	fs.BoolVar(&o.migration, "migration", false, "Inital database and tables.")
-	fs.StringVar(&o.sqlDriver, "sql-driver", "", "enable which sql backend.")
+	fs.BoolVar(&o.migration, "migration", false, "Inital database and tables, the same as migrate up.")
+	fs.StringVar(&o.sqlDriver, "sql-driver", "", "enable which sql backend, mysql, postgres or sqlite.")
 
 }
 
@@ -100,10 +109,18 @@ func (o *Options) Run() error {
 		return migration.Migration(o.sqlDriver)
 	}
 
-	if !config.CONFIG.MySQL.IsEmpty() || !config.CONFIG.SQLite.IsEmpty() {
+	if !config.CONFIG.MySQL.IsEmpty() || !config.CONFIG.SQLite.IsEmpty() || !config.CONFIG.PostgreSQL.IsEmpty() {
 		if err := model2.InitDB(config.CONFIG.DatabaseDriver); err != nil {
 			return err
 		}
+		// 数据库版本与当前版本不一致时拒绝启动
+		if err := migration.CheckVersion(model2.DB); err != nil {
+			return err
+		}
+	}
+
+	if err := auther.LoadKeys(); err != nil {
+		return err
 	}
 
 	return app.NewHTTPSever()
@@ -0,0 +1,31 @@
+package query
+
+import "time"
+
+// AuditListQuery 审计日志的过滤条件，title 模糊匹配用户名，host 为操作的任意一个主机，path 为前缀匹配，since 与 until 为 RFC3339 时间
+type AuditListQuery struct {
+	ListQuery
+	UserID     uint      `form:"user_id" json:"user_id"`
+	TokenID    uint      `form:"token_id" json:"token_id"`
+	Host       string    `form:"host" json:"host"`
+	Zone       string    `form:"zone" json:"zone"`
+	Method     string    `form:"method" json:"method"`
+	Path       string    `form:"path" json:"path"`
+	Event      string    `form:"event" json:"event"`
+	Status     int       `form:"status" json:"status"`
+	Code       int       `form:"code" json:"code"`
+	Failed     bool      `form:"failed" json:"failed"`
+	MinLatency int64     `form:"min_latency" json:"min_latency"`
+	Since      time.Time `form:"since" json:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until      time.Time `form:"until" json:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// AuditExportQuery 导出审计日志，format 为 csv 或 jsonl，忽略分页参数
+type AuditExportQuery struct {
+	AuditListQuery
+	Format string `form:"format,default=jsonl" json:"format" binding:"oneof=csv jsonl"`
+}
+
+type AuditQuery struct {
+	ID uint `uri:"id" binding:"required"`
+}
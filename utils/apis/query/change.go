@@ -0,0 +1,16 @@
+package query
+
+type ChangeListQuery struct {
+	ListQuery
+	// Status 为空时返回全部状态的变更
+	Status      string `form:"status" json:"status" binding:"omitempty,oneof=pending approved rejected cancelled expired executed failed"`
+	RequesterID uint   `form:"requester_id" json:"requester_id"`
+}
+
+type ChangeQuery struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+type ChangeCommentQuery struct {
+	Comment string `form:"comment" json:"comment" binding:"omitempty,max=1024"`
+}
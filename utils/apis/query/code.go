@@ -55,9 +55,42 @@ var (
 	ErrCreatedUser           = &Errno{Code: 50114, Message: "用户创建失败"}
 	ErrDashboardFailed       = &Errno{Code: 50115, Message: "Get host status failed"}
 	ErrNoPermission          = &Errno{Code: 50116, Message: "user has no permission"}
+	ErrPasswordTooShort      = &Errno{Code: 50117, Message: "Password is too short"}
+	ErrPasswordTooSimple     = &Errno{Code: 50118, Message: "Password must contain more kinds of upper, lower, digit and symbol characters"}
+	ErrPasswordReused        = &Errno{Code: 50119, Message: "Password was used recently"}
+	ErrLocalLoginDisabled    = &Errno{Code: 50120, Message: "Local login is disabled"}
+	ErrOIDCDisabled          = &Errno{Code: 50121, Message: "OpenID Connect login is disabled"}
+	ErrOIDCLogin             = &Errno{Code: 50122, Message: "OpenID Connect login failed"}
+	ErrTokenExpired          = &Errno{Code: 50123, Message: "Token is expired"}
+	ErrTokenExpiry           = &Errno{Code: 50124, Message: "Token expiry must be in the future"}
+	ErrTokenScope            = &Errno{Code: 50125, Message: "Route scope must be \"METHOD /path\""}
+	ErrRefreshTokenInvalid   = &Errno{Code: 50126, Message: "Refresh token is invalid or expired"}
+	ErrRefreshTokenReused    = &Errno{Code: 50127, Message: "Refresh token was already used, the session is revoked"}
+	ErrSessionRevoked        = &Errno{Code: 50128, Message: "Session is revoked or expired"}
+	ErrSessionNotFound       = &Errno{Code: 50129, Message: "Session not found"}
+	ErrMFAEnabled            = &Errno{Code: 50130, Message: "MFA is already enabled"}
+	ErrMFANotEnrolled        = &Errno{Code: 50131, Message: "MFA is not enabled"}
+	ErrMFACode               = &Errno{Code: 50132, Message: "Invalid or used verification code"}
+	ErrMFAEnrollRequired     = &Errno{Code: 50133, Message: "MFA enrollment is required by your roles"}
+	ErrMFAStepUpRequired     = &Errno{Code: 50134, Message: "This operation requires a recent MFA verification"}
+	ErrMFAChallenge          = &Errno{Code: 50135, Message: "MFA challenge is invalid or expired"}
+	ErrLoginLocked           = &Errno{Code: 50136, Message: "Too many failed login attempts, try again later"}
+	ErrLockoutNotFound       = &Errno{Code: 50137, Message: "Lockout does not exist"}
+	ErrChangePending         = &Errno{Code: 50138, Message: "The operation requires approval, a change request is created"}
+	ErrChangeNotFound        = &Errno{Code: 50139, Message: "Change request does not exist"}
+	ErrChangeNotPending      = &Errno{Code: 50140, Message: "Change request is not pending"}
+	ErrChangeExpired         = &Errno{Code: 50141, Message: "Change request is expired"}
+	ErrChangeSelfApproval    = &Errno{Code: 50142, Message: "Change request cannot be approved by its requester"}
+	ErrChangeApprover        = &Errno{Code: 50143, Message: "You are not an approver of this change request"}
+	ErrChangeApproved        = &Errno{Code: 50144, Message: "You have already approved this change request"}
+	ErrAuditNotFound         = &Errno{Code: 50145, Message: "Audit log does not exist"}
+	ErrPruneRunning          = &Errno{Code: 50146, Message: "Audit pruning is already running"}
+	ErrMFAStepUpEnroll       = &Errno{Code: 50147, Message: "This operation requires MFA, enroll MFA first"}
+	ErrMFAStepUpToken        = &Errno{Code: 50148, Message: "API tokens cannot perform operations that require MFA"}
 
 	// routers
 	ErrRouterIsEmpty = &Errno{Code: 60004, Message: "Router is empty"}
+	ErrRouterExist   = &Errno{Code: 60006, Message: "Router does exist"}
 
 	// batch
 	BatchSuccessCreated = &Errno{Code: 70000, Message: "The batch mission has created"}
@@ -43,6 +43,16 @@ func AuthNoPermission(ctx *gin.Context, msg *Errno) {
 	})
 }
 
+// API202Response 请求已接受但尚未执行
+func API202Response(ctx *gin.Context, err error, data interface{}) {
+	returnCode, message := DecodeErr(err)
+	ctx.JSON(http.StatusAccepted, Response{
+		Code: returnCode,
+		Msg:  message,
+		Data: data,
+	})
+}
+
 // APIResponse ....
 func APIResponse(ctx *gin.Context, err error, data interface{}) {
 	returnCode, message := DecodeErr(err)
@@ -5,4 +5,18 @@ type RoleEditQuery struct {
 	Name        string `form:"name" json:"name,omitempty" binding:"required"`
 	Description string `form:"description" json:"description"`
 	RouterIDs   []int  `json:"router_ids" form:"router_ids"`
+	// Scopes 为 nil 时更新角色不修改 scope，为空数组时清除 scope
+	Scopes []RoleScopeQuery `json:"scopes" form:"scopes" binding:"omitempty,dive"`
+	// RequireMFA 为 nil 时更新角色不修改
+	RequireMFA *bool `json:"require_mfa,omitempty" form:"require_mfa"`
+}
+
+type RoleScopeQuery struct {
+	Kind  string `json:"kind" form:"kind" binding:"required,oneof=host tag"`
+	Value string `json:"value" form:"value" binding:"required"`
+}
+
+type RouterQuery struct {
+	Path   string `json:"path" form:"path" binding:"required,startswith=/"`
+	Method string `json:"method" form:"method" binding:"required,oneof=GET POST PUT DELETE PATCH *"`
 }
@@ -5,6 +5,7 @@ type TemplateEditQuery struct {
 	Description string `form:"description" json:"description"`
 	Target      string `form:"target" json:"target"`
 	ID          uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message     string `form:"message" json:"message,omitempty"`
 }
 
 type PortEditQuery struct {
@@ -12,6 +13,7 @@ type PortEditQuery struct {
 	Protocol   string `form:"protocol" json:"protocol" binding:"required"`
 	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
 	ID         uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message    string `form:"message" json:"message,omitempty"`
 }
 
 type RichEditQuery struct {
@@ -25,4 +27,110 @@ type RichEditQuery struct {
 	LimitUnit   string `form:"limit_unit" json:"limit_unit,omitempty" binding:"omitempty"`
 	TemplateId  int    `form:"template_id" json:"template_id" binding:"required"`
 	ID          uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message     string `form:"message" json:"message,omitempty"`
+}
+
+type TemplateRevisionQuery struct {
+	ID       uint `uri:"id" json:"id" binding:"required"`
+	Revision int  `uri:"revision" json:"revision" binding:"required"`
+}
+
+type TemplateRevisionDiffQuery struct {
+	From int `form:"from" json:"from" binding:"required"`
+	To   int `form:"to" json:"to" binding:"required"`
+}
+
+type TemplateDeleteQuery struct {
+	ID      uint64 `form:"id" json:"id,omitempty" binding:"required"`
+	Message string `form:"message" json:"message,omitempty"`
+}
+
+type ServiceEditQuery struct {
+	Name       string `form:"name" json:"name" binding:"required"`
+	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
+	ID         uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message    string `form:"message" json:"message,omitempty"`
+}
+
+type ForwardPortEditQuery struct {
+	Port       string `form:"port" json:"port" binding:"required"`
+	Protocol   string `form:"protocol" json:"protocol" binding:"required"`
+	ToPort     string `form:"toport" json:"toport,omitempty" binding:"omitempty"`
+	ToAddr     string `form:"toaddr" json:"toaddr,omitempty" binding:"omitempty"`
+	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
+	ID         uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message    string `form:"message" json:"message,omitempty"`
+}
+
+type IcmpBlockEditQuery struct {
+	Name       string `form:"name" json:"name" binding:"required"`
+	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
+	ID         uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message    string `form:"message" json:"message,omitempty"`
+}
+
+type SourceEditQuery struct {
+	Address    string `form:"address" json:"address,omitempty" binding:"required_without_all=Mac Ipset"`
+	Mac        string `form:"mac" json:"mac,omitempty" binding:"omitempty"`
+	Ipset      string `form:"ipset" json:"ipset,omitempty" binding:"omitempty"`
+	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
+	ID         uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message    string `form:"message" json:"message,omitempty"`
+}
+
+type ProtocolEditQuery struct {
+	Value      string `form:"value" json:"value" binding:"required"`
+	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
+	ID         uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message    string `form:"message" json:"message,omitempty"`
+}
+
+type InterfaceEditQuery struct {
+	Name       string `form:"name" json:"name" binding:"required"`
+	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
+	ID         uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	Message    string `form:"message" json:"message,omitempty"`
+}
+
+type MasqueradeEditQuery struct {
+	Enable     *bool  `form:"enable" json:"enable" binding:"required"`
+	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
+	Message    string `form:"message" json:"message,omitempty"`
+}
+
+type TemplateDriftQuery struct {
+	TemplateID uint   `form:"template_id" json:"template_id"`
+	Status     string `form:"status" json:"status" binding:"omitempty,oneof=in_sync drifted remediated error"`
+}
+
+type DriftModeEditQuery struct {
+	TemplateId int    `form:"template_id" json:"template_id" binding:"required"`
+	Mode       string `form:"mode" json:"mode" binding:"required,oneof=off audit enforce"`
+}
+
+type TemplateApplyQuery struct {
+	Revision        int    `form:"revision" json:"revision,omitempty"`
+	Mode            string `form:"mode" json:"mode,omitempty" binding:"omitempty,oneof=permanent runtime"`
+	ContinueOnError bool   `form:"continue_on_error" json:"continue_on_error,omitempty"`
+	HostIDs         []uint `form:"host_ids" json:"host_ids,omitempty"`
+}
+
+type TemplateJobQuery struct {
+	ID uint `uri:"job" json:"id" binding:"required"`
+}
+
+type TemplateImportQuery struct {
+	Name    string `form:"name" json:"name,omitempty"`
+	Message string `form:"message" json:"message,omitempty"`
+}
+
+type TemplateExportQuery struct {
+	Revision int `form:"revision" json:"revision,omitempty"`
+}
+
+type TemplateCaptureQuery struct {
+	HostID  uint   `form:"host_id" json:"host_id" binding:"required"`
+	Name    string `form:"name" json:"name" binding:"required"`
+	Zone    string `form:"zone" json:"zone,omitempty"`
+	Message string `form:"message" json:"message,omitempty"`
 }
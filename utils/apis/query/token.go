@@ -1,8 +1,22 @@
 package query
 
+import "time"
+
 type TokenEditQuery struct {
-	Description string `json:"description,omitempty" binding:"omitempty"`
-	SignedTo    string `json:"signed_to,omitempty" binding:"omitempty"`
-	IsUpdate    bool   `json:"is_update,omitempty" binding:"omitempty"`
-	ID          uint64 `form:"id" json:"id,omitempty" binding:"omitempty"`
+	// Description 为 nil 时更新 token 不修改描述
+	Description *string `json:"description,omitempty" binding:"omitempty"`
+	SignedTo    string  `json:"signed_to,omitempty" binding:"omitempty"`
+	IsUpdate    bool    `json:"is_update,omitempty" binding:"omitempty"`
+	ID          uint64  `form:"id" json:"id,omitempty" binding:"omitempty"`
+	// ExpiresAt 为空时 token 不过期
+	ExpiresAt *time.Time `json:"expires_at,omitempty" binding:"omitempty"`
+	// RoleIDs 与 Scopes 为 nil 时更新 token 不修改权限
+	RoleIDs []int             `json:"role_ids,omitempty" binding:"omitempty"`
+	Scopes  []TokenScopeQuery `json:"scopes,omitempty" binding:"omitempty,dive"`
+}
+
+// TokenScopeQuery route 的 value 为 "METHOD /path"，host 与 tag 限制 token 可以操作的主机
+type TokenScopeQuery struct {
+	Kind  string `json:"kind" form:"kind" binding:"required,oneof=route host tag"`
+	Value string `json:"value" form:"value" binding:"required"`
 }
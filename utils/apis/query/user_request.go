@@ -23,6 +23,46 @@ type UserResp struct {
 	IsPrivileged bool     `form:"is_privileged" json:"is_privileged,omitempty" binding:"required"`
 	Roles        []string `form:"roles" json:"roles,omitempty" binding:"required"`
 	LoginIP      string   `form:"login_ip" json:"login_ip,omitempty"`
+	RefreshToken string   `form:"refresh_token" json:"refresh_token,omitempty"`
+	// ExpiresIn access token 的有效期(秒)
+	ExpiresIn int `form:"expires_in" json:"expires_in,omitempty"`
+	// MFARequired 为 true 时需要使用 MFAToken 与验证码调用 /sso/signin/mfa 完成登录
+	MFARequired bool   `form:"mfa_required" json:"mfa_required,omitempty"`
+	MFAToken    string `form:"mfa_token" json:"mfa_token,omitempty"`
+	// MFAEnrollmentRequired 用户的角色要求二次认证但用户尚未绑定
+	MFAEnrollmentRequired bool `form:"mfa_enrollment_required" json:"mfa_enrollment_required,omitempty"`
+}
+
+type MFASigninQuery struct {
+	MFAToken string `form:"mfa_token" json:"mfa_token" binding:"required"`
+	Code     string `form:"code" json:"code" binding:"required"`
+}
+
+type MFACodeQuery struct {
+	// Code TOTP 验证码或恢复码
+	Code string `form:"code" json:"code" binding:"required"`
+}
+
+type MFAResetQuery struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+type RefreshQuery struct {
+	RefreshToken string `form:"refresh_token" json:"refresh_token" binding:"required"`
+}
+
+type LogoutQuery struct {
+	RefreshToken string `form:"refresh_token" json:"refresh_token,omitempty"`
+}
+
+type SessionQuery struct {
+	ID      uint `uri:"id" binding:"required"`
+	Session uint `uri:"session" binding:"omitempty"`
+}
+
+type SessionListQuery struct {
+	// All 为 true 时同时返回已注销与已过期的会话
+	All bool `form:"all" json:"all"`
 }
 
 type UserEditQuery struct {
@@ -40,3 +80,19 @@ type InfoResp struct {
 	Username string `form:"username" json:"username" binding:"required"`
 	UserRole string `form:"role" json:"role" binding:"required"`
 }
+
+type OIDCCallbackQuery struct {
+	Code             string `form:"code" json:"code"`
+	State            string `form:"state" json:"state" binding:"required"`
+	Error            string `form:"error" json:"error"`
+	ErrorDescription string `form:"error_description" json:"error_description"`
+}
+
+type LockoutListQuery struct {
+	// All 为 true 时同时返回有失败计数但未锁定的记录
+	All bool `form:"all" json:"all"`
+}
+
+type LockoutQuery struct {
+	ID uint `uri:"id" binding:"required"`
+}
@@ -0,0 +1,34 @@
+package auther
+
+import (
+	"errors"
+)
+
+// SignCheckpoint 使用审计 checkpoint 密钥签名，返回密钥 id 与签名
+func SignCheckpoint(content string) (string, string, error) {
+	keys.RLock()
+	key := keys.checkpoint
+	keys.RUnlock()
+	if key == nil {
+		return "", "", errors.New("audit checkpoint key is not loaded")
+	}
+	signature, err := key.method.Sign(content, key.private)
+	if err != nil {
+		return "", "", err
+	}
+	return key.id, signature, nil
+}
+
+// VerifyCheckpoint 校验 checkpoint 的签名，kid 为当前的 checkpoint 密钥或 jwt 的校验密钥
+func VerifyCheckpoint(kid, content, signature string) error {
+	keys.RLock()
+	key := keys.verify[kid]
+	if keys.checkpoint != nil && keys.checkpoint.id == kid {
+		key = keys.checkpoint
+	}
+	keys.RUnlock()
+	if key == nil {
+		return errors.New("unknown checkpoint key " + kid)
+	}
+	return key.method.Verify(content, signature, key.public)
+}
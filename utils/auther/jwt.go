@@ -9,19 +9,25 @@ import (
 	"github.com/cylonchau/firewalld-gateway/config"
 )
 
-const UserIDKey = "userID"
+const (
+	UserIDKey = "userID"
+	// TokenIDKey 使用 API token 请求时保存 token id
+	TokenIDKey = "tokenID"
+	// SessionIDKey 使用登录会话请求时保存会话 key
+	SessionIDKey = "sessionID"
 
-var jsonSecret = []byte("jwt")
-
-func keyFunc(_ *jwt.Token) (i interface{}, err error) {
-	return jsonSecret, nil
-}
+	mfaPurpose = "mfa"
+	// MFAChallengeTTL 二次认证凭证的有效期
+	MFAChallengeTTL = 5 * time.Minute
+)
 
 // jwt包自带的jwt.StandardClaims只包含了官方字段，若需要额外记录其他字段，就可以自定义结构体
 // 如果想要保存更多信息，都可以添加到这个结构体中
 
 type Token struct {
 	UserID int64 `json:"user_id"`
+	// SessionID 签发 token 的登录会话，会话被注销后 token 立即失效
+	SessionID string `json:"sid"`
 	jwt.StandardClaims
 }
 
@@ -30,19 +36,58 @@ type PerToken struct {
 	jwt.StandardClaims
 }
 
-func GenToken(userID int64) (string, error) {
+func GenToken(userID int64, sessionID string) (string, error) {
 	// 创建一个我们自己的声明的数据
 	c := Token{
 		userID,
+		sessionID,
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * 30000).Unix(), // 过期时间
-			Issuer:    config.CONFIG.AppName,                      // 签发人
+			ExpiresAt: time.Now().Add(AccessTTL()).Unix(), // 过期时间
+			IssuedAt:  time.Now().Unix(),
+			Issuer:    config.CONFIG.AppName, // 签发人
 		},
 	}
-	// 使用指定的签名方法创建签名对象
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
-	// 使用指定的secret签名并获得完整的编码后的字符串token
-	return token.SignedString(jsonSecret)
+	// 使用当前的签发密钥签名并获得完整的编码后的字符串token
+	return sign(c)
+}
+
+// MFAChallenge 密码校验通过后等待二次认证的凭证，不能用于访问接口
+type MFAChallenge struct {
+	UserID  int64  `json:"user_id"`
+	Purpose string `json:"purpose"`
+	jwt.StandardClaims
+}
+
+// GenMFAChallenge 签发二次认证的凭证，challengeID 为数据库中记录的 challenge，凭证只能兑换一次会话
+func GenMFAChallenge(userID int64, challengeID string) (string, error) {
+	return sign(MFAChallenge{
+		userID,
+		mfaPurpose,
+		jwt.StandardClaims{
+			Id:        challengeID,
+			ExpiresAt: time.Now().Add(MFAChallengeTTL).Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Issuer:    config.CONFIG.AppName,
+		},
+	})
+}
+
+// ParseMFAChallenge 解析二次认证的凭证，返回用户 id 与 challenge id
+func ParseMFAChallenge(tokenString string) (int64, string, error) {
+	claims := &MFAChallenge{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
+	if err != nil {
+		return 0, "", err
+	}
+	if !token.Valid || claims.Purpose != mfaPurpose || claims.Id == "" {
+		return 0, "", errors.New("invalid mfa challenge")
+	}
+	return claims.UserID, claims.Id, nil
+}
+
+// AccessTTL access token 的有效期
+func AccessTTL() time.Duration {
+	return time.Duration(config.CONFIG.Session.AccessTTL) * time.Second
 }
 
 func SignPermanentToken(signBy string) (string, error) {
@@ -54,21 +99,14 @@ func SignPermanentToken(signBy string) (string, error) {
 			Issuer:   config.CONFIG.AppName, // 签发人
 		},
 	}
-	// 使用指定的签名方法创建签名对象
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// 使用指定的secret签名并获得完整的编码后的字符串token
-	return token.SignedString(jsonSecret)
+	// 使用当前的签发密钥签名并获得完整的编码后的字符串token
+	return sign(claims)
 }
 
 func GetInfo(token string) (int64, error) {
 	var enconterError error
 	var tokenOk *jwt.Token
-	tokenOk, enconterError = jwt.ParseWithClaims(token, &Token{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return jsonSecret, nil
-	})
+	tokenOk, enconterError = jwt.ParseWithClaims(token, &Token{}, keyFunc)
 	if enconterError == nil {
 		if claims, ok := tokenOk.Claims.(*Token); ok && tokenOk.Valid {
 			return claims.UserID, nil
@@ -91,22 +129,3 @@ func ParseToken(tokenString string) (*Token, error) {
 	}
 	return nil, errors.New("invalid token")
 }
-
-// RefreshToken 刷新AccessToken
-func RefreshToken(accessToken, refreshToken string) (newAToken, newRToken string, err error) {
-	// refresh token无效直接返回
-	var encounterError error
-	if _, encounterError = jwt.Parse(refreshToken, keyFunc); encounterError == nil {
-		// 从旧access token中解析出claims数据
-		var claims Token
-		if _, encounterError = jwt.ParseWithClaims(accessToken, &claims, keyFunc); encounterError == nil {
-			v, _ := err.(*jwt.ValidationError)
-			// 当 access token是过期错误 并且 refresh token没有过期时就创建一个新的access middlewares
-			if v.Errors == jwt.ValidationErrorExpired {
-				token, _ := GenToken(claims.UserID)
-				return token, "", nil
-			}
-		}
-	}
-	return "", "", encounterError
-}
@@ -0,0 +1,266 @@
+package auther
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+// signingKey 一个用于签发或校验 token 的密钥，HMAC 的 private 与 public 均为 secret
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+type keySet struct {
+	sync.RWMutex
+	signing *signingKey
+	verify  map[string]*signingKey
+	// checkpoint 签名审计日志 checkpoint 的密钥
+	checkpoint *signingKey
+}
+
+var keys = &keySet{verify: make(map[string]*signingKey)}
+
+// JWK json web key，只包含公钥部分
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// LoadKeys 根据配置加载签发密钥与校验密钥，重复调用会替换已加载的密钥
+func LoadKeys() error {
+	cfg := config.CONFIG.JWT
+	signing, err := loadKey(cfg.Algorithm, cfg.KeyID, cfg.Secret, cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load jwt signing key: %w", err)
+	}
+	if signing.private == nil {
+		return errors.New("load jwt signing key: key_file must contain a private key")
+	}
+	verify := map[string]*signingKey{signing.id: signing}
+	for _, v := range cfg.VerifyKeys {
+		if v.Secret == "" && v.KeyFile == "" {
+			return fmt.Errorf("load jwt verify key %s: secret or key_file is required", v.KeyID)
+		}
+		key, err := loadKey(v.Algorithm, v.KeyID, v.Secret, v.KeyFile)
+		if err != nil {
+			return fmt.Errorf("load jwt verify key %s: %w", v.KeyID, err)
+		}
+		if _, ok := verify[key.id]; ok {
+			return fmt.Errorf("duplicate jwt key id %s", key.id)
+		}
+		verify[key.id] = key
+	}
+
+	// checkpoint 的签名需要在重启后与其他副本上校验，必须单独配置，不使用 jwt 的密钥
+	checkpointKey := config.CONFIG.Audit.CheckpointKey
+	if checkpointKey.Algorithm == "" {
+		return errors.New("load audit checkpoint key: audit.checkpoint_key is required")
+	}
+	checkpoint, err := loadKey(checkpointKey.Algorithm, checkpointKey.KeyID, checkpointKey.Secret, checkpointKey.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load audit checkpoint key: %w", err)
+	}
+	if checkpoint.private == nil {
+		return errors.New("load audit checkpoint key: key_file must contain a private key")
+	}
+	if _, ok := verify[checkpoint.id]; ok {
+		return errors.New("load audit checkpoint key: the key must differ from the jwt keys")
+	}
+
+	keys.Lock()
+	defer keys.Unlock()
+	keys.signing, keys.verify, keys.checkpoint = signing, verify, checkpoint
+	return nil
+}
+
+func loadKey(algorithm, kid, secret, keyFile string) (*signingKey, error) {
+	key := &signingKey{id: kid, method: jwt.GetSigningMethod(algorithm)}
+	switch key.method.(type) {
+	case *jwt.SigningMethodHMAC:
+		switch {
+		case secret != "":
+			key.private = []byte(secret)
+		case keyFile != "":
+			content, err := os.ReadFile(keyFile)
+			if err != nil {
+				return nil, err
+			}
+			key.private = content
+		default:
+			// 随机生成的密钥在重启后失效，多副本之间也无法互相校验 token，必须配置
+			return nil, fmt.Errorf("secret or key_file is required by %s", algorithm)
+		}
+		key.public = key.private
+		if key.id == "" {
+			sum := sha256.Sum256(key.private.([]byte))
+			key.id = base64.RawURLEncoding.EncodeToString(sum[:8])
+		}
+		return key, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm %s", algorithm)
+	}
+
+	if keyFile == "" {
+		return nil, fmt.Errorf("key_file is required by %s", algorithm)
+	}
+	content, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if key.private, key.public, err = parsePEMKey(content); err != nil {
+		return nil, err
+	}
+	if err = checkKeyType(key); err != nil {
+		return nil, err
+	}
+	if key.id == "" {
+		der, err := x509.MarshalPKIXPublicKey(key.public)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(der)
+		key.id = base64.RawURLEncoding.EncodeToString(sum[:8])
+	}
+	return key, nil
+}
+
+// parsePEMKey 解析 PEM 格式的私钥或公钥，公钥文件只能用于校验
+func parsePEMKey(content []byte) (private, public interface{}, err error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, nil, errors.New("invalid PEM key")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		return nil, public, err
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+		return nil, public, err
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block %s", block.Type)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.New("unsupported private key")
+	}
+	return private, signer.Public(), nil
+}
+
+// checkKeyType 确保密钥类型与签名算法一致
+func checkKeyType(key *signingKey) error {
+	switch key.method.(type) {
+	case *jwt.SigningMethodRSA:
+		if _, ok := key.public.(*rsa.PublicKey); ok {
+			return nil
+		}
+	case *jwt.SigningMethodECDSA:
+		if public, ok := key.public.(*ecdsa.PublicKey); ok {
+			if public.Curve != elliptic.P256() {
+				return errors.New("ES256 requires a P-256 key")
+			}
+			return nil
+		}
+	case *jwt.SigningMethodEd25519:
+		if _, ok := key.public.(ed25519.PublicKey); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("key does not match algorithm %s", key.method.Alg())
+}
+
+// sign 使用当前的签发密钥签名，并在 header 中写入 kid
+func sign(claims jwt.Claims) (string, error) {
+	keys.RLock()
+	signing := keys.signing
+	keys.RUnlock()
+	if signing == nil {
+		return "", errors.New("jwt signing key is not loaded")
+	}
+	token := jwt.NewWithClaims(signing.method, claims)
+	token.Header["kid"] = signing.id
+	return token.SignedString(signing.private)
+}
+
+// keyFunc 根据 kid 查找校验密钥，没有 kid 的 token 使用当前的签发密钥，算法必须与密钥一致
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	keys.RLock()
+	defer keys.RUnlock()
+	key := keys.signing
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = keys.verify[kid]
+	}
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+// JWKS 返回所有非对称校验密钥的公钥，HMAC 密钥不会公开
+func JWKS() []JWK {
+	keys.RLock()
+	defer keys.RUnlock()
+	list := []JWK{}
+	for _, key := range keys.verify {
+		jwk := JWK{Kid: key.id, Alg: key.method.Alg(), Use: "sig"}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty, jwk.Crv = "EC", public.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		list = append(list, jwk)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Kid < list[j].Kid })
+	return list
+}
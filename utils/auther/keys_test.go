@@ -0,0 +1,332 @@
+package auther
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+const checkpointSection = `
+[audit.checkpoint_key]
+algorithm = "HS256"
+key_id = "checkpoint"
+secret = "checkpoint-test-secret"
+`
+
+// testKey 测试使用的密钥，HS256 只有 secret，其他算法的私钥与公钥保存在 file 与 public 中
+type testKey struct {
+	alg     string
+	secret  string
+	file    string
+	public  string
+	private crypto.Signer
+}
+
+// generateKey 在 dir 中生成 alg 的密钥文件
+func generateKey(t *testing.T, dir, alg, name string) *testKey {
+	t.Helper()
+	key := &testKey{alg: alg}
+	var (
+		block *pem.Block
+		err   error
+	)
+	switch alg {
+	case "HS256":
+		key.secret = name + "-test-secret"
+		return key
+	case "RS256":
+		private, _ := rsa.GenerateKey(rand.Reader, 2048)
+		key.private, block = private, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(private)}
+	case "ES256":
+		private, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		key.private, block = private, &pem.Block{Type: "EC PRIVATE KEY"}
+		block.Bytes, err = x509.MarshalECPrivateKey(private)
+	case "P-384":
+		// 曲线不是 P-256 的 ES256 密钥
+		private, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		key.alg, key.private, block = "ES256", private, &pem.Block{Type: "EC PRIVATE KEY"}
+		block.Bytes, err = x509.MarshalECPrivateKey(private)
+	case "EdDSA":
+		_, private, _ := ed25519.GenerateKey(rand.Reader)
+		key.private, block = private, &pem.Block{Type: "PRIVATE KEY"}
+		block.Bytes, err = x509.MarshalPKCS8PrivateKey(private)
+	default:
+		t.Fatalf("unsupported algorithm %s", alg)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.file, key.public = filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".pub")
+	if err = os.WriteFile(key.file, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(key.public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// section 返回密钥的 toml 配置，public 为 true 时只配置公钥文件
+func (k *testKey) section(table, kid string, public bool) string {
+	content := fmt.Sprintf("\n%s\nalgorithm = %q\nkey_id = %q\n", table, k.alg, kid)
+	switch {
+	case k.secret != "":
+		return content + fmt.Sprintf("secret = %q\n", k.secret)
+	case public:
+		return content + fmt.Sprintf("key_file = %q\n", k.public)
+	}
+	return content + fmt.Sprintf("key_file = %q\n", k.file)
+}
+
+// loadKeys 使用 content 初始化配置并加载密钥
+func loadKeys(t *testing.T, content string) error {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "firewalld-gateway.toml")
+	if err := os.WriteFile(file, []byte("appname = \"test\"\n"+content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitConfiguration(file); err != nil {
+		t.Fatal(err)
+	}
+	return LoadKeys()
+}
+
+func mustLoadKeys(t *testing.T, content string) {
+	t.Helper()
+	if err := loadKeys(t, content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// forge 使用任意的算法、kid 与密钥签名 token，kid 为空时 header 中没有 kid
+func forge(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, Token{UserID: 1, StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()}})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	hs := generateKey(t, dir, "HS256", "hs")
+	rs := generateKey(t, dir, "RS256", "rs")
+	es := generateKey(t, dir, "ES256", "es")
+	ed := generateKey(t, dir, "EdDSA", "ed")
+	p384 := generateKey(t, dir, "P-384", "p384")
+	hsFile := filepath.Join(dir, "hs.key")
+	if err := os.WriteFile(hsFile, []byte("file-test-secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"HS256 secret", hs.section("[jwt]", "", false) + checkpointSection, ""},
+		{"HS256 key file", fmt.Sprintf("[jwt]\nkey_file = %q\n", hsFile) + checkpointSection, ""},
+		{"RS256", rs.section("[jwt]", "", false) + checkpointSection, ""},
+		{"ES256", es.section("[jwt]", "", false) + checkpointSection, ""},
+		{"EdDSA", ed.section("[jwt]", "", false) + checkpointSection, ""},
+		{"public verify keys", ed.section("[jwt]", "new", false) + rs.section("[[jwt.verify_keys]]", "rs", true) +
+			es.section("[[jwt.verify_keys]]", "es", true) + checkpointSection, ""},
+		{"asymmetric checkpoint key", hs.section("[jwt]", "", false) + ed.section("[audit.checkpoint_key]", "", false), ""},
+		// 不配置时不能使用随机生成的密钥
+		{"HS256 without secret", "[jwt]\nalgorithm = \"HS256\"\n" + checkpointSection, "secret or key_file is required by HS256"},
+		{"RS256 without key file", "[jwt]\nalgorithm = \"RS256\"\n" + checkpointSection, "key_file is required by RS256"},
+		{"unsupported algorithm", "[jwt]\nalgorithm = \"none\"\nsecret = \"x\"\n" + checkpointSection, "unsupported jwt algorithm none"},
+		{"key of other algorithm", fmt.Sprintf("[jwt]\nalgorithm = \"RS256\"\nkey_file = %q\n", es.file) + checkpointSection, "key does not match algorithm RS256"},
+		{"EdDSA key as ES256", fmt.Sprintf("[jwt]\nalgorithm = \"ES256\"\nkey_file = %q\n", ed.file) + checkpointSection, "key does not match algorithm ES256"},
+		{"P-384 key", p384.section("[jwt]", "", false) + checkpointSection, "ES256 requires a P-256 key"},
+		{"public signing key", rs.section("[jwt]", "", true) + checkpointSection, "key_file must contain a private key"},
+		{"verify key without key", hs.section("[jwt]", "", false) + "\n[[jwt.verify_keys]]\nalgorithm = \"HS256\"\nkey_id = \"old\"\n" + checkpointSection,
+			"load jwt verify key old: secret or key_file is required"},
+		{"duplicate key id", hs.section("[jwt]", "same", false) + rs.section("[[jwt.verify_keys]]", "same", true) + checkpointSection, "duplicate jwt key id same"},
+		{"without checkpoint key", hs.section("[jwt]", "", false), "audit.checkpoint_key is required"},
+		{"public checkpoint key", hs.section("[jwt]", "", false) + ed.section("[audit.checkpoint_key]", "", true), "key_file must contain a private key"},
+		{"checkpoint key of jwt", hs.section("[jwt]", "", false) + hs.section("[audit.checkpoint_key]", "", false), "the key must differ from the jwt keys"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := loadKeys(t, tc.content)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestKeyID(t *testing.T) {
+	dir := t.TempDir()
+	for _, alg := range []string{"HS256", "RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			key := generateKey(t, dir, alg, alg)
+			mustLoadKeys(t, key.section("[jwt]", "", false)+checkpointSection)
+			derived := keys.signing.id
+			if derived == "" {
+				t.Fatal("expected a derived key id")
+			}
+			// 未配置 key_id 时由密钥计算，私钥与公钥得到相同的 kid，重启后不变
+			if alg != "HS256" {
+				mustLoadKeys(t, generateKey(t, dir, alg, alg+"-new").section("[jwt]", "new", false)+
+					key.section("[[jwt.verify_keys]]", "", true)+checkpointSection)
+				if _, ok := keys.verify[derived]; !ok {
+					t.Fatalf("expected verify key %s, got %v", derived, keys.verify)
+				}
+			}
+			mustLoadKeys(t, key.section("[jwt]", "", false)+checkpointSection)
+			if keys.signing.id != derived {
+				t.Fatalf("expected key id %s, got %s", derived, keys.signing.id)
+			}
+
+			token, err := GenToken(1, "session")
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, _ := jwt.Parse(token, keyFunc)
+			if parsed == nil || parsed.Header["kid"] != derived || parsed.Header["alg"] != alg {
+				t.Fatalf("expected kid %s and alg %s, got %+v", derived, alg, parsed)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	for _, alg := range []string{"HS256", "RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			old, current := generateKey(t, dir, alg, alg+"-old"), generateKey(t, dir, alg, alg+"-current")
+			mustLoadKeys(t, old.section("[jwt]", "old", false)+checkpointSection)
+			oldToken, err := GenToken(1, "session")
+			if err != nil {
+				t.Fatal(err)
+			}
+			oldPermanent, err := SignPermanentToken("admin")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 轮换期间旧的密钥只用于校验，非对称算法只需要公钥
+			mustLoadKeys(t, current.section("[jwt]", "current", false)+old.section("[[jwt.verify_keys]]", "old", true)+checkpointSection)
+			if claims, err := ParseToken(oldToken); err != nil || claims.UserID != 1 {
+				t.Fatalf("expected the token of the old key to be valid, got %+v %v", claims, err)
+			}
+			if _, err = jwt.ParseWithClaims(oldPermanent, &PerToken{}, keyFunc); err != nil {
+				t.Fatalf("expected the permanent token of the old key to be valid, got %v", err)
+			}
+			newToken, err := GenToken(2, "session")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claims, err := ParseToken(newToken); err != nil || claims.UserID != 2 {
+				t.Fatalf("expected the token of the current key to be valid, got %+v %v", claims, err)
+			}
+			if parsed, _ := jwt.Parse(newToken, keyFunc); parsed.Header["kid"] != "current" {
+				t.Fatalf("expected kid current, got %v", parsed.Header["kid"])
+			}
+			jwks := JWKS()
+			if alg == "HS256" && len(jwks) != 0 {
+				t.Fatalf("HMAC keys must not be published, got %+v", jwks)
+			}
+			if alg != "HS256" && (len(jwks) != 2 || jwks[0].Kid != "current" || jwks[1].Kid != "old" || jwks[1].Alg != alg) {
+				t.Fatalf("expected jwks of current and old keys, got %+v", jwks)
+			}
+
+			// 签名与 kid 不一致，以及没有 kid 时只使用当前的签发密钥
+			oldSigning := interface{}(old.private)
+			if alg == "HS256" {
+				oldSigning = []byte(old.secret)
+			}
+			method := jwt.GetSigningMethod(alg)
+			if _, err = ParseToken(forge(t, method, "current", oldSigning)); err == nil {
+				t.Fatal("expected the old key signing with kid current to be rejected")
+			}
+			if _, err = ParseToken(forge(t, method, "", oldSigning)); err == nil {
+				t.Fatal("expected the old key signing without kid to be rejected")
+			}
+			if _, err = ParseToken(forge(t, method, "other", oldSigning)); err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+				t.Fatalf("expected unknown signing key, got %v", err)
+			}
+
+			// 轮换结束后旧的 token 失效
+			mustLoadKeys(t, current.section("[jwt]", "current", false)+checkpointSection)
+			if _, err = ParseToken(oldToken); err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+				t.Fatalf("expected unknown signing key after rotation, got %v", err)
+			}
+			if _, err = ParseToken(newToken); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestKeyFuncRejectsAlgorithmMismatch(t *testing.T) {
+	dir := t.TempDir()
+	for _, alg := range []string{"RS256", "ES256", "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			key := generateKey(t, dir, alg, alg)
+			mustLoadKeys(t, key.section("[jwt]", "asymmetric", false)+checkpointSection)
+			public, err := os.ReadFile(key.public)
+			if err != nil {
+				t.Fatal(err)
+			}
+			der, err := x509.MarshalPKIXPublicKey(key.private.Public())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 使用公开的公钥作为 HMAC 密钥伪造 token
+			for name, token := range map[string]string{
+				"pem":         forge(t, jwt.SigningMethodHS256, "asymmetric", public),
+				"der":         forge(t, jwt.SigningMethodHS256, "asymmetric", der),
+				"without kid": forge(t, jwt.SigningMethodHS256, "", public),
+			} {
+				if _, err = ParseToken(token); err == nil || !strings.Contains(err.Error(), "unexpected signing method") {
+					t.Fatalf("%s: expected unexpected signing method, got %v", name, err)
+				}
+			}
+		})
+	}
+
+	// 其他非对称算法的 token 使用 HMAC 的 kid
+	hs := generateKey(t, dir, "HS256", "hs")
+	mustLoadKeys(t, hs.section("[jwt]", "hmac", false)+checkpointSection)
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := ParseToken(forge(t, jwt.SigningMethodEdDSA, "hmac", private)); err == nil || !strings.Contains(err.Error(), "unexpected signing method") {
+		t.Fatalf("expected unexpected signing method, got %v", err)
+	}
+	if _, err := ParseToken(forge(t, jwt.SigningMethodHS512, "hmac", []byte(hs.secret))); err == nil || !strings.Contains(err.Error(), "unexpected signing method") {
+		t.Fatalf("expected unexpected signing method of HS512, got %v", err)
+	}
+}
@@ -36,7 +36,7 @@ func (c *DbusClientSerivce) Listforwards(zone string) ([]api2.ForwardPort, error
 
 	c.printPath(api2.ZONE_GETFORWARDPORT)
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
-	call := obj.Call(api2.ZONE_GETFORWARDPORT, dbus.FlagNoAutoStart, zone)
+	call := c.call(obj, api2.ZONE_GETFORWARDPORT, zone)
 
 	c.eventLogFormat.encounterError = call.Err
 	var forwards []api2.ForwardPort
@@ -93,7 +93,7 @@ func (c *DbusClientSerivce) PermanentGetForwardPort(zone string) ([]api2.Forward
 		c.printResourceEventLog()
 
 		c.printPath(api2.CONFIG_GETFORWARDPORT)
-		call := obj.Call(api2.CONFIG_GETFORWARDPORT, dbus.FlagNoAutoStart)
+		call := c.call(obj, api2.CONFIG_GETFORWARDPORT)
 
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil && len(call.Body) >= 0 {
@@ -157,7 +157,7 @@ func (c *DbusClientSerivce) AddForwardPort(zone string, timeout uint32, forward
 	c.printResourceEventLog()
 
 	c.printPath(api2.ZONE_ADDFORWARDPORT)
-	call := obj.Call(api2.ZONE_ADDFORWARDPORT, dbus.FlagNoAutoStart, zone, forward.Port, forward.Protocol, forward.ToPort, forward.ToAddr, timeout)
+	call := c.call(obj, api2.ZONE_ADDFORWARDPORT, zone, forward.Port, forward.Protocol, forward.ToPort, forward.ToAddr, timeout)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil && len(call.Body) > 0 {
@@ -197,7 +197,7 @@ func (c *DbusClientSerivce) AddPermanentForwardPort(zone string, forward *api2.F
 	c.printResourceEventLog()
 
 	c.printPath(api2.CONFIG_ZONE_ADDFORWARDPORT)
-	call := obj.Call(api2.CONFIG_ZONE_ADDFORWARDPORT, dbus.FlagNoAutoStart, forward.Port, forward.Protocol, forward.ToPort, forward.ToAddr)
+	call := c.call(obj, api2.CONFIG_ZONE_ADDFORWARDPORT, forward.Port, forward.Protocol, forward.ToPort, forward.ToAddr)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil {
@@ -244,7 +244,7 @@ func (c *DbusClientSerivce) RemoveForwardPort(zone string, forward *api2.Forward
 	c.printResourceEventLog()
 
 	c.printPath(api2.ZONE_REMOVEFORWARDPORT)
-	call := obj.Call(api2.ZONE_REMOVEFORWARDPORT, dbus.FlagNoAutoStart, zone, forward.Port, forward.Protocol, forward.ToPort, forward.ToAddr)
+	call := c.call(obj, api2.ZONE_REMOVEFORWARDPORT, zone, forward.Port, forward.Protocol, forward.ToPort, forward.ToAddr)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil {
@@ -287,7 +287,7 @@ func (c *DbusClientSerivce) RemovePermanentForwardPort(zone string, forward *api
 
 		c.printResourceEventLog()
 		c.printPath(api2.CONFIG_ZONE_REMOVEFORWARDPORT)
-		call := obj.Call(api2.CONFIG_ZONE_REMOVEFORWARDPORT, dbus.FlagNoAutoStart, forward.Port, forward.Protocol, forward.ToPort, forward.ToAddr)
+		call := c.call(obj, api2.CONFIG_ZONE_REMOVEFORWARDPORT, forward.Port, forward.Protocol, forward.ToPort, forward.ToAddr)
 
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
@@ -340,7 +340,7 @@ func (c *DbusClientSerivce) QueryForwardPort(zone, portProtocol, toHostPort stri
 		c.printResourceEventLog()
 
 		c.printPath(api2.ZONE_QUERYFORWARDPORT)
-		call := obj.Call(api2.ZONE_QUERYFORWARDPORT, dbus.FlagNoAutoStart, zone, port, protocol, toPort, toAddr)
+		call := c.call(obj, api2.ZONE_QUERYFORWARDPORT, zone, port, protocol, toPort, toAddr)
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil || call.Body[0].(bool) {
 			c.eventLogFormat.Format = QueryResourceSuccessFormat
@@ -387,7 +387,7 @@ func (c *DbusClientSerivce) PermanentQueryForwardPort(zone, portProtocol, toHost
 
 			c.printResourceEventLog()
 			c.printPath(api2.CONFIG_ZONE_QUERYFORWARDPORT)
-			call := obj.Call(api2.CONFIG_ZONE_QUERYFORWARDPORT, dbus.FlagNoAutoStart, port, protocol, toPort, toAddr)
+			call := c.call(obj, api2.CONFIG_ZONE_QUERYFORWARDPORT, port, protocol, toPort, toAddr)
 			c.eventLogFormat.encounterError = call.Err
 
 			if enconterError == nil || call.Body[0].(bool) {
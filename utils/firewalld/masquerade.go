@@ -4,8 +4,6 @@
 package firewalld
 
 import (
-	"github.com/godbus/dbus/v5"
-
 	"github.com/cylonchau/firewalld-gateway/api"
 )
 
@@ -36,7 +34,7 @@ func (c *DbusClientSerivce) EnableMasquerade(zone string, timeout uint32) error
 	c.printResourceEventLog()
 
 	c.printPath(api.ZONE_ADDMASQUERADE)
-	call := obj.Call(api.ZONE_ADDMASQUERADE, dbus.FlagNoAutoStart, zone, timeout)
+	call := c.call(obj, api.ZONE_ADDMASQUERADE, zone, timeout)
 	c.eventLogFormat.encounterError = call.Err
 
 	if c.eventLogFormat.encounterError == nil {
@@ -78,7 +76,7 @@ func (c *DbusClientSerivce) EnablePermanentMasquerade(zone string) error {
 		c.printResourceEventLog()
 
 		c.printPath(api.CONFIG_ZONE_ADDMASQUERADE)
-		call := obj.Call(api.CONFIG_ZONE_ADDMASQUERADE, dbus.FlagNoAutoStart)
+		call := c.call(obj, api.CONFIG_ZONE_ADDMASQUERADE)
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
 			c.eventLogFormat.Format = SwitchPermanentResourceSuccessFormat
@@ -117,7 +115,7 @@ func (c *DbusClientSerivce) DisableMasquerade(zone string) (err error) {
 	obj := c.client.Object(api.INTERFACE, api.PATH)
 
 	c.printPath(api.ZONE_REMOVEMASQUERADE)
-	call := obj.Call(api.ZONE_REMOVEMASQUERADE, dbus.FlagNoAutoStart, zone)
+	call := c.call(obj, api.ZONE_REMOVEMASQUERADE, zone)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError != nil {
@@ -157,7 +155,7 @@ func (c *DbusClientSerivce) DisablePermanentMasquerade(zone string) (err error)
 		obj := c.client.Object(api.INTERFACE, path)
 
 		c.printPath(api.CONFIG_ZONE_REMOVEMASQUERADE)
-		call := obj.Call(api.CONFIG_ZONE_REMOVEMASQUERADE, dbus.FlagNoAutoStart)
+		call := c.call(obj, api.CONFIG_ZONE_REMOVEMASQUERADE)
 		c.eventLogFormat.encounterError = call.Err
 
 		if c.eventLogFormat.encounterError == nil {
@@ -199,7 +197,7 @@ func (c *DbusClientSerivce) QueryPermanentMasquerade(zone string) (bool, error)
 		obj := c.client.Object(api.INTERFACE, path)
 
 		c.printPath(api.CONFIG_ZONE_QUERYMASQUERADE)
-		call := obj.Call(api.CONFIG_ZONE_QUERYMASQUERADE, dbus.FlagNoAutoStart)
+		call := c.call(obj, api.CONFIG_ZONE_QUERYMASQUERADE)
 
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
@@ -240,7 +238,7 @@ func (c *DbusClientSerivce) QueryMasquerade(zone string) (b bool, err error) {
 	obj := c.client.Object(api.INTERFACE, api.PATH)
 
 	c.printPath(api.ZONE_QUERYMASQUERADE)
-	call := obj.Call(api.ZONE_QUERYMASQUERADE, dbus.FlagNoAutoStart, zone)
+	call := c.call(obj, api.ZONE_QUERYMASQUERADE, zone)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil {
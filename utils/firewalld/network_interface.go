@@ -4,8 +4,6 @@
 package firewalld
 
 import (
-	"github.com/godbus/dbus/v5"
-
 	"github.com/cylonchau/firewalld-gateway/api"
 )
 
@@ -39,7 +37,7 @@ func (c *DbusClientSerivce) BindInterface(zone, interfaceName string) (string, e
 	obj := c.client.Object(api.INTERFACE, api.PATH)
 
 	c.printPath(api.ZONE_ADDINTERFACE)
-	call := obj.Call(api.ZONE_ADDINTERFACE, dbus.FlagNoAutoStart, zone, interfaceName)
+	call := c.call(obj, api.ZONE_ADDINTERFACE, zone, interfaceName)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError != nil {
@@ -78,7 +76,7 @@ func (c *DbusClientSerivce) BindPermanentInterface(zone, interfaceName string) (
 	if c.eventLogFormat.encounterError == nil {
 		obj := c.client.Object(api.INTERFACE, path)
 		c.printPath(api.CONFIG_ZONE_ADDINTERFACE)
-		call := obj.Call(api.CONFIG_ZONE_ADDINTERFACE, dbus.FlagNoAutoStart, interfaceName)
+		call := c.call(obj, api.CONFIG_ZONE_ADDINTERFACE, interfaceName)
 
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
@@ -118,7 +116,7 @@ func (c *DbusClientSerivce) QueryInterface(zone, interfaceName string) bool {
 	obj := c.client.Object(api.INTERFACE, api.PATH)
 
 	c.printPath(api.ZONE_QUERYINTERFACE)
-	call := obj.Call(api.ZONE_QUERYINTERFACE, dbus.FlagNoAutoStart, zone, interfaceName)
+	call := c.call(obj, api.ZONE_QUERYINTERFACE, zone, interfaceName)
 
 	if call.Body[0].(bool) {
 		c.eventLogFormat.Format = QueryResourceSuccessFormat
@@ -158,7 +156,7 @@ func (c *DbusClientSerivce) QueryPermanentInterface(zone, interfaceName string)
 		obj := c.client.Object(api.INTERFACE, path)
 
 		c.printPath(api.CONFIG_ZONE_ADDINTERFACE)
-		call := obj.Call(api.CONFIG_ZONE_ADDINTERFACE, dbus.FlagNoAutoStart, interfaceName)
+		call := c.call(obj, api.CONFIG_ZONE_ADDINTERFACE, interfaceName)
 
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
@@ -195,7 +193,7 @@ func (c *DbusClientSerivce) RemoveInterface(zone, interfaceName string) error {
 	obj := c.client.Object(api.INTERFACE, api.PATH)
 
 	c.printPath(api.ZONE_REMOVEINTERFACE)
-	call := obj.Call(api.ZONE_REMOVEINTERFACE, dbus.FlagNoAutoStart, zone, interfaceName)
+	call := c.call(obj, api.ZONE_REMOVEINTERFACE, zone, interfaceName)
 	c.eventLogFormat.encounterError = call.Err
 
 	if c.eventLogFormat.encounterError == nil {
@@ -236,7 +234,7 @@ func (c *DbusClientSerivce) PermanentRemoveInterface(zone, interfaceName string)
 		obj := c.client.Object(api.INTERFACE, path)
 
 		c.printPath(api.CONFIG_ZONE_REMOVEINTERFACE)
-		call := obj.Call(api.CONFIG_ZONE_REMOVEINTERFACE, dbus.FlagNoAutoStart, interfaceName)
+		call := c.call(obj, api.CONFIG_ZONE_REMOVEINTERFACE, interfaceName)
 		c.eventLogFormat.encounterError = call.Err
 
 		if c.eventLogFormat.encounterError == nil {
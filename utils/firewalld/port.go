@@ -30,7 +30,7 @@ func (c *DbusClientSerivce) AddPort(port *api2.Port, zone string, timeout uint32
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 	c.printPath(api2.ZONE_ADDPORT)
 	klog.V(4).Infof("Trying create port rule in zone %s, %s/%s, timeout is %d", zone, port.Port, port.Protocol, timeout)
-	call := obj.Call(api2.ZONE_ADDPORT, dbus.FlagNoAutoStart, zone, port.Port, port.Protocol, timeout)
+	call := c.call(obj, api2.ZONE_ADDPORT, zone, port.Port, port.Protocol, timeout)
 	if call.Err != nil || len(call.Body) <= 0 {
 		klog.Errorf("Create a port rule failed: %v", call.Err.Error())
 		return call.Err
@@ -55,7 +55,7 @@ func (c *DbusClientSerivce) PermanentAddPort(port string, zone string) (enconter
 			obj := c.client.Object(api2.INTERFACE, path)
 			c.printPath(api2.CONFIG_ZONE_ADDPORT)
 			klog.V(4).Infof("Trying create port Permanent rule in zone %s, %s/%s.", zone, port, protocol)
-			call := obj.Call(api2.CONFIG_ZONE_ADDPORT, dbus.FlagNoAutoStart, port, protocol)
+			call := c.call(obj, api2.CONFIG_ZONE_ADDPORT, port, protocol)
 			enconterError = call.Err
 			if enconterError == nil {
 				return nil
@@ -81,7 +81,7 @@ func (c *DbusClientSerivce) GetPorts(zone string) (relits []api2.Port, enconterE
 	}
 
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
-	call := obj.Call(api2.ZONE_GETPORTS, dbus.FlagNoAutoStart, zone)
+	call := c.call(obj, api2.ZONE_GETPORTS, zone)
 	c.printPath(api2.ZONE_GETPORTS)
 	klog.V(4).Infof("Trying to get port rule in zone %s.", zone)
 
@@ -119,7 +119,7 @@ func (c *DbusClientSerivce) PermanentGetPort(zone string) (list []api2.Port, enc
 		obj := c.client.Object(api2.INTERFACE, path)
 		c.printPath(api2.CONFIG_ZONE_GETPORTS)
 		klog.V(4).Infof("Trying to get permanent port rule in zone %s.", zone)
-		call := obj.Call(api2.CONFIG_ZONE_GETPORTS, dbus.FlagNoAutoStart)
+		call := c.call(obj, api2.CONFIG_ZONE_GETPORTS)
 
 		enconterError = call.Err
 		if enconterError == nil {
@@ -163,7 +163,7 @@ func (c *DbusClientSerivce) RemovePort(port *api2.Port, zone string) error {
 	c.printPath(api2.ZONE_REMOVEPORT)
 	klog.V(4).Infof("Trying to remove port rule in zone %s, port rule is: %s/%s", zone, port.Port, port.Protocol)
 
-	call := obj.Call(api2.ZONE_REMOVEPORT, dbus.FlagNoAutoStart, zone, port.Port, port.Protocol)
+	call := c.call(obj, api2.ZONE_REMOVEPORT, zone, port.Port, port.Protocol)
 
 	if call.Err != nil {
 		klog.Errorf("Remove port rule failed: %v", call.Err)
@@ -194,7 +194,7 @@ func (c *DbusClientSerivce) PermanentRemovePort(port, zone string) (enconterErro
 			c.printPath(api2.CONFIG_ZONE_REMOVEPORT)
 			klog.V(4).Infof("Try to remove permanent port rule in zone %s, %s/%s.", zone, port, protocol)
 
-			call := obj.Call(api2.CONFIG_ZONE_REMOVEPORT, dbus.FlagNoAutoStart, port, protocol)
+			call := c.call(obj, api2.CONFIG_ZONE_REMOVEPORT, port, protocol)
 			enconterError = call.Err
 			if enconterError == nil {
 				return nil
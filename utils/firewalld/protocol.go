@@ -4,8 +4,6 @@
 package firewalld
 
 import (
-	"github.com/godbus/dbus/v5"
-
 	"github.com/cylonchau/firewalld-gateway/api"
 )
 
@@ -34,7 +32,7 @@ func (c *DbusClientSerivce) AddProtocol(zone, protocol string, timeout uint32) e
 	obj := c.client.Object(api.INTERFACE, api.PATH)
 
 	c.printPath(api.ZONE_ADDPROTOCOL)
-	call := obj.Call(api.ZONE_ADDPROTOCOL, dbus.FlagNoAutoStart, zone, protocol, timeout)
+	call := c.call(obj, api.ZONE_ADDPROTOCOL, zone, protocol, timeout)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError != nil {
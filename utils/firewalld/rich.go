@@ -1,8 +1,6 @@
 package firewalld
 
 import (
-	"github.com/godbus/dbus/v5"
-
 	api2 "github.com/cylonchau/firewalld-gateway/api"
 )
 
@@ -22,7 +20,7 @@ func (c *DbusClientSerivce) GetRichRules(zone string) (ruleList []*api2.Rule, er
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 
 	c.printPath(api2.ZONE_GETRICHRULES)
-	call := obj.Call(api2.ZONE_GETRICHRULES, dbus.FlagNoAutoStart, zone)
+	call := c.call(obj, api2.ZONE_GETRICHRULES, zone)
 	c.eventLogFormat.encounterError = call.Err
 
 	if c.eventLogFormat.encounterError == nil {
@@ -54,7 +52,7 @@ func (c *DbusClientSerivce) GetPermanentRichRules(zone string) (ruleList []*api2
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 
 	c.printPath(api2.CONFIG_ZONE_GETRICHRULES)
-	call := obj.Call(api2.ZONE_GETRICHRULES, dbus.FlagNoAutoStart, zone)
+	call := c.call(obj, api2.ZONE_GETRICHRULES, zone)
 	c.eventLogFormat.encounterError = call.Err
 
 	if c.eventLogFormat.encounterError == nil {
@@ -86,7 +84,7 @@ func (c *DbusClientSerivce) AddRichRule(zone string, rule *api2.Rule, timeout ui
 
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 	c.printPath(api2.ZONE_ADDRICHRULE)
-	call := obj.Call(api2.ZONE_ADDRICHRULE, dbus.FlagNoAutoStart, zone, rule.ToString(), timeout)
+	call := c.call(obj, api2.ZONE_ADDRICHRULE, zone, rule.ToString(), timeout)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError != nil {
@@ -118,7 +116,7 @@ func (c *DbusClientSerivce) AddPermanentRichRule(zone string, rule *api2.Rule) e
 		obj := c.client.Object(api2.INTERFACE, path)
 		c.printPath(api2.CONFIG_ZONE_ADDRICHRULE)
 
-		call := obj.Call(api2.CONFIG_ZONE_ADDRICHRULE, dbus.FlagNoAutoStart, rule.ToString())
+		call := c.call(obj, api2.CONFIG_ZONE_ADDRICHRULE, rule.ToString())
 
 		if c.eventLogFormat.encounterError = call.Err; c.eventLogFormat.encounterError == nil {
 			c.eventLogFormat.Format = CreatePermanentResourceSuccessFormat
@@ -147,7 +145,7 @@ func (c *DbusClientSerivce) RemoveRichRule(zone string, rule *api2.Rule) error {
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 
 	c.printPath(api2.ZONE_REOMVERICHRULE)
-	call := obj.Call(api2.ZONE_REOMVERICHRULE, dbus.FlagNoAutoStart, zone, rule.ToString())
+	call := c.call(obj, api2.ZONE_REOMVERICHRULE, zone, rule.ToString())
 
 	if c.eventLogFormat.encounterError = call.Err; c.eventLogFormat.encounterError != nil {
 		c.eventLogFormat.Format = RemoveResourceFailedFormat
@@ -175,7 +173,7 @@ func (c *DbusClientSerivce) RemovePermanentRichRule(zone string, rule *api2.Rule
 	if c.eventLogFormat.encounterError == nil {
 		obj := c.client.Object(api2.INTERFACE, path)
 		c.printPath(api2.CONFIG_ZONE_REOMVERICHRULE)
-		call := obj.Call(api2.CONFIG_ZONE_REOMVERICHRULE, dbus.FlagNoAutoStart, rule.ToString())
+		call := c.call(obj, api2.CONFIG_ZONE_REOMVERICHRULE, rule.ToString())
 
 		if c.eventLogFormat.encounterError = call.Err; c.eventLogFormat.encounterError == nil {
 			c.eventLogFormat.Format = RemovePermanentResourceSuccessFormat
@@ -205,7 +203,7 @@ func (c *DbusClientSerivce) QueryPermanentRichRule(zone string, rule *api2.Rule)
 	if c.eventLogFormat.encounterError = err; c.eventLogFormat.encounterError == nil {
 		obj := c.client.Object(api2.INTERFACE, path)
 		c.printPath(api2.CONFIG_ZONE_QUERYRICHRULE)
-		call := obj.Call(api2.CONFIG_ZONE_QUERYRICHRULE, dbus.FlagNoAutoStart, rule.ToString())
+		call := c.call(obj, api2.CONFIG_ZONE_QUERYRICHRULE, rule.ToString())
 
 		if c.eventLogFormat.encounterError = call.Err; c.eventLogFormat.encounterError == nil && (len(call.Body) == 0 || call.Body[0].(bool)) {
 			c.eventLogFormat.Format = QueryPermanentResourceSuccessFormat
@@ -232,7 +230,7 @@ func (c *DbusClientSerivce) QueryRichRule(zone string, rule *api2.Rule) bool {
 
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 	c.printPath(api2.ZONE_QUERYRICHRULE)
-	call := obj.Call(api2.ZONE_QUERYRICHRULE, dbus.FlagNoAutoStart, zone, rule.ToString())
+	call := c.call(obj, api2.ZONE_QUERYRICHRULE, zone, rule.ToString())
 
 	if c.eventLogFormat.encounterError = call.Err; c.eventLogFormat.encounterError == nil && call.Body[0].(bool) {
 		c.eventLogFormat.Format = QueryResourceSuccessFormat
@@ -34,7 +34,7 @@ func (c *DbusClientSerivce) ListServices() (list []string, err error) {
 	c.eventLogFormat.encounterError = nil
 	c.printResourceEventLog()
 
-	call := obj.Call(api2.INTERFACE_LISTSERVICES, dbus.FlagNoAutoStart)
+	call := c.call(obj, api2.INTERFACE_LISTSERVICES)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil {
@@ -75,7 +75,7 @@ func (c *DbusClientSerivce) AddNewService(name string, setting *api2.ServiceSett
 	c.printResourceEventLog()
 
 	c.printPath(api2.CONFIG_ADDSERVICE)
-	call := obj.Call(api2.CONFIG_ADDSERVICE, dbus.FlagNoAutoStart, name, &setting)
+	call := c.call(obj, api2.CONFIG_ADDSERVICE, name, &setting)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError != nil {
@@ -113,7 +113,7 @@ func (c *DbusClientSerivce) AddServiceRuntime(zone, service string, timeout uint
 	c.printResourceEventLog()
 
 	c.printPath(api2.ZONE_ADDSERVICE)
-	call := obj.Call(api2.ZONE_ADDSERVICE, dbus.FlagNoAutoStart, zone, service, timeout)
+	call := c.call(obj, api2.ZONE_ADDSERVICE, zone, service, timeout)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil {
@@ -151,7 +151,7 @@ func (c *DbusClientSerivce) AddPermanentService(zone, service string) error {
 		c.printResourceEventLog()
 
 		c.printPath(api2.CONFIG_ZONE_ADDSERVICE)
-		call := obj.Call(api2.CONFIG_ZONE_ADDSERVICE, dbus.FlagNoAutoStart, service)
+		call := c.call(obj, api2.CONFIG_ZONE_ADDSERVICE, service)
 
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
@@ -187,7 +187,7 @@ func (c *DbusClientSerivce) QueryService(zone, service string) bool {
 	c.printResourceEventLog()
 
 	c.printPath(api2.ZONE_QUERYSERVICE)
-	call := obj.Call(api2.ZONE_QUERYSERVICE, dbus.FlagNoAutoStart, zone, service)
+	call := c.call(obj, api2.ZONE_QUERYSERVICE, zone, service)
 	if !call.Body[0].(bool) {
 		c.eventLogFormat.Format = QueryNotFount
 		c.printResourceEventLog()
@@ -219,7 +219,7 @@ func (c *DbusClientSerivce) PermanentQueryService(zone, service string) bool {
 		c.printResourceEventLog()
 
 		c.printPath(api2.CONFIG_ZONE_QUERYSERVICE)
-		call := obj.Call(api2.CONFIG_ZONE_QUERYSERVICE, dbus.FlagNoAutoStart, service)
+		call := c.call(obj, api2.CONFIG_ZONE_QUERYSERVICE, service)
 
 		if call.Body[0].(bool) {
 			return true
@@ -252,7 +252,7 @@ func (c *DbusClientSerivce) RemoveRuntimeService(zone, service string) error {
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 
 	c.printPath(api2.ZONE_REMOVESERVICE)
-	call := obj.Call(api2.ZONE_REMOVESERVICE, dbus.FlagNoAutoStart, zone, service)
+	call := c.call(obj, api2.ZONE_REMOVESERVICE, zone, service)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError != nil {
@@ -287,7 +287,7 @@ func (c *DbusClientSerivce) RemovePermanentService(service string) error {
 		c.printResourceEventLog()
 
 		c.printPath(api2.CONFIG_ZONE_REMOVESERVICE)
-		call := obj.Call(api2.CONFIG_ZONE_REMOVESERVICE, dbus.FlagNoAutoStart, service)
+		call := c.call(obj, api2.CONFIG_ZONE_REMOVESERVICE, service)
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
 			c.eventLogFormat.resourceType = RemoveResourceSuccessFormat
@@ -319,7 +319,7 @@ func (c *DbusClientSerivce) GetPermanentServices() (list []string, err error) {
 		c.printResourceEventLog()
 
 		c.printPath(api2.CONFIG_ZONE_GETSERVICES)
-		call := obj.Call(api2.CONFIG_ZONE_GETSERVICES, dbus.FlagNoAutoStart)
+		call := c.call(obj, api2.CONFIG_ZONE_GETSERVICES)
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
 			services, ok := call.Body[0].([]string)
@@ -360,7 +360,7 @@ func (c *DbusClientSerivce) GetRuntimeServices(zone string) (list []string, err
 	c.printResourceEventLog()
 
 	c.printPath(api2.ZONE_GETSERVICES)
-	call := obj.Call(api2.ZONE_GETSERVICES, dbus.FlagNoAutoStart, zone)
+	call := c.call(obj, api2.ZONE_GETSERVICES, zone)
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil {
 		services, ok := call.Body[0].([]string)
@@ -0,0 +1,115 @@
+package firewalld
+
+import (
+	"sort"
+
+	"k8s.io/klog/v2"
+
+	api2 "github.com/cylonchau/firewalld-gateway/api"
+)
+
+// runtimeItem 表示 zone settings 中的一项在 runtime 中的增删方法
+type runtimeItem struct {
+	add     string
+	remove  string
+	args    []interface{}
+	timeout bool
+}
+
+// runtimeItems 将 settings 展开为以 "字段/值" 为 key 的 runtime 操作集合
+func runtimeItems(setting *api2.Settings) map[string]runtimeItem {
+	items := make(map[string]runtimeItem)
+	for _, v := range setting.Service {
+		items["service/"+v] = runtimeItem{api2.ZONE_ADDSERVICE, api2.ZONE_REMOVESERVICE, []interface{}{v}, true}
+	}
+	for _, v := range setting.Port {
+		items["port/"+v.Key()] = runtimeItem{api2.ZONE_ADDPORT, api2.ZONE_REMOVEPORT, []interface{}{v.Port, v.Protocol}, true}
+	}
+	for _, v := range setting.IcmpBlock {
+		items["icmpblock/"+v.Name] = runtimeItem{api2.ZONE_ADDICMPBLOCK, api2.ZONE_REMOVEICMPBLOCK, []interface{}{v.Name}, true}
+	}
+	for _, v := range setting.ForwardPort {
+		items["forwardport/"+v.Key()] = runtimeItem{api2.ZONE_ADDFORWARDPORT, api2.ZONE_REMOVEFORWARDPORT, []interface{}{v.Port, v.Protocol, v.ToPort, v.ToAddr}, true}
+	}
+	for _, v := range setting.Interface {
+		items["interface/"+v.Name] = runtimeItem{api2.ZONE_ADDINTERFACE, api2.ZONE_REMOVEINTERFACE, []interface{}{v.Name}, false}
+	}
+	for _, v := range setting.Source {
+		source := v.Address
+		switch {
+		case v.Mac != "":
+			source = v.Mac
+		case v.Ipset != "":
+			source = "ipset:" + v.Ipset
+		}
+		items["source/"+v.Key()] = runtimeItem{api2.ZONE_ADDSOURCE, api2.ZONE_REMOVESOURCE, []interface{}{source}, false}
+	}
+	for _, v := range setting.Rule {
+		items["rule/"+api2.NormalizeRule(v)] = runtimeItem{api2.ZONE_ADDRICHRULE, api2.ZONE_REOMVERICHRULE, []interface{}{v}, true}
+	}
+	for _, v := range setting.Protocol {
+		items["protocol/"+v.Value] = runtimeItem{api2.ZONE_ADDPROTOCOL, api2.ZONE_REMOVEPROTOCOL, []interface{}{v.Value}, true}
+	}
+	for _, v := range setting.SourcePort {
+		items["sourceport/"+v.Key()] = runtimeItem{api2.ZONE_ADDSOURCEPORT, api2.ZONE_REMOVESOURCEPORT, []interface{}{v.Port, v.Protocol}, true}
+	}
+	if setting.Masquerade {
+		items["masquerade"] = runtimeItem{api2.ZONE_ADDMASQUERADE, api2.ZONE_REMOVEMASQUERADE, nil, true}
+	}
+	if setting.IcmpBlockInversion {
+		items["icmp-block-inversion"] = runtimeItem{api2.ZONE_ADDICMPBLOCKINVERSION, api2.ZONE_REMOVEICMPBLOCKINVERSION, nil, false}
+	}
+	return items
+}
+
+// RuntimeApply 只修改 runtime，将当前 zone 调整为 setting 描述的内容，firewalld reload 后失效
+// target、short、description 等只能通过 permanent 配置修改，这里不做处理
+func (c *DbusClientSerivce) RuntimeApply(setting api2.Settings) (encounterError error) {
+	zone := c.GetDefaultZone()
+
+	var live *api2.Settings
+	if live, encounterError = c.GetZoneSettings(zone); encounterError != nil {
+		return encounterError
+	}
+	current, desired := runtimeItems(live), runtimeItems(&setting)
+
+	obj := c.client.Object(api2.INTERFACE, api2.PATH)
+	call := func(method string, item runtimeItem, timeout bool) error {
+		args := append([]interface{}{zone}, item.args...)
+		if timeout {
+			args = append(args, uint32(0))
+		}
+		c.printPath(method)
+		return c.call(obj, method, args...).Err
+	}
+
+	// 先删除多余的项，再添加缺少的项
+	for _, key := range sortedKeys(current) {
+		if _, ok := desired[key]; !ok {
+			klog.V(4).Infof("Remove runtime %s from zone %s", key, zone)
+			if encounterError = call(current[key].remove, current[key], false); encounterError != nil {
+				klog.Errorf("Remove runtime %s from zone %s failed: %v", key, zone, encounterError)
+				return encounterError
+			}
+		}
+	}
+	for _, key := range sortedKeys(desired) {
+		if _, ok := current[key]; !ok {
+			klog.V(4).Infof("Add runtime %s to zone %s", key, zone)
+			if encounterError = call(desired[key].add, desired[key], desired[key].timeout); encounterError != nil {
+				klog.Errorf("Add runtime %s to zone %s failed: %v", key, zone, encounterError)
+				return encounterError
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(items map[string]runtimeItem) []string {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,15 +1,21 @@
 package firewalld
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/godbus/dbus/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"k8s.io/klog/v2"
 
 	"github.com/cylonchau/firewalld-gateway/api"
 	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/metrics"
+	"github.com/cylonchau/firewalld-gateway/utils/tracing"
 )
 
 var (
@@ -23,14 +29,27 @@ type DbusClientSerivce struct {
 	ip             string
 	port           string
 	eventLogFormat logFormat
+	// ctx 中的 span 是每次 D-Bus 调用的 span 的父 span
+	ctx context.Context
 }
 
 func NewDbusClientService(addr string) (*DbusClientSerivce, error) {
+	return NewDbusClientServiceWithContext(context.Background(), addr)
+}
+
+// NewDbusClientServiceWithContext 连接主机的 D-Bus 服务，连接与之后的每次调用都记录为 ctx 中 span 的子 span
+func NewDbusClientServiceWithContext(ctx context.Context, addr string) (client *DbusClientSerivce, encounterError error) {
 	var (
-		encounterError error
-		conn           *dbus.Conn
-		reply          dbus.RequestNameReply
+		conn  *dbus.Conn
+		reply dbus.RequestNameReply
+	)
+	connectCtx, span := tracing.Tracer().Start(ctx, "dbus.connect",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("net.peer.name", addr)),
 	)
+	defer func() {
+		tracing.End(span, encounterError)
+	}()
 	if config.CONFIG.Port == "" {
 		klog.V(5).Infof("Start connect to D-Bus service: %s:%s", addr, PORT)
 	} else {
@@ -52,17 +71,22 @@ func NewDbusClientService(addr string) (*DbusClientSerivce, error) {
 				klog.Warningf("You are already the owner of %s. no need to ask again.", registionName)
 			}
 			if encounterError == nil {
+				client = &DbusClientSerivce{
+					conn,
+					"",
+					addr,
+					PORT,
+					logFormat{},
+					connectCtx,
+				}
 				obj := conn.Object(api.INTERFACE, api.PATH)
-				call := obj.Call(api.INTERFACE_GETDEFAULTZONE, dbus.FlagNoAutoStart)
+				call := client.call(obj, api.INTERFACE_GETDEFAULTZONE)
 				encounterError = call.Err
 				if encounterError == nil {
-					return &DbusClientSerivce{
-						conn,
-						call.Body[0].(string),
-						addr,
-						PORT,
-						logFormat{},
-					}, encounterError
+					client.defaultZone = call.Body[0].(string)
+					client.ctx = ctx
+					metrics.HostUp.WithLabelValues(addr).Set(1)
+					return client, encounterError
 				}
 			}
 		}
@@ -70,6 +94,7 @@ func NewDbusClientService(addr string) (*DbusClientSerivce, error) {
 	if encounterError != nil && conn != nil {
 		conn.Close()
 	}
+	metrics.HostUp.WithLabelValues(addr).Set(0)
 	klog.Errorf("Connect to firewalld service failed: %v", encounterError)
 	return nil, encounterError
 }
@@ -88,6 +113,29 @@ func (c *DbusClientSerivce) Destroy() {
 	}
 }
 
+// call 调用 firewalld 的 D-Bus 方法，记录调用次数、失败次数与耗时，并为每次调用创建 span
+func (c *DbusClientSerivce) call(obj dbus.BusObject, method string, args ...interface{}) *dbus.Call {
+	_, span := tracing.Tracer().Start(c.ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "dbus"),
+			attribute.String("rpc.method", method),
+			attribute.String("rpc.service", string(obj.Destination())),
+			attribute.String("dbus.path", string(obj.Path())),
+			attribute.String("net.peer.name", c.ip),
+		),
+	)
+	start := time.Now()
+	call := obj.Call(method, dbus.FlagNoAutoStart, args...)
+	metrics.DbusCalls.WithLabelValues(method, c.ip).Inc()
+	metrics.DbusCallDuration.WithLabelValues(method, c.ip).Observe(time.Since(start).Seconds())
+	if call.Err != nil {
+		metrics.DbusCallErrors.WithLabelValues(method, c.ip).Inc()
+	}
+	tracing.End(span, call.Err)
+	return call
+}
+
 /************************************************** fw service area ***********************************************************/
 
 // @title         Reload
@@ -100,7 +148,7 @@ func (c *DbusClientSerivce) Reload() error {
 	obj := c.client.Object(api.INTERFACE, api.PATH)
 	c.printPath(api.INTERFACE_RELOAD)
 	klog.V(4).Infof("Try to reload firewalld runtime.")
-	call := obj.Call(api.INTERFACE_RELOAD, dbus.FlagNoAutoStart)
+	call := c.call(obj, api.INTERFACE_RELOAD)
 
 	if call.Err != nil {
 		klog.Errorf("Reload firewalld failed: %v", call.Err.Error())
@@ -144,7 +192,7 @@ func (c *DbusClientSerivce) RuntimeFlush(zone string) (encounterError error) {
 		obj := c.client.Object(api.INTERFACE, path)
 		c.printPath(api.CONFIG_UPDATE)
 		klog.V(4).Infof("Try to flush current active zone (%s).", zone)
-		call := obj.Call(api.CONFIG_UPDATE, dbus.FlagNoAutoStart, defaultZoneSetting)
+		call := c.call(obj, api.CONFIG_UPDATE, defaultZoneSetting)
 		encounterError = call.Err
 		if encounterError == nil || len(call.Body) <= 0 {
 			if encounterError = c.Reload(); encounterError == nil {
@@ -166,7 +214,7 @@ func (c *DbusClientSerivce) RuntimeSet(setting api.Settings) (encounterError err
 		obj := c.client.Object(api.INTERFACE, path)
 		c.printPath(api.CONFIG_UPDATE)
 		klog.V(4).Infof("Try to flush current active zone (%s).", zone)
-		call := obj.Call(api.CONFIG_UPDATE, dbus.FlagNoAutoStart, setting)
+		call := c.call(obj, api.CONFIG_UPDATE, setting)
 		encounterError = call.Err
 		if encounterError == nil || len(call.Body) <= 0 {
 			if encounterError = c.Reload(); encounterError == nil {
@@ -41,7 +41,7 @@ func (c *DbusClientSerivce) SetDefaultZone(zone string) (err error) {
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 
 	c.printPath(api2.INTERFACE_SETDEFAULTZONE)
-	call := obj.Call(api2.INTERFACE_SETDEFAULTZONE, dbus.FlagNoAutoStart, zone)
+	call := c.call(obj, api2.INTERFACE_SETDEFAULTZONE, zone)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil {
@@ -70,7 +70,7 @@ func (c *DbusClientSerivce) GetZones() ([]string, error) {
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 
 	c.printPath(api2.ZONE_GETZONES)
-	call := obj.Call(api2.ZONE_GETZONES, dbus.FlagNoAutoStart)
+	call := c.call(obj, api2.ZONE_GETZONES)
 
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil && len(call.Body) > 0 {
@@ -115,7 +115,10 @@ func (c *DbusClientSerivce) getZoneId(zone string) int {
 // ###middlewares      	  author           2021-09-26
 // ###param         zone		       string         "zone name."
 // ###return        error            error          "Possible errors: INVALID_ZONE"
-func (c *DbusClientSerivce) GetZoneSettings(zone string) error {
+func (c *DbusClientSerivce) GetZoneSettings(zone string) (*api2.Settings, error) {
+	if zone == "" {
+		zone = c.GetDefaultZone()
+	}
 
 	//print log
 	c.eventLogFormat.Format = ListResourceStartFormat
@@ -127,17 +130,56 @@ func (c *DbusClientSerivce) GetZoneSettings(zone string) error {
 		c.printResourceEventLog()
 		obj := c.client.Object(api2.INTERFACE, api2.PATH)
 		c.printPath(api2.INTERFACE_GETZONESETTINGS)
-		call := obj.Call(api2.INTERFACE_GETZONESETTINGS, dbus.FlagNoAutoStart, zone)
+		call := c.call(obj, api2.INTERFACE_GETZONESETTINGS, zone)
 		c.eventLogFormat.encounterError = call.Err
 
 		if c.eventLogFormat.encounterError == nil {
-			c.eventLogFormat.Format = ListResourceSuccessFormat
-			return nil
+			settings := &zoneSettings{}
+			if c.eventLogFormat.encounterError = call.Store(settings); c.eventLogFormat.encounterError == nil {
+				c.eventLogFormat.Format = ListResourceSuccessFormat
+				return settings.toSettings(), nil
+			}
 		}
 	}
 	c.eventLogFormat.Format = ListResourceFailedFormat
 	c.printResourceEventLog()
-	return c.eventLogFormat.encounterError
+	return nil, c.eventLogFormat.encounterError
+}
+
+// ###title         GetPermanentZoneSettings
+// ###description   Return permanent settings of given zone.
+// ###param         zone		       string         "zone name."
+// ###return        error            error          "Possible errors: INVALID_ZONE"
+func (c *DbusClientSerivce) GetPermanentZoneSettings(zone string) (*api2.Settings, error) {
+	if zone == "" {
+		zone = c.GetDefaultZone()
+	}
+
+	//print log
+	c.eventLogFormat.Format = ListPermanentResourceStartFormat
+	c.eventLogFormat.resourceType = "zone setting"
+	c.eventLogFormat.resource = zone
+	c.eventLogFormat.encounterError = nil
+	c.printResourceEventLog()
+
+	var path dbus.ObjectPath
+	if path, c.eventLogFormat.encounterError = c.generatePath(zone, api2.ZONE_PATH); c.eventLogFormat.encounterError == nil {
+		obj := c.client.Object(api2.INTERFACE, path)
+		c.printPath(api2.CONFIG_GETSETTINGS)
+		call := c.call(obj, api2.CONFIG_GETSETTINGS)
+		c.eventLogFormat.encounterError = call.Err
+
+		if c.eventLogFormat.encounterError == nil {
+			settings := &zoneSettings{}
+			if c.eventLogFormat.encounterError = call.Store(settings); c.eventLogFormat.encounterError == nil {
+				c.eventLogFormat.Format = ListPermanentResourceSuccessFormat
+				return settings.toSettings(), nil
+			}
+		}
+	}
+	c.eventLogFormat.Format = ListPermanentResourceFailedFormat
+	c.printResourceEventLog()
+	return nil, c.eventLogFormat.encounterError
 }
 
 // ###title         RemoveZone
@@ -162,7 +204,7 @@ func (c *DbusClientSerivce) RemoveZone(zone string) error {
 			obj := c.client.Object(api2.INTERFACE, path)
 
 			c.printPath(api2.INTERFACE)
-			call := obj.Call(api2.CONFIG_REMOVEZONE, dbus.FlagNoAutoStart)
+			call := c.call(obj, api2.CONFIG_REMOVEZONE)
 			c.eventLogFormat.encounterError = call.Err
 			if c.eventLogFormat.encounterError == nil {
 				c.eventLogFormat.Format = RemoveResourceSuccessFormat
@@ -194,7 +236,7 @@ func (c *DbusClientSerivce) AddZone(setting *api2.Settings) error {
 		obj := c.client.Object(api2.INTERFACE, api2.CONFIG_PATH)
 
 		c.printPath(api2.CONFIG_ADDZONE)
-		call := obj.Call(api2.CONFIG_ADDZONE, dbus.FlagNoAutoStart, setting.Short, setting)
+		call := c.call(obj, api2.CONFIG_ADDZONE, setting.Short, setting)
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil {
 			c.eventLogFormat.Format = CreateResourceSuccessFormat
@@ -224,7 +266,7 @@ func (c *DbusClientSerivce) GetZoneOfInterface(iface string) string {
 	obj := c.client.Object(api2.INTERFACE, api2.PATH)
 
 	c.printPath(api2.ZONE_GETZONEOFINTERFACE)
-	call := obj.Call(api2.ZONE_GETZONEOFINTERFACE, dbus.FlagNoAutoStart, iface)
+	call := c.call(obj, api2.ZONE_GETZONEOFINTERFACE, iface)
 	c.eventLogFormat.encounterError = call.Err
 	if c.eventLogFormat.encounterError == nil && len(call.Body) > 0 {
 		name, ok := call.Body[0].(string)
@@ -260,7 +302,7 @@ func (c *DbusClientSerivce) GetDefaultPolicy() string {
 	if c.eventLogFormat.encounterError == nil {
 		obj := c.client.Object(api2.INTERFACE, path)
 		c.printPath(api2.CONFIG_DEFAULT_POLICY)
-		call := obj.Call(api2.CONFIG_DEFAULT_POLICY, dbus.FlagNoAutoStart)
+		call := c.call(obj, api2.CONFIG_DEFAULT_POLICY)
 		c.eventLogFormat.encounterError = call.Err
 		if c.eventLogFormat.encounterError == nil && len(call.Body) > 0 {
 			name, ok := call.Body[0].(string)
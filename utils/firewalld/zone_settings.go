@@ -0,0 +1,87 @@
+package firewalld
+
+import (
+	"net"
+	"strings"
+
+	api2 "github.com/cylonchau/firewalld-gateway/api"
+)
+
+// zoneSettings 与 firewalld getZoneSettings/getSettings 返回的
+// (sssbsasa(ss)asba(ssss)asasasasa(ss)b) 结构一一对应
+type zoneSettings struct {
+	Version            string
+	Short              string
+	Description        string
+	Unused             bool
+	Target             string
+	Services           []string
+	Ports              []zonePort
+	IcmpBlocks         []string
+	Masquerade         bool
+	ForwardPorts       []zoneForwardPort
+	Interfaces         []string
+	Sources            []string
+	Rules              []string
+	Protocols          []string
+	SourcePorts        []zonePort
+	IcmpBlockInversion bool
+}
+
+type zonePort struct {
+	Port     string
+	Protocol string
+}
+
+type zoneForwardPort struct {
+	Port     string
+	Protocol string
+	ToPort   string
+	ToAddr   string
+}
+
+func (z *zoneSettings) toSettings() *api2.Settings {
+	settings := &api2.Settings{
+		Version:            z.Version,
+		Short:              z.Short,
+		Description:        z.Description,
+		Target:             z.Target,
+		Service:            z.Services,
+		Masquerade:         z.Masquerade,
+		Rule:               z.Rules,
+		IcmpBlockInversion: z.IcmpBlockInversion,
+	}
+	for _, v := range z.Ports {
+		settings.Port = append(settings.Port, &api2.Port{Port: v.Port, Protocol: v.Protocol})
+	}
+	for _, v := range z.IcmpBlocks {
+		settings.IcmpBlock = append(settings.IcmpBlock, &api2.IcmpBlock{Name: v})
+	}
+	for _, v := range z.ForwardPorts {
+		settings.ForwardPort = append(settings.ForwardPort, &api2.ForwardPort{Port: v.Port, Protocol: v.Protocol, ToPort: v.ToPort, ToAddr: v.ToAddr})
+	}
+	for _, v := range z.Interfaces {
+		settings.Interface = append(settings.Interface, &api2.Interface{Name: v})
+	}
+	for _, v := range z.Sources {
+		settings.Source = append(settings.Source, parseSource(v))
+	}
+	for _, v := range z.Protocols {
+		settings.Protocol = append(settings.Protocol, &api2.Protocol{Value: v})
+	}
+	for _, v := range z.SourcePorts {
+		settings.SourcePort = append(settings.SourcePort, &api2.SourcePort{Port: v.Port, Protocol: v.Protocol})
+	}
+	return settings
+}
+
+// parseSource firewalld 中 source 以 "ipset:name"、MAC 地址或 IP/CIDR 的字符串形式保存
+func parseSource(source string) *api2.Source {
+	if strings.HasPrefix(source, "ipset:") {
+		return &api2.Source{Ipset: strings.TrimPrefix(source, "ipset:")}
+	}
+	if _, err := net.ParseMAC(source); err == nil && len(source) == 17 {
+		return &api2.Source{Mac: source}
+	}
+	return &api2.Source{Address: source}
+}
@@ -0,0 +1,157 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid ldap credentials")
+	ErrUserNotFound       = errors.New("ldap user not found")
+)
+
+// Entry 目录中的一个用户
+type Entry struct {
+	DN       string
+	Username string
+	Groups   []string
+}
+
+// dial 按配置连接目录服务，ldap:// 且开启 start_tls 时升级为 TLS
+func dial() (*goldap.Conn, error) {
+	cfg := config.CONFIG.LDAP
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CAFile)
+		}
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	conn, err := goldap.DialURL(cfg.URL, goldap.DialWithTLSConfig(tlsConfig), goldap.DialWithDialer(&net.Dialer{Timeout: timeout}))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(timeout)
+	if cfg.StartTLS && u.Scheme == "ldap" {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// bind 使用服务账号绑定，bind_dn 为空时匿名搜索
+func bind(conn *goldap.Conn) error {
+	cfg := config.CONFIG.LDAP
+	if cfg.BindDN == "" {
+		return conn.UnauthenticatedBind("")
+	}
+	return conn.Bind(cfg.BindDN, cfg.BindPassword)
+}
+
+func search(conn *goldap.Conn, username string) (*Entry, error) {
+	cfg := config.CONFIG.LDAP
+	request := goldap.NewSearchRequest(
+		cfg.BaseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 2, cfg.Timeout, false,
+		strings.ReplaceAll(cfg.UserFilter, "%s", goldap.EscapeFilter(username)),
+		[]string{"dn", cfg.UsernameAttribute, cfg.GroupAttribute},
+		nil,
+	)
+	result, err := conn.Search(request)
+	if err != nil && !goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+		return nil, err
+	}
+	if result == nil || len(result.Entries) == 0 {
+		return nil, ErrUserNotFound
+	}
+	// 过滤条件匹配到多个用户时拒绝登录
+	if len(result.Entries) > 1 {
+		return nil, fmt.Errorf("ldap filter matched more than one user for %s", username)
+	}
+	entry := result.Entries[0]
+	name := entry.GetAttributeValue(cfg.UsernameAttribute)
+	if name == "" {
+		name = username
+	}
+	return &Entry{DN: entry.DN, Username: name, Groups: entry.GetAttributeValues(cfg.GroupAttribute)}, nil
+}
+
+// Authenticate 搜索用户并以用户的 DN 与密码绑定
+func Authenticate(username, password string) (*Entry, error) {
+	// 空密码在很多目录服务上会被当作匿名绑定而成功
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = bind(conn); err != nil {
+		return nil, err
+	}
+	entry, err := search(conn, username)
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.Bind(entry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Lookup 使用服务账号查询用户，用于周期同步角色
+func Lookup(username string) (*Entry, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = bind(conn); err != nil {
+		return nil, err
+	}
+	return search(conn, username)
+}
+
+// MapRoles 将组 DN 按配置映射为网关的角色，没有配置映射时返回 nil，不修改用户的角色
+func MapRoles(groups []string) []string {
+	mappings := config.CONFIG.LDAP.RoleMappings
+	if len(mappings) == 0 {
+		return nil
+	}
+	roles := []string{}
+	for _, mapping := range mappings {
+		for _, group := range groups {
+			// DN 不区分大小写
+			if strings.EqualFold(group, mapping.Group) {
+				roles = append(roles, mapping.Roles...)
+				break
+			}
+		}
+	}
+	return roles
+}
@@ -0,0 +1,317 @@
+package ldap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+const (
+	testBaseDN          = "ou=people,dc=example,dc=com"
+	testServiceDN       = "cn=readonly,dc=example,dc=com"
+	testServicePassword = "readonly-secret"
+	testAdminsGroup     = "cn=firewall-admins,ou=groups,dc=example,dc=com"
+)
+
+// testUser 目录中的用户
+type testUser struct {
+	uid, password string
+	groups        []string
+}
+
+// testDirectory 只实现 bind、search、StartTLS 与 unbind 的目录服务，记录收到的操作
+type testDirectory struct {
+	listener net.Listener
+	tls      *tls.Config
+	users    map[string]testUser
+
+	sync.Mutex
+	// operations 按顺序记录的操作，例如 "starttls"、"bind cn=readonly,...(tls)"、"search (uid=alice)"
+	operations []string
+	conns      int
+}
+
+func newTestDirectory(t *testing.T, cert tls.Certificate) *testDirectory {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &testDirectory{
+		listener: listener,
+		tls:      &tls.Config{Certificates: []tls.Certificate{cert}},
+		users: map[string]testUser{
+			"uid=alice," + testBaseDN: {uid: "alice", password: "alice-secret", groups: []string{strings.ToUpper(testAdminsGroup)}},
+			"uid=bob," + testBaseDN:   {uid: "bob", password: "bob-secret"},
+		},
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			d.Lock()
+			d.conns++
+			d.Unlock()
+			go d.serve(conn)
+		}
+	}()
+	return d
+}
+
+func (d *testDirectory) URL() string {
+	return "ldap://" + d.listener.Addr().String()
+}
+
+func (d *testDirectory) record(operation string) {
+	d.Lock()
+	defer d.Unlock()
+	d.operations = append(d.operations, operation)
+}
+
+func (d *testDirectory) recorded() ([]string, int) {
+	d.Lock()
+	defer d.Unlock()
+	return append([]string{}, d.operations...), d.conns
+}
+
+func (d *testDirectory) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	secure := ""
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+		switch request.Tag {
+		case goldap.ApplicationBindRequest:
+			dn := request.Children[1].Value.(string)
+			password := request.Children[2].Data.String()
+			d.record("bind " + dn + secure)
+			code := int64(goldap.LDAPResultInvalidCredentials)
+			if user, ok := d.users[dn]; dn == "" || dn == testServiceDN && password == testServicePassword || ok && user.password == password {
+				code = goldap.LDAPResultSuccess
+			}
+			writeResult(conn, id, goldap.ApplicationBindResponse, code)
+		case goldap.ApplicationSearchRequest:
+			filter, _ := goldap.DecompileFilter(request.Children[6])
+			d.record("search " + filter + secure)
+			for dn, user := range d.users {
+				if strings.HasPrefix(request.Children[0].Value.(string), testBaseDN) && strings.Contains(filter, "(uid="+user.uid+")") {
+					writeEntry(conn, id, dn, user)
+				}
+			}
+			writeResult(conn, id, goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess)
+		case goldap.ApplicationExtendedRequest:
+			d.record("starttls")
+			writeResult(conn, id, goldap.ApplicationExtendedResponse, goldap.LDAPResultSuccess)
+			server := tls.Server(conn, d.tls)
+			if err = server.Handshake(); err != nil {
+				return
+			}
+			conn, secure = server, " (tls)"
+		default:
+			return
+		}
+	}
+}
+
+func writeResult(conn net.Conn, id int64, tag ber.Tag, code int64) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	packet.AppendChild(response)
+	conn.Write(packet.Bytes())
+}
+
+func writeEntry(conn net.Conn, id int64, dn string, user testUser) {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "objectName"))
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range map[string][]string{"uid": {user.uid}, "memberOf": user.groups} {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	entry.AppendChild(attributes)
+	packet.AppendChild(entry)
+	conn.Write(packet.Bytes())
+}
+
+// testCertificate 生成 127.0.0.1 的自签名证书，返回证书与保存证书的 PEM 文件
+func testCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test directory"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "ca.pem")
+	if err = os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, file
+}
+
+// setupLDAP 启动目录服务并生成 ldap 配置，extra 为追加到 [ldap] 中的配置
+func setupLDAP(t *testing.T, extra string) *testDirectory {
+	t.Helper()
+	cert, caFile := testCertificate(t)
+	d := newTestDirectory(t, cert)
+	content := fmt.Sprintf(`[ldap]
+enabled = true
+url = %q
+ca_file = %q
+timeout = 5
+base_dn = %q
+user_filter = "(&(objectClass=person)(uid=%%s))"
+username_attribute = "uid"
+group_attribute = "memberOf"
+%s
+[[ldap.role_mappings]]
+group = %q
+roles = ["host_editer", "port_editer"]
+`, d.URL(), caFile, testBaseDN, extra, testAdminsGroup)
+	file := filepath.Join(t.TempDir(), "firewalld-gateway.toml")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitConfiguration(file); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestAuthenticateWithStartTLS(t *testing.T) {
+	d := setupLDAP(t, fmt.Sprintf("start_tls = true\nbind_dn = %q\nbind_password = %q\n", testServiceDN, testServicePassword))
+
+	entry, err := Authenticate("alice", "alice-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.DN != "uid=alice,"+testBaseDN || entry.Username != "alice" || !reflect.DeepEqual(entry.Groups, []string{strings.ToUpper(testAdminsGroup)}) {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	operations, _ := d.recorded()
+	expected := []string{
+		"starttls",
+		"bind " + testServiceDN + " (tls)",
+		"search (&(objectClass=person)(uid=alice)) (tls)",
+		"bind uid=alice," + testBaseDN + " (tls)",
+	}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Fatalf("operations:\nexpected %q\ngot      %q", expected, operations)
+	}
+}
+
+func TestStartTLSRequiresTrustedCertificate(t *testing.T) {
+	setupLDAP(t, "start_tls = true\n")
+	config.CONFIG.LDAP.CAFile = ""
+
+	if _, err := Authenticate("alice", "alice-secret"); err == nil {
+		t.Fatal("StartTLS succeeded with an untrusted certificate")
+	}
+}
+
+func TestAuthenticateFailures(t *testing.T) {
+	d := setupLDAP(t, "")
+
+	if _, err := Authenticate("alice", "wrong"); err != ErrInvalidCredentials {
+		t.Fatalf("wrong password: expected %v, got %v", ErrInvalidCredentials, err)
+	}
+	if _, err := Authenticate("carol", "carol-secret"); err != ErrUserNotFound {
+		t.Fatalf("unknown user: expected %v, got %v", ErrUserNotFound, err)
+	}
+	// 过滤条件中的特殊字符需要转义，否则 alice)(uid=* 会匹配到 alice
+	if _, err := Authenticate("alice)(uid=*", "alice-secret"); err != ErrUserNotFound {
+		t.Fatalf("filter injection: expected %v, got %v", ErrUserNotFound, err)
+	}
+
+	// 空密码不连接目录服务，避免被当作匿名绑定
+	_, before := d.recorded()
+	if _, err := Authenticate("alice", ""); err != ErrInvalidCredentials {
+		t.Fatalf("empty password: expected %v, got %v", ErrInvalidCredentials, err)
+	}
+	if _, after := d.recorded(); after != before {
+		t.Fatal("empty password connected to the directory")
+	}
+}
+
+func TestLookupBindsAnonymously(t *testing.T) {
+	d := setupLDAP(t, "")
+
+	entry, err := Lookup("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Username != "bob" || len(entry.Groups) != 0 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	operations, _ := d.recorded()
+	if expected := []string{"bind ", "search (&(objectClass=person)(uid=bob))"}; !reflect.DeepEqual(operations, expected) {
+		t.Fatalf("operations:\nexpected %q\ngot      %q", expected, operations)
+	}
+}
+
+func TestMapRoles(t *testing.T) {
+	setupLDAP(t, "")
+
+	// 组 DN 不区分大小写
+	if roles := MapRoles([]string{strings.ToUpper(testAdminsGroup)}); !reflect.DeepEqual(roles, []string{"host_editer", "port_editer"}) {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+	if roles := MapRoles([]string{"cn=others,ou=groups,dc=example,dc=com"}); roles == nil || len(roles) != 0 {
+		t.Fatalf("unmapped groups should clear the roles, got %#v", roles)
+	}
+	config.CONFIG.LDAP.RoleMappings = nil
+	if roles := MapRoles([]string{testAdminsGroup}); roles != nil {
+		t.Fatalf("roles should be kept without mappings, got %v", roles)
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "firewalld_gateway"
+	// otherMethod 代替不是标准 HTTP 方法的 method 标签
+	otherMethod = "other"
+)
+
+// httpMethods 标准的 HTTP 方法，客户端可以发送任意的方法，不在其中的记为 other，避免产生过多的序列
+var httpMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+var (
+	// HTTPRequests 与 HTTPRequestDuration 按路由而不是请求路径统计，避免路径参数产生过多的序列
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	DbusCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "dbus",
+		Name:      "calls_total",
+		Help:      "Total number of D-Bus calls to firewalld by method and host.",
+	}, []string{"method", "host"})
+	DbusCallErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "dbus",
+		Name:      "call_errors_total",
+		Help:      "Total number of failed D-Bus calls to firewalld by method and host.",
+	}, []string{"method", "host"})
+	DbusCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "dbus",
+		Name:      "call_duration_seconds",
+		Help:      "Latency of D-Bus calls to firewalld by method and host.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "host"})
+	// HostUp 最近一次连接主机的 D-Bus 服务是否成功
+	HostUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "host_up",
+		Help:      "Whether the last connection to the D-Bus service of the host succeeded.",
+	}, []string{"host"})
+
+	BatchQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "batch",
+		Name:      "queue_depth",
+		Help:      "Number of events waiting in the batch queue.",
+	})
+	BatchEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "batch",
+		Name:      "events_total",
+		Help:      "Total number of processed batch events by event and result.",
+	}, []string{"event", "result"})
+	BatchRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "batch",
+		Name:      "retries_total",
+		Help:      "Total number of batch event retries by event.",
+	}, []string{"event"})
+	BatchFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "batch",
+		Name:      "failures_total",
+		Help:      "Total number of batch events dropped after exceeding the retry number by event.",
+	}, []string{"event"})
+
+	TemplateDrift = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "template",
+		Name:      "drift_checks_total",
+		Help:      "Total number of template drift checks by template and status.",
+	}, []string{"template", "status"})
+
+	LoginFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "login_failures_total",
+		Help:      "Total number of failed logins by reason.",
+	}, []string{"reason"})
+)
+
+// Method 返回请求的 method 标签
+func Method(method string) string {
+	if httpMethods[method] {
+		return method
+	}
+	return otherMethod
+}
+
+func init() {
+	prometheus.MustRegister(
+		HTTPRequests,
+		HTTPRequestDuration,
+		DbusCalls,
+		DbusCallErrors,
+		DbusCallDuration,
+		HostUp,
+		BatchQueueDepth,
+		BatchEvents,
+		BatchRetries,
+		BatchFailures,
+		TemplateDrift,
+		LoginFailures,
+	)
+}
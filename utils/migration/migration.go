@@ -3,61 +3,71 @@ package migration
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
 	"github.com/cylonchau/firewalld-gateway/config"
-	"github.com/cylonchau/firewalld-gateway/server/app/router"
+	v1 "github.com/cylonchau/firewalld-gateway/utils/migration/v1"
 	"github.com/cylonchau/firewalld-gateway/utils/model"
 )
 
+// RegisterRouter 注册服务的全部路由，用于初始化路由表；由 server 设置，
+// migration 不直接依赖 router，router 的 /readyz 才能检查数据库版本
+var RegisterRouter func(e *gin.Engine)
+
+// registeredRoutes 返回服务注册的全部路由
+func registeredRoutes() (gin.RoutesInfo, error) {
+	if RegisterRouter == nil {
+		return nil, errors.New("migration.RegisterRouter is not set")
+	}
+	http := gin.New()
+	RegisterRouter(http)
+	return http.Routes(), nil
+}
+
+// Migration 将数据库迁移到最新版本，与 migrate up 相同
 func Migration(driver string) error {
-	if driver == "" {
-		return errors.New("Unkown database driver")
+	dbInterface, enconterError := Open(driver)
+	if enconterError != nil {
+		return enconterError
 	}
-	var (
-		dbInterface   *gorm.DB
-		enconterError error
-	)
+	return Up(dbInterface, 0, false, os.Stdout)
+}
+
+// Open 按驱动打开迁移使用的数据库连接
+func Open(driver string) (*gorm.DB, error) {
 	switch driver {
 	case "mysql":
-		if dbInterface, enconterError = MySQL(); enconterError == nil {
-			// 执行迁移
-			if err := autoMigrate(dbInterface); err != nil {
-				return err
-			}
-		}
-		return enconterError
+		return MySQL()
+	case "postgres":
+		return PostgreSQL()
 	case "sqlite":
-		if dbInterface, enconterError = SQLite(); enconterError == nil {
-			if err := autoMigrate(dbInterface); err != nil {
-				return err
-			}
-		}
-		return nil
+		return SQLite()
 	}
-	return enconterError
+	return nil, errors.New("Unkown database driver")
 }
 
 func initialData(db *gorm.DB) {
 	// inital roles
 	var (
-		user_w_router_ids, user_r_router_ids         []model.Router // /security/user
-		token_w_router_ids, token_r_router_ids       []model.Router // token
-		host_w_router_ids, host_r_router_ids         []model.Router // /fw/host/
-		tag_w_router_ids, tag_r_router_ids           []model.Router // /fw/tag/
-		auth_w_router_ids, auth_r_router_ids         []model.Router // /security/auth
-		service_r_router_ids, service_w_router_ids   []model.Router // service
-		nat_r_router_ids, nat_w_router_ids           []model.Router // NAT
-		port_r_router_ids, port_w_router_ids         []model.Router // port
-		rich_r_router_ids, rich_w_router_ids         []model.Router // rich
-		setting_w_router_ids, setting_r_router_ids   []model.Router // setting
-		template_w_router_ids, template_r_router_ids []model.Router
-		audit_r_router_ids                           []model.Router // audit
+		user_w_router_ids, user_r_router_ids         []v1.Router // /security/user
+		token_w_router_ids, token_r_router_ids       []v1.Router // token
+		host_w_router_ids, host_r_router_ids         []v1.Router // /fw/host/
+		tag_w_router_ids, tag_r_router_ids           []v1.Router // /fw/tag/
+		auth_w_router_ids, auth_r_router_ids         []v1.Router // /security/auth
+		service_r_router_ids, service_w_router_ids   []v1.Router // service
+		nat_r_router_ids, nat_w_router_ids           []v1.Router // NAT
+		port_r_router_ids, port_w_router_ids         []v1.Router // port
+		rich_r_router_ids, rich_w_router_ids         []v1.Router // rich
+		setting_w_router_ids, setting_r_router_ids   []v1.Router // setting
+		template_w_router_ids, template_r_router_ids []v1.Router
+		audit_r_router_ids                           []v1.Router // audit
 	)
 
 	db.Select([]string{"id"}).Where("path LIKE '%/security/users%' AND method != 'GET'").Find(&user_w_router_ids)                   // user
@@ -81,88 +91,218 @@ func initialData(db *gorm.DB) {
 	db.Select([]string{"id"}).Where("path LIKE '%/rich%' AND method != 'GET'").Find(&setting_w_router_ids)                          // fw setting
 	db.Select([]string{"id"}).Where("path LIKE '%/rich%' AND method = 'GET'").Find(&setting_r_router_ids)                           // fw setting
 	db.Select([]string{"id"}).Where("path LIKE '%/audit%' and method = 'GET'").Find(&audit_r_router_ids)                            // fw audit
-	db.Select([]string{"id"}).Where("path LIKE '%/rich%' AND method = 'GET'").Find(&setting_r_router_ids)                           // fw setting
+	db.Select([]string{"id"}).Where("path LIKE '%/fw/template%' AND method != 'GET'").Find(&template_w_router_ids)                  // template
+	db.Select([]string{"id"}).Where("path LIKE '%/fw/template%' AND method = 'GET'").Find(&template_r_router_ids)                   // template
 	db.Select([]string{"id"}).Where("path LIKE '%/audit%' and method = 'GET'").Find(&audit_r_router_ids)                            // fw audit
-	db.Create(&model.Role{Name: "user_editer", Routers: user_w_router_ids})
-	db.Create(&model.Role{Name: "user_viewer", Routers: user_r_router_ids})
-	db.Create(&model.Role{Name: "token_viewer", Routers: token_r_router_ids})
-	db.Create(&model.Role{Name: "token_editer", Routers: token_w_router_ids})
-	db.Create(&model.Role{Name: "host_editer", Routers: host_w_router_ids})
-	db.Create(&model.Role{Name: "host_viewer", Routers: host_r_router_ids})
-	db.Create(&model.Role{Name: "tag_editer", Routers: tag_w_router_ids})
-	db.Create(&model.Role{Name: "tag_viewer", Routers: tag_r_router_ids})
-	db.Create(&model.Role{Name: "auth_viewer", Routers: auth_r_router_ids})
-	db.Create(&model.Role{Name: "auth_editer", Routers: auth_w_router_ids})
-	db.Create(&model.Role{Name: "service_viewer", Routers: service_r_router_ids})
-	db.Create(&model.Role{Name: "service_editer", Routers: service_w_router_ids})
-	db.Create(&model.Role{Name: "nat_viewer", Routers: nat_r_router_ids})
-	db.Create(&model.Role{Name: "nat_editer", Routers: nat_w_router_ids})
-	db.Create(&model.Role{Name: "port_viewer", Routers: port_r_router_ids})
-	db.Create(&model.Role{Name: "port_editer", Routers: port_w_router_ids})
-	db.Create(&model.Role{Name: "rich_viewer", Routers: rich_r_router_ids})
-	db.Create(&model.Role{Name: "rich_editer", Routers: rich_w_router_ids})
-	db.Create(&model.Role{Name: "setting_viewer", Routers: setting_r_router_ids})
-	db.Create(&model.Role{Name: "setting_editer", Routers: setting_w_router_ids})
-	db.Create(&model.Role{Name: "template_viewer", Routers: template_r_router_ids})
-	db.Create(&model.Role{Name: "template_editer", Routers: template_w_router_ids})
-	db.Create(&model.Role{Name: "audit_viewer", Routers: audit_r_router_ids})
-
-	db.Create(&model.User{Username: "admin", Password: model.EncryptPassword("admin")})
+	db.Create(&v1.Role{Name: "user_editer", Routers: user_w_router_ids})
+	db.Create(&v1.Role{Name: "user_viewer", Routers: user_r_router_ids})
+	db.Create(&v1.Role{Name: "token_viewer", Routers: token_r_router_ids})
+	db.Create(&v1.Role{Name: "token_editer", Routers: token_w_router_ids})
+	db.Create(&v1.Role{Name: "host_editer", Routers: host_w_router_ids})
+	db.Create(&v1.Role{Name: "host_viewer", Routers: host_r_router_ids})
+	db.Create(&v1.Role{Name: "tag_editer", Routers: tag_w_router_ids})
+	db.Create(&v1.Role{Name: "tag_viewer", Routers: tag_r_router_ids})
+	db.Create(&v1.Role{Name: "auth_viewer", Routers: auth_r_router_ids})
+	db.Create(&v1.Role{Name: "auth_editer", Routers: auth_w_router_ids})
+	db.Create(&v1.Role{Name: "service_viewer", Routers: service_r_router_ids})
+	db.Create(&v1.Role{Name: "service_editer", Routers: service_w_router_ids})
+	db.Create(&v1.Role{Name: "nat_viewer", Routers: nat_r_router_ids})
+	db.Create(&v1.Role{Name: "nat_editer", Routers: nat_w_router_ids})
+	db.Create(&v1.Role{Name: "port_viewer", Routers: port_r_router_ids})
+	db.Create(&v1.Role{Name: "port_editer", Routers: port_w_router_ids})
+	db.Create(&v1.Role{Name: "rich_viewer", Routers: rich_r_router_ids})
+	db.Create(&v1.Role{Name: "rich_editer", Routers: rich_w_router_ids})
+	db.Create(&v1.Role{Name: "setting_viewer", Routers: setting_r_router_ids})
+	db.Create(&v1.Role{Name: "setting_editer", Routers: setting_w_router_ids})
+	db.Create(&v1.Role{Name: "template_viewer", Routers: template_r_router_ids})
+	db.Create(&v1.Role{Name: "template_editer", Routers: template_w_router_ids})
+	db.Create(&v1.Role{Name: "audit_viewer", Routers: audit_r_router_ids})
+
+	password, _ := model.HashPassword("admin")
+	db.Create(&v1.User{Username: "admin", Password: password, Source: model.UserSourceLocal})
 }
 
 func autoMigrate(dbInterface *gorm.DB) (enconterError error) {
 	dbInterface.Migrator().CurrentDatabase()
-	if !dbInterface.Migrator().HasTable(&model.User{}) {
-		if enconterError = dbInterface.Migrator().AutoMigrate(&model.User{}); enconterError != nil {
+	if !dbInterface.Migrator().HasTable(&v1.User{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.User{}); enconterError != nil {
 			return enconterError
 		}
+	} else {
+		for _, column := range []string{"Source", "Subject"} {
+			if !dbInterface.Migrator().HasColumn(&v1.User{}, column) {
+				if enconterError = dbInterface.Migrator().AddColumn(&v1.User{}, column); enconterError != nil {
+					return enconterError
+				}
+			}
+		}
+		// 旧版本的 password 为 varchar(32)，放不下 bcrypt/argon2id 的哈希；username 放不下外部身份源的用户名
+		widen := map[string]string{"password": "Password", "username": "Username"}
+		lengths := map[string]int64{"password": 255, "username": 64}
+		if columns, err := dbInterface.Migrator().ColumnTypes(&v1.User{}); err == nil {
+			for _, column := range columns {
+				if length, ok := column.Length(); ok && widen[column.Name()] != "" && length < lengths[column.Name()] {
+					if enconterError = dbInterface.Migrator().AlterColumn(&v1.User{}, widen[column.Name()]); enconterError != nil {
+						return enconterError
+					}
+				}
+			}
+		}
 	}
-	if !dbInterface.Migrator().HasTable(&model.Tag{}) {
-		if enconterError = dbInterface.Migrator().AutoMigrate(&model.Tag{}); enconterError != nil {
+	// 已有的审计日志需要补充操作的主机
+	backfillAuditHosts := !dbInterface.Migrator().HasTable(&v1.AuditHost{})
+	for _, item := range []interface{}{&v1.PasswordHistory{}, &v1.OIDCState{}, &v1.RoleScope{}, &v1.Session{}, &v1.UserMFA{}, &v1.RecoveryCode{}, &v1.Lockout{}, &v1.ChangeRequest{}, &v1.ChangeComment{}, &v1.ChangeApproverRole{}, &v1.AuditCheckpoint{}, &v1.AuditHost{}, &v1.AuditArchive{}, &v1.AuditChainHead{}, &v1.LeaderLease{}, &v1.BatchTask{}, &v1.MFAChallenge{}} {
+		if !dbInterface.Migrator().HasTable(item) {
+			if enconterError = dbInterface.Migrator().AutoMigrate(item); enconterError != nil {
+				return enconterError
+			}
+		}
+	}
+	if !dbInterface.Migrator().HasColumn(&v1.Session{}, "MFAVerifiedAt") {
+		if enconterError = dbInterface.Migrator().AddColumn(&v1.Session{}, "MFAVerifiedAt"); enconterError != nil {
+			return enconterError
+		}
+	}
+	if !dbInterface.Migrator().HasTable(&v1.Tag{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.Tag{}); enconterError != nil {
 			return enconterError
 		}
 	}
-	if !dbInterface.Migrator().HasTable(&model.Host{}) {
-		if enconterError = dbInterface.Migrator().AutoMigrate(&model.Host{}); enconterError != nil {
+	if !dbInterface.Migrator().HasTable(&v1.Host{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.Host{}); enconterError != nil {
 			return enconterError
 		}
 	}
-	if !dbInterface.Migrator().HasTable(&model.Template{}) {
-		if enconterError = dbInterface.Migrator().AutoMigrate(&model.Template{}); enconterError != nil {
+	if !dbInterface.Migrator().HasTable(&v1.Template{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.Template{}); enconterError != nil {
 			return enconterError
 		}
+	} else {
+		for _, column := range []string{"Masquerade", "DriftMode"} {
+			if !dbInterface.Migrator().HasColumn(&v1.Template{}, column) {
+				if enconterError = dbInterface.Migrator().AddColumn(&v1.Template{}, column); enconterError != nil {
+					return enconterError
+				}
+			}
+		}
 	}
-	if !dbInterface.Migrator().HasTable(&model.Port{}) {
-		if enconterError = dbInterface.Migrator().AutoMigrate(&model.Port{}); enconterError != nil {
+	if !dbInterface.Migrator().HasTable(&v1.Port{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.Port{}); enconterError != nil {
 			return enconterError
 		}
 	}
-	if !dbInterface.Migrator().HasTable(&model.Rich{}) {
-		if enconterError = dbInterface.Migrator().AutoMigrate(&model.Rich{}); enconterError != nil {
+	if !dbInterface.Migrator().HasTable(&v1.Rich{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.Rich{}); enconterError != nil {
 			return enconterError
 		}
 
 	}
-	if !dbInterface.Migrator().HasTable(&model.Token{}) {
-		if enconterError = dbInterface.Migrator().AutoMigrate(&model.Token{}); enconterError != nil {
+	for _, item := range []interface{}{&v1.Service{}, &v1.ForwardPort{}, &v1.IcmpBlock{}, &v1.Source{}, &v1.Protocol{}, &v1.Interface{}} {
+		if !dbInterface.Migrator().HasTable(item) {
+			if enconterError = dbInterface.Migrator().AutoMigrate(item); enconterError != nil {
+				return enconterError
+			}
+		}
+	}
+	if !dbInterface.Migrator().HasTable(&v1.TemplateRevision{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.TemplateRevision{}); enconterError != nil {
 			return enconterError
 		}
 	}
-	if !dbInterface.Migrator().HasTable(&model.Audit{}) {
-		if enconterError = dbInterface.Migrator().AutoMigrate(&model.Audit{}); enconterError != nil {
+	if !dbInterface.Migrator().HasTable(&v1.TemplateDrift{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.TemplateDrift{}); enconterError != nil {
 			return enconterError
 		}
 	}
-
-	if !dbInterface.Migrator().HasTable(&model.Role{}) || !dbInterface.Migrator().HasTable(&model.Router{}) {
-		if enconterError = dbInterface.AutoMigrate(&model.Role{}, &model.Router{}); enconterError != nil {
+	if !dbInterface.Migrator().HasTable(&v1.TemplateApplication{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.TemplateApplication{}); enconterError != nil {
+			return enconterError
+		}
+	} else {
+		for _, column := range []string{"JobID", "Mode"} {
+			if !dbInterface.Migrator().HasColumn(&v1.TemplateApplication{}, column) {
+				if enconterError = dbInterface.Migrator().AddColumn(&v1.TemplateApplication{}, column); enconterError != nil {
+					return enconterError
+				}
+			}
+		}
+	}
+	if !dbInterface.Migrator().HasTable(&v1.TemplateJob{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.TemplateJob{}); enconterError != nil {
+			return enconterError
+		}
+	}
+	if !dbInterface.Migrator().HasTable(&v1.Token{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.Token{}); enconterError != nil {
 			return enconterError
 		}
-		http := gin.New()
-		router.RegisteredRouter(http)
+	} else {
+		for _, column := range []string{"Prefix", "Hash", "ExpiresAt", "LastUsedAt", "LastUsedIP"} {
+			if !dbInterface.Migrator().HasColumn(&v1.Token{}, column) {
+				if enconterError = dbInterface.Migrator().AddColumn(&v1.Token{}, column); enconterError != nil {
+					return enconterError
+				}
+			}
+		}
+		if !dbInterface.Migrator().HasIndex(&v1.Token{}, "Hash") {
+			if enconterError = dbInterface.Migrator().CreateIndex(&v1.Token{}, "Hash"); enconterError != nil {
+				return enconterError
+			}
+		}
+		// 旧版本以明文保存永久 token，这些 token 无法再用于认证，清除明文
+		if dbInterface.Migrator().HasColumn(&v1.Token{}, "token") {
+			if enconterError = dbInterface.Table("tokens").Where("token <> ?", "").Update("token", "").Error; enconterError != nil {
+				return enconterError
+			}
+		}
+	}
+	if !dbInterface.Migrator().HasTable(&v1.TokenScope{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.TokenScope{}); enconterError != nil {
+			return enconterError
+		}
+	}
+	if !dbInterface.Migrator().HasTable(&v1.Audit{}) {
+		if enconterError = dbInterface.Migrator().AutoMigrate(&v1.Audit{}); enconterError != nil {
+			return enconterError
+		}
+	} else {
+		if dbInterface.Dialector.Name() == "mysql" && !dbInterface.Migrator().HasColumn(&v1.Audit{}, "Status") {
+			// method 原为 char(5)，mysql 无法保存 DELETE
+			if enconterError = dbInterface.Migrator().AlterColumn(&v1.Audit{}, "Method"); enconterError != nil {
+				return enconterError
+			}
+		}
+		for _, column := range []string{"TokenID", "Event", "Detail", "Host", "Zone", "Body", "Status", "Code", "Latency", "SnapshotBefore", "SnapshotAfter", "PrevHash", "Hash"} {
+			if !dbInterface.Migrator().HasColumn(&v1.Audit{}, column) {
+				if enconterError = dbInterface.Migrator().AddColumn(&v1.Audit{}, column); enconterError != nil {
+					return enconterError
+				}
+			}
+		}
+		for _, index := range []string{"CreatedAt", "Event", "Host", "Zone", "Status", "Code", "Hash"} {
+			if !dbInterface.Migrator().HasIndex(&v1.Audit{}, index) {
+				if enconterError = dbInterface.Migrator().CreateIndex(&v1.Audit{}, index); enconterError != nil {
+					return enconterError
+				}
+			}
+		}
+		if backfillAuditHosts {
+			if enconterError = model.BackfillAuditHosts(dbInterface); enconterError != nil {
+				return enconterError
+			}
+		}
+	}
 
-		for _, route := range http.Routes() {
-			result := dbInterface.Create(&model.Router{
+	if !dbInterface.Migrator().HasTable(&v1.Role{}) || !dbInterface.Migrator().HasTable(&v1.Router{}) {
+		if enconterError = dbInterface.AutoMigrate(&v1.Role{}, &v1.Router{}); enconterError != nil {
+			return enconterError
+		}
+		routes, err := registeredRoutes()
+		if err != nil {
+			return err
+		}
+		for _, route := range routes {
+			result := dbInterface.Create(&v1.Router{
 				Path:   route.Path,
 				Method: route.Method,
 			})
@@ -172,6 +312,37 @@ func autoMigrate(dbInterface *gorm.DB) (enconterError error) {
 			}
 		}
 		initialData(dbInterface)
+	} else {
+		if !dbInterface.Migrator().HasColumn(&v1.Role{}, "RequireMFA") {
+			if enconterError = dbInterface.Migrator().AddColumn(&v1.Role{}, "RequireMFA"); enconterError != nil {
+				return enconterError
+			}
+		}
+		// 旧版本的 method 为 char(4)，放不下 DELETE 与 PATCH
+		if columns, err := dbInterface.Migrator().ColumnTypes(&v1.Router{}); err == nil {
+			for _, column := range columns {
+				if length, ok := column.Length(); ok && column.Name() == "method" && length < 10 {
+					if enconterError = dbInterface.Migrator().AlterColumn(&v1.Router{}, "Method"); enconterError != nil {
+						return enconterError
+					}
+				}
+			}
+		}
+		// 新版本增加的路由
+		routes, err := registeredRoutes()
+		if err != nil {
+			return err
+		}
+		for _, route := range routes {
+			var count int64
+			dbInterface.Model(&v1.Router{}).Where("path = ? AND method = ?", route.Path, route.Method).Count(&count)
+			if count > 0 {
+				continue
+			}
+			if enconterError = dbInterface.Create(&v1.Router{Path: route.Path, Method: route.Method}).Error; enconterError != nil {
+				return enconterError
+			}
+		}
 	}
 	return nil
 }
@@ -190,3 +361,7 @@ func MySQL() (*gorm.DB, error) {
 	)
 	return gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Info)})
 }
+
+func PostgreSQL() (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(config.CONFIG.PostgreSQL.DSN()), &gorm.Config{Logger: logger.Default.LogMode(logger.Info)})
+}
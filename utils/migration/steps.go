@@ -0,0 +1,96 @@
+package migration
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	v1 "github.com/cylonchau/firewalld-gateway/utils/migration/v1"
+)
+
+// steps 按版本排序的迁移，新的迁移只能追加在最后
+var steps = []Step{
+	{Version: 1, Name: "baseline", Up: baselineUp, Down: baselineDown, Baseline: true},
+	{Version: 2, Name: "drop_plaintext_tokens", Up: dropPlaintextTokensUp, Down: dropPlaintextTokensDown},
+	{Version: 3, Name: "widen_ip_columns", Up: widenIPColumnsUp, Down: widenIPColumnsDown},
+	{Version: 4, Name: "add_template_job_trace_parent", Up: addTemplateJobTraceParentUp, Down: addTemplateJobTraceParentDown},
+}
+
+// baselineUp 建表并初始化路由、角色与 admin 用户；已经部署的旧版本没有迁移记录，
+// 同样从版本 1 开始，只补充缺少的表、列与路由
+func baselineUp(tx *gorm.DB) error {
+	if tx.DryRun {
+		return tx.Migrator().CreateTable(v1.Models...)
+	}
+	return autoMigrate(tx)
+}
+
+func baselineDown(tx *gorm.DB) error {
+	return tx.Migrator().DropTable(append(append([]interface{}{}, v1.Models...), v1.JoinTables...)...)
+}
+
+// dropPlaintextTokensUp 删除旧版本保存明文 token 的列，版本 1 已经清除了其中的数据
+func dropPlaintextTokensUp(tx *gorm.DB) error {
+	if !tx.DryRun && !tx.Migrator().HasColumn(&v1.Token{}, "token") {
+		return nil
+	}
+	return tx.Exec("ALTER TABLE tokens DROP COLUMN token").Error
+}
+
+func dropPlaintextTokensDown(tx *gorm.DB) error {
+	if !tx.DryRun && tx.Migrator().HasColumn(&v1.Token{}, "token") {
+		return nil
+	}
+	return tx.Exec("ALTER TABLE tokens ADD COLUMN token varchar(255) DEFAULT ''").Error
+}
+
+// ipColumns 以 uint32 保存 IPv4 地址的列，int 放不下 128.0.0.0 及以上的地址
+var ipColumns = []struct {
+	table  string
+	column string
+}{
+	{"hosts", "ip"},
+	{"audits", "ip"},
+	{"sessions", "ip"},
+	{"template_drifts", "ip"},
+	{"template_applications", "ip"},
+	{"tokens", "last_used_ip"},
+	{"users", "login_ip"},
+}
+
+// widenIPColumnsUp 把 IPv4 地址的列改为 bigint，sqlite 的整数为 64 位，不需要修改
+func widenIPColumnsUp(tx *gorm.DB) error {
+	return alterIPColumns(tx, "bigint")
+}
+
+// widenIPColumnsDown 改回 int，已经保存了 128.0.0.0 及以上的地址时 postgres 与 mysql 拒绝回滚
+func widenIPColumnsDown(tx *gorm.DB) error {
+	return alterIPColumns(tx, "int")
+}
+
+func alterIPColumns(tx *gorm.DB, columnType string) error {
+	for _, ip := range ipColumns {
+		var sql string
+		switch tx.Dialector.Name() {
+		case "postgres":
+			sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", ip.table, ip.column, columnType)
+		case "mysql":
+			sql = fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", ip.table, ip.column, columnType)
+		default:
+			return nil
+		}
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTemplateJobTraceParentUp 保存创建模板下发任务的 span，任务改为由 leader 执行
+func addTemplateJobTraceParentUp(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE template_jobs ADD COLUMN trace_parent varchar(64) DEFAULT ''").Error
+}
+
+func addTemplateJobTraceParentDown(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE template_jobs DROP COLUMN trace_parent").Error
+}
@@ -0,0 +1,535 @@
+// Package v1 版本 1 (baseline) 的表结构。迁移只使用这里的副本，model 之后的修改不会改变已经发布的迁移，
+// 修改表结构需要在 migration 中增加新的迁移；类型名与 model 相同，中间表与外键约束的名称保持一致
+package v1
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type User struct {
+	gorm.Model
+	Username string `gorm:"index;type:varchar(64)"`
+	Password string `gorm:"type:varchar(255)"`
+	Roles    []Role `gorm:"many2many:user_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	LoginIP  int    `gorm:"index;type:int"`
+	Source   string `gorm:"type:varchar(10)"`
+	Subject  string `gorm:"index;type:varchar(255)"`
+}
+
+type Tag struct {
+	gorm.Model
+	Name        string `gorm:"index;type:varchar(255)"`
+	Description string `gorm:"type:varchar(255)"`
+	Hosts       []Host `gorm:"foreignKey:TagId;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
+
+func (*Tag) TableName() string {
+	return "tags"
+}
+
+type Host struct {
+	gorm.Model
+	Hostname string `gorm:"index;type:varchar(255)"`
+	IP       uint32 `gorm:"index;type:int"`
+	TagId    int    `gorm:"index;type:int"`
+}
+
+func (*Host) TableName() string {
+	return "hosts"
+}
+
+type Template struct {
+	gorm.Model
+	Name        string `gorm:"index;type:varchar(255)"`
+	Description string `gorm:"type:varchar(255)"`
+	Target      string `gorm:"type:varchar(100)"`
+	Masquerade  bool   `gorm:"default:false"`
+	DriftMode   string `gorm:"type:varchar(10);default:audit"`
+}
+
+func (*Template) TableName() string {
+	return "templates"
+}
+
+type Port struct {
+	gorm.Model
+	Port       uint16 `gorm:"index;type:smallint;unsigned"`
+	Protocol   string `gorm:"type:varchar(255)"`
+	TemplateId int    `gorm:"type:int"`
+}
+
+func (*Port) TableName() string {
+	return "ports"
+}
+
+// Rich 的 source、destination、port、protocol 以 json 保存
+type Rich struct {
+	gorm.Model
+	Family      string `gorm:"type:char(4)"`
+	Source      string
+	Destination string
+	Port        string
+	Protocol    string
+	Action      string `gorm:"type:varchar(30)"`
+	Limit       uint16 `gorm:"type:varchar(255)"`
+	LimitUnit   string `gorm:"type:char(1)"`
+	TemplateID  int    `gorm:"type:int"`
+}
+
+func (*Rich) TableName() string {
+	return "riches"
+}
+
+type Service struct {
+	gorm.Model
+	Name       string `gorm:"index;type:varchar(255)"`
+	TemplateID int    `gorm:"index;type:int"`
+}
+
+func (*Service) TableName() string {
+	return "services"
+}
+
+type ForwardPort struct {
+	gorm.Model
+	Port       string `gorm:"index;type:varchar(20)"`
+	Protocol   string `gorm:"type:varchar(10)"`
+	ToPort     string `gorm:"type:varchar(20)"`
+	ToAddr     string `gorm:"type:varchar(64)"`
+	TemplateID int    `gorm:"index;type:int"`
+}
+
+func (*ForwardPort) TableName() string {
+	return "forward_ports"
+}
+
+type IcmpBlock struct {
+	gorm.Model
+	Name       string `gorm:"index;type:varchar(64)"`
+	TemplateID int    `gorm:"index;type:int"`
+}
+
+func (*IcmpBlock) TableName() string {
+	return "icmp_blocks"
+}
+
+type Source struct {
+	gorm.Model
+	Address    string `gorm:"index;type:varchar(64)"`
+	Mac        string `gorm:"type:varchar(17)"`
+	Ipset      string `gorm:"type:varchar(64)"`
+	TemplateID int    `gorm:"index;type:int"`
+}
+
+func (*Source) TableName() string {
+	return "sources"
+}
+
+type Protocol struct {
+	gorm.Model
+	Value      string `gorm:"index;type:varchar(20)"`
+	TemplateID int    `gorm:"index;type:int"`
+}
+
+func (*Protocol) TableName() string {
+	return "protocols"
+}
+
+type Interface struct {
+	gorm.Model
+	Name       string `gorm:"index;type:varchar(20)"`
+	TemplateID int    `gorm:"index;type:int"`
+}
+
+func (*Interface) TableName() string {
+	return "interfaces"
+}
+
+type TemplateRevision struct {
+	gorm.Model
+	TemplateID uint   `gorm:"index;uniqueIndex:idx_template_revision"`
+	Revision   int    `gorm:"index;uniqueIndex:idx_template_revision;type:int"`
+	Author     string `gorm:"type:varchar(64)"`
+	Message    string `gorm:"type:varchar(255)"`
+	Content    string `gorm:"type:text"`
+}
+
+func (*TemplateRevision) TableName() string {
+	return "template_revisions"
+}
+
+type TemplateDrift struct {
+	gorm.Model
+	TemplateID uint   `gorm:"index"`
+	Revision   int    `gorm:"type:int"`
+	HostID     uint   `gorm:"index"`
+	IP         uint32 `gorm:"type:int"`
+	Status     string `gorm:"index;type:varchar(20)"`
+	Diff       string `gorm:"type:text"`
+	Error      string `gorm:"type:varchar(255)"`
+	CheckedAt  time.Time
+}
+
+func (*TemplateDrift) TableName() string {
+	return "template_drifts"
+}
+
+type TemplateApplication struct {
+	gorm.Model
+	JobID      uint   `gorm:"index"`
+	TemplateID uint   `gorm:"index"`
+	RevisionID uint   `gorm:"index"`
+	Revision   int    `gorm:"type:int"`
+	HostID     uint   `gorm:"index"`
+	IP         uint32 `gorm:"type:int"`
+	AppliedBy  string `gorm:"type:varchar(64)"`
+	Mode       string `gorm:"type:varchar(10)"`
+	Status     string `gorm:"type:varchar(20)"`
+	Error      string `gorm:"type:varchar(255)"`
+}
+
+func (*TemplateApplication) TableName() string {
+	return "template_applications"
+}
+
+type TemplateJob struct {
+	gorm.Model
+	TemplateID      uint `gorm:"index"`
+	RevisionID      uint
+	Revision        int    `gorm:"type:int"`
+	Mode            string `gorm:"type:varchar(10)"`
+	ContinueOnError bool
+	Hosts           string `gorm:"type:text"`
+	Status          string `gorm:"index;type:varchar(20)"`
+	CreatedBy       string `gorm:"type:varchar(64)"`
+	Applied         int    `gorm:"type:int"`
+	Skipped         int    `gorm:"type:int"`
+	Failed          int    `gorm:"type:int"`
+	FinishedAt      *time.Time
+}
+
+func (*TemplateJob) TableName() string {
+	return "template_jobs"
+}
+
+type Token struct {
+	gorm.Model
+	Prefix      string `gorm:"type:varchar(16)"`
+	Hash        string `gorm:"index;type:varchar(64)"`
+	SignedTo    string `gorm:"type:varchar(255)"`
+	SignedBy    string `gorm:"type:varchar(255)"`
+	Description string `gorm:"type:varchar(255)"`
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	LastUsedIP  uint32       `gorm:"type:int"`
+	Roles       []Role       `gorm:"many2many:token_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Scopes      []TokenScope `gorm:"foreignKey:TokenID"`
+}
+
+type TokenScope struct {
+	gorm.Model
+	TokenID uint   `gorm:"index"`
+	Kind    string `gorm:"type:varchar(10)"`
+	Value   string `gorm:"type:varchar(255)"`
+}
+
+type Audit struct {
+	ID             uint      `gorm:"primarykey"`
+	CreatedAt      time.Time `gorm:"index"`
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	UserID         uint64         `gorm:"index;type:int"`
+	TokenID        uint           `gorm:"index"`
+	IP             uint32         `gorm:"index;type:int"`
+	Method         string         `gorm:"type:varchar(10)"`
+	Path           string         `gorm:"varchar(50)"`
+	Browser        string         `gorm:"varchar(50)"`
+	System         string         `gorm:"varchar(50)"`
+	Event          string         `gorm:"index;type:varchar(32)"`
+	Detail         string         `gorm:"type:varchar(255)"`
+	Host           string         `gorm:"index;type:varchar(255)"`
+	Zone           string         `gorm:"index;type:varchar(64)"`
+	Body           string         `gorm:"type:text"`
+	Status         int            `gorm:"index"`
+	Code           int            `gorm:"index"`
+	Latency        int64
+	SnapshotBefore string `gorm:"type:text"`
+	SnapshotAfter  string `gorm:"type:text"`
+	PrevHash       string `gorm:"type:varchar(64)"`
+	Hash           string `gorm:"index;type:varchar(64)"`
+}
+
+func (*Audit) TableName() string {
+	return "audits"
+}
+
+type AuditHost struct {
+	ID      uint   `gorm:"primarykey"`
+	AuditID uint   `gorm:"index"`
+	Host    string `gorm:"index:idx_audit_hosts_host;type:varchar(255)"`
+}
+
+func (*AuditHost) TableName() string {
+	return "audit_hosts"
+}
+
+type AuditCheckpoint struct {
+	gorm.Model
+	AuditID   uint   `gorm:"index"`
+	Hash      string `gorm:"type:varchar(64)"`
+	KeyID     string `gorm:"type:varchar(64)"`
+	Signature string `gorm:"type:text"`
+}
+
+func (*AuditCheckpoint) TableName() string {
+	return "audit_checkpoints"
+}
+
+type AuditArchive struct {
+	gorm.Model
+	File      string `gorm:"type:varchar(255)"`
+	Checksum  string `gorm:"type:varchar(64)"`
+	FirstID   uint
+	LastID    uint `gorm:"index"`
+	FirstAt   time.Time
+	LastAt    time.Time
+	Records   int
+	LastHash  string `gorm:"type:varchar(64)"`
+	KeyID     string `gorm:"type:varchar(64)"`
+	Signature string `gorm:"type:text"`
+}
+
+func (*AuditArchive) TableName() string {
+	return "audit_archives"
+}
+
+type AuditChainHead struct {
+	ID      uint `gorm:"primaryKey;autoIncrement:false"`
+	AuditID uint
+	Hash    string `gorm:"type:varchar(64)"`
+}
+
+func (*AuditChainHead) TableName() string {
+	return "audit_chain_heads"
+}
+
+type Role struct {
+	gorm.Model
+	Name        string   `gorm:"size:50;not null;unique"`
+	Routers     []Router `gorm:"many2many:role_routers;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Users       []User   `gorm:"many2many:user_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Tokens      []Token  `gorm:"many2many:token_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Description string   `gorm:"size:255;not null"`
+	RequireMFA  bool     `gorm:"not null;default:false"`
+}
+
+func (*Role) TableName() string {
+	return "roles"
+}
+
+type Router struct {
+	gorm.Model
+	Path   string `gorm:"type:varchar(255);not null;"`
+	Method string `gorm:"type:varchar(10);not null;"`
+	Roles  []Role `gorm:"many2many:role_routers;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+}
+
+func (*Router) TableName() string {
+	return "routers"
+}
+
+type RoleScope struct {
+	gorm.Model
+	RoleID uint   `gorm:"index"`
+	Kind   string `gorm:"type:varchar(10)"`
+	Value  string `gorm:"type:varchar(255)"`
+}
+
+func (*RoleScope) TableName() string {
+	return "role_scopes"
+}
+
+type PasswordHistory struct {
+	gorm.Model
+	UserID   uint   `gorm:"index"`
+	Password string `gorm:"type:varchar(255)"`
+}
+
+func (*PasswordHistory) TableName() string {
+	return "password_histories"
+}
+
+type OIDCState struct {
+	gorm.Model
+	State     string    `gorm:"uniqueIndex;type:varchar(64)"`
+	Verifier  string    `gorm:"type:varchar(64)"`
+	Nonce     string    `gorm:"type:varchar(64)"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (*OIDCState) TableName() string {
+	return "oidc_states"
+}
+
+type Session struct {
+	gorm.Model
+	SessionKey    string `gorm:"uniqueIndex;type:varchar(64)"`
+	UserID        uint   `gorm:"index"`
+	RefreshHash   string `gorm:"type:varchar(64)"`
+	IP            uint32 `gorm:"type:int"`
+	UserAgent     string `gorm:"type:varchar(255)"`
+	LastSeenAt    time.Time
+	ExpiresAt     time.Time `gorm:"index"`
+	RevokedAt     *time.Time
+	RevokedReason string `gorm:"type:varchar(20)"`
+	MFAVerifiedAt *time.Time
+}
+
+func (*Session) TableName() string {
+	return "sessions"
+}
+
+type UserMFA struct {
+	gorm.Model
+	UserID      uint   `gorm:"uniqueIndex"`
+	Secret      string `gorm:"type:varchar(64)"`
+	Enabled     bool   `gorm:"not null;default:false"`
+	LastCounter int64
+	EnabledAt   *time.Time
+}
+
+func (*UserMFA) TableName() string {
+	return "user_mfas"
+}
+
+type RecoveryCode struct {
+	gorm.Model
+	UserID uint   `gorm:"index"`
+	Hash   string `gorm:"type:varchar(64)"`
+	UsedAt *time.Time
+}
+
+func (*RecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+type MFAChallenge struct {
+	gorm.Model
+	Challenge string    `gorm:"uniqueIndex;type:varchar(64)"`
+	UserID    uint      `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (*MFAChallenge) TableName() string {
+	return "mfa_challenges"
+}
+
+type Lockout struct {
+	gorm.Model
+	Kind          string `gorm:"uniqueIndex:idx_lockout_subject;type:varchar(10)"`
+	Subject       string `gorm:"uniqueIndex:idx_lockout_subject;type:varchar(64)"`
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+func (*Lockout) TableName() string {
+	return "login_lockouts"
+}
+
+type ChangeRequest struct {
+	gorm.Model
+	Policy        string `gorm:"type:varchar(64)"`
+	Method        string `gorm:"type:varchar(10)"`
+	Route         string `gorm:"type:varchar(255)"`
+	Path          string `gorm:"type:varchar(255)"`
+	RawQuery      string `gorm:"type:text"`
+	Body          string `gorm:"type:text"`
+	ContentType   string `gorm:"type:varchar(100)"`
+	Hosts         string `gorm:"type:varchar(1024)"`
+	RequesterID   uint   `gorm:"index"`
+	TokenID       uint
+	Approvals     int
+	Status        string    `gorm:"index;type:varchar(16)"`
+	ExpiresAt     time.Time `gorm:"index"`
+	ExecutedBy    uint
+	ExecutedAt    *time.Time
+	ResultStatus  int
+	Result        string               `gorm:"type:text"`
+	ApproverRoles []ChangeApproverRole `gorm:"foreignKey:ChangeID"`
+	Comments      []ChangeComment      `gorm:"foreignKey:ChangeID"`
+}
+
+func (*ChangeRequest) TableName() string {
+	return "change_requests"
+}
+
+type ChangeComment struct {
+	gorm.Model
+	ChangeID uint `gorm:"index"`
+	UserID   uint
+	Action   string `gorm:"type:varchar(16)"`
+	Comment  string `gorm:"type:varchar(1024)"`
+}
+
+func (*ChangeComment) TableName() string {
+	return "change_comments"
+}
+
+type ChangeApproverRole struct {
+	gorm.Model
+	ChangeID uint   `gorm:"index"`
+	Role     string `gorm:"index;type:varchar(64)"`
+}
+
+func (*ChangeApproverRole) TableName() string {
+	return "change_approver_roles"
+}
+
+type LeaderLease struct {
+	Name                 string `gorm:"primaryKey;type:varchar(64)"`
+	HolderIdentity       string `gorm:"type:varchar(255)"`
+	LeaseDurationSeconds int
+	AcquireTime          time.Time
+	RenewTime            time.Time
+	LeaderTransitions    int
+	Version              int64
+}
+
+func (*LeaderLease) TableName() string {
+	return "leader_leases"
+}
+
+type BatchTask struct {
+	gorm.Model
+	Name        string    `gorm:"uniqueIndex;type:varchar(64)"`
+	Event       string    `gorm:"type:varchar(32)"`
+	Host        string    `gorm:"type:varchar(255)"`
+	Task        string    `gorm:"type:text"`
+	TraceParent string    `gorm:"type:varchar(64)"`
+	Status      string    `gorm:"index:idx_batch_tasks_status_run_at;type:varchar(16)"`
+	RunAt       time.Time `gorm:"index:idx_batch_tasks_status_run_at"`
+	Attempts    int
+	Error       string `gorm:"type:varchar(255)"`
+}
+
+func (*BatchTask) TableName() string {
+	return "batch_tasks"
+}
+
+// Models 版本 1 的全部表，按依赖顺序排列
+var Models = []interface{}{
+	&User{}, &Tag{}, &Host{}, &Template{}, &Port{}, &Rich{}, &Service{},
+	&ForwardPort{}, &IcmpBlock{}, &Source{}, &Protocol{}, &Interface{},
+	&TemplateRevision{}, &TemplateDrift{}, &TemplateApplication{}, &TemplateJob{},
+	&Token{}, &TokenScope{}, &Audit{}, &Role{}, &Router{},
+	&PasswordHistory{}, &OIDCState{}, &RoleScope{}, &Session{}, &UserMFA{},
+	&RecoveryCode{}, &Lockout{}, &ChangeRequest{}, &ChangeComment{}, &ChangeApproverRole{},
+	&AuditCheckpoint{}, &AuditHost{}, &AuditArchive{}, &AuditChainHead{}, &LeaderLease{},
+	&BatchTask{}, &MFAChallenge{},
+}
+
+// JoinTables 多对多关系的中间表
+var JoinTables = []interface{}{"role_routers", "user_roles", "token_roles"}
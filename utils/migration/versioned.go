@@ -0,0 +1,299 @@
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/cylonchau/firewalld-gateway/utils/model"
+)
+
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateDirty    = "dirty"
+	StateModified = "modified"
+	StateUnknown  = "unknown"
+)
+
+// Step 一次版本迁移，Version 从 1 开始连续递增，已经发布的迁移不能再修改，修改表结构需要增加新的迁移；
+// 迁移只使用 SQL 或迁移包中的表结构副本，不引用 model 中的类型，model 的修改不会改变已经发布的迁移；
+// Up 与 Down 在 dry-run 时收到 DryRun 的会话，只生成 SQL 不执行，其中的查询不会返回结果
+type Step struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+	// Baseline 的 up 按版本 1 的表结构建表或升级旧版本的表，执行的 SQL 取决于已有的表，校验和只计算 down 的 SQL
+	Baseline bool
+}
+
+// StepStatus 迁移在数据库中的状态，StateUnknown 表示数据库中的迁移比当前版本新
+type StepStatus struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	Checksum  string     `json:"checksum"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// Latest 返回当前版本需要的数据库版本
+func Latest() uint {
+	return steps[len(steps)-1].Version
+}
+
+// Status 返回所有迁移的状态，按版本排序
+func Status(db *gorm.DB) ([]StepStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]StepStatus, 0, len(steps))
+	for _, step := range steps {
+		checksum, err := stepChecksum(db, step)
+		if err != nil {
+			return nil, err
+		}
+		status := StepStatus{Version: step.Version, Name: step.Name, State: StatePending, Checksum: checksum}
+		if record, ok := applied[step.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			switch {
+			case record.Dirty:
+				status.State = StateDirty
+			case record.Checksum != checksum:
+				status.State, status.Checksum = StateModified, record.Checksum
+			default:
+				status.State = StateApplied
+			}
+			delete(applied, step.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, StepStatus{Version: record.Version, Name: record.Name, State: StateUnknown, Checksum: record.Checksum, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// CheckVersion 检查数据库是否正好迁移到当前版本，服务启动前调用
+func CheckVersion(db *gorm.DB) error {
+	statuses, err := Status(db)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		switch status.State {
+		case StatePending:
+			return fmt.Errorf("database schema is behind version %d, run migrate up", Latest())
+		case StateDirty:
+			return fmt.Errorf("migration %d %s did not complete, run migrate up to retry it", status.Version, status.Name)
+		case StateModified:
+			return fmt.Errorf("migration %d %s was modified after it was applied", status.Version, status.Name)
+		case StateUnknown:
+			return fmt.Errorf("database schema version %d is newer than version %d of this binary", status.Version, Latest())
+		}
+	}
+	return nil
+}
+
+// Up 按顺序执行版本不大于 target 且没有执行或没有完成的迁移，target 为 0 时迁移到最新版本；
+// dryRun 为 true 时只把 SQL 输出到 out
+func Up(db *gorm.DB, target uint, dryRun bool, out io.Writer) error {
+	if target == 0 {
+		target = Latest()
+	}
+	statuses, err := Status(db)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		if status.State == StateModified {
+			return fmt.Errorf("migration %d %s was modified after it was applied", status.Version, status.Name)
+		}
+		if status.State == StateUnknown {
+			return fmt.Errorf("database schema version %d is newer than version %d of this binary", status.Version, Latest())
+		}
+	}
+	if !dryRun {
+		if err = db.AutoMigrate(&model.SchemaMigration{}); err != nil {
+			return err
+		}
+	}
+
+	for i, step := range steps {
+		if step.Version > target || statuses[i].State == StateApplied {
+			continue
+		}
+		if dryRun {
+			if err = printStep(db, step, "up", step.Up, out); err != nil {
+				return err
+			}
+			continue
+		}
+		record := &model.SchemaMigration{Version: step.Version, Name: step.Name, Checksum: statuses[i].Checksum, Dirty: true, AppliedAt: time.Now()}
+		if err = db.Save(record).Error; err != nil {
+			return err
+		}
+		if err = step.Up(db); err != nil {
+			return fmt.Errorf("migration %d %s failed: %w", step.Version, step.Name, err)
+		}
+		record.Dirty, record.AppliedAt = false, time.Now()
+		if err = db.Save(record).Error; err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "migrated %d %s\n", step.Version, step.Name)
+	}
+	return nil
+}
+
+// Down 按倒序回滚版本大于 target 的迁移，dryRun 为 true 时只把 SQL 输出到 out
+func Down(db *gorm.DB, target uint, dryRun bool, out io.Writer) error {
+	statuses, err := Status(db)
+	if err != nil {
+		return err
+	}
+	for i := len(statuses) - 1; i >= 0; i-- {
+		status := statuses[i]
+		if status.Version <= target || status.State == StatePending {
+			continue
+		}
+		if status.State == StateUnknown {
+			return fmt.Errorf("migration %d %s is unknown to this binary and cannot be rolled back", status.Version, status.Name)
+		}
+		step := stepByVersion(status.Version)
+		if dryRun {
+			if err = printStep(db, step, "down", step.Down, out); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = db.Model(&model.SchemaMigration{}).Where("version = ?", step.Version).Update("dirty", true).Error; err != nil {
+			return err
+		}
+		if err = step.Down(db); err != nil {
+			return fmt.Errorf("rolling back migration %d %s failed: %w", step.Version, step.Name, err)
+		}
+		if err = db.Where("version = ?", step.Version).Delete(&model.SchemaMigration{}).Error; err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "rolled back %d %s\n", step.Version, step.Name)
+	}
+	return nil
+}
+
+// Current 返回数据库中最后一个完成的迁移版本，没有执行过迁移时为 0
+func Current(db *gorm.DB) (uint, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+	var current uint
+	for version, record := range applied {
+		if !record.Dirty && version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+func stepByVersion(version uint) Step {
+	for _, step := range steps {
+		if step.Version == version {
+			return step
+		}
+	}
+	return Step{}
+}
+
+func appliedMigrations(db *gorm.DB) (map[uint]model.SchemaMigration, error) {
+	db = db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)})
+	applied := make(map[uint]model.SchemaMigration)
+	if !db.Migrator().HasTable(&model.SchemaMigration{}) {
+		return applied, nil
+	}
+	records := []model.SchemaMigration{}
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func printStep(db *gorm.DB, step Step, direction string, migrate func(tx *gorm.DB) error, out io.Writer) error {
+	statements, err := dryRun(db, migrate)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "-- %d %s %s\n", step.Version, step.Name, direction)
+	for _, statement := range statements {
+		fmt.Fprintf(out, "%s;\n", statement)
+	}
+	return nil
+}
+
+// stepChecksum 计算迁移在当前数据库方言下生成的 SQL 的 sha256
+func stepChecksum(db *gorm.DB, step Step) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%s\n", step.Version, step.Name)
+	migrations := []func(tx *gorm.DB) error{step.Down}
+	if !step.Baseline {
+		migrations = append(migrations, step.Up)
+	}
+	for _, migrate := range migrations {
+		statements, err := dryRun(db, migrate)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(hash, strings.Join(statements, ";\n"))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// dryRun 在 DryRun 的会话中执行迁移，返回生成的 SQL
+func dryRun(db *gorm.DB, migrate func(tx *gorm.DB) error) ([]string, error) {
+	recorder := &sqlRecorder{}
+	if err := migrate(db.Session(&gorm.Session{DryRun: true, NewDB: true, Logger: recorder})); err != nil {
+		return nil, err
+	}
+	return recorder.statements, nil
+}
+
+// sqlRecorder 记录 DryRun 时生成的 SQL 的 gorm logger
+type sqlRecorder struct {
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *sqlRecorder) Info(context.Context, string, ...interface{}) {}
+
+func (r *sqlRecorder) Warn(context.Context, string, ...interface{}) {}
+
+func (r *sqlRecorder) Error(context.Context, string, ...interface{}) {}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if err != nil && !errors.Is(err, gorm.ErrDryRunModeUnsupported) {
+		return
+	}
+	if sql, _ := fc(); sql != "" {
+		r.statements = append(r.statements, sql)
+	}
+}
@@ -1,28 +1,126 @@
 package model
 
 import (
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+
+	query2 "github.com/cylonchau/firewalld-gateway/utils/apis/query"
 )
 
-const audit_table_name = "audits"
+const (
+	audit_table_name      = "audits"
+	audit_host_table_name = "audit_hosts"
+)
 
 type Audit struct {
-	gorm.Model
-	UserID  uint64 `json:"user_id" gorm:"index;type:int"`
-	IP      uint32 `json:"ip" gorm:"index;type:int"`
-	Method  string `json:"method" gorm:"type:char(5)"`
-	Path    string `json:"path" gorm:"varchar(50)"`
-	Browser string `json:"browser" gorm:"varchar(50)"`
-	System  string `json:"system" gorm:"varchar(50)"`
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time      `json:"-"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	UserID    uint64         `json:"user_id" gorm:"index;type:int"`
+	TokenID   uint           `json:"token_id" gorm:"index"`
+	IP        uint32         `json:"ip" gorm:"index;type:bigint"`
+	Method    string         `json:"method" gorm:"type:varchar(10)"`
+	Path      string         `json:"path" gorm:"varchar(50)"`
+	Browser   string         `json:"browser" gorm:"varchar(50)"`
+	System    string         `json:"system" gorm:"varchar(50)"`
+	// Event 非请求类的审计事件，例如 login_failed，Detail 为事件的说明
+	Event  string `json:"event" gorm:"index;type:varchar(32)"`
+	Detail string `json:"detail" gorm:"type:varchar(255)"`
+	// Host 与 Zone 为请求操作的主机与 zone，多个时以逗号分隔
+	Host string `json:"host" gorm:"index;type:varchar(255)"`
+	Zone string `json:"zone" gorm:"index;type:varchar(64)"`
+	// Body 为去除敏感字段后的请求内容
+	Body string `json:"body" gorm:"type:text"`
+	// Status 为响应的 http 状态码，Code 为响应内容中的错误码
+	Status int `json:"status" gorm:"index"`
+	Code   int `json:"code" gorm:"index"`
+	// Latency 为请求的处理时间，单位毫秒
+	Latency int64 `json:"latency"`
+	// SnapshotBefore 与 SnapshotAfter 为修改请求执行前后被修改资源的快照
+	SnapshotBefore string `json:"before,omitempty" gorm:"type:text"`
+	SnapshotAfter  string `json:"after,omitempty" gorm:"type:text"`
+	// PrevHash 为上一条审计日志的 Hash，Hash 为 PrevHash 与本条日志内容的 sha256
+	PrevHash string `json:"prev_hash" gorm:"type:varchar(64)"`
+	Hash     string `json:"hash" gorm:"index;type:varchar(64)"`
+}
+
+// AuditHost 审计日志操作的主机，用于按主机精确查询审计日志
+type AuditHost struct {
+	ID      uint   `json:"id" gorm:"primarykey"`
+	AuditID uint   `json:"audit_id" gorm:"index"`
+	Host    string `json:"host" gorm:"index:idx_audit_hosts_host;type:varchar(255)"`
+}
+
+func (*AuditHost) TableName() string {
+	return audit_host_table_name
+}
+
+// auditHosts 返回审计日志操作的主机
+func auditHosts(audit *Audit) []AuditHost {
+	hosts := []AuditHost{}
+	for _, host := range strings.Split(audit.Host, ",") {
+		if host != "" {
+			hosts = append(hosts, AuditHost{AuditID: audit.ID, Host: host})
+		}
+	}
+	return hosts
+}
+
+// BackfillAuditHosts 为已有的审计日志补充操作的主机
+func BackfillAuditHosts(db *gorm.DB) error {
+	audits := []*Audit{}
+	return db.Unscoped().Select("id", "host").Where("host <> ?", "").FindInBatches(&audits, 500, func(tx *gorm.DB, batch int) error {
+		hosts := []AuditHost{}
+		for _, audit := range audits {
+			hosts = append(hosts, auditHosts(audit)...)
+		}
+		if len(hosts) == 0 {
+			return nil
+		}
+		return db.Create(&hosts).Error
+	}).Error
 }
 
 type AuditList struct {
-	Username string `json:"username" gorm:"index;type:varchar(20)"`
-	IP       uint32 `json:"ip" gorm:"index;type:int"`
-	Method   string `json:"method" gorm:"type:char(5)"`
-	Path     string `json:"path" gorm:"varchar(50)"`
-	Browser  string `json:"browser" gorm:"varchar(50)"`
-	System   string `json:"system" gorm:"varchar(50)"`
+	ID        uint      `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint64    `json:"user_id"`
+	Username  string    `json:"username" gorm:"index;type:varchar(20)"`
+	TokenID   uint      `json:"token_id"`
+	IP        uint32    `json:"ip" gorm:"index;type:bigint"`
+	Method    string    `json:"method" gorm:"type:varchar(10)"`
+	Path      string    `json:"path" gorm:"varchar(50)"`
+	Browser   string    `json:"browser" gorm:"varchar(50)"`
+	System    string    `json:"system" gorm:"varchar(50)"`
+	Event     string    `json:"event"`
+	Detail    string    `json:"detail"`
+	Host      string    `json:"host"`
+	Zone      string    `json:"zone"`
+	Body      string    `json:"body"`
+	Status    int       `json:"status"`
+	Code      int       `json:"code"`
+	Latency   int64     `json:"latency"`
+	Hash      string    `json:"hash"`
+}
+
+// AuditDetail 审计日志与修改前后的快照
+type AuditDetail struct {
+	AuditList
+	SnapshotBefore string `json:"before"`
+	SnapshotAfter  string `json:"after"`
+	PrevHash       string `json:"prev_hash"`
+}
+
+// auditListeners 审计日志保存后的回调，例如转发到 syslog
+var auditListeners []func(audit *Audit)
+
+// OnAuditLog 注册审计日志保存后的回调，回调在写入审计日志的请求中执行，不能阻塞
+func OnAuditLog(listener func(audit *Audit)) {
+	auditListeners = append(auditListeners, listener)
 }
 
 func (*Audit) TableName() string {
@@ -33,50 +131,143 @@ func (*AuditList) TableName() string {
 	return audit_table_name
 }
 
-func GetAuditLogs(title string, offset, limit int, sort string) (map[string]interface{}, error) {
+// auditColumns 审计日志列表返回的字段，快照只在详情中返回
+func auditColumns() []string {
+	columns := []string{user_table_name + ".username"}
+	for _, column := range []string{"id", "created_at", "user_id", "ip", "path", "method", "browser", "system", "token_id",
+		"event", "detail", "host", "zone", "body", "status", "code", "latency", "hash"} {
+		columns = append(columns, audit_table_name+"."+column)
+	}
+	return columns
+}
+
+func detailColumns() []string {
+	return append(auditColumns(), audit_table_name+".snapshot_before", audit_table_name+".snapshot_after", audit_table_name+".prev_hash")
+}
+
+// filterAuditLogs 按审计日志的过滤条件构造查询
+func filterAuditLogs(filter *query2.AuditListQuery) *gorm.DB {
+	tx := DB.Table(audit_table_name).
+		Joins("left join "+user_table_name+" on "+user_table_name+".id = "+audit_table_name+".user_id").
+		Where(audit_table_name+".deleted_at IS ?", nil)
+	if filter.Title != "" {
+		tx = tx.Where(user_table_name+".username LIKE ?", "%"+filter.Title+"%")
+	}
+	if filter.UserID > 0 {
+		tx = tx.Where(audit_table_name+".user_id = ?", filter.UserID)
+	}
+	if filter.TokenID > 0 {
+		tx = tx.Where(audit_table_name+".token_id = ?", filter.TokenID)
+	}
+	if filter.Host != "" {
+		tx = tx.Where(audit_table_name+".id IN (?)", DB.Table(audit_host_table_name).Select("audit_id").Where("host = ?", filter.Host))
+	}
+	if filter.Zone != "" {
+		tx = tx.Where(audit_table_name+".zone = ?", filter.Zone)
+	}
+	if filter.Method != "" {
+		tx = tx.Where(audit_table_name+".method = ?", filter.Method)
+	}
+	if filter.Path != "" {
+		tx = tx.Where(audit_table_name+".path LIKE ?", filter.Path+"%")
+	}
+	if filter.Event != "" {
+		tx = tx.Where(audit_table_name+".event = ?", filter.Event)
+	}
+	if filter.Status > 0 {
+		tx = tx.Where(audit_table_name+".status = ?", filter.Status)
+	}
+	if filter.Code > 0 {
+		tx = tx.Where(audit_table_name+".code = ?", filter.Code)
+	}
+	if filter.Failed {
+		tx = tx.Where(audit_table_name+".status >= ? OR "+audit_table_name+".code NOT IN (?)",
+			400, []int{0, query2.OK.Code, query2.BatchSuccessCreated.Code})
+	}
+	if filter.MinLatency > 0 {
+		tx = tx.Where(audit_table_name+".latency >= ?", filter.MinLatency)
+	}
+	if !filter.Since.IsZero() {
+		tx = tx.Where(audit_table_name+".created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		tx = tx.Where(audit_table_name+".created_at < ?", filter.Until)
+	}
+	return tx
+}
+
+func GetAuditLogs(filter *query2.AuditListQuery) (map[string]interface{}, error) {
 	logs := []*AuditList{}
 	response := make(map[string]interface{})
 	var count int64
 
+	offset, limit, sort := int(filter.Offset), int(filter.Limit), filter.Sort
+	if offset < 1 {
+		offset = 1
+	}
+	if sort != "asc" {
+		sort = "desc"
+	}
+
 	// 查询审计日志
-	query := DB.Table(audit_table_name).
-		Select([]string{
-			audit_table_name + ".ip",
-			audit_table_name + ".path",
-			audit_table_name + ".method",
-			audit_table_name + ".browser",
-			audit_table_name + ".system",
-			user_table_name + ".username",
-		}).
-		Joins("join "+user_table_name+" on "+user_table_name+".id = "+audit_table_name+".user_id").
-		Where(audit_table_name+".deleted_at IS ?", nil).
+	result := filterAuditLogs(filter).
+		Select(auditColumns()).
+		Order(audit_table_name + ".id " + sort).
 		Limit(limit).
-		Offset((offset - 1) * limit)
-
-	if title != "" {
-		query = query.Where(user_table_name+".username LIKE ?", "%"+title+"%")
+		Offset((offset - 1) * limit).
+		Scan(&logs)
+	if result.Error != nil {
+		return nil, result.Error
 	}
 
-	result := query.Order(audit_table_name + ".id " + sort).Scan(&logs)
+	// 获取内容的总数
+	if err := filterAuditLogs(filter).Count(&count).Error; err != nil {
+		return nil, err
+	}
 
-	// 查询总数
-	totalQuery := DB.Table(audit_table_name).
-		Where(audit_table_name+".deleted_at IS ?", nil)
+	response["list"] = logs
+	response["total"] = count
+	return response, nil
+}
 
-	if title != "" {
-		totalQuery = totalQuery.Joins("join "+user_table_name+" on "+user_table_name+".id = "+audit_table_name+".user_id").
-			Where(user_table_name+".username LIKE ?", "%"+title+"%")
+// GetAuditLog 返回审计日志与修改前后的快照
+func GetAuditLog(id uint) (*AuditDetail, error) {
+	log := &AuditDetail{}
+	result := DB.Table(audit_table_name).
+		Select(detailColumns()).
+		Joins("left join "+user_table_name+" on "+user_table_name+".id = "+audit_table_name+".user_id").
+		Where(audit_table_name+".id = ? AND "+audit_table_name+".deleted_at IS ?", id, nil).
+		Limit(1).
+		Scan(log)
+	if result.Error != nil {
+		return nil, result.Error
 	}
+	if result.RowsAffected == 0 {
+		return nil, query2.ErrAuditNotFound
+	}
+	return log, nil
+}
 
-	// 获取内容的总数
-	totalQuery.Count(&count)
-
-	if result.Error != gorm.ErrRecordNotFound {
-		response["list"] = logs
-		response["total"] = count
-		return response, nil
+// ExportAuditLogs 按写入顺序逐条读取符合过滤条件的审计日志与快照，不分页
+func ExportAuditLogs(filter *query2.AuditListQuery, fn func(log *AuditDetail) error) error {
+	rows, err := filterAuditLogs(filter).
+		Select(detailColumns()).
+		Order(audit_table_name + ".id asc").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		log := &AuditDetail{}
+		if err = DB.ScanRows(rows, log); err != nil {
+			return err
+		}
+		if err = fn(log); err != nil {
+			return err
+		}
 	}
-	return nil, result.Error
+	return rows.Err()
 }
 
 func AppendAuditLog(auditLog map[string]interface{}) {
@@ -89,5 +280,41 @@ func AppendAuditLog(auditLog map[string]interface{}) {
 		Browser: auditLog["browser"].(string),
 		System:  auditLog["system"].(string),
 	}
-	DB.Create(auditItem)
+	if tokenID, ok := auditLog["token_id"].(uint); ok {
+		auditItem.TokenID = tokenID
+	}
+	if event, ok := auditLog["event"].(string); ok {
+		auditItem.Event = event
+	}
+	if detail, ok := auditLog["detail"].(string); ok {
+		if len(detail) > 255 {
+			detail = detail[:255]
+		}
+		auditItem.Detail = detail
+	}
+	if host, ok := auditLog["host"].(string); ok {
+		if len(host) > 255 {
+			host = host[:255]
+		}
+		auditItem.Host = host
+	}
+	if zone, ok := auditLog["zone"].(string); ok {
+		if len(zone) > 64 {
+			zone = zone[:64]
+		}
+		auditItem.Zone = zone
+	}
+	auditItem.Body, _ = auditLog["body"].(string)
+	auditItem.Status, _ = auditLog["status"].(int)
+	auditItem.Code, _ = auditLog["code"].(int)
+	auditItem.Latency, _ = auditLog["latency"].(int64)
+	auditItem.SnapshotBefore, _ = auditLog["before"].(string)
+	auditItem.SnapshotAfter, _ = auditLog["after"].(string)
+	if err := appendChained(auditItem); err != nil {
+		klog.Errorf("Append audit log %s %s failed: %v", auditItem.Method, auditItem.Path, err)
+		return
+	}
+	for _, listener := range auditListeners {
+		listener(auditItem)
+	}
 }
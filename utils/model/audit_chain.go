@@ -0,0 +1,295 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+)
+
+const (
+	audit_checkpoint_table_name = "audit_checkpoints"
+	audit_chain_head_table_name = "audit_chain_heads"
+)
+
+// AuditChainHead 审计日志链的末端，只有一行；写入审计日志与清理日志时先锁定这一行，
+// 多个副本之间串行链接日志，等待锁的事务读到的是上一个事务更新后的末端
+type AuditChainHead struct {
+	ID      uint   `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	AuditID uint   `json:"audit_id"`
+	Hash    string `json:"hash" gorm:"type:varchar(64)"`
+}
+
+// AuditCheckpoint 对审计日志链末端的签名，用于发现删除末尾的日志或重新计算整条链
+type AuditCheckpoint struct {
+	gorm.Model
+	AuditID   uint   `json:"audit_id" gorm:"index"`
+	Hash      string `json:"hash" gorm:"type:varchar(64)"`
+	KeyID     string `json:"key_id" gorm:"type:varchar(64)"`
+	Signature string `json:"signature" gorm:"type:text"`
+}
+
+func (*AuditCheckpoint) TableName() string {
+	return audit_checkpoint_table_name
+}
+
+func (*AuditChainHead) TableName() string {
+	return audit_chain_head_table_name
+}
+
+// AuditVerification 审计日志链的校验结果，BrokenID 为第一个断开的链接所在的日志
+type AuditVerification struct {
+	Valid       bool   `json:"valid"`
+	Checked     int64  `json:"checked"`
+	Legacy      int64  `json:"legacy"`
+	Archived    int64  `json:"archived"`
+	Checkpoints int    `json:"checkpoints"`
+	LastID      uint   `json:"last_id"`
+	LastHash    string `json:"last_hash"`
+	BrokenID    uint   `json:"broken_id,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// auditChainContent 参与 hash 计算的审计日志内容，时间精确到毫秒，与数据库保存的精度一致
+type auditChainContent struct {
+	CreatedAt      int64
+	UserID         uint64
+	TokenID        uint
+	IP             uint32
+	Method         string
+	Path           string
+	Browser        string
+	System         string
+	Event          string
+	Detail         string
+	Host           string
+	Zone           string
+	Body           string
+	Status         int
+	Code           int
+	Latency        int64
+	SnapshotBefore string
+	SnapshotAfter  string
+}
+
+// chainLock 同一实例内串行写入审计日志，多个实例之间由事务中对 AuditChainHead 的行锁串行
+var chainLock sync.Mutex
+
+var errChainBroken = errors.New("audit chain is broken")
+
+func auditHash(audit *Audit) string {
+	content, _ := json.Marshal(auditChainContent{
+		CreatedAt:      audit.CreatedAt.UnixMilli(),
+		UserID:         audit.UserID,
+		TokenID:        audit.TokenID,
+		IP:             audit.IP,
+		Method:         audit.Method,
+		Path:           audit.Path,
+		Browser:        audit.Browser,
+		System:         audit.System,
+		Event:          audit.Event,
+		Detail:         audit.Detail,
+		Host:           audit.Host,
+		Zone:           audit.Zone,
+		Body:           audit.Body,
+		Status:         audit.Status,
+		Code:           audit.Code,
+		Latency:        audit.Latency,
+		SnapshotBefore: audit.SnapshotBefore,
+		SnapshotAfter:  audit.SnapshotAfter,
+	})
+	sum := sha256.Sum256(append([]byte(audit.PrevHash+"\n"), content...))
+	return hex.EncodeToString(sum[:])
+}
+
+// lockAuditChainHead 在事务中锁定并返回链的末端，旧版本升级后第一次写入时根据最后一条日志或最后归档的日志创建
+func lockAuditChainHead(tx *gorm.DB) (*AuditChainHead, error) {
+	head := &AuditChainHead{}
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", 1).Limit(1).Find(head)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return head, result.Error
+	}
+
+	last := &Audit{}
+	if err := tx.Unscoped().Select("id", "hash").Order("id desc").Limit(1).Find(last).Error; err != nil {
+		return nil, err
+	}
+	head = &AuditChainHead{ID: 1, AuditID: last.ID, Hash: last.Hash}
+	// 全部日志都已清理时链接到最后归档的日志
+	if last.ID == 0 {
+		archive, err := latestAuditArchive(tx)
+		if err != nil {
+			return nil, err
+		}
+		if archive != nil {
+			head.AuditID, head.Hash = archive.LastID, archive.LastHash
+		}
+	}
+	// 其他副本同时创建时只保留一行，重新锁定后读到的是先提交的事务写入的末端
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(head).Error; err != nil {
+		return nil, err
+	}
+	head = &AuditChainHead{}
+	return head, tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", 1).First(head).Error
+}
+
+// appendChained 将审计日志链接到链的末端后保存
+func appendChained(audit *Audit) error {
+	chainLock.Lock()
+	defer chainLock.Unlock()
+	return DB.Transaction(func(tx *gorm.DB) error {
+		head, err := lockAuditChainHead(tx)
+		if err != nil {
+			return err
+		}
+		audit.CreatedAt = time.Now().Truncate(time.Millisecond)
+		audit.PrevHash = head.Hash
+		audit.Hash = auditHash(audit)
+		if err := tx.Create(audit).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(head).Updates(map[string]interface{}{"audit_id": audit.ID, "hash": audit.Hash}).Error; err != nil {
+			return err
+		}
+		if hosts := auditHosts(audit); len(hosts) > 0 {
+			return tx.Create(&hosts).Error
+		}
+		return nil
+	})
+}
+
+func checkpointContent(auditID uint, hash string) string {
+	return fmt.Sprintf("%d:%s", auditID, hash)
+}
+
+// CreateAuditCheckpoint 签名审计日志链的末端，链的末端已经签名时返回已有的 checkpoint
+func CreateAuditCheckpoint() (*AuditCheckpoint, error) {
+	last := &Audit{}
+	result := DB.Unscoped().Where("hash <> ?", "").Order("id desc").Limit(1).Find(last)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	checkpoint := &AuditCheckpoint{}
+	result = DB.Where("audit_id = ?", last.ID).Limit(1).Find(checkpoint)
+	if result.Error != nil || result.RowsAffected > 0 {
+		return checkpoint, result.Error
+	}
+	kid, signature, err := auther.SignCheckpoint(checkpointContent(last.ID, last.Hash))
+	if err != nil {
+		return nil, err
+	}
+	checkpoint = &AuditCheckpoint{AuditID: last.ID, Hash: last.Hash, KeyID: kid, Signature: signature}
+	return checkpoint, DB.Create(checkpoint).Error
+}
+
+// GetAuditCheckpoints 分页返回 checkpoint
+func GetAuditCheckpoints(offset, limit int) (map[string]interface{}, error) {
+	if offset < 1 {
+		offset = 1
+	}
+	checkpoints := []AuditCheckpoint{}
+	var count int64
+	tx := DB.Model(&AuditCheckpoint{})
+	if err := tx.Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Order("id desc").Limit(limit).Offset((offset - 1) * limit).Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"list": checkpoints, "total": count}, nil
+}
+
+// VerifyAuditChain 按 id 顺序校验全部审计日志的 hash 链与 checkpoint、归档记录的签名，返回第一个断开的链接；
+// 启用 hash 链之前的日志不参与校验，只计数；已经清理的日志从最后归档的日志开始校验
+func VerifyAuditChain() (*AuditVerification, error) {
+	verification := &AuditVerification{Valid: true}
+	broken := func(id uint, format string, args ...interface{}) error {
+		verification.Valid = false
+		verification.BrokenID = id
+		verification.Reason = fmt.Sprintf(format, args...)
+		return errChainBroken
+	}
+
+	checkpoints := []AuditCheckpoint{}
+	if err := DB.Order("audit_id").Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+	verification.Checkpoints = len(checkpoints)
+	for _, checkpoint := range checkpoints {
+		if err := auther.VerifyCheckpoint(checkpoint.KeyID, checkpointContent(checkpoint.AuditID, checkpoint.Hash), checkpoint.Signature); err != nil {
+			broken(checkpoint.AuditID, "signature of checkpoint %d is invalid: %v", checkpoint.ID, err)
+			return verification, nil
+		}
+	}
+
+	archives := []AuditArchive{}
+	if err := DB.Order("last_id").Find(&archives).Error; err != nil {
+		return nil, err
+	}
+	for i := range archives {
+		if err := auther.VerifyCheckpoint(archives[i].KeyID, archiveContent(&archives[i]), archives[i].Signature); err != nil {
+			broken(archives[i].LastID, "signature of archive %d is invalid: %v", archives[i].ID, err)
+			return verification, nil
+		}
+		verification.Archived += int64(archives[i].Records)
+	}
+
+	var (
+		chained bool
+		next    int
+		audits  []*Audit
+	)
+	if len(archives) > 0 {
+		anchor := archives[len(archives)-1]
+		verification.LastID, verification.LastHash = anchor.LastID, anchor.LastHash
+		chained = anchor.LastHash != ""
+		// 已清理的日志的 checkpoint 只校验签名
+		for next < len(checkpoints) && checkpoints[next].AuditID <= anchor.LastID {
+			next++
+		}
+	}
+	err := DB.Unscoped().Order("id").FindInBatches(&audits, 500, func(tx *gorm.DB, batch int) error {
+		for _, audit := range audits {
+			if audit.Hash == "" {
+				if chained {
+					return broken(audit.ID, "audit log %d is not chained", audit.ID)
+				}
+				verification.Legacy++
+				continue
+			}
+			chained = true
+			if audit.PrevHash != verification.LastHash {
+				return broken(audit.ID, "previous audit log of %d is missing or modified", audit.ID)
+			}
+			if auditHash(audit) != audit.Hash {
+				return broken(audit.ID, "audit log %d is modified", audit.ID)
+			}
+			for ; next < len(checkpoints) && checkpoints[next].AuditID <= audit.ID; next++ {
+				if checkpoints[next].AuditID < audit.ID {
+					return broken(checkpoints[next].AuditID, "audit log %d signed by checkpoint %d is missing", checkpoints[next].AuditID, checkpoints[next].ID)
+				}
+				if checkpoints[next].Hash != audit.Hash {
+					return broken(audit.ID, "audit log %d does not match checkpoint %d", audit.ID, checkpoints[next].ID)
+				}
+			}
+			verification.Checked++
+			verification.LastID, verification.LastHash = audit.ID, audit.Hash
+		}
+		return nil
+	}).Error
+	if err != nil && err != errChainBroken {
+		return nil, err
+	}
+	if verification.Valid && next < len(checkpoints) {
+		broken(checkpoints[next].AuditID, "audit logs after %d are missing, checkpoint %d signed audit log %d", verification.LastID, checkpoints[next].ID, checkpoints[next].AuditID)
+	}
+	return verification, nil
+}
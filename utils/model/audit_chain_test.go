@@ -0,0 +1,196 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+)
+
+const chainKeys = `
+[jwt]
+algorithm = "HS256"
+secret = "jwt-test-secret"
+
+[audit.checkpoint_key]
+algorithm = "HS256"
+key_id = "checkpoint"
+secret = "checkpoint-test-secret"
+`
+
+// setupAuditChain 初始化数据库与 checkpoint 密钥，写入 n 条审计日志
+func setupAuditChain(t *testing.T, n int) {
+	t.Helper()
+	setupTest(t, chainKeys, &User{}, &Audit{}, &AuditHost{}, &AuditChainHead{}, &AuditCheckpoint{}, &AuditArchive{})
+	if err := auther.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	appendAudits(t, n)
+}
+
+func appendAudits(t *testing.T, n int) {
+	t.Helper()
+	var count int64
+	DB.Unscoped().Model(&Audit{}).Count(&count)
+	for i := 0; i < n; i++ {
+		AppendAuditLog(map[string]interface{}{
+			"user_id": int64(1), "ip": uint32(0x0A000001), "method": "POST", "browser": "", "system": "",
+			"path": fmt.Sprintf("/fw/v1/port/%d", int(count)+i), "host": "10.0.0.1", "status": 200,
+		})
+	}
+	var appended int64
+	DB.Unscoped().Model(&Audit{}).Count(&appended)
+	if appended != count+int64(n) {
+		t.Fatalf("expected %d audit logs, got %d", count+int64(n), appended)
+	}
+}
+
+func verifyAuditChain(t *testing.T) *AuditVerification {
+	t.Helper()
+	verification, err := VerifyAuditChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verification
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	cases := []struct {
+		name   string
+		tamper func(t *testing.T)
+		broken uint
+		reason string
+	}{
+		{"intact", func(t *testing.T) {}, 0, ""},
+		{"modified row", func(t *testing.T) {
+			DB.Model(&Audit{}).Where("id = ?", 3).Update("path", "/fw/v1/service")
+		}, 3, "audit log 3 is modified"},
+		// 重新计算被修改的日志的 hash 后，下一条日志的链接断开
+		{"modified row with hash", func(t *testing.T) {
+			audit := &Audit{}
+			DB.First(audit, 3)
+			audit.Path = "/fw/v1/service"
+			DB.Model(audit).Updates(map[string]interface{}{"path": audit.Path, "hash": auditHash(audit)})
+		}, 4, "previous audit log of 4 is missing or modified"},
+		{"deleted middle row", func(t *testing.T) {
+			DB.Unscoped().Delete(&Audit{}, 3)
+		}, 4, "previous audit log of 4 is missing or modified"},
+		{"soft deleted row", func(t *testing.T) {
+			DB.Delete(&Audit{}, 3)
+			DB.Model(&Audit{}).Unscoped().Where("id = ?", 3).Update("path", "/fw/v1/service")
+		}, 3, "audit log 3 is modified"},
+		// 末尾的日志没有后续的链接，只能通过 checkpoint 发现
+		{"truncated tail", func(t *testing.T) {
+			DB.Unscoped().Where("id > ?", 3).Delete(&Audit{})
+		}, 5, "audit logs after 3 are missing"},
+		{"bad checkpoint signature", func(t *testing.T) {
+			DB.Model(&AuditCheckpoint{}).Where("audit_id = ?", 5).Update("hash", strings.Repeat("0", 64))
+		}, 5, "signature of checkpoint 1 is invalid"},
+		{"unknown checkpoint key", func(t *testing.T) {
+			DB.Model(&AuditCheckpoint{}).Where("audit_id = ?", 5).Update("key_id", "other")
+		}, 5, "unknown checkpoint key other"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupAuditChain(t, 5)
+			if checkpoint, err := CreateAuditCheckpoint(); err != nil || checkpoint.AuditID != 5 {
+				t.Fatalf("expected checkpoint of audit log 5, got %+v %v", checkpoint, err)
+			}
+			tc.tamper(t)
+
+			verification := verifyAuditChain(t)
+			if tc.broken == 0 {
+				if !verification.Valid || verification.Checked != 5 || verification.LastID != 5 || verification.Checkpoints != 1 {
+					t.Fatalf("expected a valid chain of 5 audit logs, got %+v", verification)
+				}
+				return
+			}
+			if verification.Valid || verification.BrokenID != tc.broken || !strings.Contains(verification.Reason, tc.reason) {
+				t.Fatalf("expected broken at %d with %q, got %+v", tc.broken, tc.reason, verification)
+			}
+		})
+	}
+}
+
+// pruneAudits 与 auditor 的清理一样归档并删除不超过 cutoff 的审计日志，不写入归档文件
+func pruneAudits(t *testing.T, cutoff uint) {
+	t.Helper()
+	audits, err := GetExpiredAudits(cutoff, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, last := audits[0], audits[len(audits)-1]
+	if err = PruneAudits(&AuditArchive{
+		FirstID: first.ID, LastID: last.ID, FirstAt: first.CreatedAt, LastAt: last.CreatedAt,
+		Records: len(audits), LastHash: last.Hash,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyAuditChainAfterPrune(t *testing.T) {
+	setupAuditChain(t, 6)
+	if _, err := CreateAuditCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	pruneAudits(t, 4)
+	// 清理后写入的日志链接到链的末端
+	appendAudits(t, 2)
+	if _, err := CreateAuditCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	verification := verifyAuditChain(t)
+	if !verification.Valid || verification.Archived != 4 || verification.Checked != 4 || verification.LastID != 8 || verification.Checkpoints != 2 {
+		t.Fatalf("expected a valid chain of 4 archived and 4 audit logs, got %+v", verification)
+	}
+
+	// 只保留最新的一条日志，checkpoint 签名的日志已经归档
+	cutoff, err := ExpiredAuditCutoff(time.Time{}, 1)
+	if err != nil || cutoff != 7 {
+		t.Fatalf("expected cutoff 7, got %d %v", cutoff, err)
+	}
+	pruneAudits(t, cutoff)
+	appendAudits(t, 1)
+	verification = verifyAuditChain(t)
+	if !verification.Valid || verification.Archived != 7 || verification.Checked != 2 || verification.LastID != 9 {
+		t.Fatalf("expected a valid chain of 7 archived and 2 audit logs, got %+v", verification)
+	}
+}
+
+func TestVerifyAuditChainAcrossArchive(t *testing.T) {
+	cases := []struct {
+		name   string
+		tamper func(t *testing.T)
+		broken uint
+		reason string
+	}{
+		{"first row after archive deleted", func(t *testing.T) {
+			DB.Unscoped().Delete(&Audit{}, 5)
+		}, 6, "previous audit log of 6 is missing or modified"},
+		{"archive rewritten", func(t *testing.T) {
+			DB.Model(&AuditArchive{}).Where("last_id = ?", 4).Update("last_hash", strings.Repeat("0", 64))
+		}, 4, "signature of archive 1 is invalid"},
+		// 归档后删除了 checkpoint 签名的日志
+		{"checkpointed rows deleted", func(t *testing.T) {
+			DB.Unscoped().Where("id > ?", 4).Delete(&Audit{})
+		}, 6, "audit logs after 4 are missing"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupAuditChain(t, 6)
+			if _, err := CreateAuditCheckpoint(); err != nil {
+				t.Fatal(err)
+			}
+			pruneAudits(t, 4)
+			tc.tamper(t)
+
+			verification := verifyAuditChain(t)
+			if verification.Valid || verification.BrokenID != tc.broken || !strings.Contains(verification.Reason, tc.reason) {
+				t.Fatalf("expected broken at %d with %q, got %+v", tc.broken, tc.reason, verification)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/utils/auther"
+)
+
+const audit_archive_table_name = "audit_archives"
+
+// AuditArchive 一批已经清理的审计日志，File 为归档文件，Checksum 为归档文件的 sha256；
+// LastHash 为最后一条日志的 hash，是清理后 hash 链的起点
+type AuditArchive struct {
+	gorm.Model
+	File      string    `json:"file" gorm:"type:varchar(255)"`
+	Checksum  string    `json:"checksum" gorm:"type:varchar(64)"`
+	FirstID   uint      `json:"first_id"`
+	LastID    uint      `json:"last_id" gorm:"index"`
+	FirstAt   time.Time `json:"first_at"`
+	LastAt    time.Time `json:"last_at"`
+	Records   int       `json:"records"`
+	LastHash  string    `json:"last_hash" gorm:"type:varchar(64)"`
+	KeyID     string    `json:"key_id" gorm:"type:varchar(64)"`
+	Signature string    `json:"signature" gorm:"type:text"`
+}
+
+func (*AuditArchive) TableName() string {
+	return audit_archive_table_name
+}
+
+func archiveContent(archive *AuditArchive) string {
+	return fmt.Sprintf("archive:%d:%d:%s:%s", archive.FirstID, archive.LastID, archive.LastHash, archive.Checksum)
+}
+
+// latestAuditArchive 返回最近清理的一批审计日志，没有清理过时返回 nil
+func latestAuditArchive(tx *gorm.DB) (*AuditArchive, error) {
+	archive := &AuditArchive{}
+	result := tx.Order("last_id desc").Limit(1).Find(archive)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return archive, nil
+}
+
+// ExpiredAuditCutoff 返回需要清理的最大审计日志 id，早于 before 或者超出最新 keep 条的日志需要清理，
+// before 为零值或 keep 为 0 时不按对应的条件清理，返回 0 时没有需要清理的日志；
+// 最新的一条日志总是保留，避免清空后数据库重新使用已经归档的 id
+func ExpiredAuditCutoff(before time.Time, keep int) (uint, error) {
+	var cutoff uint
+	if !before.IsZero() {
+		audit := &Audit{}
+		if err := DB.Unscoped().Select("id").Where("created_at < ?", before).Order("id desc").Limit(1).Find(audit).Error; err != nil {
+			return 0, err
+		}
+		cutoff = audit.ID
+	}
+	if keep > 0 {
+		audit := &Audit{}
+		if err := DB.Unscoped().Select("id").Order("id desc").Offset(keep).Limit(1).Find(audit).Error; err != nil {
+			return 0, err
+		}
+		if audit.ID > cutoff {
+			cutoff = audit.ID
+		}
+	}
+	if cutoff > 0 {
+		previous := &Audit{}
+		if err := DB.Unscoped().Select("id").Order("id desc").Offset(1).Limit(1).Find(previous).Error; err != nil {
+			return 0, err
+		}
+		if previous.ID < cutoff {
+			cutoff = previous.ID
+		}
+	}
+	return cutoff, nil
+}
+
+// GetExpiredAudits 按 id 顺序返回不超过 cutoff 的最早 limit 条审计日志，包括被软删除的日志
+func GetExpiredAudits(cutoff uint, limit int) ([]*Audit, error) {
+	audits := []*Audit{}
+	if err := DB.Unscoped().Where("id <= ?", cutoff).Order("id").Limit(limit).Find(&audits).Error; err != nil {
+		return nil, err
+	}
+	return audits, nil
+}
+
+// PruneAudits 签名并保存归档记录，删除归档范围内的审计日志
+func PruneAudits(archive *AuditArchive) error {
+	var err error
+	if archive.KeyID, archive.Signature, err = auther.SignCheckpoint(archiveContent(archive)); err != nil {
+		return err
+	}
+	chainLock.Lock()
+	defer chainLock.Unlock()
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// 与其他副本写入审计日志串行，清理后链的末端仍然有效
+		if _, err := lockAuditChainHead(tx); err != nil {
+			return err
+		}
+		if err := tx.Create(archive).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("audit_id BETWEEN ? AND ?", archive.FirstID, archive.LastID).Delete(&AuditHost{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id BETWEEN ? AND ?", archive.FirstID, archive.LastID).Delete(&Audit{}).Error
+	})
+}
+
+// GetAuditArchives 分页返回归档记录
+func GetAuditArchives(offset, limit int) (map[string]interface{}, error) {
+	if offset < 1 {
+		offset = 1
+	}
+	archives := []AuditArchive{}
+	var count int64
+	tx := DB.Model(&AuditArchive{})
+	if err := tx.Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Order("last_id desc").Limit(limit).Offset((offset - 1) * limit).Find(&archives).Error; err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"list": archives, "total": count}, nil
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const batch_task_table_name = "batch_tasks"
+
+const (
+	BatchTaskPending = "pending"
+	BatchTaskQueued  = "queued"
+	BatchTaskFailed  = "failed"
+)
+
+// BatchTask 多副本部署时保存在数据库中的异步任务，所有副本都可以创建，只有 leader 取出处理；
+// Task 为任务内容的 json，TraceParent 为创建任务的 span，Attempts 为已经失败的次数，
+// 处理成功的任务直接删除，超过重试次数的任务保留为 failed；模板下发任务保存在 TemplateJob 中，不使用 BatchTask
+type BatchTask struct {
+	gorm.Model
+	Name        string    `json:"name" gorm:"uniqueIndex;type:varchar(64)"`
+	Event       string    `json:"event" gorm:"type:varchar(32)"`
+	Host        string    `json:"host" gorm:"type:varchar(255)"`
+	Task        string    `json:"task" gorm:"type:text"`
+	TraceParent string    `json:"trace_parent" gorm:"type:varchar(64)"`
+	Status      string    `json:"status" gorm:"index:idx_batch_tasks_status_run_at;type:varchar(16)"`
+	RunAt       time.Time `json:"run_at" gorm:"index:idx_batch_tasks_status_run_at"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error" gorm:"type:varchar(255)"`
+}
+
+func (*BatchTask) TableName() string {
+	return batch_task_table_name
+}
+
+func CreateBatchTask(task *BatchTask) error {
+	task.Status = BatchTaskPending
+	return DB.Create(task).Error
+}
+
+// ClaimBatchTasks 取出最多 limit 个已经到期的任务并标记为 queued，任务只会被一个副本取出
+func ClaimBatchTasks(limit int) ([]BatchTask, error) {
+	tasks := []BatchTask{}
+	if err := DB.Where("status = ? AND run_at <= ?", BatchTaskPending, time.Now()).Order("run_at").Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	claimed := tasks[:0]
+	for _, task := range tasks {
+		result := DB.Model(&BatchTask{}).Where("id = ? AND status = ?", task.ID, BatchTaskPending).Update("status", BatchTaskQueued)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			claimed = append(claimed, task)
+		}
+	}
+	return claimed, nil
+}
+
+// ResetBatchTasks 将上一个 leader 取出但没有处理完的任务放回等待处理
+func ResetBatchTasks() (int64, error) {
+	result := DB.Model(&BatchTask{}).Where("status = ?", BatchTaskQueued).Update("status", BatchTaskPending)
+	return result.RowsAffected, result.Error
+}
+
+// RetryBatchTask 记录失败次数与原因，任务在 runAt 之后重新处理
+func RetryBatchTask(name string, attempts int, runAt time.Time, reason string) error {
+	return DB.Model(&BatchTask{}).Where("name = ?", name).Updates(map[string]interface{}{
+		"status":   BatchTaskPending,
+		"attempts": attempts,
+		"run_at":   runAt,
+		"error":    truncate(reason, 255),
+	}).Error
+}
+
+// FinishBatchTask 删除处理成功的任务，失败时保留任务并记录原因
+func FinishBatchTask(name string, err error) error {
+	if err == nil {
+		return DB.Unscoped().Where("name = ?", name).Delete(&BatchTask{}).Error
+	}
+	return DB.Model(&BatchTask{}).Where("name = ?", name).Updates(map[string]interface{}{
+		"status": BatchTaskFailed,
+		"error":  truncate(err.Error(), 255),
+	}).Error
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,326 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	query2 "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const (
+	change_request_table_name  = "change_requests"
+	change_comment_table_name  = "change_comments"
+	change_approver_table_name = "change_approver_roles"
+
+	ChangePending   = "pending"
+	ChangeApproved  = "approved"
+	ChangeRejected  = "rejected"
+	ChangeCancelled = "cancelled"
+	ChangeExpired   = "expired"
+	ChangeExecuted  = "executed"
+	ChangeFailed    = "failed"
+
+	ChangeActionComment = "comment"
+	ChangeActionApprove = "approve"
+	ChangeActionReject  = "reject"
+	ChangeActionCancel  = "cancel"
+
+	// changeResultLimit 保存的执行结果的最大长度
+	changeResultLimit = 64 * 1024
+)
+
+// ChangeRequest 需要审批的修改请求，保存完整的请求，审批通过后按原请求执行
+type ChangeRequest struct {
+	gorm.Model
+	Policy        string               `json:"policy" gorm:"type:varchar(64)"`
+	Method        string               `json:"method" gorm:"type:varchar(10)"`
+	Route         string               `json:"route" gorm:"type:varchar(255)"`
+	Path          string               `json:"path" gorm:"type:varchar(255)"`
+	RawQuery      string               `json:"raw_query" gorm:"type:text"`
+	Body          string               `json:"-" gorm:"type:text"`
+	RedactedBody  string               `json:"body" gorm:"-"`
+	ContentType   string               `json:"content_type" gorm:"type:varchar(100)"`
+	Hosts         string               `json:"hosts" gorm:"type:varchar(1024)"`
+	RequesterID   uint                 `json:"requester_id" gorm:"index"`
+	TokenID       uint                 `json:"token_id"`
+	Approvals     int                  `json:"approvals"`
+	Status        string               `json:"status" gorm:"index;type:varchar(16)"`
+	ExpiresAt     time.Time            `json:"expires_at" gorm:"index"`
+	ExecutedBy    uint                 `json:"executed_by"`
+	ExecutedAt    *time.Time           `json:"executed_at"`
+	ResultStatus  int                  `json:"result_status"`
+	Result        string               `json:"result" gorm:"type:text"`
+	ApproverRoles []ChangeApproverRole `json:"approver_roles,omitempty" gorm:"foreignKey:ChangeID"`
+	Comments      []ChangeComment      `json:"comments,omitempty" gorm:"foreignKey:ChangeID"`
+}
+
+type ChangeRequestList struct {
+	ID           uint       `json:"id"`
+	Policy       string     `json:"policy"`
+	Method       string     `json:"method"`
+	Path         string     `json:"path"`
+	Hosts        string     `json:"hosts"`
+	RequesterID  uint       `json:"requester_id"`
+	TokenID      uint       `json:"token_id"`
+	Approvals    int        `json:"approvals"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	ExecutedAt   *time.Time `json:"executed_at"`
+	ResultStatus int        `json:"result_status"`
+}
+
+// ChangeComment 变更请求的评论与审批记录
+type ChangeComment struct {
+	gorm.Model
+	ChangeID uint   `json:"change_id" gorm:"index"`
+	UserID   uint   `json:"user_id"`
+	Action   string `json:"action" gorm:"type:varchar(16)"`
+	Comment  string `json:"comment" gorm:"type:varchar(1024)"`
+}
+
+// ChangeApproverRole 可以审批变更的角色
+type ChangeApproverRole struct {
+	gorm.Model
+	ChangeID uint   `json:"change_id" gorm:"index"`
+	Role     string `json:"role" gorm:"index;type:varchar(64)"`
+}
+
+func (*ChangeRequest) TableName() string {
+	return change_request_table_name
+}
+
+func (*ChangeRequestList) TableName() string {
+	return change_request_table_name
+}
+
+func (*ChangeComment) TableName() string {
+	return change_comment_table_name
+}
+
+func (*ChangeApproverRole) TableName() string {
+	return change_approver_table_name
+}
+
+// approverChanges 返回用户可以审批的变更 id 的子查询
+func approverChanges(uid uint) *gorm.DB {
+	return DB.Table(change_approver_table_name).
+		Select("change_id").
+		Where("deleted_at IS NULL AND role IN (?)", DB.Table("roles").
+			Select("roles.name").
+			Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+			Where("user_roles.user_id = ? AND roles.deleted_at IS NULL", uid))
+}
+
+// expireChangeRequests 将超过有效期仍未审批的变更标记为过期
+func expireChangeRequests(tx *gorm.DB) error {
+	return tx.Model(&ChangeRequest{}).
+		Where("status = ? AND expires_at < ?", ChangePending, time.Now()).
+		Update("status", ChangeExpired).Error
+}
+
+// CreateChangeRequest 保存待审批的变更，roles 为可以审批的角色
+func CreateChangeRequest(change *ChangeRequest, roles []string) error {
+	change.Status = ChangePending
+	change.ExpiresAt = time.Now().Add(time.Duration(config.CONFIG.Approval.Expiry) * time.Second)
+	if change.Approvals < 1 {
+		change.Approvals = 1
+	}
+	for _, role := range roles {
+		change.ApproverRoles = append(change.ApproverRoles, ChangeApproverRole{Role: role})
+	}
+	return DB.Create(change).Error
+}
+
+// GetChangeRequests 分页返回 viewer 可以查看的变更请求，除 1 号管理员外只能查看自己提交的与自己可以审批的变更
+func GetChangeRequests(viewer uint, status string, requester uint, offset, limit int, sort string) (map[string]interface{}, error) {
+	if err := expireChangeRequests(DB); err != nil {
+		return nil, err
+	}
+	if offset < 1 {
+		offset = 1
+	}
+	if sort != "asc" {
+		sort = "desc"
+	}
+	changes := []ChangeRequestList{}
+	var count int64
+	tx := DB.Model(&ChangeRequestList{}).Where("deleted_at IS ?", nil)
+	if viewer != 1 {
+		tx = tx.Where("requester_id = ? OR id IN (?)", viewer, approverChanges(viewer))
+	}
+	if status != "" {
+		tx = tx.Where("status = ?", status)
+	}
+	if requester > 0 {
+		tx = tx.Where("requester_id = ?", requester)
+	}
+	if err := tx.Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Order("id " + sort).Limit(limit).Offset((offset - 1) * limit).Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"list": changes, "total": count}, nil
+}
+
+// GetChangeRequest 返回 viewer 可以查看的变更请求与评论
+func GetChangeRequest(id, viewer uint) (*ChangeRequest, error) {
+	if err := expireChangeRequests(DB); err != nil {
+		return nil, err
+	}
+	change := &ChangeRequest{}
+	tx := DB.Preload("ApproverRoles").Preload("Comments").Where("id = ?", id)
+	if viewer != 1 {
+		tx = tx.Where("requester_id = ? OR id IN (?)", viewer, approverChanges(viewer))
+	}
+	result := tx.Limit(1).Find(change)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, query2.ErrChangeNotFound
+	}
+	return change, nil
+}
+
+// CommentChangeRequest 评论可以查看的变更请求
+func CommentChangeRequest(id, uid uint, comment string) error {
+	if _, err := GetChangeRequest(id, uid); err != nil {
+		return err
+	}
+	return DB.Create(&ChangeComment{ChangeID: id, UserID: uid, Action: ChangeActionComment, Comment: comment}).Error
+}
+
+// pendingChange 在事务中读取待审批的变更，已过期的变更标记为过期
+func pendingChange(tx *gorm.DB, id uint) (*ChangeRequest, error) {
+	change := &ChangeRequest{}
+	result := tx.Where("id = ?", id).Limit(1).Find(change)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, query2.ErrChangeNotFound
+	}
+	if change.Status != ChangePending {
+		return nil, query2.ErrChangeNotPending
+	}
+	if !change.ExpiresAt.After(time.Now()) {
+		if err := tx.Model(change).Update("status", ChangeExpired).Error; err != nil {
+			return nil, err
+		}
+		return nil, query2.ErrChangeExpired
+	}
+	return change, nil
+}
+
+// isApprover 判断用户是否可以审批变更，审批人不能是申请人，1 号管理员可以审批所有变更
+func isApprover(tx *gorm.DB, change *ChangeRequest, uid uint) error {
+	if uid == change.RequesterID {
+		return query2.ErrChangeSelfApproval
+	}
+	if uid == 1 {
+		return nil
+	}
+	var count int64
+	if err := tx.Table(change_approver_table_name).
+		Where("change_id = ? AND change_id IN (?)", change.ID, approverChanges(uid)).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return query2.ErrChangeApprover
+	}
+	return nil
+}
+
+// ApproveChangeRequest 审批变更，审批人数达到要求时将变更标记为已批准并返回 true，调用方需要执行变更
+func ApproveChangeRequest(id, uid uint, comment string) (change *ChangeRequest, ready bool, enconterError error) {
+	enconterError = DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if change, err = pendingChange(tx, id); err != nil {
+			return err
+		}
+		if err = isApprover(tx, change, uid); err != nil {
+			return err
+		}
+		var count int64
+		if err = tx.Model(&ChangeComment{}).Where("change_id = ? AND user_id = ? AND action = ?", id, uid, ChangeActionApprove).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return query2.ErrChangeApproved
+		}
+		if err = tx.Create(&ChangeComment{ChangeID: id, UserID: uid, Action: ChangeActionApprove, Comment: comment}).Error; err != nil {
+			return err
+		}
+		if err = tx.Model(&ChangeComment{}).Where("change_id = ? AND action = ?", id, ChangeActionApprove).Distinct("user_id").Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) < change.Approvals {
+			return nil
+		}
+		// 以状态为条件更新，并发审批时只有一个请求会执行变更
+		result := tx.Model(&ChangeRequest{}).Where("id = ? AND status = ?", id, ChangePending).Update("status", ChangeApproved)
+		if result.Error != nil {
+			return result.Error
+		}
+		ready = result.RowsAffected > 0
+		return nil
+	})
+	return change, ready, enconterError
+}
+
+// RejectChangeRequest 驳回变更
+func RejectChangeRequest(id, uid uint, comment string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		change, err := pendingChange(tx, id)
+		if err != nil {
+			return err
+		}
+		if err = isApprover(tx, change, uid); err != nil {
+			return err
+		}
+		if err = tx.Create(&ChangeComment{ChangeID: id, UserID: uid, Action: ChangeActionReject, Comment: comment}).Error; err != nil {
+			return err
+		}
+		return tx.Model(change).Update("status", ChangeRejected).Error
+	})
+}
+
+// CancelChangeRequest 申请人撤回变更，1 号管理员可以撤回所有变更
+func CancelChangeRequest(id, uid uint, comment string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		change, err := pendingChange(tx, id)
+		if err != nil {
+			return err
+		}
+		if uid != change.RequesterID && uid != 1 {
+			return query2.ErrNoPermission
+		}
+		if err = tx.Create(&ChangeComment{ChangeID: id, UserID: uid, Action: ChangeActionCancel, Comment: comment}).Error; err != nil {
+			return err
+		}
+		return tx.Model(change).Update("status", ChangeCancelled).Error
+	})
+}
+
+// FinishChangeRequest 记录变更的执行结果，handler 出错时状态码可能仍为 200，需要同时检查响应中的错误码
+func FinishChangeRequest(id, executor uint, status, code int, result string) error {
+	if len(result) > changeResultLimit {
+		result = result[:changeResultLimit]
+	}
+	state := ChangeExecuted
+	if status >= 300 || (code != 0 && code != query2.OK.Code && code != query2.BatchSuccessCreated.Code) {
+		state = ChangeFailed
+	}
+	return DB.Model(&ChangeRequest{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        state,
+		"executed_by":   executor,
+		"executed_at":   time.Now(),
+		"result_status": status,
+		"result":        result,
+	}).Error
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+func TestFinishChangeRequestReadsResponseCode(t *testing.T) {
+	setupTest(t, "", &ChangeRequest{}, &ChangeApproverRole{}, &ChangeComment{})
+
+	cases := []struct {
+		name   string
+		status int
+		code   int
+		state  string
+	}{
+		{"success", 200, query.OK.Code, ChangeExecuted},
+		{"batch created", 200, query.BatchSuccessCreated.Code, ChangeExecuted},
+		{"no code", 204, 0, ChangeExecuted},
+		// handler 出错时状态码仍为 200，只在响应中返回错误码
+		{"error code", 200, query.ErrDatabase.Code, ChangeFailed},
+		{"error status", 500, query.InternalServerError.Code, ChangeFailed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			change := &ChangeRequest{Method: "POST", Path: "/fw/v1/port"}
+			if err := CreateChangeRequest(change, []string{"port_editer"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := FinishChangeRequest(change.ID, 1, tc.status, tc.code, "{}"); err != nil {
+				t.Fatal(err)
+			}
+			finished := &ChangeRequest{}
+			if err := DB.First(finished, change.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if finished.Status != tc.state || finished.ResultStatus != tc.status {
+				t.Fatalf("expected %s with status %d, got %s with status %d", tc.state, tc.status, finished.Status, finished.ResultStatus)
+			}
+		})
+	}
+}
+
+func TestChangeRequestHidesRawBody(t *testing.T) {
+	change := &ChangeRequest{Body: `{"password":"s3cret"}`, RedactedBody: `{"password":"******"}`}
+	content, err := json.Marshal(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "s3cret") || !strings.Contains(string(content), `"body":"{\"password\":\"******\"}"`) {
+		t.Fatalf("unexpected change json %s", content)
+	}
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const (
+	oidc_state_table_name = "oidc_states"
+
+	UserSourceLocal = "local"
+	UserSourceOIDC  = "oidc"
+	UserSourceLDAP  = "ldap"
+)
+
+// OIDCState 一次 OIDC 登录的 state，保存 PKCE 的 code_verifier 与 nonce，回调时取出并删除
+type OIDCState struct {
+	gorm.Model
+	State     string    `gorm:"uniqueIndex;type:varchar(64)"`
+	Verifier  string    `gorm:"type:varchar(64)"`
+	Nonce     string    `gorm:"type:varchar(64)"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func (*OIDCState) TableName() string {
+	return oidc_state_table_name
+}
+
+func CreateOIDCState(state, verifier, nonce string, ttl time.Duration) error {
+	return DB.Create(&OIDCState{State: state, Verifier: verifier, Nonce: nonce, ExpiresAt: time.Now().Add(ttl)}).Error
+}
+
+// ConsumeOIDCState 取出并删除 state，state 只能使用一次，过期的 state 一并清理
+func ConsumeOIDCState(state string) (*OIDCState, error) {
+	DB.Unscoped().Where("expires_at < ?", time.Now()).Delete(&OIDCState{})
+	oidcState := &OIDCState{}
+	if result := DB.Where("state = ?", state).Limit(1).Find(oidcState); result.Error != nil || result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if result := DB.Unscoped().Delete(oidcState); result.Error != nil || result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return oidcState, nil
+}
+
+// SyncExternalUser 查找外部身份源(OIDC、LDAP)对应的用户，不存在时按 provision 创建；
+// roles 不为 nil 时用这些名称的角色替换用户的角色
+func SyncExternalUser(source, subject, username string, roles []string, provision bool) (*User, error) {
+	user := &User{}
+	result := DB.Where("source = ? AND subject = ?", source, subject).Limit(1).Find(user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if !provision {
+			return nil, query.ErrUserNotExist
+		}
+		// 不与同名的本地用户关联，避免外部身份源冒用本地账号
+		if !checkUserExist(username) {
+			return nil, query.ErrUserExist
+		}
+		user = &User{Username: username, Source: source, Subject: subject}
+		if err := DB.Create(user).Error; err != nil {
+			return nil, err
+		}
+	}
+	if roles != nil {
+		var list []Role
+		if len(roles) > 0 {
+			if err := DB.Where("name IN ?", roles).Find(&list).Error; err != nil {
+				return nil, err
+			}
+		}
+		if err := DB.Model(user).Association("Roles").Replace(list); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// GetExternalUsers 返回来自某个外部身份源的全部用户
+func GetExternalUsers(source string) ([]User, error) {
+	users := []User{}
+	if err := DB.Select("id", "username", "subject").Where("source = ?", source).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
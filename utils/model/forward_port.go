@@ -0,0 +1,122 @@
+package model
+
+import (
+	"gorm.io/gorm"
+
+	queryapi "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const forward_port_table_name = "forward_ports"
+
+type ForwardPort struct {
+	gorm.Model
+	Port       string `json:"port" gorm:"index;type:varchar(20)"`
+	Protocol   string `json:"protocol" gorm:"type:varchar(10)"`
+	ToPort     string `json:"toport" gorm:"type:varchar(20)"`
+	ToAddr     string `json:"toaddr" gorm:"type:varchar(64)"`
+	TemplateID int    `json:"template_id" gorm:"index;type:int"`
+}
+
+type ForwardPortList struct {
+	ID         int    `json:"id"`
+	Port       string `json:"port"`
+	Protocol   string `json:"protocol"`
+	ToPort     string `json:"toport"`
+	ToAddr     string `json:"toaddr"`
+	Template   string `json:"template"`
+	TemplateID int    `json:"template_id"`
+}
+
+type ForwardPortListWithoutID struct {
+	Port     string `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	ToPort   string `json:"toport,omitempty"`
+	ToAddr   string `json:"toaddr,omitempty"`
+}
+
+func (*ForwardPortList) TableName() string {
+	return forward_port_table_name
+}
+
+func (*ForwardPortListWithoutID) TableName() string {
+	return forward_port_table_name
+}
+
+func CreateForwardPort(tx *gorm.DB, query *queryapi.ForwardPortEditQuery) (enconterError error) {
+	forwardPort := &ForwardPort{
+		Port:       query.Port,
+		Protocol:   query.Protocol,
+		ToPort:     query.ToPort,
+		ToAddr:     query.ToAddr,
+		TemplateID: query.TemplateId,
+	}
+	result := tx.Create(forwardPort)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func GetForwardPorts(title string, offset, limit int, sort string) (map[string]interface{}, error) {
+	forwardPorts := []*ForwardPortList{}
+	response := make(map[string]interface{})
+	var count int64
+	forwardPortQuery := DB.Table(forward_port_table_name).
+		Select([]string{
+			forward_port_table_name + ".id",
+			forward_port_table_name + ".port",
+			forward_port_table_name + ".protocol",
+			forward_port_table_name + ".to_port",
+			forward_port_table_name + ".to_addr",
+			"templates.name AS template",
+			"templates.id AS template_id"}).
+		Joins("JOIN templates ON "+forward_port_table_name+".template_id = templates.id").
+		Where(forward_port_table_name+".deleted_at IS ?", nil)
+	if title != "" {
+		forwardPortQuery.Where(forward_port_table_name+".port LIKE ?", title+"%")
+	}
+	result := forwardPortQuery.
+		Order(forward_port_table_name + ".id " + sort).
+		Limit(limit).Offset((offset - 1) * limit).
+		Scan(&forwardPorts)
+	DB.Model(&ForwardPort{}).Distinct("id").Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = forwardPorts
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
+
+func UpdateForwardPortWithID(tx *gorm.DB, query *queryapi.ForwardPortEditQuery) (enconterError error) {
+	forwardPort := map[string]interface{}{
+		"port":        query.Port,
+		"protocol":    query.Protocol,
+		"to_port":     query.ToPort,
+		"to_addr":     query.ToAddr,
+		"template_id": query.TemplateId,
+	}
+	result := tx.Model(&ForwardPort{}).Where("id = ?", query.ID).Updates(forwardPort)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func DeleteForwardPortWithID(tx *gorm.DB, id uint64) error {
+	result := tx.Delete(&ForwardPort{}, id)
+	if result.Error == nil {
+		return nil
+	}
+	return result.Error
+}
+
+func QueryTemplateIDWithForwardPortID(id uint64) (templateID int, enconterError error) {
+	result := DB.Model(&ForwardPort{}).Select("template_id").Where("id = ?", id).Scan(&templateID)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return templateID, enconterError
+}
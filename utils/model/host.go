@@ -14,7 +14,7 @@ const host_table_name = "hosts"
 type Host struct {
 	gorm.Model
 	Hostname string `json:"hostname" gorm:"index;type:varchar(255)"`
-	IP       uint32 `json:"ip" gorm:"index;type:int"`
+	IP       uint32 `json:"ip" gorm:"index;type:bigint"`
 	TagId    int    `json:"tag_id" gorm:"index;type:int"`
 }
 
@@ -134,8 +134,8 @@ func GetHosts(offset, limit int, sort string) (map[string]interface{}, error) {
 			host_table_name + ".id",
 			host_table_name + ".hostname",
 			host_table_name + ".ip",
-			"tags.name tag",
-			"tags.id tag_id"}).
+			"tags.name AS tag",
+			"tags.id AS tag_id"}).
 		Joins("join tags on "+host_table_name+".tag_id = tags.id").
 		Limit(limit).
 		Offset((offset-1)*limit).
@@ -173,8 +173,8 @@ func HostClassify() ([]*Classify, error) {
 			"COUNT(*) AS count",
 			"tags.name AS name"}).
 		Group("tags.name").
-		Where(host_table_name + ".`deleted_at` IS NULL").
-		Where("tags.`deleted_at` IS NULL").
+		Where(host_table_name + ".deleted_at IS NULL").
+		Where("tags.deleted_at IS NULL").
 		Scan(&hosts)
 	if result.Error != gorm.ErrRecordNotFound || result.Error == nil {
 		return hosts, nil
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"gorm.io/gorm"
+
+	queryapi "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const icmp_block_table_name = "icmp_blocks"
+
+type IcmpBlock struct {
+	gorm.Model
+	Name       string `json:"name" gorm:"index;type:varchar(64)"`
+	TemplateID int    `json:"template_id" gorm:"index;type:int"`
+}
+
+type IcmpBlockList struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Template   string `json:"template"`
+	TemplateID int    `json:"template_id"`
+}
+
+type IcmpBlockListWithoutID struct {
+	Name string `json:"name,omitempty"`
+}
+
+func (*IcmpBlockList) TableName() string {
+	return icmp_block_table_name
+}
+
+func (*IcmpBlockListWithoutID) TableName() string {
+	return icmp_block_table_name
+}
+
+func CreateIcmpBlock(tx *gorm.DB, query *queryapi.IcmpBlockEditQuery) (enconterError error) {
+	icmpBlock := &IcmpBlock{
+		Name:       query.Name,
+		TemplateID: query.TemplateId,
+	}
+	result := tx.Create(icmpBlock)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func GetIcmpBlocks(title string, offset, limit int, sort string) (map[string]interface{}, error) {
+	icmpBlocks := []*IcmpBlockList{}
+	response := make(map[string]interface{})
+	var count int64
+	icmpBlockQuery := DB.Table(icmp_block_table_name).
+		Select([]string{
+			icmp_block_table_name + ".id",
+			icmp_block_table_name + ".name",
+			"templates.name AS template",
+			"templates.id AS template_id"}).
+		Joins("JOIN templates ON "+icmp_block_table_name+".template_id = templates.id").
+		Where(icmp_block_table_name+".deleted_at IS ?", nil)
+	if title != "" {
+		icmpBlockQuery.Where(icmp_block_table_name+".name LIKE ?", title+"%")
+	}
+	result := icmpBlockQuery.
+		Order(icmp_block_table_name + ".id " + sort).
+		Limit(limit).Offset((offset - 1) * limit).
+		Scan(&icmpBlocks)
+	DB.Model(&IcmpBlock{}).Distinct("id").Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = icmpBlocks
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
+
+func UpdateIcmpBlockWithID(tx *gorm.DB, query *queryapi.IcmpBlockEditQuery) (enconterError error) {
+	icmpBlock := map[string]interface{}{
+		"name":        query.Name,
+		"template_id": query.TemplateId,
+	}
+	result := tx.Model(&IcmpBlock{}).Where("id = ?", query.ID).Updates(icmpBlock)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func DeleteIcmpBlockWithID(tx *gorm.DB, id uint64) error {
+	result := tx.Delete(&IcmpBlock{}, id)
+	if result.Error == nil {
+		return nil
+	}
+	return result.Error
+}
+
+func QueryTemplateIDWithIcmpBlockID(id uint64) (templateID int, enconterError error) {
+	result := DB.Model(&IcmpBlock{}).Select("template_id").Where("id = ?", id).Scan(&templateID)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return templateID, enconterError
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"gorm.io/gorm"
+
+	queryapi "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const interface_table_name = "interfaces"
+
+type Interface struct {
+	gorm.Model
+	Name       string `json:"name" gorm:"index;type:varchar(20)"`
+	TemplateID int    `json:"template_id" gorm:"index;type:int"`
+}
+
+type InterfaceList struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Template   string `json:"template"`
+	TemplateID int    `json:"template_id"`
+}
+
+type InterfaceListWithoutID struct {
+	Name string `json:"name,omitempty"`
+}
+
+func (*InterfaceList) TableName() string {
+	return interface_table_name
+}
+
+func (*InterfaceListWithoutID) TableName() string {
+	return interface_table_name
+}
+
+func CreateInterface(tx *gorm.DB, query *queryapi.InterfaceEditQuery) (enconterError error) {
+	iface := &Interface{
+		Name:       query.Name,
+		TemplateID: query.TemplateId,
+	}
+	result := tx.Create(iface)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func GetInterfaces(title string, offset, limit int, sort string) (map[string]interface{}, error) {
+	ifaces := []*InterfaceList{}
+	response := make(map[string]interface{})
+	var count int64
+	ifaceQuery := DB.Table(interface_table_name).
+		Select([]string{
+			interface_table_name + ".id",
+			interface_table_name + ".name",
+			"templates.name AS template",
+			"templates.id AS template_id"}).
+		Joins("JOIN templates ON "+interface_table_name+".template_id = templates.id").
+		Where(interface_table_name+".deleted_at IS ?", nil)
+	if title != "" {
+		ifaceQuery.Where(interface_table_name+".name LIKE ?", title+"%")
+	}
+	result := ifaceQuery.
+		Order(interface_table_name + ".id " + sort).
+		Limit(limit).Offset((offset - 1) * limit).
+		Scan(&ifaces)
+	DB.Model(&Interface{}).Distinct("id").Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = ifaces
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
+
+func UpdateInterfaceWithID(tx *gorm.DB, query *queryapi.InterfaceEditQuery) (enconterError error) {
+	iface := map[string]interface{}{
+		"name":        query.Name,
+		"template_id": query.TemplateId,
+	}
+	result := tx.Model(&Interface{}).Where("id = ?", query.ID).Updates(iface)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func DeleteInterfaceWithID(tx *gorm.DB, id uint64) error {
+	result := tx.Delete(&Interface{}, id)
+	if result.Error == nil {
+		return nil
+	}
+	return result.Error
+}
+
+func QueryTemplateIDWithInterfaceID(id uint64) (templateID int, enconterError error) {
+	result := DB.Model(&Interface{}).Select("template_id").Where("id = ?", id).Scan(&templateID)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return templateID, enconterError
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const leader_lease_table_name = "leader_leases"
+
+// LeaderLease 不在 Kubernetes 中运行时用于选主的记录，Version 在每次修改时加一，用于判断记录是否已经被其他副本修改
+type LeaderLease struct {
+	Name                 string    `json:"name" gorm:"primaryKey;type:varchar(64)"`
+	HolderIdentity       string    `json:"holder_identity" gorm:"type:varchar(255)"`
+	LeaseDurationSeconds int       `json:"lease_duration_seconds"`
+	AcquireTime          time.Time `json:"acquire_time"`
+	RenewTime            time.Time `json:"renew_time"`
+	LeaderTransitions    int       `json:"leader_transitions"`
+	Version              int64     `json:"version"`
+}
+
+func (*LeaderLease) TableName() string {
+	return leader_lease_table_name
+}
+
+// GetLeaderLease 返回选主记录，记录不存在时返回 gorm.ErrRecordNotFound
+func GetLeaderLease(name string) (*LeaderLease, error) {
+	lease := &LeaderLease{}
+	if err := DB.Where("name = ?", name).First(lease).Error; err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func CreateLeaderLease(lease *LeaderLease) error {
+	return DB.Create(lease).Error
+}
+
+// UpdateLeaderLease 只在记录仍然是 version 版本时修改，记录已经被其他副本修改时返回 false
+func UpdateLeaderLease(lease *LeaderLease, version int64) (bool, error) {
+	result := DB.Model(&LeaderLease{}).
+		Where("name = ? AND version = ?", lease.Name, version).
+		Updates(map[string]interface{}{
+			"holder_identity":        lease.HolderIdentity,
+			"lease_duration_seconds": lease.LeaseDurationSeconds,
+			"acquire_time":           lease.AcquireTime,
+			"renew_time":             lease.RenewTime,
+			"leader_transitions":     lease.LeaderTransitions,
+			"version":                gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/praserx/ipconv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+const (
+	lockout_table_name = "login_lockouts"
+
+	LockoutKindUser = "user"
+	LockoutKindIP   = "ip"
+)
+
+// Lockout 用户名或来源 ip 的登录失败计数，不存在的用户名同样计数，不会暴露用户是否存在
+type Lockout struct {
+	gorm.Model
+	Kind          string     `json:"kind" gorm:"uniqueIndex:idx_lockout_subject;type:varchar(10)"`
+	Subject       string     `json:"subject" gorm:"uniqueIndex:idx_lockout_subject;type:varchar(64)"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+type LockoutList struct {
+	ID            uint       `json:"id"`
+	Kind          string     `json:"kind"`
+	Subject       string     `json:"subject"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+func (*Lockout) TableName() string {
+	return lockout_table_name
+}
+
+func (*LockoutList) TableName() string {
+	return lockout_table_name
+}
+
+// lockoutSubjects 返回需要计数的用户名与来源 ip，无法获取来源 ip 时只按用户名计数
+func lockoutSubjects(username string, ip uint32) map[string]string {
+	subjects := map[string]string{LockoutKindUser: strings.ToLower(strings.TrimSpace(username))}
+	if len(subjects[LockoutKindUser]) > 64 {
+		subjects[LockoutKindUser] = subjects[LockoutKindUser][:64]
+	}
+	if ip > 0 {
+		subjects[LockoutKindIP] = ipconv.IntToIPv4(ip).String()
+	}
+	return subjects
+}
+
+// lockDuration 达到失败次数上限后，每多失败一次锁定时间翻倍
+func lockDuration(failures, threshold int) time.Duration {
+	cfg := config.CONFIG.Lockout
+	duration := time.Duration(cfg.Duration) * time.Second
+	max := time.Duration(cfg.MaxDuration) * time.Second
+	for i := threshold; i < failures && duration < max; i++ {
+		duration *= 2
+	}
+	if max > 0 && duration > max {
+		duration = max
+	}
+	return duration
+}
+
+// CheckLockout 返回用户名或来源 ip 剩余的锁定时间，未锁定时为 0
+func CheckLockout(username string, ip uint32) (time.Duration, error) {
+	if config.CONFIG.Lockout.MaxAttempts <= 0 {
+		return 0, nil
+	}
+	var remaining time.Duration
+	now := time.Now()
+	for kind, subject := range lockoutSubjects(username, ip) {
+		lockout := &Lockout{}
+		result := DB.Where("kind = ? AND subject = ? AND locked_until > ?", kind, subject, now).Limit(1).Find(lockout)
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected > 0 && lockout.LockedUntil.Sub(now) > remaining {
+			remaining = lockout.LockedUntil.Sub(now)
+		}
+	}
+	return remaining, nil
+}
+
+// RecordLoginFailure 记录一次登录失败，window 内没有失败时重新计数，返回失败后剩余的锁定时间
+func RecordLoginFailure(username string, ip uint32) (time.Duration, error) {
+	cfg := config.CONFIG.Lockout
+	if cfg.MaxAttempts <= 0 {
+		return 0, nil
+	}
+	thresholds := map[string]int{LockoutKindUser: cfg.MaxAttempts, LockoutKindIP: cfg.IPMaxAttempts}
+	var remaining time.Duration
+	now := time.Now()
+	for kind, subject := range lockoutSubjects(username, ip) {
+		if thresholds[kind] <= 0 {
+			continue
+		}
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			// 同时失败的请求只有一个能创建计数，其它请求等待锁定后在同一行上累加，不会丢失计数
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&Lockout{Kind: kind, Subject: subject}).Error; err != nil {
+				return err
+			}
+			lockout := &Lockout{}
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("kind = ? AND subject = ?", kind, subject).First(lockout).Error; err != nil {
+				return err
+			}
+			if now.Sub(lockout.LastFailureAt) > time.Duration(cfg.Window)*time.Second {
+				lockout.Failures = 0
+			}
+			lockout.Failures++
+			lockout.LastFailureAt = now
+			if lockout.Failures >= thresholds[kind] {
+				lockedUntil := now.Add(lockDuration(lockout.Failures, thresholds[kind]))
+				lockout.LockedUntil = &lockedUntil
+				if lockedUntil.Sub(now) > remaining {
+					remaining = lockedUntil.Sub(now)
+				}
+			}
+			return tx.Save(lockout).Error
+		})
+		if err != nil {
+			return remaining, err
+		}
+	}
+	return remaining, nil
+}
+
+// ClearLoginFailures 登录成功后清除用户名的失败计数，来源 ip 的计数保留到 window 结束，避免使用一个账号掩护对其它账号的尝试
+func ClearLoginFailures(username string) error {
+	return DB.Unscoped().
+		Where("kind = ? AND subject = ?", LockoutKindUser, lockoutSubjects(username, 0)[LockoutKindUser]).
+		Delete(&Lockout{}).Error
+}
+
+// GetLockouts 返回被锁定的用户名与来源 ip，all 为 true 时同时返回有失败计数但未锁定的记录
+func GetLockouts(all bool) ([]LockoutList, error) {
+	lockouts := []LockoutList{}
+	tx := DB.Where("deleted_at IS ?", nil)
+	if !all {
+		tx = tx.Where("locked_until > ?", time.Now())
+	}
+	if err := tx.Order("last_failure_at desc").Find(&lockouts).Error; err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
+
+// ClearLockout 解除锁定并清除失败计数
+func ClearLockout(id uint) (bool, error) {
+	result := DB.Unscoped().Where("id = ?", id).Delete(&Lockout{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRecordLoginFailureConcurrently(t *testing.T) {
+	setupTest(t, `
+[lockout]
+max_attempts = 100
+ip_max_attempts = 100
+`, &Lockout{})
+
+	const attempts = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := RecordLoginFailure("Alice", 0x7f000001); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("record failure: %v", err)
+	}
+
+	for kind, subject := range map[string]string{LockoutKindUser: "alice", LockoutKindIP: "127.0.0.1"} {
+		lockouts := []Lockout{}
+		if err := DB.Where("kind = ? AND subject = ?", kind, subject).Find(&lockouts).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(lockouts) != 1 || lockouts[0].Failures != attempts {
+			t.Fatalf("%s %s: expected one row with %d failures, got %+v", kind, subject, attempts, lockouts)
+		}
+	}
+}
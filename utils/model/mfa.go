@@ -0,0 +1,289 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	query2 "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/totp"
+)
+
+const (
+	user_mfa_table_name      = "user_mfas"
+	recovery_code_table_name = "mfa_recovery_codes"
+	mfa_challenge_table_name = "mfa_challenges"
+)
+
+// UserMFA 用户的 TOTP 密钥，enabled 为 false 时为尚未确认的绑定
+type UserMFA struct {
+	gorm.Model
+	UserID      uint       `json:"user_id" gorm:"uniqueIndex"`
+	Secret      string     `json:"-" gorm:"type:varchar(64)"`
+	Enabled     bool       `json:"enabled" gorm:"not null;default:false"`
+	LastCounter int64      `json:"-"`
+	EnabledAt   *time.Time `json:"enabled_at"`
+}
+
+// RecoveryCode 恢复码，只保存 sha256，每个恢复码只能使用一次
+type RecoveryCode struct {
+	gorm.Model
+	UserID uint       `json:"user_id" gorm:"index"`
+	Hash   string     `json:"-" gorm:"type:varchar(64)"`
+	UsedAt *time.Time `json:"used_at"`
+}
+
+// MFAChallenge 密码校验通过后等待二次认证的登录，mfa_token 兑换会话时删除，每个 mfa_token 只能使用一次
+type MFAChallenge struct {
+	gorm.Model
+	Challenge string    `gorm:"uniqueIndex;type:varchar(64)"`
+	UserID    uint      `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// MFAStatus 用户的二次认证状态
+type MFAStatus struct {
+	Enabled       bool       `json:"enabled"`
+	Required      bool       `json:"required"`
+	EnabledAt     *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodes int64      `json:"recovery_codes"`
+}
+
+// MFAEnrollment 绑定时返回的密钥与 otpauth 地址
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+func (*UserMFA) TableName() string {
+	return user_mfa_table_name
+}
+
+func (*RecoveryCode) TableName() string {
+	return recovery_code_table_name
+}
+
+func (*MFAChallenge) TableName() string {
+	return mfa_challenge_table_name
+}
+
+// CreateMFAChallenge 记录一次等待二次认证的登录，返回 challenge id
+func CreateMFAChallenge(uid uint, ttl time.Duration) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	challenge := hex.EncodeToString(b)
+	if err := DB.Create(&MFAChallenge{Challenge: challenge, UserID: uid, ExpiresAt: time.Now().Add(ttl)}).Error; err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// ConsumeMFAChallenge 删除用户的 challenge，已经使用或过期时返回 ErrMFAChallenge，过期的 challenge 一并清理
+func ConsumeMFAChallenge(challenge string, uid uint) error {
+	DB.Unscoped().Where("expires_at < ?", time.Now()).Delete(&MFAChallenge{})
+	result := DB.Unscoped().Where("challenge = ? AND user_id = ? AND expires_at >= ?", challenge, uid, time.Now()).Delete(&MFAChallenge{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return query2.ErrMFAChallenge
+	}
+	return nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// replaceRecoveryCodes 生成新的恢复码并作废旧的恢复码
+func replaceRecoveryCodes(tx *gorm.DB, uid uint) ([]string, error) {
+	if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, config.CONFIG.MFA.RecoveryCodes)
+	for i := 0; i < config.CONFIG.MFA.RecoveryCodes; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(b))
+		code = code[:4] + "-" + code[4:]
+		if err := tx.Create(&RecoveryCode{UserID: uid, Hash: hashRecoveryCode(code)}).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func getUserMFA(tx *gorm.DB, uid uint) (*UserMFA, error) {
+	mfa := &UserMFA{}
+	result := tx.Where("user_id = ?", uid).Limit(1).Find(mfa)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return mfa, nil
+}
+
+// MFAState 返回用户是否启用了二次认证，以及用户的角色是否要求二次认证
+func MFAState(uid uint) (enabled, required bool, enconterError error) {
+	var count int64
+	if enconterError = DB.Model(&UserMFA{}).Where("user_id = ? AND enabled = ?", uid, true).Count(&count).Error; enconterError != nil {
+		return false, false, enconterError
+	}
+	enabled = count > 0
+	if enconterError = DB.Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ? AND roles.require_mfa = ? AND roles.deleted_at IS NULL", uid, true).
+		Count(&count).Error; enconterError != nil {
+		return false, false, enconterError
+	}
+	return enabled, count > 0, nil
+}
+
+// GetMFAStatus 返回用户的二次认证状态
+func GetMFAStatus(uid uint) (*MFAStatus, error) {
+	enabled, required, err := MFAState(uid)
+	if err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{Enabled: enabled, Required: required}
+	if !enabled {
+		return status, nil
+	}
+	mfa, err := getUserMFA(DB, uid)
+	if err != nil {
+		return nil, err
+	}
+	status.EnabledAt = mfa.EnabledAt
+	err = DB.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", uid).Count(&status.RecoveryCodes).Error
+	return status, err
+}
+
+// EnrollMFA 为用户生成新的 TOTP 密钥，需要使用验证码确认后才会启用
+func EnrollMFA(uid uint, account string) (*MFAEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		mfa, err := getUserMFA(tx, uid)
+		if err != nil {
+			return err
+		}
+		if mfa == nil {
+			return tx.Create(&UserMFA{UserID: uid, Secret: secret}).Error
+		}
+		if mfa.Enabled {
+			return query2.ErrMFAEnabled
+		}
+		return tx.Model(mfa).Updates(map[string]interface{}{"secret": secret, "last_counter": 0}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &MFAEnrollment{Secret: secret, URI: totp.ProvisioningURI(config.CONFIG.MFA.Issuer, account, secret)}, nil
+}
+
+// ConfirmMFA 使用验证码确认绑定并启用二次认证，返回恢复码
+func ConfirmMFA(uid uint, code string) (codes []string, enconterError error) {
+	enconterError = DB.Transaction(func(tx *gorm.DB) error {
+		mfa, err := getUserMFA(tx, uid)
+		if err != nil {
+			return err
+		}
+		if mfa == nil {
+			return query2.ErrMFANotEnrolled
+		}
+		if mfa.Enabled {
+			return query2.ErrMFAEnabled
+		}
+		counter, ok := totp.Validate(mfa.Secret, code, time.Now())
+		if !ok {
+			return query2.ErrMFACode
+		}
+		now := time.Now()
+		if err = tx.Model(mfa).Updates(map[string]interface{}{"enabled": true, "enabled_at": now, "last_counter": counter}).Error; err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(tx, uid)
+		return err
+	})
+	return codes, enconterError
+}
+
+// VerifyMFA 校验 TOTP 验证码或恢复码，同一个时间步的验证码与已使用的恢复码不能再次使用
+func VerifyMFA(uid uint, code string) error {
+	mfa, err := getUserMFA(DB, uid)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return query2.ErrMFANotEnrolled
+	}
+	if counter, ok := totp.Validate(mfa.Secret, code, time.Now()); ok {
+		result := DB.Model(&UserMFA{}).
+			Where("id = ? AND last_counter < ?", mfa.ID, counter).
+			Update("last_counter", counter)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return query2.ErrMFACode
+		}
+		return nil
+	}
+	result := DB.Model(&RecoveryCode{}).
+		Where("user_id = ? AND hash = ? AND used_at IS NULL", uid, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return query2.ErrMFACode
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func RegenerateRecoveryCodes(uid uint) (codes []string, enconterError error) {
+	enconterError = DB.Transaction(func(tx *gorm.DB) error {
+		mfa, err := getUserMFA(tx, uid)
+		if err != nil {
+			return err
+		}
+		if mfa == nil || !mfa.Enabled {
+			return query2.ErrMFANotEnrolled
+		}
+		codes, err = replaceRecoveryCodes(tx, uid)
+		return err
+	})
+	return codes, enconterError
+}
+
+// DisableMFA 删除用户的 TOTP 密钥与恢复码
+func DisableMFA(uid uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", uid).Delete(&UserMFA{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", uid).Delete(&RecoveryCode{}).Error
+	})
+}
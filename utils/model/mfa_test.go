@@ -0,0 +1,106 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+	"github.com/cylonchau/firewalld-gateway/utils/totp"
+)
+
+// enrollMFA 绑定并确认用户的 TOTP，返回密钥、确认使用的时间步与恢复码
+func enrollMFA(t *testing.T, uid uint) (string, int64, []string) {
+	t.Helper()
+	setupTest(t, "\n[mfa]\nrecovery_codes = 3\n", &UserMFA{}, &RecoveryCode{})
+	enrollment, err := EnrollMFA(uid, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := totp.Counter(time.Now())
+	code, _ := totp.Code(enrollment.Secret, counter)
+	codes, err := ConfirmMFA(uid, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 3 {
+		t.Fatalf("expected 3 recovery codes, got %v", codes)
+	}
+	return enrollment.Secret, counter, codes
+}
+
+func TestVerifyMFARejectsReplay(t *testing.T) {
+	secret, counter, _ := enrollMFA(t, 1)
+	code := func(counter int64) string {
+		code, _ := totp.Code(secret, counter)
+		return code
+	}
+
+	cases := []struct {
+		name     string
+		code     string
+		expected error
+	}{
+		// 确认绑定使用的验证码不能用于登录
+		{"code of confirmation", code(counter), query.ErrMFACode},
+		{"earlier code", code(counter - 1), query.ErrMFACode},
+		{"next code", code(counter + 1), nil},
+		{"replayed code", code(counter + 1), query.ErrMFACode},
+		{"wrong code", "000000", query.ErrMFACode},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := VerifyMFA(1, tc.code); err != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+	mfa, _ := getUserMFA(DB, 1)
+	if mfa.LastCounter != counter+1 {
+		t.Fatalf("expected last counter %d, got %d", counter+1, mfa.LastCounter)
+	}
+	if err := VerifyMFA(2, code(counter+1)); err != query.ErrMFANotEnrolled {
+		t.Fatalf("expected %v for user without mfa, got %v", query.ErrMFANotEnrolled, err)
+	}
+}
+
+func TestVerifyMFARecoveryCodes(t *testing.T) {
+	_, _, codes := enrollMFA(t, 1)
+
+	cases := []struct {
+		name     string
+		code     string
+		expected error
+	}{
+		{"recovery code", codes[0], nil},
+		{"used recovery code", codes[0], query.ErrMFACode},
+		// 恢复码不区分大小写，可以省略 -
+		{"normalized recovery code", strings.ToUpper(strings.ReplaceAll(codes[1], "-", "")), nil},
+		{"used normalized recovery code", codes[1], query.ErrMFACode},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := VerifyMFA(1, tc.code); err != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	var unused int64
+	DB.Model(&RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", 1).Count(&unused)
+	if unused != 1 {
+		t.Fatalf("expected 1 unused recovery code, got %d", unused)
+	}
+
+	// 重新生成后旧的恢复码作废
+	regenerated, err := RegenerateRecoveryCodes(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = VerifyMFA(1, codes[2]); err != query.ErrMFACode {
+		t.Fatalf("expected %v for replaced recovery code, got %v", query.ErrMFACode, err)
+	}
+	if err = VerifyMFA(1, regenerated[2]); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,10 +3,12 @@ package model
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"k8s.io/klog/v2"
@@ -82,6 +84,15 @@ func InitDB(driver string) error {
 				return nil
 			}
 		}
+	case "postgres":
+		if DB, enconterError = gorm.Open(postgres.Open(config.CONFIG.PostgreSQL.DSN()), &gorm.Config{Logger: newLogger}); enconterError == nil {
+			if dbConn, enconterError = DB.DB(); enconterError == nil {
+				dbConn.SetMaxOpenConns(config.CONFIG.PostgreSQL.MaxOpenConnection)
+				dbConn.SetMaxIdleConns(config.CONFIG.PostgreSQL.MaxIdleConnection)
+				klog.V(4).Infof("Databases stats is %+v", dbConn.Stats())
+				return nil
+			}
+		}
 	case "sqlite":
 		if DB, enconterError = gorm.Open(sqlite.Open(config.CONFIG.SQLite.File+".db"), &gorm.Config{Logger: newLogger}); enconterError == nil {
 			if dbConn, enconterError = DB.DB(); enconterError == nil {
@@ -96,3 +107,28 @@ func InitDB(driver string) error {
 
 	return enconterError
 }
+
+// quoteColumn 按数据库的方言转义表名与列名，limit 等关键字作为列名时需要转义
+func quoteColumn(column string) string {
+	return DB.Statement.Quote(column)
+}
+
+// textColumn 将数字列转换为字符串，postgres 不能直接对数字列使用 LIKE
+func textColumn(column string) string {
+	if DB.Dialector.Name() == "mysql" {
+		return "CAST(" + column + " AS CHAR)"
+	}
+	return "CAST(" + column + " AS TEXT)"
+}
+
+// PingDB 检查数据库连接
+func PingDB(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database is not initialized")
+	}
+	conn, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return conn.PingContext(ctx)
+}
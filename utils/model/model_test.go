@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+// setupTest 使用临时目录中的 sqlite 数据库初始化配置与 models 的表，extra 为追加的 toml 配置，
+// 多个连接时同时执行的请求在不同的连接上执行
+func setupTest(t *testing.T, extra string, models ...interface{}) {
+	t.Helper()
+	dir := t.TempDir()
+	content := fmt.Sprintf(`appname = "test"
+database_driver = "sqlite"
+
+[sqlite]
+file = %q
+max_open_connection = 4
+`, filepath.Join(dir, "uranus")) + extra
+	file := filepath.Join(dir, "firewalld-gateway.toml")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InitConfiguration(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitDB("sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if conn, err := DB.DB(); err == nil {
+			conn.Close()
+		}
+	})
+	if err := DB.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,196 @@
+package model
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const (
+	password_history_table_name = "password_histories"
+
+	PasswordBcrypt   = "bcrypt"
+	PasswordArgon2id = "argon2id"
+
+	argon2KeyLength  = 32
+	argon2SaltLength = 16
+)
+
+// PasswordHistory 用户使用过的密码哈希，用于密码复用检查
+type PasswordHistory struct {
+	gorm.Model
+	UserID   uint   `json:"user_id" gorm:"index"`
+	Password string `json:"-" gorm:"type:varchar(255)"`
+}
+
+func (*PasswordHistory) TableName() string {
+	return password_history_table_name
+}
+
+// HashPassword 按配置的算法计算密码哈希
+func HashPassword(p string) (string, error) {
+	cfg := config.CONFIG.Password
+	switch cfg.Algorithm {
+	case PasswordArgon2id:
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(p), salt, cfg.Argon2Time, cfg.Argon2Memory, cfg.Argon2Threads, argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, cfg.Argon2Memory, cfg.Argon2Time, cfg.Argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case PasswordBcrypt, "":
+		hash, err := bcrypt.GenerateFromPassword([]byte(p), cfg.Cost)
+		return string(hash), err
+	}
+	return "", fmt.Errorf("unsupported password algorithm %s", cfg.Algorithm)
+}
+
+// VerifyPassword 校验密码，rehash 表示哈希的算法或参数与当前配置不一致，需要重新计算
+func VerifyPassword(hash, p string) (ok, rehash bool) {
+	cfg := config.CONFIG.Password
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		var (
+			version      int
+			memory, time uint32
+			threads      uint8
+		)
+		parts := strings.Split(hash, "$")
+		if len(parts) != 6 {
+			return false, false
+		}
+		if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return false, false
+		}
+		if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+			return false, false
+		}
+		salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+		if err != nil {
+			return false, false
+		}
+		key, err := base64.RawStdEncoding.DecodeString(parts[5])
+		if err != nil {
+			return false, false
+		}
+		if subtle.ConstantTimeCompare(key, argon2.IDKey([]byte(p), salt, time, memory, threads, uint32(len(key)))) != 1 {
+			return false, false
+		}
+		return true, cfg.Algorithm != PasswordArgon2id || memory != cfg.Argon2Memory || time != cfg.Argon2Time || threads != cfg.Argon2Threads
+	case strings.HasPrefix(hash, "$2"):
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(p)) != nil {
+			return false, false
+		}
+		cost, _ := bcrypt.Cost([]byte(hash))
+		return true, (cfg.Algorithm != PasswordBcrypt && cfg.Algorithm != "") || cost != cfg.Cost
+	}
+	// 旧版本保存的加盐 md5，校验通过后总是需要重新计算
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(legacyPassword(p))) == 1 {
+		return true, true
+	}
+	return false, false
+}
+
+var dummyPassword struct {
+	once sync.Once
+	hash string
+}
+
+// VerifyDummyPassword 用户不存在时同样计算一次密码哈希，避免通过响应时间判断用户是否存在
+func VerifyDummyPassword(p string) {
+	dummyPassword.once.Do(func() {
+		dummyPassword.hash, _ = HashPassword(Secret + config.CONFIG.AppName)
+	})
+	VerifyPassword(dummyPassword.hash, p)
+}
+
+// legacyPassword 旧版本的密码哈希，仅用于校验尚未重新计算的密码
+func legacyPassword(p string) string {
+	h := md5.New()
+	h.Write([]byte(Secret + config.CONFIG.AppName))
+	return hex.EncodeToString(h.Sum([]byte(p)))
+}
+
+// CheckPasswordPolicy 校验密码长度与复杂度
+func CheckPasswordPolicy(p string) error {
+	cfg := config.CONFIG.Password
+	if len([]rune(p)) < cfg.MinLength {
+		return query.ErrPasswordTooShort
+	}
+	var upper, lower, digit, symbol int
+	for _, r := range p {
+		switch {
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	if upper+lower+digit+symbol < cfg.Complexity {
+		return query.ErrPasswordTooSimple
+	}
+	return nil
+}
+
+// checkPasswordReused 检查密码是否与当前密码或最近的历史密码相同
+func checkPasswordReused(tx *gorm.DB, uid uint, p string) error {
+	history := config.CONFIG.Password.History
+	if history <= 0 {
+		return nil
+	}
+	var hashes []string
+	if err := tx.Model(&User{}).Where("id = ?", uid).Pluck("password", &hashes).Error; err != nil {
+		return err
+	}
+	var histories []string
+	if err := tx.Model(&PasswordHistory{}).
+		Where("user_id = ?", uid).
+		Order("id desc").
+		Limit(history).
+		Pluck("password", &histories).Error; err != nil {
+		return err
+	}
+	for _, hash := range append(hashes, histories...) {
+		if ok, _ := VerifyPassword(hash, p); ok {
+			return query.ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// setPassword 计算并保存用户的新密码，同时记录到历史密码中
+func setPassword(tx *gorm.DB, uid uint, p string) error {
+	hash, err := HashPassword(p)
+	if err != nil {
+		return err
+	}
+	if err = tx.Model(&User{}).Where("id = ?", uid).Update("password", hash).Error; err != nil {
+		return err
+	}
+	return tx.Create(&PasswordHistory{UserID: uid, Password: hash}).Error
+}
+
+// RehashPassword 登录成功后用当前配置的算法重新计算密码哈希，新的哈希同样记录到历史密码中
+func RehashPassword(uid uint, p string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, uid, p)
+	})
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+func TestRehashPasswordRecordsHistory(t *testing.T) {
+	setupTest(t, `
+[password]
+algorithm = "bcrypt"
+cost = 4
+history = 5
+`, &User{}, &PasswordHistory{})
+
+	user := &User{Username: "alice", Password: legacyPassword("Secret-123"), Source: UserSourceLocal}
+	if err := DB.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash := VerifyPassword(user.Password, "Secret-123"); !ok || !rehash {
+		t.Fatalf("legacy hash: expected ok and rehash, got %v %v", ok, rehash)
+	}
+	if err := RehashPassword(user.ID, "Secret-123"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DB.First(user, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash := VerifyPassword(user.Password, "Secret-123"); !ok || rehash || !strings.HasPrefix(user.Password, "$2") {
+		t.Fatalf("expected a current bcrypt hash, got %s", user.Password)
+	}
+	var histories []string
+	if err := DB.Model(&PasswordHistory{}).Where("user_id = ?", user.ID).Pluck("password", &histories).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(histories) != 1 || histories[0] != user.Password {
+		t.Fatalf("expected the new hash in the password history, got %v", histories)
+	}
+	if err := checkPasswordReused(DB, user.ID, "Secret-123"); err != query.ErrPasswordReused {
+		t.Fatalf("expected %v, got %v", query.ErrPasswordReused, err)
+	}
+}
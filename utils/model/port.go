@@ -34,13 +34,13 @@ func (*PortListWithoutID) TableName() string {
 	return port_table_name
 }
 
-func CreatePort(port uint16, protocol string, template_id int) (enconterError error) {
+func CreatePort(tx *gorm.DB, port uint16, protocol string, template_id int) (enconterError error) {
 	Port := &Port{
 		Port:       port,
 		Protocol:   protocol,
 		TemplateId: template_id,
 	}
-	result := DB.Create(Port)
+	result := tx.Create(Port)
 	if enconterError = result.Error; enconterError == nil {
 		return nil
 	}
@@ -57,12 +57,12 @@ func GetPorts(title string, offset, limit int, sort string) (map[string]interfac
 			port_table_name + ".id",
 			port_table_name + ".port",
 			port_table_name + ".protocol",
-			"templates.name template",
-			"templates.id template_id"}).
+			"templates.name AS template",
+			"templates.id AS template_id"}).
 		Joins("JOIN templates ON "+port_table_name+".template_id = templates.id").
 		Where(port_table_name+".deleted_at IS ?", nil)
 	if title != "" {
-		portQuery.Where("ports.`port` LIKE ?", title+"%")
+		portQuery.Where(textColumn(port_table_name+".port")+" LIKE ?", title+"%")
 	}
 	result := portQuery.
 		Order(port_table_name + ".id " + sort).
@@ -85,24 +85,32 @@ func CheckPortIsExistWithName(name string) bool {
 	return true
 }
 
-func DeletePortWithID(id uint64) error {
-	result := DB.Delete(&Port{}, id)
+func DeletePortWithID(tx *gorm.DB, id uint64) error {
+	result := tx.Delete(&Port{}, id)
 	if result.Error == nil {
 		return nil
 	}
 	return result.Error
 }
 
-func UpdatePortWithID(id uint64, port uint16, protocol string, template_id int) (enconterError error) {
+func UpdatePortWithID(tx *gorm.DB, id uint64, port uint16, protocol string, template_id int) (enconterError error) {
 	p := &Port{
 		Port:       port,
 		Protocol:   protocol,
 		TemplateId: template_id,
 	}
-	result := DB.Model(&Port{}).Where("id = ?", id).Updates(p)
+	result := tx.Model(&Port{}).Where("id = ?", id).Updates(p)
 	if enconterError = result.Error; enconterError == nil {
 		return nil
 	}
 
 	return enconterError
 }
+
+func QueryTemplateIDWithPortID(id uint64) (templateID int, enconterError error) {
+	result := DB.Model(&Port{}).Select("template_id").Where("id = ?", id).Scan(&templateID)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return templateID, enconterError
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"gorm.io/gorm"
+
+	queryapi "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const protocol_table_name = "protocols"
+
+type Protocol struct {
+	gorm.Model
+	Value      string `json:"value" gorm:"index;type:varchar(20)"`
+	TemplateID int    `json:"template_id" gorm:"index;type:int"`
+}
+
+type ProtocolList struct {
+	ID         int    `json:"id"`
+	Value      string `json:"value"`
+	Template   string `json:"template"`
+	TemplateID int    `json:"template_id"`
+}
+
+type ProtocolListWithoutID struct {
+	Value string `json:"value,omitempty"`
+}
+
+func (*ProtocolList) TableName() string {
+	return protocol_table_name
+}
+
+func (*ProtocolListWithoutID) TableName() string {
+	return protocol_table_name
+}
+
+func CreateProtocol(tx *gorm.DB, query *queryapi.ProtocolEditQuery) (enconterError error) {
+	protocol := &Protocol{
+		Value:      query.Value,
+		TemplateID: query.TemplateId,
+	}
+	result := tx.Create(protocol)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func GetProtocols(title string, offset, limit int, sort string) (map[string]interface{}, error) {
+	protocols := []*ProtocolList{}
+	response := make(map[string]interface{})
+	var count int64
+	protocolQuery := DB.Table(protocol_table_name).
+		Select([]string{
+			protocol_table_name + ".id",
+			protocol_table_name + ".value",
+			"templates.name AS template",
+			"templates.id AS template_id"}).
+		Joins("JOIN templates ON "+protocol_table_name+".template_id = templates.id").
+		Where(protocol_table_name+".deleted_at IS ?", nil)
+	if title != "" {
+		protocolQuery.Where(protocol_table_name+".value LIKE ?", title+"%")
+	}
+	result := protocolQuery.
+		Order(protocol_table_name + ".id " + sort).
+		Limit(limit).Offset((offset - 1) * limit).
+		Scan(&protocols)
+	DB.Model(&Protocol{}).Distinct("id").Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = protocols
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
+
+func UpdateProtocolWithID(tx *gorm.DB, query *queryapi.ProtocolEditQuery) (enconterError error) {
+	protocol := map[string]interface{}{
+		"value":       query.Value,
+		"template_id": query.TemplateId,
+	}
+	result := tx.Model(&Protocol{}).Where("id = ?", query.ID).Updates(protocol)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func DeleteProtocolWithID(tx *gorm.DB, id uint64) error {
+	result := tx.Delete(&Protocol{}, id)
+	if result.Error == nil {
+		return nil
+	}
+	return result.Error
+}
+
+func QueryTemplateIDWithProtocolID(id uint64) (templateID int, enconterError error) {
+	result := DB.Model(&Protocol{}).Select("template_id").Where("id = ?", id).Scan(&templateID)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return templateID, enconterError
+}
@@ -82,7 +82,6 @@ func (r *RichList) Scan(value interface{}) error {
 		var result = &RichList{}
 		encounterError = json.Unmarshal(bytes, result)
 		if encounterError == nil {
-			panic(result)
 			if !isEmptyStruct(result.Port) {
 
 				r.Port = result.Port
@@ -107,7 +106,7 @@ func (r *RichList) Value() (value driver.Value, err error) {
 	return json.Marshal(r)
 }
 
-func CreateRich(query *queryapi.RichEditQuery) (enconterError error) {
+func CreateRich(tx *gorm.DB, query *queryapi.RichEditQuery) (enconterError error) {
 
 	var port *api.Port
 	if query.Port != "" {
@@ -132,7 +131,7 @@ func CreateRich(query *queryapi.RichEditQuery) (enconterError error) {
 		LimitUnit:  query.LimitUnit,
 		TemplateID: query.TemplateId,
 	}
-	result := DB.Create(rich)
+	result := tx.Create(rich)
 	if enconterError = result.Error; enconterError == nil {
 		return nil
 	}
@@ -153,14 +152,14 @@ func GetRich(title string, offset, limit int, sort string) (map[string]interface
 			rich_table_name + ".port",
 			rich_table_name + ".protocol",
 			rich_table_name + ".action",
-			rich_table_name + ".`limit`",
+			quoteColumn(rich_table_name + ".limit"),
 			rich_table_name + ".limit_unit",
-			"templates.name template",
-			"templates.id template_id"}).
+			"templates.name AS template",
+			"templates.id AS template_id"}).
 		Joins("JOIN templates ON "+rich_table_name+".template_id = templates.id").
 		Where(rich_table_name+".deleted_at is ?", nil)
 	if title != "" {
-		richQuery.Where(rich_table_name+".`destination` LIKE ?", "%"+title+"%")
+		richQuery.Where(rich_table_name+".destination LIKE ?", "%"+title+"%")
 	}
 	result := richQuery.Order(rich_table_name + ".id " + sort).
 		Limit(limit).
@@ -201,7 +200,7 @@ func isEmptyStruct(s interface{}) bool {
 	return true
 }
 
-func UpdateRichWithID(query *queryapi.RichEditQuery) (enconterError error) {
+func UpdateRichWithID(tx *gorm.DB, query *queryapi.RichEditQuery) (enconterError error) {
 	var port *api.Port
 	if query.Port != "" {
 		s := strings.Split(query.Port, "/")
@@ -225,7 +224,7 @@ func UpdateRichWithID(query *queryapi.RichEditQuery) (enconterError error) {
 		LimitUnit:  query.LimitUnit,
 		TemplateID: query.TemplateId,
 	}
-	result := DB.Model(&Rich{}).Where("id = ?", query.ID).Updates(rich)
+	result := tx.Model(&Rich{}).Where("id = ?", query.ID).Updates(rich)
 	if enconterError = result.Error; enconterError == nil {
 		return nil
 	}
@@ -233,10 +232,18 @@ func UpdateRichWithID(query *queryapi.RichEditQuery) (enconterError error) {
 	return enconterError
 }
 
-func DeleteRichWithID(id uint64) error {
-	result := DB.Delete(&Rich{}, id)
+func DeleteRichWithID(tx *gorm.DB, id uint64) error {
+	result := tx.Delete(&Rich{}, id)
 	if result.Error == nil {
 		return nil
 	}
 	return result.Error
 }
+
+func QueryTemplateIDWithRichID(id uint64) (templateID int, enconterError error) {
+	result := DB.Model(&Rich{}).Select("template_id").Where("id = ?", id).Scan(&templateID)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return templateID, enconterError
+}
@@ -16,12 +16,15 @@ type Role struct {
 	Users       []User   `gorm:"many2many:user_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Tokens      []Token  `gorm:"many2many:token_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Description string   `gorm:"size:255;not null" json:"description"`
+	// RequireMFA 拥有该角色的用户必须启用二次认证
+	RequireMFA bool `gorm:"not null;default:false" json:"require_mfa"`
 }
 
 type RoleList struct {
 	ID          int    `json:"id"`
 	Name        string `gorm:"size:50;not null;unique" json:"name"`
 	Description string `gorm:"size:255;not null" json:"description"`
+	RequireMFA  bool   `json:"require_mfa"`
 }
 
 type RoleInfo struct {
@@ -56,7 +59,7 @@ func GetRoles(title string, offset, limit int, sort string) (map[string]interfac
 	response := make(map[string]interface{})
 	var count int64
 
-	query := DB.Select([]string{"id", "name", "description"}).Limit(limit).Offset((offset - 1) * limit)
+	query := DB.Select([]string{"id", "name", "description", "require_mfa"}).Limit(limit).Offset((offset - 1) * limit)
 
 	if title != "" {
 		query = query.Where("name LIKE ?", title+"%")
@@ -93,20 +96,21 @@ func GetRolesByUID(userID uint) ([]RoleList, error) {
 
 // Create role
 func CreateRole(query *query2.RoleEditQuery, routers []Router) (enconterError error) {
-	if checkRoleIsExistWithName(query.Name) {
+	if !checkRoleIsExistWithName(query.Name) {
+		return query2.ErrRoleExist
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
 		role := &Role{
 			Name:        query.Name,
 			Description: query.Description,
 			Routers:     routers,
+			RequireMFA:  query.RequireMFA != nil && *query.RequireMFA,
 		}
-		result := DB.Create(role)
-		if enconterError := result.Error; enconterError == nil {
-			return nil
+		if err := tx.Create(role).Error; err != nil {
+			return err
 		}
-	} else {
-		enconterError = query2.ErrRoleExist
-	}
-	return enconterError
+		return replaceRoleScopes(tx, role.ID, query.Scopes)
+	})
 }
 
 func checkRoleIsExistWithName(name string) bool {
@@ -124,12 +128,20 @@ func UpdateRoleWithID(query *query2.RoleEditQuery) (enconterError error) {
 	}
 	routers := GenerateRouterWithID(query.RouterIDs)
 	role.Routers = routers
-	result := DB.Model(&Role{}).Where("id = ?", query.ID).Updates(role)
-	if enconterError = result.Error; enconterError == nil {
-		return nil
-	}
-
-	return enconterError
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Role{}).Where("id = ?", query.ID).Updates(role).Error; err != nil {
+			return err
+		}
+		if query.RequireMFA != nil {
+			if err := tx.Model(&Role{}).Where("id = ?", query.ID).Update("require_mfa", *query.RequireMFA).Error; err != nil {
+				return err
+			}
+		}
+		if query.Scopes == nil {
+			return nil
+		}
+		return replaceRoleScopes(tx, uint(query.ID), query.Scopes)
+	})
 }
 
 func DeleteRoleWithID(id uint64) error {
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"github.com/praserx/ipconv"
+	"gorm.io/gorm"
+
+	query2 "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const (
+	role_scope_table_name = "role_scopes"
+
+	ScopeHost = "host"
+	ScopeTag  = "tag"
+)
+
+// RoleScope 角色可以操作的主机范围，host 为主机 ip 或主机名，tag 为标签名；角色没有 scope 时不限制主机
+type RoleScope struct {
+	gorm.Model
+	RoleID uint   `json:"role_id" gorm:"index"`
+	Kind   string `json:"kind" gorm:"type:varchar(10)"`
+	Value  string `json:"value" gorm:"type:varchar(255)"`
+}
+
+type RoleScopeList struct {
+	ID     int    `json:"id"`
+	RoleID int    `json:"role_id"`
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+}
+
+func (*RoleScope) TableName() string {
+	return role_scope_table_name
+}
+
+func (*RoleScopeList) TableName() string {
+	return role_scope_table_name
+}
+
+// Permission 一个角色可以访问的路由与主机范围
+type Permission struct {
+	RoleID  uint
+	Routers []Router
+	Scopes  []RoleScope
+}
+
+func replaceRoleScopes(tx *gorm.DB, roleID uint, scopes []query2.RoleScopeQuery) error {
+	if err := tx.Unscoped().Where("role_id = ?", roleID).Delete(&RoleScope{}).Error; err != nil {
+		return err
+	}
+	for _, scope := range scopes {
+		if err := tx.Create(&RoleScope{RoleID: roleID, Kind: scope.Kind, Value: scope.Value}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetRoleScopes(roleID uint) ([]RoleScopeList, error) {
+	scopes := []RoleScopeList{}
+	if err := DB.Where("role_id = ?", roleID).Where("deleted_at is ?", nil).Find(&scopes).Error; err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// GetPermissionsWithUID 返回用户每个角色的路由与主机范围
+func GetPermissionsWithUID(uid uint) ([]Permission, error) {
+	var roles []Role
+	if err := DB.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", uid).
+		Preload("Routers").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return permissionsOfRoles(roles)
+}
+
+func permissionsOfRoles(roles []Role) ([]Permission, error) {
+	permissions := make([]Permission, 0, len(roles))
+	for _, role := range roles {
+		var scopes []RoleScope
+		if err := DB.Where("role_id = ?", role.ID).Find(&scopes).Error; err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, Permission{RoleID: role.ID, Routers: role.Routers, Scopes: scopes})
+	}
+	return permissions, nil
+}
+
+// HostInScopes 判断主机是否在 scopes 范围内，主机不在主机列表中时只能匹配 ip 形式的 host scope
+func HostInScopes(ip uint32, scopes []RoleScope) (bool, error) {
+	host := &Host{}
+	result := DB.Select("id", "hostname", "ip", "tag_id").Where("ip = ?", ip).Limit(1).Find(host)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	var tag string
+	if result.RowsAffected > 0 && host.TagId > 0 {
+		if err := DB.Model(&Tag{}).Select("name").Where("id = ?", host.TagId).Limit(1).Scan(&tag).Error; err != nil {
+			return false, err
+		}
+	}
+	address := ipconv.IntToIPv4(ip).String()
+	for _, scope := range scopes {
+		switch scope.Kind {
+		case ScopeHost:
+			if scope.Value == address || (result.RowsAffected > 0 && scope.Value == host.Hostname) {
+				return true, nil
+			}
+		case ScopeTag:
+			if tag != "" && scope.Value == tag {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+
+	query2 "github.com/cylonchau/firewalld-gateway/utils/apis/query"
 )
 
 var router_table_name = "routers"
@@ -12,14 +14,14 @@ var router_table_name = "routers"
 type Router struct {
 	gorm.Model
 	Path   string `json:"path" gorm:"type:varchar(255);not null;"`
-	Method string `json:"method" gorm:"type:char(4);not null;"`
+	Method string `json:"method" gorm:"type:varchar(10);not null;"`
 	Roles  []Role `gorm:"many2many:role_routers;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 }
 
 type RouterList struct {
 	ID        int       `json:"id" gorm:"primarykey;column:id"`
 	Path      string    `json:"path" gorm:"type:varchar(255);not null;column:path"`
-	Method    string    `json:"method" gorm:"type:char(4);not null;column:method"`
+	Method    string    `json:"method" gorm:"type:varchar(10);not null;column:method"`
 	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
 }
 
@@ -91,3 +93,15 @@ func GetRoutersWithRID(roleIDs []string) (routers []*RouterList, encounterError
 	}
 	return nil, encounterError
 }
+
+// CreateRouter 添加路由规则，path 可以使用 gin 的路由模式，以 /* 结尾时匹配该前缀下的所有路由，method 为 * 时匹配所有方法
+func CreateRouter(path, method string) error {
+	var count int64
+	if err := DB.Model(&Router{}).Where("path = ? AND method = ?", path, method).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return query2.ErrRouterExist
+	}
+	return DB.Create(&Router{Path: path, Method: method}).Error
+}
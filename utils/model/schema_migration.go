@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+const schema_migration_table_name = "schema_migrations"
+
+// SchemaMigration 已经执行的数据库迁移，Checksum 为执行时迁移 SQL 的 sha256，
+// Dirty 为 true 表示迁移开始执行后没有完成，需要重新执行 migrate up
+type SchemaMigration struct {
+	Version   uint      `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `json:"name" gorm:"type:varchar(255)"`
+	Checksum  string    `json:"checksum" gorm:"type:varchar(64)"`
+	Dirty     bool      `json:"dirty"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func (*SchemaMigration) TableName() string {
+	return schema_migration_table_name
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"gorm.io/gorm"
+
+	queryapi "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const service_table_name = "services"
+
+type Service struct {
+	gorm.Model
+	Name       string `json:"name" gorm:"index;type:varchar(255)"`
+	TemplateID int    `json:"template_id" gorm:"index;type:int"`
+}
+
+type ServiceList struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Template   string `json:"template"`
+	TemplateID int    `json:"template_id"`
+}
+
+type ServiceListWithoutID struct {
+	Name string `json:"name,omitempty"`
+}
+
+func (*ServiceList) TableName() string {
+	return service_table_name
+}
+
+func (*ServiceListWithoutID) TableName() string {
+	return service_table_name
+}
+
+func CreateService(tx *gorm.DB, query *queryapi.ServiceEditQuery) (enconterError error) {
+	service := &Service{
+		Name:       query.Name,
+		TemplateID: query.TemplateId,
+	}
+	result := tx.Create(service)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func GetServices(title string, offset, limit int, sort string) (map[string]interface{}, error) {
+	services := []*ServiceList{}
+	response := make(map[string]interface{})
+	var count int64
+	serviceQuery := DB.Table(service_table_name).
+		Select([]string{
+			service_table_name + ".id",
+			service_table_name + ".name",
+			"templates.name AS template",
+			"templates.id AS template_id"}).
+		Joins("JOIN templates ON "+service_table_name+".template_id = templates.id").
+		Where(service_table_name+".deleted_at IS ?", nil)
+	if title != "" {
+		serviceQuery.Where(service_table_name+".name LIKE ?", title+"%")
+	}
+	result := serviceQuery.
+		Order(service_table_name + ".id " + sort).
+		Limit(limit).Offset((offset - 1) * limit).
+		Scan(&services)
+	DB.Model(&Service{}).Distinct("id").Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = services
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
+
+func UpdateServiceWithID(tx *gorm.DB, query *queryapi.ServiceEditQuery) (enconterError error) {
+	service := map[string]interface{}{
+		"name":        query.Name,
+		"template_id": query.TemplateId,
+	}
+	result := tx.Model(&Service{}).Where("id = ?", query.ID).Updates(service)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func DeleteServiceWithID(tx *gorm.DB, id uint64) error {
+	result := tx.Delete(&Service{}, id)
+	if result.Error == nil {
+		return nil
+	}
+	return result.Error
+}
+
+func QueryTemplateIDWithServiceID(id uint64) (templateID int, enconterError error) {
+	result := DB.Model(&Service{}).Select("template_id").Where("id = ?", id).Scan(&templateID)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return templateID, enconterError
+}
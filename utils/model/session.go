@@ -0,0 +1,257 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"k8s.io/klog/v2"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+	query2 "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const (
+	session_table_name = "sessions"
+
+	SessionRevokedLogout   = "logout"
+	SessionRevokedAdmin    = "admin"
+	SessionRevokedReuse    = "reuse"
+	SessionRevokedPassword = "password"
+	SessionRevokedUser     = "user_deleted"
+
+	// sessionRetention 过期与注销的会话保留一段时间，便于管理员查看
+	sessionRetention = 7 * 24 * time.Hour
+)
+
+// Session 登录会话，refresh token 为 "会话 key.随机串"，只保存 refresh token 的 sha256，每次刷新都会轮换
+type Session struct {
+	gorm.Model
+	SessionKey    string     `json:"-" gorm:"uniqueIndex;type:varchar(64)"`
+	UserID        uint       `json:"user_id" gorm:"index"`
+	RefreshHash   string     `json:"-" gorm:"type:varchar(64)"`
+	IP            uint32     `json:"ip" gorm:"type:bigint"`
+	UserAgent     string     `json:"user_agent" gorm:"type:varchar(255)"`
+	LastSeenAt    time.Time  `json:"last_seen_at"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason" gorm:"type:varchar(20)"`
+	// MFAVerifiedAt 会话最近一次通过二次认证的时间，用于敏感操作的再次验证
+	MFAVerifiedAt *time.Time `json:"mfa_verified_at"`
+}
+
+type SessionList struct {
+	ID            uint       `json:"id"`
+	UserID        uint       `json:"user_id"`
+	IP            uint32     `json:"ip"`
+	UserAgent     string     `json:"user_agent"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason"`
+	MFAVerifiedAt *time.Time `json:"mfa_verified_at"`
+}
+
+func (*Session) TableName() string {
+	return session_table_name
+}
+
+func (*SessionList) TableName() string {
+	return session_table_name
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashRefreshToken(refresh string) string {
+	sum := sha256.Sum256([]byte(refresh))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken(key string) (refresh, hash string, err error) {
+	secret, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	refresh = key + "." + secret
+	return refresh, hashRefreshToken(refresh), nil
+}
+
+func refreshTTL() time.Duration {
+	return time.Duration(config.CONFIG.Session.RefreshTTL) * time.Second
+}
+
+// CreateSession 创建登录会话，返回会话 key 与 refresh token，mfa 表示登录时是否通过了二次认证
+func CreateSession(uid uint, ip uint32, userAgent string, mfa bool) (key, refresh string, enconterError error) {
+	if key, enconterError = randomString(24); enconterError != nil {
+		return "", "", enconterError
+	}
+	var hash string
+	if refresh, hash, enconterError = newRefreshToken(key); enconterError != nil {
+		return "", "", enconterError
+	}
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	now := time.Now()
+	// 顺便清理早已过期的会话
+	DB.Unscoped().Where("expires_at < ?", now.Add(-sessionRetention)).Delete(&Session{})
+	session := &Session{
+		SessionKey:  key,
+		UserID:      uid,
+		RefreshHash: hash,
+		IP:          ip,
+		UserAgent:   userAgent,
+		LastSeenAt:  now,
+		ExpiresAt:   now.Add(refreshTTL()),
+	}
+	if mfa {
+		session.MFAVerifiedAt = &now
+	}
+	enconterError = DB.Create(session).Error
+	return key, refresh, enconterError
+}
+
+// findSessionWithRefresh 根据 refresh token 中的 key 查找会话，match 表示 refresh token 是否为会话当前的 refresh token
+func findSessionWithRefresh(refresh string) (session *Session, match bool, enconterError error) {
+	i := strings.IndexByte(refresh, '.')
+	if i <= 0 {
+		return nil, false, query2.ErrRefreshTokenInvalid
+	}
+	session = &Session{}
+	result := DB.Where("session_key = ?", refresh[:i]).Limit(1).Find(session)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, query2.ErrRefreshTokenInvalid
+	}
+	match = subtle.ConstantTimeCompare([]byte(session.RefreshHash), []byte(hashRefreshToken(refresh))) == 1
+	return session, match, nil
+}
+
+// RefreshSession 校验并轮换 refresh token；使用已经轮换过的 refresh token 说明 token 可能被盗用，注销整个会话
+func RefreshSession(refresh string, ip uint32, userAgent string) (uid uint, key, newRefresh string, enconterError error) {
+	session, match, enconterError := findSessionWithRefresh(refresh)
+	if enconterError != nil {
+		return 0, "", "", enconterError
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return 0, "", "", query2.ErrRefreshTokenInvalid
+	}
+	if !match {
+		klog.Warningf("refresh token reuse detected for session %d of user %d, the session is revoked", session.ID, session.UserID)
+		revokeSessions(DB.Where("id = ?", session.ID), SessionRevokedReuse)
+		return 0, "", "", query2.ErrRefreshTokenReused
+	}
+	if user, err := QueryUserWithUID(int64(session.UserID)); err != nil || user.ID == 0 {
+		revokeSessions(DB.Where("id = ?", session.ID), SessionRevokedUser)
+		return 0, "", "", query2.ErrRefreshTokenInvalid
+	}
+
+	newRefresh, hash, enconterError := newRefreshToken(session.SessionKey)
+	if enconterError != nil {
+		return 0, "", "", enconterError
+	}
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	// 以旧的 refresh token 为条件更新，并发使用同一个 refresh token 时只有一个请求能够成功
+	result := DB.Model(&Session{}).
+		Where("id = ? AND refresh_hash = ? AND revoked_at IS NULL", session.ID, session.RefreshHash).
+		Updates(map[string]interface{}{
+			"refresh_hash": hash,
+			"ip":           ip,
+			"user_agent":   userAgent,
+			"last_seen_at": now,
+			"expires_at":   now.Add(refreshTTL()),
+		})
+	if result.Error != nil {
+		return 0, "", "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		klog.Warningf("refresh token reuse detected for session %d of user %d, the session is revoked", session.ID, session.UserID)
+		revokeSessions(DB.Where("id = ?", session.ID), SessionRevokedReuse)
+		return 0, "", "", query2.ErrRefreshTokenReused
+	}
+	return session.UserID, session.SessionKey, newRefresh, nil
+}
+
+// GetActiveSession 返回未被注销且未过期的会话
+func GetActiveSession(key string) (*Session, bool) {
+	if key == "" {
+		return nil, false
+	}
+	session := &Session{}
+	result := DB.Where("session_key = ? AND revoked_at IS NULL AND expires_at > ?", key, time.Now()).Limit(1).Find(session)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, false
+	}
+	return session, true
+}
+
+// MarkSessionMFA 记录会话通过了二次认证
+func MarkSessionMFA(key string) error {
+	return DB.Model(&Session{}).Where("session_key = ?", key).Update("mfa_verified_at", time.Now()).Error
+}
+
+func revokeSessions(tx *gorm.DB, reason string) (int64, error) {
+	result := tx.Model(&Session{}).
+		Where("revoked_at IS NULL").
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason})
+	return result.RowsAffected, result.Error
+}
+
+// RevokeSession 注销会话
+func RevokeSession(key, reason string) error {
+	_, err := revokeSessions(DB.Where("session_key = ?", key), reason)
+	return err
+}
+
+// RevokeSessionWithRefresh 使用 refresh token 注销会话，已轮换过的 refresh token 按盗用处理
+func RevokeSessionWithRefresh(refresh string) error {
+	session, match, err := findSessionWithRefresh(refresh)
+	if err != nil {
+		return err
+	}
+	reason := SessionRevokedLogout
+	if !match {
+		reason = SessionRevokedReuse
+	}
+	_, err = revokeSessions(DB.Where("id = ?", session.ID), reason)
+	return err
+}
+
+// RevokeUserSessions 注销用户的会话，sessionID 为 0 时注销用户的全部会话
+func RevokeUserSessions(uid, sessionID uint, reason string) (int64, error) {
+	tx := DB.Where("user_id = ?", uid)
+	if sessionID > 0 {
+		tx = tx.Where("id = ?", sessionID)
+	}
+	return revokeSessions(tx, reason)
+}
+
+// GetUserSessions 返回用户的会话，all 为 false 时只返回有效的会话
+func GetUserSessions(uid uint, all bool) ([]SessionList, error) {
+	sessions := []SessionList{}
+	tx := DB.Where("user_id = ?", uid).Where("deleted_at IS ?", nil)
+	if !all {
+		tx = tx.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	}
+	if err := tx.Order("id desc").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
@@ -0,0 +1,116 @@
+package model
+
+import (
+	"gorm.io/gorm"
+
+	queryapi "github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+const source_table_name = "sources"
+
+type Source struct {
+	gorm.Model
+	Address    string `json:"address" gorm:"index;type:varchar(64)"`
+	Mac        string `json:"mac" gorm:"type:varchar(17)"`
+	Ipset      string `json:"ipset" gorm:"type:varchar(64)"`
+	TemplateID int    `json:"template_id" gorm:"index;type:int"`
+}
+
+type SourceList struct {
+	ID         int    `json:"id"`
+	Address    string `json:"address"`
+	Mac        string `json:"mac"`
+	Ipset      string `json:"ipset"`
+	Template   string `json:"template"`
+	TemplateID int    `json:"template_id"`
+}
+
+type SourceListWithoutID struct {
+	Address string `json:"address,omitempty"`
+	Mac     string `json:"mac,omitempty"`
+	Ipset   string `json:"ipset,omitempty"`
+}
+
+func (*SourceList) TableName() string {
+	return source_table_name
+}
+
+func (*SourceListWithoutID) TableName() string {
+	return source_table_name
+}
+
+func CreateSource(tx *gorm.DB, query *queryapi.SourceEditQuery) (enconterError error) {
+	source := &Source{
+		Address:    query.Address,
+		Mac:        query.Mac,
+		Ipset:      query.Ipset,
+		TemplateID: query.TemplateId,
+	}
+	result := tx.Create(source)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func GetSources(title string, offset, limit int, sort string) (map[string]interface{}, error) {
+	sources := []*SourceList{}
+	response := make(map[string]interface{})
+	var count int64
+	sourceQuery := DB.Table(source_table_name).
+		Select([]string{
+			source_table_name + ".id",
+			source_table_name + ".address",
+			source_table_name + ".mac",
+			source_table_name + ".ipset",
+			"templates.name AS template",
+			"templates.id AS template_id"}).
+		Joins("JOIN templates ON "+source_table_name+".template_id = templates.id").
+		Where(source_table_name+".deleted_at IS ?", nil)
+	if title != "" {
+		sourceQuery.Where(source_table_name+".address LIKE ?", title+"%")
+	}
+	result := sourceQuery.
+		Order(source_table_name + ".id " + sort).
+		Limit(limit).Offset((offset - 1) * limit).
+		Scan(&sources)
+	DB.Model(&Source{}).Distinct("id").Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = sources
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
+
+func UpdateSourceWithID(tx *gorm.DB, query *queryapi.SourceEditQuery) (enconterError error) {
+	source := map[string]interface{}{
+		"address":     query.Address,
+		"mac":         query.Mac,
+		"ipset":       query.Ipset,
+		"template_id": query.TemplateId,
+	}
+	result := tx.Model(&Source{}).Where("id = ?", query.ID).Updates(source)
+	if enconterError = result.Error; enconterError == nil {
+		return nil
+	}
+
+	return enconterError
+}
+
+func DeleteSourceWithID(tx *gorm.DB, id uint64) error {
+	result := tx.Delete(&Source{}, id)
+	if result.Error == nil {
+		return nil
+	}
+	return result.Error
+}
+
+func QueryTemplateIDWithSourceID(id uint64) (templateID int, enconterError error) {
+	result := DB.Model(&Source{}).Select("template_id").Where("id = ?", id).Scan(&templateID)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return templateID, enconterError
+}
@@ -5,7 +5,6 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/cylonchau/firewalld-gateway/api"
-	"github.com/cylonchau/firewalld-gateway/config"
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
 )
 
@@ -16,6 +15,8 @@ type Template struct {
 	Name        string `json:"name" gorm:"index;type:varchar(255)"`
 	Description string `json:"description" gorm:"type:varchar(255)"`
 	Target      string `json:"target" gorm:"type:varchar(100)"`
+	Masquerade  bool   `json:"masquerade" gorm:"default:false"`
+	DriftMode   string `json:"drift_mode" gorm:"type:varchar(10);default:audit"`
 }
 
 type TemplateList struct {
@@ -23,6 +24,7 @@ type TemplateList struct {
 	Name        string `json:"name"`
 	Target      string `json:"target,omitempty"`
 	Description string `json:"description,omitempty"`
+	DriftMode   string `json:"drift_mode,omitempty"`
 }
 
 type TemplateListWithoutID struct {
@@ -32,11 +34,18 @@ type TemplateListWithoutID struct {
 }
 
 type TemplateWithDetails struct {
-	Target      string              `json:"target"`
-	Description string              `json:"description"`
-	Short       string              `json:"short"`
-	Riches      []RichListWithoutID `json:"rich"`
-	Ports       []PortListWithoutID `json:"port"`
+	Target       string                     `json:"target"`
+	Description  string                     `json:"description"`
+	Short        string                     `json:"short"`
+	Masquerade   bool                       `json:"masquerade,omitempty"`
+	Riches       []RichListWithoutID        `json:"rich"`
+	Ports        []PortListWithoutID        `json:"port"`
+	Services     []ServiceListWithoutID     `json:"service,omitempty"`
+	ForwardPorts []ForwardPortListWithoutID `json:"forwardport,omitempty"`
+	IcmpBlocks   []IcmpBlockListWithoutID   `json:"icmpblock,omitempty"`
+	Sources      []SourceListWithoutID      `json:"source,omitempty"`
+	Protocols    []ProtocolListWithoutID    `json:"protocol,omitempty"`
+	Interfaces   []InterfaceListWithoutID   `json:"interface,omitempty"`
 }
 
 func (*TemplateList) TableName() string {
@@ -47,27 +56,59 @@ func (*TemplateListWithoutID) TableName() string {
 	return template_table_name
 }
 
-func GetRichWithDetailsByTemplateID(templateID uint) (*api.Settings, error) {
-	result := &api.Settings{}
-
+// GetTemplateDetails 读取模板当前的完整内容
+func GetTemplateDetails(db *gorm.DB, templateID uint) (*TemplateWithDetails, error) {
 	// 查询 Template
 	template := &Template{}
-	if err := DB.Table(template_table_name).First(&template, templateID).Error; err != nil {
+	if err := db.Table(template_table_name).First(&template, templateID).Error; err != nil {
 		return nil, err
 	}
-	result.Target = template.Target
-	result.Description = template.Description
-	result.Short = template.Name
+	details := &TemplateWithDetails{
+		Target:      template.Target,
+		Description: template.Description,
+		Short:       template.Name,
+		Masquerade:  template.Masquerade,
+	}
 	// 查询所有 Rich 记录
-	var riches []RichListWithoutID
-	if err := DB.Where("template_id = ?", templateID).Where(rich_table_name+".deleted_at is ?", nil).Find(&riches).Error; err != nil {
+	if err := db.Where("template_id = ?", templateID).Where(rich_table_name+".deleted_at is ?", nil).Find(&details.Riches).Error; err != nil {
+		return nil, err
+	}
+	// 查询所有 Port 记录
+	if err := db.Where("template_id = ?", templateID).Where(port_table_name+".deleted_at is ?", nil).Find(&details.Ports).Error; err != nil {
 		return nil, err
 	}
+	// 查询 service、forward port、icmp block、source、protocol、interface 记录
+	for _, v := range []struct {
+		table string
+		dest  interface{}
+	}{
+		{service_table_name, &details.Services},
+		{forward_port_table_name, &details.ForwardPorts},
+		{icmp_block_table_name, &details.IcmpBlocks},
+		{source_table_name, &details.Sources},
+		{protocol_table_name, &details.Protocols},
+		{interface_table_name, &details.Interfaces},
+	} {
+		if err := db.Where("template_id = ?", templateID).Where(v.table+".deleted_at is ?", nil).Find(v.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return details, nil
+}
+
+// ToSettings 将模板内容转换为可以下发到 firewalld 的 zone settings
+func (t *TemplateWithDetails) ToSettings() *api.Settings {
+	result := &api.Settings{
+		Target:      t.Target,
+		Description: t.Description,
+		Short:       t.Short,
+		Masquerade:  t.Masquerade,
+	}
 
 	apiRichRule := []*api.Rule{}
-	copier.Copy(&apiRichRule, &riches)
+	copier.Copy(&apiRichRule, &t.Riches)
 	for k, v := range apiRichRule {
-		switch riches[k].Action {
+		switch t.Riches[k].Action {
 		case "accept":
 			v.Accept = &api.Accept{Flag: true}
 		case "drop":
@@ -77,47 +118,54 @@ func GetRichWithDetailsByTemplateID(templateID uint) (*api.Settings, error) {
 		}
 		result.Rule = append(result.Rule, v.ToString())
 	}
-	//
-	//// 查询所有 Port 记录
-	var ports []*PortListWithoutID
-	if err := DB.Where("template_id = ?", templateID).Where(port_table_name+".deleted_at is ?", nil).Find(&ports).Error; err != nil {
-		return result, err
-	}
 
 	apiPortsRule := []*api.Port{}
-	copier.Copy(&apiPortsRule, &ports)
+	copier.Copy(&apiPortsRule, &t.Ports)
 	result.Port = apiPortsRule
 
-	result.Port = append(result.Port, &api.Port{
-		Port:     config.CONFIG.DbusPort,
-		Protocol: "tcp",
-	})
-
-	return result, nil
+	for _, v := range t.Services {
+		result.Service = append(result.Service, v.Name)
+	}
+	for _, v := range t.ForwardPorts {
+		result.ForwardPort = append(result.ForwardPort, &api.ForwardPort{Port: v.Port, Protocol: v.Protocol, ToPort: v.ToPort, ToAddr: v.ToAddr})
+	}
+	for _, v := range t.IcmpBlocks {
+		result.IcmpBlock = append(result.IcmpBlock, &api.IcmpBlock{Name: v.Name})
+	}
+	for _, v := range t.Sources {
+		result.Source = append(result.Source, &api.Source{Address: v.Address, Mac: v.Mac, Ipset: v.Ipset})
+	}
+	for _, v := range t.Protocols {
+		result.Protocol = append(result.Protocol, &api.Protocol{Value: v.Value})
+	}
+	for _, v := range t.Interfaces {
+		result.Interface = append(result.Interface, &api.Interface{Name: v.Name})
+	}
+	return result
 }
 
-func CreateTemplate(name, description, target string) (enconterError error) {
+func CreateTemplate(tx *gorm.DB, name, description, target string) (templateID uint, enconterError error) {
 	if CheckTemplateIsExistWithName(name) {
 		template := &Template{
 			Name:        name,
 			Description: description,
 			Target:      target,
 		}
-		result := DB.Create(template)
+		result := tx.Create(template)
 		if enconterError = result.Error; enconterError == nil {
-			return nil
+			return template.ID, nil
 		}
 	} else {
 		enconterError = query.ErrTemplateExist
 	}
-	return enconterError
+	return 0, enconterError
 }
 
 func GetTemplates(title string, offset, limit int, sort string) (map[string]interface{}, error) {
 	templates := []*TemplateList{}
 	response := make(map[string]interface{})
 	var count int64
-	result := DB.Select([]string{"id", "name", "description", "target"}).
+	result := DB.Select([]string{"id", "name", "description", "target", "drift_mode"}).
 		Limit(limit).Offset(offset).
 		Where("deleted_at is ?", nil).
 		Where(template_table_name+".name LIKE ?", "%"+title+"%").
@@ -163,13 +211,13 @@ func DeleteTemplateWithID(id uint64) error {
 	return result.Error
 }
 
-func UpdateTemplateWithID(id uint64, name, description, target string) (enconterError error) {
+func UpdateTemplateWithID(tx *gorm.DB, id uint64, name, description, target string) (enconterError error) {
 	template := &Template{
 		Name:        name,
 		Description: description,
 		Target:      target,
 	}
-	result := DB.Model(&Template{}).Where("id = ?", id).Updates(template)
+	result := tx.Model(&Template{}).Where("id = ?", id).Updates(template)
 	if enconterError = result.Error; enconterError == nil {
 		return nil
 	}
@@ -177,8 +225,24 @@ func UpdateTemplateWithID(id uint64, name, description, target string) (enconter
 	return enconterError
 }
 
+func UpdateTemplateMasquerade(tx *gorm.DB, id int, enable bool) (enconterError error) {
+	result := tx.Model(&Template{}).Where("id = ?", id).Update("masquerade", enable)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return enconterError
+}
+
 func TemplateCounter() int64 {
 	var count int64
 	DB.Model(&Template{}).Distinct("id").Count(&count)
 	return count
 }
+
+func GetTemplateWithID(id uint) (*Template, error) {
+	template := &Template{}
+	if err := DB.First(template, id).Error; err != nil {
+		return nil, err
+	}
+	return template, nil
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"time"
+
+	json "github.com/json-iterator/go"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+const (
+	template_drift_table_name = "template_drifts"
+
+	// 模板的漂移处理模式，off 不检查，audit 只记录，enforce 记录并自动修复
+	DriftModeOff     = "off"
+	DriftModeAudit   = "audit"
+	DriftModeEnforce = "enforce"
+
+	DriftInSync     = "in_sync"
+	DriftDetected   = "drifted"
+	DriftRemediated = "remediated"
+	DriftError      = "error"
+)
+
+// TemplateDrift 每台主机在模板下最近一次漂移检查的结果，一台主机一条记录
+type TemplateDrift struct {
+	gorm.Model
+	TemplateID uint      `json:"template_id" gorm:"index"`
+	Revision   int       `json:"revision" gorm:"type:int"`
+	HostID     uint      `json:"host_id" gorm:"index"`
+	IP         uint32    `json:"ip" gorm:"type:bigint"`
+	Status     string    `json:"status" gorm:"index;type:varchar(20)"`
+	Diff       string    `json:"-" gorm:"type:text"`
+	Error      string    `json:"error" gorm:"type:varchar(255)"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+type TemplateDriftList struct {
+	ID         int               `json:"id"`
+	TemplateID int               `json:"template_id"`
+	Template   string            `json:"template"`
+	Revision   int               `json:"revision"`
+	HostID     int               `json:"host_id"`
+	IP         uint32            `json:"ip"`
+	Status     string            `json:"status"`
+	Diff       string            `json:"-"`
+	Changes    *api.SettingsDiff `json:"diff,omitempty" gorm:"-"`
+	Error      string            `json:"error"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
+
+func (*TemplateDrift) TableName() string {
+	return template_drift_table_name
+}
+
+func (*TemplateDriftList) TableName() string {
+	return template_drift_table_name
+}
+
+// Settings 返回该版本下发到主机时实际使用的 zone settings，包含 D-Bus 端口
+func (r *TemplateRevision) Settings() (*api.Settings, error) {
+	details, err := r.Details()
+	if err != nil {
+		return nil, err
+	}
+	settings := details.ToSettings()
+	// 模板中已经包含 D-Bus 端口时不再重复添加
+	dbusPort := &api.Port{
+		Port:     config.CONFIG.DbusPort,
+		Protocol: "tcp",
+	}
+	for _, v := range settings.Port {
+		if v.Key() == dbusPort.Key() {
+			return settings, nil
+		}
+	}
+	settings.Port = append(settings.Port, dbusPort)
+	return settings, nil
+}
+
+// GetDriftTemplates 返回需要进行漂移检查的模板
+func GetDriftTemplates() ([]Template, error) {
+	var templates []Template
+	if err := DB.Where("drift_mode <> ?", DriftModeOff).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func UpdateTemplateDriftMode(id int, mode string) (enconterError error) {
+	result := DB.Model(&Template{}).Where("id = ?", id).Update("drift_mode", mode)
+	if enconterError = result.Error; enconterError == nil && result.RowsAffected == 0 {
+		enconterError = gorm.ErrRecordNotFound
+	}
+	return enconterError
+}
+
+// GetLatestTemplateApplications 返回模板在每台主机上最近一次成功下发的记录，key 为主机 ID
+func GetLatestTemplateApplications(templateID uint) (map[uint]*TemplateApplication, error) {
+	var applications []*TemplateApplication
+	if err := DB.Where("template_id = ? AND status = ?", templateID, TemplateApplied).
+		Order("id asc").
+		Find(&applications).Error; err != nil {
+		return nil, err
+	}
+	latest := make(map[uint]*TemplateApplication, len(applications))
+	for _, v := range applications {
+		latest[v.HostID] = v
+	}
+	return latest, nil
+}
+
+// RecordTemplateDrift 更新主机在模板下的漂移检查结果
+func RecordTemplateDrift(drift *TemplateDrift, diff *api.SettingsDiff) error {
+	if diff != nil {
+		content, err := json.Marshal(diff)
+		if err != nil {
+			return err
+		}
+		drift.Diff = string(content)
+	}
+	drift.CheckedAt = time.Now()
+	return DB.Where(TemplateDrift{TemplateID: drift.TemplateID, HostID: drift.HostID}).
+		Assign(map[string]interface{}{
+			"revision":   drift.Revision,
+			"ip":         drift.IP,
+			"status":     drift.Status,
+			"diff":       drift.Diff,
+			"error":      drift.Error,
+			"checked_at": drift.CheckedAt,
+		}).
+		FirstOrCreate(&TemplateDrift{}).Error
+}
+
+func GetTemplateDrifts(templateID uint, status string, offset, limit int, sort string) (map[string]interface{}, error) {
+	drifts := []*TemplateDriftList{}
+	response := make(map[string]interface{})
+	var count int64
+	driftQuery := DB.Table(template_drift_table_name).
+		Select([]string{
+			template_drift_table_name + ".id",
+			template_drift_table_name + ".template_id",
+			"templates.name AS template",
+			template_drift_table_name + ".revision",
+			template_drift_table_name + ".host_id",
+			template_drift_table_name + ".ip",
+			template_drift_table_name + ".status",
+			template_drift_table_name + ".diff",
+			template_drift_table_name + ".error",
+			template_drift_table_name + ".checked_at"}).
+		Joins("JOIN templates ON "+template_drift_table_name+".template_id = templates.id").
+		Where(template_drift_table_name+".deleted_at IS ?", nil)
+	if templateID > 0 {
+		driftQuery.Where(template_drift_table_name+".template_id = ?", templateID)
+	}
+	if status != "" {
+		driftQuery.Where(template_drift_table_name+".status = ?", status)
+	}
+	driftQuery.Count(&count)
+	result := driftQuery.
+		Order(template_drift_table_name + ".id " + sort).
+		Limit(limit).Offset((offset - 1) * limit).
+		Scan(&drifts)
+	for _, v := range drifts {
+		if v.Diff != "" {
+			v.Changes = &api.SettingsDiff{}
+			json.Unmarshal([]byte(v.Diff), v.Changes)
+		}
+	}
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = drifts
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
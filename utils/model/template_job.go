@@ -0,0 +1,191 @@
+package model
+
+import (
+	"time"
+
+	json "github.com/json-iterator/go"
+	"gorm.io/gorm"
+)
+
+const (
+	template_job_table_name = "template_jobs"
+
+	// 模板下发方式，permanent 写入永久配置并 reload，runtime 只修改运行时配置
+	TemplateModePermanent = "permanent"
+	TemplateModeRuntime   = "runtime"
+
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// TemplateJob 一次模板下发操作，每台主机的结果记录在 TemplateApplication 中
+type TemplateJob struct {
+	gorm.Model
+	TemplateID      uint       `json:"template_id" gorm:"index"`
+	RevisionID      uint       `json:"revision_id"`
+	Revision        int        `json:"revision" gorm:"type:int"`
+	Mode            string     `json:"mode" gorm:"type:varchar(10)"`
+	ContinueOnError bool       `json:"continue_on_error"`
+	Hosts           string     `json:"-" gorm:"type:text"`
+	Status          string     `json:"status" gorm:"index;type:varchar(20)"`
+	CreatedBy       string     `json:"created_by" gorm:"type:varchar(64)"`
+	Applied         int        `json:"applied" gorm:"type:int"`
+	Skipped         int        `json:"skipped" gorm:"type:int"`
+	Failed          int        `json:"failed" gorm:"type:int"`
+	FinishedAt      *time.Time `json:"finished_at"`
+	// TraceParent 为创建任务的 span，任务由 leader 执行时作为父 span
+	TraceParent string `json:"-" gorm:"type:varchar(64)"`
+}
+
+type TemplateJobList struct {
+	ID              int        `json:"id"`
+	TemplateID      int        `json:"template_id"`
+	Revision        int        `json:"revision"`
+	Mode            string     `json:"mode"`
+	ContinueOnError bool       `json:"continue_on_error"`
+	Status          string     `json:"status"`
+	CreatedBy       string     `json:"created_by"`
+	Applied         int        `json:"applied"`
+	Skipped         int        `json:"skipped"`
+	Failed          int        `json:"failed"`
+	CreatedAt       time.Time  `json:"created_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+func (*TemplateJob) TableName() string {
+	return template_job_table_name
+}
+
+func (*TemplateJobList) TableName() string {
+	return template_job_table_name
+}
+
+// HostIDs 返回任务指定的主机，为空表示模板 tag 下的所有主机
+func (j *TemplateJob) HostIDs() []uint {
+	var ids []uint
+	if j.Hosts != "" {
+		json.Unmarshal([]byte(j.Hosts), &ids)
+	}
+	return ids
+}
+
+func CreateTemplateJob(job *TemplateJob, hostIDs []uint) error {
+	if len(hostIDs) > 0 {
+		content, err := json.Marshal(hostIDs)
+		if err != nil {
+			return err
+		}
+		job.Hosts = string(content)
+	}
+	job.Status = JobPending
+	return DB.Create(job).Error
+}
+
+// ClaimTemplateJobs 取出最多 limit 个等待执行的任务并标记为 running，任务只会被一个副本取出
+func ClaimTemplateJobs(limit int) ([]TemplateJob, error) {
+	jobs := []TemplateJob{}
+	if err := DB.Where("status = ?", JobPending).Order("id").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	claimed := jobs[:0]
+	for _, job := range jobs {
+		result := DB.Model(&TemplateJob{}).Where("id = ? AND status = ?", job.ID, JobPending).Update("status", JobRunning)
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status = JobRunning
+			claimed = append(claimed, job)
+		}
+	}
+	return claimed, nil
+}
+
+// ResetTemplateJobs 将上一个 leader 没有执行完的任务放回等待执行，并删除这些任务已经记录的主机结果，
+// 重新执行时会再次下发到所有主机
+func ResetTemplateJobs() (reset int64, err error) {
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		running := tx.Model(&TemplateJob{}).Select("id").Where("status = ?", JobRunning)
+		if err := tx.Unscoped().Where("job_id IN (?)", running).Delete(&TemplateApplication{}).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&TemplateJob{}).Where("status = ?", JobRunning).Update("status", JobPending)
+		reset = result.RowsAffected
+		return result.Error
+	})
+	return reset, err
+}
+
+// UpdateTemplateJobStatus 更新任务状态，任务结束时统计每台主机的结果
+func UpdateTemplateJobStatus(job *TemplateJob, status string) error {
+	job.Status = status
+	updates := map[string]interface{}{"status": status}
+	if status == JobSucceeded || status == JobFailed {
+		var counts []struct {
+			Status string
+			Total  int
+		}
+		if err := DB.Model(&TemplateApplication{}).
+			Select("status, count(*) AS total").
+			Where("job_id = ?", job.ID).
+			Group("status").
+			Scan(&counts).Error; err != nil {
+			return err
+		}
+		for _, v := range counts {
+			switch v.Status {
+			case TemplateApplied:
+				job.Applied = v.Total
+			case TemplateSkipped:
+				job.Skipped = v.Total
+			case TemplateFailed:
+				job.Failed = v.Total
+			}
+		}
+		now := time.Now()
+		job.FinishedAt = &now
+		updates["applied"], updates["skipped"], updates["failed"], updates["finished_at"] = job.Applied, job.Skipped, job.Failed, job.FinishedAt
+	}
+	return DB.Model(&TemplateJob{}).Where("id = ?", job.ID).Updates(updates).Error
+}
+
+func GetTemplateJob(id uint) (*TemplateJob, error) {
+	job := &TemplateJob{}
+	if err := DB.First(job, id).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetTemplateJobResults 返回任务中每台主机的下发结果
+func GetTemplateJobResults(jobID uint) ([]*TemplateApplicationList, error) {
+	results := []*TemplateApplicationList{}
+	if err := DB.Where("job_id = ?", jobID).
+		Where("deleted_at is ?", nil).
+		Order("id asc").
+		Find(&results).Error; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func GetTemplateJobs(templateID uint, offset, limit int, sort string) (map[string]interface{}, error) {
+	jobs := []*TemplateJobList{}
+	response := make(map[string]interface{})
+	var count int64
+	result := DB.Where("template_id = ?", templateID).
+		Where("deleted_at is ?", nil).
+		Order(template_job_table_name + ".id " + sort).
+		Limit(limit).
+		Offset((offset - 1) * limit).
+		Find(&jobs)
+	DB.Model(&TemplateJob{}).Where("template_id = ?", templateID).Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = jobs
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
@@ -0,0 +1,71 @@
+package model
+
+import "testing"
+
+func TestClaimTemplateJobs(t *testing.T) {
+	setupTest(t, "", &TemplateJob{}, &TemplateApplication{})
+
+	for i := 0; i < 3; i++ {
+		if err := CreateTemplateJob(&TemplateJob{TemplateID: 1, Revision: 1}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	claimed, err := ClaimTemplateJobs(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 || claimed[0].ID != 1 || claimed[1].ID != 2 || claimed[0].Status != JobRunning {
+		t.Fatalf("expected jobs 1 and 2 to be claimed, got %+v", claimed)
+	}
+	// 已经取出的任务不会被再次取出
+	if claimed, err = ClaimTemplateJobs(10); err != nil || len(claimed) != 1 || claimed[0].ID != 3 {
+		t.Fatalf("expected only job 3 to be claimed, got %+v %v", claimed, err)
+	}
+	if claimed, err = ClaimTemplateJobs(10); err != nil || len(claimed) != 0 {
+		t.Fatalf("expected no job to be claimed, got %+v %v", claimed, err)
+	}
+}
+
+func TestResetTemplateJobs(t *testing.T) {
+	setupTest(t, "", &TemplateJob{}, &TemplateApplication{})
+
+	running, finished := &TemplateJob{TemplateID: 1}, &TemplateJob{TemplateID: 1}
+	for _, job := range []*TemplateJob{running, finished} {
+		if err := CreateTemplateJob(job, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := RecordTemplateApplication(&TemplateApplication{JobID: job.ID, HostID: 1, Status: TemplateApplied}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ClaimTemplateJobs(10); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateTemplateJobStatus(finished, JobSucceeded); err != nil {
+		t.Fatal(err)
+	}
+
+	reset, err := ResetTemplateJobs()
+	if err != nil || reset != 1 {
+		t.Fatalf("expected 1 job to be requeued, got %d %v", reset, err)
+	}
+	for _, job := range []*TemplateJob{running, finished} {
+		stored, err := GetTemplateJob(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := GetTemplateJobResults(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job == running && (stored.Status != JobPending || len(results) != 0) {
+			t.Fatalf("orphaned job should be pending without results, got %s with %d results", stored.Status, len(results))
+		}
+		if job == finished && (stored.Status != JobSucceeded || len(results) != 1) {
+			t.Fatalf("finished job should be kept, got %s with %d results", stored.Status, len(results))
+		}
+	}
+	if claimed, err := ClaimTemplateJobs(10); err != nil || len(claimed) != 1 || claimed[0].ID != running.ID {
+		t.Fatalf("expected the requeued job to be claimed again, got %+v %v", claimed, err)
+	}
+}
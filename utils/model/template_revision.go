@@ -0,0 +1,346 @@
+package model
+
+import (
+	"strconv"
+	"time"
+
+	json "github.com/json-iterator/go"
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+)
+
+const (
+	template_revision_table_name    = "template_revisions"
+	template_application_table_name = "template_applications"
+
+	TemplateApplied = "applied"
+	TemplateSkipped = "skipped"
+	TemplateFailed  = "failed"
+)
+
+// TemplateRevision 模板的不可变版本，Content 保存该版本完整的模板内容
+type TemplateRevision struct {
+	gorm.Model
+	TemplateID uint   `json:"template_id" gorm:"index;uniqueIndex:idx_template_revision"`
+	Revision   int    `json:"revision" gorm:"index;uniqueIndex:idx_template_revision;type:int"`
+	Author     string `json:"author" gorm:"type:varchar(64)"`
+	Message    string `json:"message" gorm:"type:varchar(255)"`
+	Content    string `json:"-" gorm:"type:text"`
+}
+
+type TemplateRevisionList struct {
+	ID         int       `json:"id"`
+	TemplateID int       `json:"template_id"`
+	Revision   int       `json:"revision"`
+	Author     string    `json:"author"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TemplateApplication 记录某个模板版本被下发到了哪台主机
+type TemplateApplication struct {
+	gorm.Model
+	JobID      uint   `json:"job_id" gorm:"index"`
+	TemplateID uint   `json:"template_id" gorm:"index"`
+	RevisionID uint   `json:"revision_id" gorm:"index"`
+	Revision   int    `json:"revision" gorm:"type:int"`
+	HostID     uint   `json:"host_id" gorm:"index"`
+	IP         uint32 `json:"ip" gorm:"type:bigint"`
+	AppliedBy  string `json:"applied_by" gorm:"type:varchar(64)"`
+	Mode       string `json:"mode" gorm:"type:varchar(10)"`
+	Status     string `json:"status" gorm:"type:varchar(20)"`
+	Error      string `json:"error" gorm:"type:varchar(255)"`
+}
+
+type TemplateApplicationList struct {
+	ID         int       `json:"id"`
+	JobID      int       `json:"job_id"`
+	TemplateID int       `json:"template_id"`
+	Revision   int       `json:"revision"`
+	HostID     int       `json:"host_id"`
+	IP         uint32    `json:"ip"`
+	AppliedBy  string    `json:"applied_by"`
+	Mode       string    `json:"mode"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (*TemplateRevision) TableName() string {
+	return template_revision_table_name
+}
+
+func (*TemplateRevisionList) TableName() string {
+	return template_revision_table_name
+}
+
+func (*TemplateApplication) TableName() string {
+	return template_application_table_name
+}
+
+func (*TemplateApplicationList) TableName() string {
+	return template_application_table_name
+}
+
+// Details 反序列化版本中保存的模板内容
+func (r *TemplateRevision) Details() (*TemplateWithDetails, error) {
+	details := &TemplateWithDetails{}
+	if err := json.Unmarshal([]byte(r.Content), details); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// NewTemplateRevision 以给定的模板内容构造一个未保存的版本
+func NewTemplateRevision(templateID uint, details *TemplateWithDetails) (*TemplateRevision, error) {
+	content, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateRevision{TemplateID: templateID, Content: string(content)}, nil
+}
+
+func createTemplateRevision(tx *gorm.DB, templateID uint, author, message string) (*TemplateRevision, error) {
+	details, err := GetTemplateDetails(tx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := NewTemplateRevision(templateID, details)
+	if err != nil {
+		return nil, err
+	}
+	// 已删除的版本同样占用唯一索引中的版本号
+	var latest int
+	if err = tx.Unscoped().Model(&TemplateRevision{}).
+		Select("COALESCE(MAX(revision), 0)").
+		Where("template_id = ?", templateID).
+		Scan(&latest).Error; err != nil {
+		return nil, err
+	}
+	revision.Revision, revision.Author, revision.Message = latest+1, author, message
+	if err = tx.Create(revision).Error; err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// TemplateRevisionCommit 修改模板后需要生成新版本的模板与版本说明
+type TemplateRevisionCommit struct {
+	TemplateID int
+	Message    string
+}
+
+// EditTemplate 在一个事务中修改模板并为 edit 返回的模板生成新版本，生成版本失败时修改也会回滚
+func EditTemplate(author string, edit func(tx *gorm.DB) ([]TemplateRevisionCommit, error)) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		commits, err := edit(tx)
+		if err != nil {
+			return err
+		}
+		for _, commit := range commits {
+			if _, err = createTemplateRevision(tx, uint(commit.TemplateID), author, commit.Message); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateTemplateRevision 以模板当前内容生成一个新版本
+func CreateTemplateRevision(templateID uint, author, message string) (revision *TemplateRevision, enconterError error) {
+	enconterError = DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		revision, err = createTemplateRevision(tx, templateID, author, message)
+		return err
+	})
+	return revision, enconterError
+}
+
+// EnsureTemplateRevision 返回与模板当前内容一致的最新版本，不一致或没有版本时先生成一个
+func EnsureTemplateRevision(templateID uint, author string) (*TemplateRevision, error) {
+	latest := &TemplateRevision{}
+	result := DB.Where("template_id = ?", templateID).Order("revision desc").Limit(1).Find(latest)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		details, err := GetTemplateDetails(DB, templateID)
+		if err != nil {
+			return nil, err
+		}
+		if content, err := json.Marshal(details); err == nil && string(content) == latest.Content {
+			return latest, nil
+		}
+	}
+	return CreateTemplateRevision(templateID, author, "snapshot of current template")
+}
+
+func GetTemplateRevision(templateID uint, revision int) (*TemplateRevision, error) {
+	templateRevision := &TemplateRevision{}
+	if err := DB.Where("template_id = ? AND revision = ?", templateID, revision).First(templateRevision).Error; err != nil {
+		return nil, err
+	}
+	return templateRevision, nil
+}
+
+// GetTemplateRevisionByID 返回任务下发的版本，版本在任务创建后被删除时同样返回
+func GetTemplateRevisionByID(id uint) (*TemplateRevision, error) {
+	templateRevision := &TemplateRevision{}
+	if err := DB.Unscoped().First(templateRevision, id).Error; err != nil {
+		return nil, err
+	}
+	return templateRevision, nil
+}
+
+func GetTemplateRevisions(templateID uint, offset, limit int, sort string) (map[string]interface{}, error) {
+	revisions := []*TemplateRevisionList{}
+	response := make(map[string]interface{})
+	var count int64
+	result := DB.Select([]string{"id", "template_id", "revision", "author", "message", "created_at"}).
+		Where("template_id = ?", templateID).
+		Where("deleted_at is ?", nil).
+		Order(template_revision_table_name + ".revision " + sort).
+		Limit(limit).
+		Offset((offset - 1) * limit).
+		Find(&revisions)
+	DB.Model(&TemplateRevision{}).Where("template_id = ?", templateID).Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = revisions
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
+
+// DiffTemplateRevisions 比较同一模板的两个版本
+func DiffTemplateRevisions(templateID uint, from, to int) (*api.SettingsDiff, error) {
+	var settings [2]*api.Settings
+	for i, revision := range []int{from, to} {
+		templateRevision, err := GetTemplateRevision(templateID, revision)
+		if err != nil {
+			return nil, err
+		}
+		details, err := templateRevision.Details()
+		if err != nil {
+			return nil, err
+		}
+		settings[i] = details.ToSettings()
+	}
+	return api.DiffSettings(settings[0], settings[1]), nil
+}
+
+// RestoreTemplateRevision 将模板内容恢复为指定版本，恢复本身也会生成一个新版本
+func RestoreTemplateRevision(templateID uint, revision int, author string) (*TemplateRevision, error) {
+	templateRevision, err := GetTemplateRevision(templateID, revision)
+	if err != nil {
+		return nil, err
+	}
+	details, err := templateRevision.Details()
+	if err != nil {
+		return nil, err
+	}
+	return ReplaceTemplateContent(templateID, details, author, "restore revision "+strconv.Itoa(revision))
+}
+
+// ReplaceTemplateContent 用 details 替换模板的全部内容，并生成一个新版本
+func ReplaceTemplateContent(templateID uint, details *TemplateWithDetails, author, message string) (revision *TemplateRevision, enconterError error) {
+	enconterError = DB.Transaction(func(tx *gorm.DB) error {
+		if err := replaceTemplateContent(tx, templateID, details); err != nil {
+			return err
+		}
+		var err error
+		revision, err = createTemplateRevision(tx, templateID, author, message)
+		return err
+	})
+	return revision, enconterError
+}
+
+func replaceTemplateContent(tx *gorm.DB, templateID uint, details *TemplateWithDetails) error {
+	if err := tx.Model(&Template{}).Where("id = ?", templateID).Updates(map[string]interface{}{
+		"name":        details.Short,
+		"description": details.Description,
+		"target":      details.Target,
+		"masquerade":  details.Masquerade,
+	}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("template_id = ?", templateID).Delete(&Port{}).Error; err != nil {
+		return err
+	}
+	for _, item := range []interface{}{&Rich{}, &Service{}, &ForwardPort{}, &IcmpBlock{}, &Source{}, &Protocol{}, &Interface{}} {
+		if err := tx.Where("template_id = ?", templateID).Delete(item).Error; err != nil {
+			return err
+		}
+	}
+	for _, p := range details.Ports {
+		port, _ := strconv.ParseUint(p.Port, 10, 16)
+		if err := tx.Create(&Port{Port: uint16(port), Protocol: p.Protocol, TemplateId: int(templateID)}).Error; err != nil {
+			return err
+		}
+	}
+	for _, r := range details.Riches {
+		if err := tx.Create(&Rich{
+			Family:      r.Family,
+			Source:      r.Source,
+			Destination: r.Destination,
+			Port:        r.Port,
+			Protocol:    r.Protocol,
+			Action:      r.Action,
+			Limit:       r.Limit,
+			LimitUnit:   r.LimitUnit,
+			TemplateID:  int(templateID),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	var items []interface{}
+	for _, v := range details.Services {
+		items = append(items, &Service{Name: v.Name, TemplateID: int(templateID)})
+	}
+	for _, v := range details.ForwardPorts {
+		items = append(items, &ForwardPort{Port: v.Port, Protocol: v.Protocol, ToPort: v.ToPort, ToAddr: v.ToAddr, TemplateID: int(templateID)})
+	}
+	for _, v := range details.IcmpBlocks {
+		items = append(items, &IcmpBlock{Name: v.Name, TemplateID: int(templateID)})
+	}
+	for _, v := range details.Sources {
+		items = append(items, &Source{Address: v.Address, Mac: v.Mac, Ipset: v.Ipset, TemplateID: int(templateID)})
+	}
+	for _, v := range details.Protocols {
+		items = append(items, &Protocol{Value: v.Value, TemplateID: int(templateID)})
+	}
+	for _, v := range details.Interfaces {
+		items = append(items, &Interface{Name: v.Name, TemplateID: int(templateID)})
+	}
+	for _, item := range items {
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RecordTemplateApplication(application *TemplateApplication) error {
+	return DB.Create(application).Error
+}
+
+func GetTemplateApplications(templateID uint, offset, limit int, sort string) (map[string]interface{}, error) {
+	applications := []*TemplateApplicationList{}
+	response := make(map[string]interface{})
+	var count int64
+	result := DB.Select([]string{"id", "job_id", "template_id", "revision", "host_id", "ip", "applied_by", "mode", "status", "error", "created_at"}).
+		Where("template_id = ?", templateID).
+		Where("deleted_at is ?", nil).
+		Order(template_application_table_name + ".id " + sort).
+		Limit(limit).
+		Offset((offset - 1) * limit).
+		Find(&applications)
+	DB.Model(&TemplateApplication{}).Where("template_id = ?", templateID).Count(&count)
+	if result.Error != gorm.ErrRecordNotFound {
+		response["list"] = applications
+		response["total"] = count
+		return response, nil
+	}
+	return nil, result.Error
+}
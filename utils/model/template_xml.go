@@ -0,0 +1,245 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+// TemplateDetailsFromZone 将 zone xml 转换为模板内容，模板无法保存的内容以 warning 的形式返回
+func TemplateDetailsFromZone(zone *api.ZoneXML) (details *TemplateWithDetails, warnings []string) {
+	details = &TemplateWithDetails{
+		Target:      zone.Target,
+		Description: zone.Description,
+		Short:       zone.Short,
+		Masquerade:  zone.Masquerade != nil,
+	}
+	for _, v := range zone.Ports {
+		if warning := addTemplatePort(details, v); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	for _, v := range zone.Services {
+		details.Services = append(details.Services, ServiceListWithoutID{Name: v.Name})
+	}
+	for _, v := range zone.ForwardPorts {
+		details.ForwardPorts = append(details.ForwardPorts, ForwardPortListWithoutID{Port: v.Port, Protocol: v.Protocol, ToPort: v.ToPort, ToAddr: v.ToAddr})
+	}
+	for _, v := range zone.IcmpBlocks {
+		details.IcmpBlocks = append(details.IcmpBlocks, IcmpBlockListWithoutID{Name: v.Name})
+	}
+	for _, v := range zone.Sources {
+		details.Sources = append(details.Sources, SourceListWithoutID{Address: v.Address, Mac: v.Mac, Ipset: v.Ipset})
+	}
+	for _, v := range zone.Protocols {
+		details.Protocols = append(details.Protocols, ProtocolListWithoutID{Value: v.Value})
+	}
+	for _, v := range zone.Interfaces {
+		details.Interfaces = append(details.Interfaces, InterfaceListWithoutID{Name: v.Name})
+	}
+	for _, v := range zone.SourcePorts {
+		warnings = append(warnings, fmt.Sprintf("source-port %s/%s is not supported by template, ignored", v.Port, v.Protocol))
+	}
+	if zone.IcmpBlockInversion != nil {
+		warnings = append(warnings, "icmp-block-inversion is not supported by template, ignored")
+	}
+	for _, v := range zone.Rules {
+		rich, err := richFromXML(&v)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		details.Riches = append(details.Riches, *rich)
+	}
+	return details, warnings
+}
+
+// MergeServiceXML 将 service xml 中的端口和协议合并到模板内容中
+func MergeServiceXML(details *TemplateWithDetails, service *api.ServiceXML) (warnings []string) {
+	exists := make(map[string]bool)
+	for _, v := range details.Ports {
+		exists["port/"+v.Port+"/"+v.Protocol] = true
+	}
+	for _, v := range details.Protocols {
+		exists["protocol/"+v.Value] = true
+	}
+	for _, v := range service.Ports {
+		if exists["port/"+v.Port+"/"+v.Protocol] {
+			continue
+		}
+		if warning := addTemplatePort(details, v); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	for _, v := range service.Protocols {
+		if !exists["protocol/"+v.Value] {
+			details.Protocols = append(details.Protocols, ProtocolListWithoutID{Value: v.Value})
+		}
+	}
+	for _, v := range service.SourcePorts {
+		warnings = append(warnings, fmt.Sprintf("source-port %s/%s is not supported by template, ignored", v.Port, v.Protocol))
+	}
+	for _, v := range service.Modules {
+		warnings = append(warnings, fmt.Sprintf("module %s is not supported by template, ignored", v.Name))
+	}
+	return warnings
+}
+
+// addTemplatePort 模板的端口只能保存单个端口，端口范围会被忽略
+func addTemplatePort(details *TemplateWithDetails, port api.XMLPort) string {
+	if _, err := strconv.ParseUint(port.Port, 10, 16); err != nil {
+		return fmt.Sprintf("port %s/%s is not supported by template, ignored", port.Port, port.Protocol)
+	}
+	details.Ports = append(details.Ports, PortListWithoutID{Port: port.Port, Protocol: port.Protocol})
+	return ""
+}
+
+// richFromXML 模板的 rich rule 只支持 source、destination、port、protocol 与 accept/drop/reject 动作
+func richFromXML(rule *api.XMLRule) (*RichListWithoutID, error) {
+	unsupported := func(element string) error {
+		return fmt.Errorf("rich rule with %s is not supported by template, ignored", element)
+	}
+	switch {
+	case rule.Service != nil:
+		return nil, unsupported("service")
+	case rule.IcmpBlock != nil:
+		return nil, unsupported("icmp-block")
+	case rule.IcmpType != nil:
+		return nil, unsupported("icmp-type")
+	case rule.ForwardPort != nil:
+		return nil, unsupported("forward-port")
+	case rule.SourcePort != nil:
+		return nil, unsupported("source-port")
+	case rule.Log != nil:
+		return nil, unsupported("log")
+	case rule.Audit != nil:
+		return nil, unsupported("audit")
+	case rule.Mark != nil:
+		return nil, unsupported("mark")
+	case rule.Reject != nil && rule.Reject.Type != "":
+		return nil, unsupported("reject type")
+	}
+
+	rich := &RichListWithoutID{Family: rule.Family}
+	if rule.Source != nil {
+		rich.Source = &api.Source{Address: rule.Source.Address, Mac: rule.Source.Mac, Ipset: rule.Source.Ipset, Invert: rule.Source.Invert}
+	}
+	if rule.Destination != nil {
+		rich.Destination = &api.Destination{Address: rule.Destination.Address, Invert: rule.Destination.Invert}
+	}
+	if rule.Port != nil {
+		rich.Port = &api.Port{Port: rule.Port.Port, Protocol: rule.Port.Protocol}
+	}
+	if rule.Protocol != nil {
+		rich.Protocol = &api.Protocol{Value: rule.Protocol.Value}
+	}
+	var limit *api.XMLLimit
+	switch {
+	case rule.Accept != nil:
+		rich.Action, limit = "accept", rule.Accept.Limit
+	case rule.Drop != nil:
+		rich.Action, limit = "drop", rule.Drop.Limit
+	case rule.Reject != nil:
+		rich.Action, limit = "reject", rule.Reject.Limit
+	default:
+		return nil, fmt.Errorf("rich rule without action is not supported by template, ignored")
+	}
+	if limit != nil {
+		// limit 的格式为 rate/duration，例如 5/m
+		value := strings.SplitN(limit.Value, "/", 2)
+		rate, err := strconv.ParseUint(value[0], 10, 16)
+		if err != nil || len(value) != 2 || value[1] == "" {
+			return nil, fmt.Errorf("rich rule limit %s is not supported by template, ignored", limit.Value)
+		}
+		rich.Limit, rich.LimitUnit = uint16(rate), value[1][:1]
+	}
+	return rich, nil
+}
+
+// ToZoneXML 将模板内容转换为 zone xml
+func (t *TemplateWithDetails) ToZoneXML() *api.ZoneXML {
+	zone := &api.ZoneXML{
+		Target:      t.Target,
+		Short:       t.Short,
+		Description: t.Description,
+	}
+	if t.Masquerade {
+		zone.Masquerade = &struct{}{}
+	}
+	for _, v := range t.Interfaces {
+		zone.Interfaces = append(zone.Interfaces, api.XMLName{Name: v.Name})
+	}
+	for _, v := range t.Sources {
+		zone.Sources = append(zone.Sources, api.XMLAddress{Address: v.Address, Mac: v.Mac, Ipset: v.Ipset})
+	}
+	for _, v := range t.Services {
+		zone.Services = append(zone.Services, api.XMLName{Name: v.Name})
+	}
+	for _, v := range t.Ports {
+		zone.Ports = append(zone.Ports, api.XMLPort{Port: v.Port, Protocol: v.Protocol})
+	}
+	for _, v := range t.Protocols {
+		zone.Protocols = append(zone.Protocols, api.XMLValue{Value: v.Value})
+	}
+	for _, v := range t.IcmpBlocks {
+		zone.IcmpBlocks = append(zone.IcmpBlocks, api.XMLName{Name: v.Name})
+	}
+	for _, v := range t.ForwardPorts {
+		zone.ForwardPorts = append(zone.ForwardPorts, api.XMLForwardPort{Port: v.Port, Protocol: v.Protocol, ToPort: v.ToPort, ToAddr: v.ToAddr})
+	}
+	for _, v := range t.Riches {
+		rule := api.XMLRule{Family: v.Family}
+		if !v.Source.IsEmpty() {
+			rule.Source = &api.XMLAddress{Address: v.Source.Address, Mac: v.Source.Mac, Ipset: v.Source.Ipset, Invert: v.Source.Invert}
+		}
+		if !v.Destination.IsEmpty() {
+			rule.Destination = &api.XMLAddress{Address: v.Destination.Address, Invert: v.Destination.Invert}
+		}
+		if !v.Port.IsEmpty() {
+			rule.Port = &api.XMLPort{Port: v.Port.Port, Protocol: v.Port.Protocol}
+		}
+		if !v.Protocol.IsEmpty() {
+			rule.Protocol = &api.XMLValue{Value: v.Protocol.Value}
+		}
+		var limit *api.XMLLimit
+		if v.Limit > 0 {
+			limit = &api.XMLLimit{Value: strconv.Itoa(int(v.Limit)) + "/" + v.LimitUnit}
+		}
+		switch v.Action {
+		case "accept":
+			rule.Accept = &api.XMLAction{Limit: limit}
+		case "drop":
+			rule.Drop = &api.XMLAction{Limit: limit}
+		case "reject":
+			rule.Reject = &api.XMLReject{Limit: limit}
+		}
+		zone.Rules = append(zone.Rules, rule)
+	}
+	return zone
+}
+
+// ImportTemplate 以 details 创建一个新模板，并生成第一个版本
+func ImportTemplate(name string, details *TemplateWithDetails, author, message string) (revision *TemplateRevision, enconterError error) {
+	if !CheckTemplateIsExistWithName(name) {
+		return nil, query.ErrTemplateExist
+	}
+	details.Short = name
+	enconterError = DB.Transaction(func(tx *gorm.DB) error {
+		template := &Template{Name: name}
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		if err := replaceTemplateContent(tx, template.ID, details); err != nil {
+			return err
+		}
+		var err error
+		revision, err = createTemplateRevision(tx, template.ID, author, message)
+		return err
+	})
+	return revision, enconterError
+}
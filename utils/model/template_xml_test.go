@@ -0,0 +1,94 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cylonchau/firewalld-gateway/api"
+)
+
+// templateModels 模板内容与版本的表
+var templateModels = []interface{}{&Template{}, &Port{}, &Rich{}, &Service{}, &ForwardPort{}, &IcmpBlock{},
+	&Source{}, &Protocol{}, &Interface{}, &TemplateRevision{}}
+
+const webZone = `<?xml version="1.0" encoding="utf-8"?>
+<zone target="DROP">
+  <short>web</short>
+  <description>Web servers behind the load balancer.</description>
+  <interface name="eth1"/>
+  <source address="10.0.0.0/8"/>
+  <service name="ssh"/>
+  <service name="http"/>
+  <port port="80" protocol="tcp"/>
+  <port port="443" protocol="tcp"/>
+  <port port="53" protocol="udp"/>
+  <protocol value="gre"/>
+  <icmp-block name="echo-request"/>
+  <masquerade/>
+  <forward-port port="8080" protocol="tcp" to-port="80" to-addr="10.0.0.5"/>
+  <rule family="ipv4">
+    <source address="192.168.0.0/16"/>
+    <port port="22" protocol="tcp"/>
+    <accept>
+      <limit value="5/m"/>
+    </accept>
+  </rule>
+  <rule family="ipv4">
+    <destination address="10.0.0.1" invert="True"/>
+    <protocol value="icmp"/>
+    <drop/>
+  </rule>
+  <rule>
+    <source ipset="blocklist"/>
+    <reject/>
+  </rule>
+</zone>
+`
+
+// importZoneXML 与导入 zone xml 的接口一样以 short 为模板名称创建模板，返回保存后的模板内容
+func importZoneXML(t *testing.T, content []byte) *TemplateWithDetails {
+	t.Helper()
+	zone, err := api.ParseZoneXML(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	details, warnings := TemplateDetailsFromZone(zone)
+	if len(warnings) > 0 {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+	revision, err := ImportTemplate(zone.Short, details, "admin", "import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := GetTemplateDetails(DB, revision.TemplateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return imported
+}
+
+func TestZoneXMLRoundTrip(t *testing.T) {
+	setupTest(t, "", templateModels...)
+	imported := importZoneXML(t, []byte(webZone))
+	if imported.Short != "web" || imported.Target != "DROP" || imported.Description != "Web servers behind the load balancer." || !imported.Masquerade ||
+		len(imported.Ports) != 3 || len(imported.Services) != 2 || len(imported.Riches) != 3 {
+		t.Fatalf("unexpected imported template %+v", imported)
+	}
+	if forward := []ForwardPortListWithoutID{{Port: "8080", Protocol: "tcp", ToPort: "80", ToAddr: "10.0.0.5"}}; !reflect.DeepEqual(imported.ForwardPorts, forward) {
+		t.Fatalf("unexpected imported forward ports %+v", imported.ForwardPorts)
+	}
+	exported, err := imported.ToZoneXML().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 导入到另一个网关
+	setupTest(t, "", templateModels...)
+	reimported := importZoneXML(t, exported)
+	if !reflect.DeepEqual(reimported, imported) {
+		t.Fatalf("template changed after export and import:\nexpected %+v\ngot      %+v\nxml:\n%s", imported, reimported, exported)
+	}
+	if again, err := reimported.ToZoneXML().Marshal(); err != nil || string(again) != string(exported) {
+		t.Fatalf("exported xml changed:\n%s\n%s", exported, again)
+	}
+}
@@ -1,60 +1,226 @@
 package model
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 
 	"github.com/cylonchau/firewalld-gateway/config"
-	token2 "github.com/cylonchau/firewalld-gateway/utils/auther"
+	query2 "github.com/cylonchau/firewalld-gateway/utils/apis/query"
 )
 
-const token_table_name = "tokens"
+const (
+	token_table_name       = "tokens"
+	token_scope_table_name = "token_scopes"
+
+	// APITokenPrefix API token 的前缀，用于与登录签发的 JWT 区分
+	APITokenPrefix = "fwg_"
+	// apiTokenDisplayLength 列表中展示的 token 前缀长度
+	apiTokenDisplayLength = 12
+
+	ScopeRoute = "route"
+)
 
+// Token 用于自动化调用的 API token，只保存 token 的 sha256，明文只在创建与轮换时返回一次
 type Token struct {
 	gorm.Model
-	Token       string `form:"token" json:"token" gorm:"index,type:text"`
-	SignedTo    string `form:"signed_to" json:"signed_to" gorm:"type:varchar(255)"`
-	SignedBy    string `form:"signed_by" json:"signed_by" gorm:"type:varchar(255)"`
-	Description string `json:"description" gorm:"type:varchar(255)"`
-	Roles       []Role `gorm:"many2many:token_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Prefix      string       `json:"prefix" gorm:"type:varchar(16)"`
+	Hash        string       `json:"-" gorm:"index;type:varchar(64)"`
+	SignedTo    string       `form:"signed_to" json:"signed_to" gorm:"type:varchar(255)"`
+	SignedBy    string       `form:"signed_by" json:"signed_by" gorm:"type:varchar(255)"`
+	Description string       `json:"description" gorm:"type:varchar(255)"`
+	ExpiresAt   *time.Time   `json:"expires_at"`
+	LastUsedAt  *time.Time   `json:"last_used_at"`
+	LastUsedIP  uint32       `json:"last_used_ip" gorm:"type:bigint"`
+	Roles       []Role       `gorm:"many2many:token_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	Scopes      []TokenScope `gorm:"foreignKey:TokenID"`
 }
 
 type TokenList struct {
-	ID          int    `json:"id"`
-	Token       string `form:"token" json:"token" gorm:"type:text"`
-	SignedTo    string `form:"signed_to" json:"signed_to" gorm:"type:varchar(255)"`
-	SignedBy    string `form:"signed_by" json:"signed_by" gorm:"type:varchar(255)"`
-	Description string `json:"description" gorm:"type:varchar(255)"`
+	ID          int        `json:"id"`
+	Prefix      string     `json:"prefix"`
+	SignedTo    string     `json:"signed_to"`
+	SignedBy    string     `json:"signed_by"`
+	Description string     `json:"description"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  uint32     `json:"last_used_ip"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TokenScope token 的显式权限，route 的 value 为 "METHOD /path"，host 与 tag 限制 token 可以操作的主机
+type TokenScope struct {
+	gorm.Model
+	TokenID uint   `json:"token_id" gorm:"index"`
+	Kind    string `json:"kind" gorm:"type:varchar(10)"`
+	Value   string `json:"value" gorm:"type:varchar(255)"`
+}
+
+// TokenSecret 创建或轮换 token 后返回给调用方的明文，之后无法再次获取
+type TokenSecret struct {
+	ID        uint       `json:"id"`
+	Token     string     `json:"token"`
+	Prefix    string     `json:"prefix"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (*Token) TableName() string {
+	return token_table_name
 }
 
 func (*TokenList) TableName() string {
 	return token_table_name
 }
 
-func CreateToken(SignedTo, description string) (enconterError error) {
-	var tokenByte string
-	if tokenByte, enconterError = token2.SignPermanentToken(SignedTo); enconterError == nil {
-		token := &Token{
-			SignedTo:    SignedTo,
-			SignedBy:    config.CONFIG.AppName,
-			Description: description,
-			Token:       tokenByte,
+func (*TokenScope) TableName() string {
+	return token_scope_table_name
+}
+
+func hashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIToken() (secret, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	secret = APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return secret, secret[:apiTokenDisplayLength], hashAPIToken(secret), nil
+}
+
+// ParseRouteScope 解析 "METHOD /path" 形式的 route scope
+func ParseRouteScope(value string) (Router, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 || !strings.HasPrefix(fields[1], "/") {
+		return Router{}, query2.ErrTokenScope
+	}
+	method := strings.ToUpper(fields[0])
+	switch method {
+	case "GET", "POST", "PUT", "DELETE", "PATCH", "*":
+	default:
+		return Router{}, query2.ErrTokenScope
+	}
+	return Router{Path: fields[1], Method: method}, nil
+}
+
+// checkTokenGrant 非管理员只能把自己拥有的角色授予 token，显式的 route scope 只有管理员可以授予
+func checkTokenGrant(tx *gorm.DB, creator uint, roleIDs []int, scopes []query2.TokenScopeQuery) error {
+	for _, scope := range scopes {
+		if scope.Kind != ScopeRoute {
+			continue
+		}
+		if _, err := ParseRouteScope(scope.Value); err != nil {
+			return err
 		}
-		result := DB.Create(token)
-		if enconterError = result.Error; enconterError == nil {
-			return nil
+		if creator != 1 {
+			return query2.ErrNoPermission
 		}
 	}
-	return enconterError
+	if creator == 1 || len(roleIDs) == 0 {
+		return nil
+	}
+	var count int64
+	if err := tx.Table("user_roles").
+		Where("user_id = ? AND role_id IN ?", creator, roleIDs).
+		Distinct("role_id").
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(distinctIDs(roleIDs)) {
+		return query2.ErrNoPermission
+	}
+	return nil
+}
+
+func distinctIDs(ids []int) map[int]struct{} {
+	set := make(map[int]struct{}, len(ids))
+	for _, id := range ids {
+		set[id] = struct{}{}
+	}
+	return set
+}
+
+func replaceTokenPermissions(tx *gorm.DB, token *Token, roleIDs []int, scopes []query2.TokenScopeQuery) error {
+	if roleIDs != nil {
+		var roles []Role
+		if len(roleIDs) > 0 {
+			if err := tx.Find(&roles, roleIDs).Error; err != nil {
+				return err
+			}
+			if len(roles) != len(distinctIDs(roleIDs)) {
+				return query2.ErrRoleNotFound
+			}
+		}
+		if err := tx.Model(token).Association("Roles").Replace(roles); err != nil {
+			return err
+		}
+	}
+	if scopes != nil {
+		if err := tx.Unscoped().Where("token_id = ?", token.ID).Delete(&TokenScope{}).Error; err != nil {
+			return err
+		}
+		for _, scope := range scopes {
+			if err := tx.Create(&TokenScope{TokenID: token.ID, Kind: scope.Kind, Value: scope.Value}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CreateToken 创建 API token，creator 为创建者的用户 id
+func CreateToken(q *query2.TokenEditQuery, creator uint) (*TokenSecret, error) {
+	if q.ExpiresAt != nil && !q.ExpiresAt.After(time.Now()) {
+		return nil, query2.ErrTokenExpiry
+	}
+	secret, prefix, hash, err := generateAPIToken()
+	if err != nil {
+		return nil, err
+	}
+	signedBy := config.CONFIG.AppName
+	if user, err := QueryUserWithUID(int64(creator)); err == nil && user.Username != "" {
+		signedBy = user.Username
+	}
+	token := &Token{
+		Prefix:    prefix,
+		Hash:      hash,
+		SignedTo:  q.SignedTo,
+		SignedBy:  signedBy,
+		ExpiresAt: q.ExpiresAt,
+	}
+	if q.Description != nil {
+		token.Description = *q.Description
+	}
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkTokenGrant(tx, creator, q.RoleIDs, q.Scopes); err != nil {
+			return err
+		}
+		if err := tx.Create(token).Error; err != nil {
+			return err
+		}
+		return replaceTokenPermissions(tx, token, q.RoleIDs, q.Scopes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TokenSecret{ID: token.ID, Token: secret, Prefix: prefix, ExpiresAt: token.ExpiresAt}, nil
 }
 
 func GetTokens(title string, offset, limit int, sort string) (map[string]interface{}, error) {
 	templates := []*TokenList{}
 	response := make(map[string]interface{})
 	var count int64
-	result := DB.Select([]string{"id", "token", "signed_to", "signed_by", "description"}).
+	result := DB.Select([]string{"id", "prefix", "signed_to", "signed_by", "description", "expires_at", "last_used_at", "last_used_ip", "created_at"}).
 		Limit(limit).Offset(offset).
 		Where("deleted_at is ?", nil).
-		Where("token like ?", "%"+title+"%").
+		Where("prefix like ? OR signed_to like ? OR description like ?", "%"+title+"%", "%"+title+"%", "%"+title+"%").
 		Order(token_table_name + ".id " + sort).
 		Find(&templates)
 	DB.Model(&Token{}).Distinct("id").Count(&count)
@@ -66,49 +232,190 @@ func GetTokens(title string, offset, limit int, sort string) (map[string]interfa
 	return nil, result.Error
 }
 
-func UpdateTokenWithID(id uint64, signedTo, description string, isUpdate bool) (enconterError error) {
-	var token *Token
-	if isUpdate && signedTo != "" {
-		var tokenByte string
-		if tokenByte, enconterError = token2.SignPermanentToken(signedTo); enconterError == nil {
-			token = &Token{
-				SignedTo:    signedTo,
-				Description: description,
-				Token:       tokenByte,
+// GetTokenScopes 返回 token 的角色与显式权限
+func GetTokenScopes(id uint) (map[string]interface{}, error) {
+	token := &Token{}
+	result := DB.Preload("Roles").Preload("Scopes").Where("id = ?", id).Limit(1).Find(token)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, query2.ErrTokenInvalid
+	}
+	roles := make([]RoleList, 0, len(token.Roles))
+	for _, role := range token.Roles {
+		roles = append(roles, RoleList{ID: int(role.ID), Name: role.Name, Description: role.Description})
+	}
+	scopes := make([]query2.TokenScopeQuery, 0, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		scopes = append(scopes, query2.TokenScopeQuery{Kind: scope.Kind, Value: scope.Value})
+	}
+	return map[string]interface{}{"roles": roles, "scopes": scopes}, nil
+}
+
+// UpdateTokenWithID 更新 token，is_update 为 true 时轮换 token 并返回新的明文；
+// 只有签发者与管理员可以修改 token，修改后 token 的角色与显式权限仍需在 editor 可以授予的范围内
+func UpdateTokenWithID(q *query2.TokenEditQuery, editor uint) (*TokenSecret, error) {
+	if q.ExpiresAt != nil && !q.ExpiresAt.After(time.Now()) {
+		return nil, query2.ErrTokenExpiry
+	}
+	var secret *TokenSecret
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		token := &Token{}
+		result := tx.Preload("Roles").Preload("Scopes").Where("id = ?", q.ID).Limit(1).Find(token)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return query2.ErrTokenInvalid
+		}
+		if err := checkTokenOwner(tx, token, editor); err != nil {
+			return err
+		}
+		// 没有修改的权限按 token 现有的权限检查，轮换 token 不能让 editor 拿到超出自己权限的 token
+		roleIDs, scopes := q.RoleIDs, q.Scopes
+		if roleIDs == nil {
+			roleIDs = make([]int, 0, len(token.Roles))
+			for _, role := range token.Roles {
+				roleIDs = append(roleIDs, int(role.ID))
+			}
+		}
+		if scopes == nil {
+			scopes = make([]query2.TokenScopeQuery, 0, len(token.Scopes))
+			for _, scope := range token.Scopes {
+				scopes = append(scopes, query2.TokenScopeQuery{Kind: scope.Kind, Value: scope.Value})
+			}
+		}
+		if err := checkTokenGrant(tx, editor, roleIDs, scopes); err != nil {
+			return err
+		}
+		updates := map[string]interface{}{}
+		if q.Description != nil {
+			updates["description"] = *q.Description
+		}
+		if q.SignedTo != "" {
+			updates["signed_to"] = q.SignedTo
+		}
+		if q.ExpiresAt != nil {
+			updates["expires_at"] = q.ExpiresAt
+		}
+		if q.IsUpdate {
+			plain, prefix, hash, err := generateAPIToken()
+			if err != nil {
+				return err
+			}
+			updates["prefix"], updates["hash"] = prefix, hash
+			secret = &TokenSecret{ID: token.ID, Token: plain, Prefix: prefix, ExpiresAt: token.ExpiresAt}
+			if q.ExpiresAt != nil {
+				secret.ExpiresAt = q.ExpiresAt
 			}
 		}
-	} else {
-		token = &Token{
-			Description: description,
+		if len(updates) > 0 {
+			if err := tx.Model(token).Updates(updates).Error; err != nil {
+				return err
+			}
 		}
+		return replaceTokenPermissions(tx, token, q.RoleIDs, q.Scopes)
+	})
+	return secret, err
+}
+
+// checkTokenOwner 只有 token 的签发者与管理员可以修改 token，使用 API token 请求时 editor 为 0
+func checkTokenOwner(tx *gorm.DB, token *Token, editor uint) error {
+	if editor == 1 {
+		return nil
+	}
+	if editor == 0 {
+		return query2.ErrNoPermission
+	}
+	user := User{}
+	result := tx.Select("id", "username").Where("id = ?", editor).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 || user.Username != token.SignedBy {
+		return query2.ErrNoPermission
 	}
-	if enconterError == nil {
-		result := DB.Model(&Token{}).Where("id = ?", id).Updates(token)
-		if enconterError = result.Error; enconterError == nil {
-			return nil
+	return nil
+}
+
+// DeleteTokenWithID 删除 token，与修改一样只有签发者与管理员可以删除 token
+func DeleteTokenWithID(id uint64, editor uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		token := &Token{}
+		result := tx.Where("id = ?", id).Limit(1).Find(token)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return query2.ErrTokenInvalid
 		}
+		if err := checkTokenOwner(tx, token, editor); err != nil {
+			return err
+		}
+		return tx.Delete(token).Error
+	})
+}
+
+// GetAPIToken 根据明文查找 token，已删除或过期的 token 返回错误
+func GetAPIToken(secret string) (*Token, error) {
+	token := &Token{}
+	result := DB.Preload("Roles.Routers").Preload("Scopes").Where("hash = ?", hashAPIToken(secret)).Limit(1).Find(token)
+	if result.Error != nil {
+		return nil, result.Error
 	}
-	return enconterError
+	if result.RowsAffected == 0 {
+		return nil, query2.ErrTokenInvalid
+	}
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+		return nil, query2.ErrTokenExpired
+	}
+	return token, nil
 }
 
-func DeleteTokenWithID(id uint64) error {
-	result := DB.Delete(&Token{}, id)
-	if result.Error == nil {
-		return nil
+// GetTokenWithID 根据 id 查找 token，用于执行审批通过的变更时重新检查 token 的权限
+func GetTokenWithID(id uint) (*Token, error) {
+	token := &Token{}
+	result := DB.Preload("Roles.Routers").Preload("Scopes").Where("id = ?", id).Limit(1).Find(token)
+	if result.Error != nil {
+		return nil, result.Error
 	}
-	return result.Error
+	if result.RowsAffected == 0 {
+		return nil, query2.ErrTokenInvalid
+	}
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(time.Now()) {
+		return nil, query2.ErrTokenExpired
+	}
+	return token, nil
 }
 
-func TokenIsDestoryed(tokenStr string) bool {
-	var count int64
-	result := DB.Model(&Token{}).
-		Where("token = ?", tokenStr).
-		Where(token_table_name+".deleted_at is NOT ?", nil).
-		Unscoped().
-		Count(&count)
+// TokenPermissions 返回 token 的权限与主机限制，限制为空时只受角色的 scope 约束
+func TokenPermissions(token *Token) (permissions []Permission, restrict []RoleScope, enconterError error) {
+	if permissions, enconterError = permissionsOfRoles(token.Roles); enconterError != nil {
+		return nil, nil, enconterError
+	}
+	var routers []Router
+	for _, scope := range token.Scopes {
+		switch scope.Kind {
+		case ScopeRoute:
+			if router, err := ParseRouteScope(scope.Value); err == nil {
+				routers = append(routers, router)
+			}
+		case ScopeHost, ScopeTag:
+			restrict = append(restrict, RoleScope{Kind: scope.Kind, Value: scope.Value})
+		}
+	}
+	if len(routers) > 0 {
+		permissions = append(permissions, Permission{Routers: routers})
+	}
+	return permissions, restrict, nil
+}
 
-	if (result.Error != gorm.ErrRecordNotFound || result.Error == nil) && count > 0 {
-		return true
+// TouchToken 记录 token 最后一次使用的时间与来源，一分钟内同一来源的请求不重复写入
+func TouchToken(token *Token, ip uint32) {
+	now := time.Now()
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < time.Minute && token.LastUsedIP == ip {
+		return
 	}
-	return false
+	DB.Model(&Token{}).Where("id = ?", token.ID).UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
 }
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
+)
+
+func TestDeleteTokenChecksOwner(t *testing.T) {
+	setupTest(t, "", &User{}, &Role{}, &Token{})
+
+	for _, name := range []string{"admin", "alice", "bob"} {
+		if err := DB.Create(&User{Username: name}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	tokens := map[string]*Token{}
+	for _, name := range []string{"alice", "bob"} {
+		tokens[name] = &Token{SignedTo: "ci", SignedBy: name}
+		if err := DB.Create(tokens[name]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		token    uint
+		editor   uint
+		expected error
+	}{
+		{"other user", tokens["alice"].ID, 3, query.ErrNoPermission},
+		// 使用 API token 请求时没有用户
+		{"api token", tokens["alice"].ID, 0, query.ErrNoPermission},
+		{"signer", tokens["alice"].ID, 2, nil},
+		{"admin", tokens["bob"].ID, 1, nil},
+		{"deleted token", tokens["alice"].ID, 2, query.ErrTokenInvalid},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := DeleteTokenWithID(uint64(tc.token), tc.editor); err != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+			var count int64
+			DB.Model(&Token{}).Where("id = ?", tc.token).Count(&count)
+			if deleted := count == 0; deleted != (tc.expected == nil || tc.expected == query.ErrTokenInvalid) {
+				t.Fatalf("token %d deleted: %v", tc.token, deleted)
+			}
+		})
+	}
+}
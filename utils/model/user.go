@@ -1,8 +1,6 @@
 package model
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"errors"
 	"net"
 	"net/http"
@@ -12,7 +10,6 @@ import (
 	"github.com/praserx/ipconv"
 	"gorm.io/gorm"
 
-	"github.com/cylonchau/firewalld-gateway/config"
 	"github.com/cylonchau/firewalld-gateway/utils/apis/query"
 )
 
@@ -22,15 +19,17 @@ const user_table_name = "users"
 
 type User struct {
 	gorm.Model
-	Username string `gorm:"index;type:varchar(20)"`
-	Password string `gorm:"type:varchar(32)"`
+	Username string `gorm:"index;type:varchar(64)"`
+	Password string `gorm:"type:varchar(255)"`
 	Roles    []Role `gorm:"many2many:user_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	LoginIP  int    `json:"login_ip" gorm:"index;type:int"`
+	LoginIP  int    `json:"login_ip" gorm:"index;type:bigint"`
+	Source   string `json:"source" gorm:"type:varchar(10)"`
+	Subject  string `json:"subject" gorm:"index;type:varchar(255)"`
 }
 
 type UserInfo struct {
 	ID       uint       `gorm:"primarykey;column:id" json:"id"`
-	Username string     `gorm:"index;type:varchar(20);column:username" json:"username"`
+	Username string     `gorm:"index;type:varchar(64);column:username" json:"username"`
 	Roles    []RoleInfo `gorm:"many2many:user_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:ID;joinForeignKey:UserID;references:ID;joinReferences:RoleID;column:roles" json:"roles"`
 }
 
@@ -39,9 +38,10 @@ type UserList struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"update_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	Username  string         `json:"username" gorm:"index;type:varchar(20)"`
+	Username  string         `json:"username" gorm:"index;type:varchar(64)"`
 	Roles     []Role         `json:"roles" gorm:"many2many:user_roles;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
-	LoginIP   int            `json:"login_ip" gorm:"index;type:int"`
+	LoginIP   int            `json:"login_ip" gorm:"index;type:bigint"`
+	Source    string         `json:"source"`
 }
 
 func (*User) TableName() string {
@@ -58,13 +58,18 @@ func (*UserList) TableName() string {
 
 func QueryUserWithUsername(username string) (User, error) {
 	user := User{}
-	result := DB.Select("id", "username", "password").Where("username = ?", username).Find(&user)
+	result := DB.Select("id", "username", "password", "source").Where("username = ?", username).Find(&user)
 	if result.Error == nil {
 		return user, nil
 	}
 	return User{}, result.Error
 }
 
+// IsLocal 用户是否为本地用户，旧版本创建的用户 source 为空
+func (u *User) IsLocal() bool {
+	return u.Source == "" || u.Source == UserSourceLocal
+}
+
 func QueryUserWithUID(uid int64) (User, error) {
 	user := User{}
 	result := DB.Select("id", "username").Where("id = ?", uid).Find(&user)
@@ -75,33 +80,45 @@ func QueryUserWithUID(uid int64) (User, error) {
 }
 
 func CreateUser(userQuery *query.UserQuery) (enconterError error) {
-	if checkUserExist(userQuery.Username) {
-		user := &User{
-			Username: userQuery.Username,
-			Password: EncryptPassword(userQuery.Password),
-		}
-		result := DB.Create(user)
-		if enconterError = result.Error; enconterError == nil {
-			return nil
-		}
-	} else {
-		enconterError = query.ErrUserExist
+	if !checkUserExist(userQuery.Username) {
+		return query.ErrUserExist
 	}
-	return enconterError
+	if enconterError = CheckPasswordPolicy(userQuery.Password); enconterError != nil {
+		return enconterError
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		user := &User{Username: userQuery.Username, Source: UserSourceLocal}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return setPassword(tx, user.ID, userQuery.Password)
+	})
 }
 
+// UpdateUserWithID 更新用户信息，密码为空时不修改密码
 func UpdateUserWithID(query *query.UserEditQuery) (enconterError error) {
-	user := &User{
-		Username: query.Username,
-		Password: EncryptPassword(query.Password),
-	}
-
-	result := DB.Model(&User{Model: gorm.Model{ID: uint(query.ID)}}).Updates(user)
-	if enconterError = result.Error; enconterError == nil {
-		return nil
+	if query.Password != "" {
+		if enconterError = CheckPasswordPolicy(query.Password); enconterError != nil {
+			return enconterError
+		}
 	}
-
-	return enconterError
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&User{Model: gorm.Model{ID: uint(query.ID)}}).Updates(&User{Username: query.Username}).Error; err != nil {
+			return err
+		}
+		if query.Password == "" {
+			return nil
+		}
+		if err := checkPasswordReused(tx, uint(query.ID), query.Password); err != nil {
+			return err
+		}
+		if err := setPassword(tx, uint(query.ID), query.Password); err != nil {
+			return err
+		}
+		// 修改密码后注销用户已有的登录会话
+		_, err := revokeSessions(tx.Where("user_id = ?", query.ID), SessionRevokedPassword)
+		return err
+	})
 }
 
 func AllocateRole2User(userID uint64, roles_id []int) (enconterError error) {
@@ -120,13 +137,6 @@ func LastLogin(uid int64, ip uint32) bool {
 	return false
 }
 
-func EncryptPassword(p string) string {
-	h := md5.New()
-	h.Write([]byte(Secret + config.CONFIG.AppName))
-	h.Sum([]byte(p))
-	return hex.EncodeToString(h.Sum([]byte(p)))
-}
-
 func checkUserExist(username string) bool {
 	result := DB.Where("username = ?", username).First(&User{})
 	if result.Error != gorm.ErrRecordNotFound || result.RowsAffected > 0 {
@@ -193,7 +203,8 @@ func GetUsers(queryString string, offset, limit int, sort string) (map[string]in
 func DeleteUserWithID(id uint64) error {
 	result := DB.Delete(&User{}, id)
 	if result.Error == nil && result.RowsAffected > 0 {
-		return nil
+		_, err := RevokeUserSessions(uint(id), 0, SessionRevokedUser)
+		return err
 	}
 	return result.Error
 }
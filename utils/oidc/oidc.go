@@ -0,0 +1,310 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	json "github.com/json-iterator/go"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+// discovery /.well-known/openid-configuration 中用到的字段
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Provider 一个 OpenID Connect 身份提供方，discovery 与 jwks 在第一次使用时获取
+type Provider struct {
+	sync.Mutex
+	client    *http.Client
+	discovery *discovery
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+var (
+	provider     *Provider
+	providerOnce sync.Once
+)
+
+// GetProvider 返回按配置创建的 provider
+func GetProvider() *Provider {
+	providerOnce.Do(func() {
+		provider = &Provider{client: &http.Client{Timeout: 10 * time.Second}}
+	})
+	return provider
+}
+
+func (p *Provider) getJSON(ctx context.Context, uri string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: %s", uri, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// discover 获取并缓存 provider 的元数据
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	issuer := strings.TrimSuffix(config.CONFIG.OIDC.Issuer, "/")
+	d := &discovery{}
+	if err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", d); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("issuer %s does not match configured issuer %s", d.Issuer, issuer)
+	}
+	p.discovery = d
+	return d, nil
+}
+
+// AuthCodeURL 生成授权码模式 + PKCE(S256) 的登录地址
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	cfg := config.CONFIG.OIDC
+	challenge := sha256.Sum256([]byte(verifier))
+	values := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {cfg.ClientID},
+		"redirect_uri":          {cfg.RedirectURL},
+		"scope":                 {strings.Join(cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return d.AuthorizationEndpoint + separator + values.Encode(), nil
+}
+
+// Exchange 使用授权码与 code_verifier 换取 id_token，并校验签名、issuer、audience 与 nonce
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (jwt.MapClaims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.CONFIG.OIDC
+	values := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if cfg.ClientSecret == "" {
+		values.Set("client_id", cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	token := struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, fmt.Errorf("token endpoint: %s %s %s", resp.Status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token endpoint returned no id_token")
+	}
+	return p.verify(ctx, d, token.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, d *discovery, idToken, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	parser := &jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}}
+	if _, err := parser.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, d, kid)
+	}); err != nil {
+		return nil, err
+	}
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, errors.New("id_token issuer mismatch")
+	}
+	if !claims.VerifyAudience(config.CONFIG.OIDC.ClientID, true) {
+		return nil, errors.New("id_token audience mismatch")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("id_token has no exp")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+	return claims, nil
+}
+
+// key 按 kid 查找 provider 的公钥，找不到时重新获取 jwks 以支持 provider 的密钥轮换
+func (p *Provider) key(ctx context.Context, d *discovery, kid string) (interface{}, error) {
+	p.Lock()
+	defer p.Unlock()
+	lookup := func() interface{} {
+		if kid == "" && len(p.keys) == 1 {
+			for _, key := range p.keys {
+				return key
+			}
+		}
+		return p.keys[kid]
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	// 避免伪造的 kid 导致频繁请求 jwks
+	if time.Since(p.fetchedAt) < 10*time.Second {
+		return nil, fmt.Errorf("unknown id_token key %s", kid)
+	}
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	p.fetchedAt = time.Now()
+	if err := p.getJSON(ctx, d.JwksURI, &set); err != nil {
+		return nil, err
+	}
+	p.keys = make(map[string]interface{})
+	for _, v := range set.Keys {
+		if key, err := v.publicKey(); err == nil {
+			p.keys[v.Kid] = key
+		}
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown id_token key %s", kid)
+}
+
+func (k *jwk) publicKey() (interface{}, error) {
+	decode := func(v string) (*big.Int, error) {
+		b, err := base64.RawURLEncoding.DecodeString(v)
+		return new(big.Int).SetBytes(b), err
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// RandomString 生成 url 安全的随机字符串，用于 state、nonce 与 code_verifier
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ClaimString 读取字符串类型的 claim
+func ClaimString(claims jwt.MapClaims, name string) string {
+	v, _ := claims[name].(string)
+	return v
+}
+
+// ClaimStrings 读取字符串数组类型的 claim，单个字符串也视为只有一个元素的数组
+func ClaimStrings(claims jwt.MapClaims, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 附录 B 中 SHA-1 使用的密钥 "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 附录 B 的 8 位验证码取后 6 位
+	cases := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tc := range cases {
+		t.Run(time.Unix(tc.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			code, err := Code(rfc6238Secret, Counter(time.Unix(tc.unix, 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, code)
+			}
+			// 密钥不区分大小写，可以带有 padding
+			if code, _ = Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq====", Counter(time.Unix(tc.unix, 0))); code != tc.expected {
+				t.Fatalf("expected %s with lower case secret, got %s", tc.expected, code)
+			}
+		})
+	}
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Fatal("expected an error of invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	counter := Counter(now)
+	cases := []struct {
+		name     string
+		code     string
+		expected int64
+		ok       bool
+	}{
+		{"current", "050471", counter, true},
+		{"spaces", " 050471 ", counter, true},
+		{"previous step", mustCode(t, counter-1), counter - 1, true},
+		{"next step", mustCode(t, counter+1), counter + 1, true},
+		{"out of skew", mustCode(t, counter-2), 0, false},
+		{"wrong code", "000000", 0, false},
+		{"short code", "50471", 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			matched, ok := Validate(rfc6238Secret, tc.code, now)
+			if ok != tc.ok || matched != tc.expected {
+				t.Fatalf("expected %d %v, got %d %v", tc.expected, tc.ok, matched, ok)
+			}
+		})
+	}
+}
+
+func mustCode(t *testing.T, counter int64) string {
+	t.Helper()
+	code, err := Code(rfc6238Secret, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
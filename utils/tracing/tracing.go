@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cylonchau/firewalld-gateway/config"
+)
+
+const instrumentationName = "github.com/cylonchau/firewalld-gateway"
+
+// Tracer 返回网关使用的 tracer，未初始化时为不记录的 noop tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Init 设置 W3C trace context 的传播方式，启用时按配置创建 OTLP/HTTP 导出，
+// 返回的函数在退出前导出剩余的 span
+func Init(ctx context.Context) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	cfg := config.CONFIG.Tracing
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.URLPath != "" {
+		options = append(options, otlptracehttp.WithURLPath(cfg.URLPath))
+	}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptrace.New(ctx, otlptracehttp.NewClient(options...))
+	if err != nil {
+		return nil, err
+	}
+	provider := NewProvider(sdktrace.NewBatchSpanProcessor(exporter))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// NewProvider 按配置的服务名与采样比例创建 TracerProvider，测试时可以传入内存中的 span processor
+func NewProvider(processor sdktrace.SpanProcessor) *sdktrace.TracerProvider {
+	cfg := config.CONFIG.Tracing
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+}
+
+// Detach 返回只保留 ctx 中 span 的新 context，请求结束后异步任务仍然可以作为请求 span 的子 span 继续追踪
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+}
+
+// End 记录错误并结束 span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}